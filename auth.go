@@ -0,0 +1,93 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// TokenExtractor extracts the OTVID token from a request, it returns a empty string if not found.
+type TokenExtractor func(r *http.Request) string
+
+// HeaderTokenExtractor extracts the token from the Authorization header with Bearer scheme.
+func HeaderTokenExtractor(r *http.Request) string {
+	return ExtractTokenFromHeader(r.Header)
+}
+
+// CookieTokenExtractor returns a TokenExtractor that extracts the token from the cookie.
+func CookieTokenExtractor(name string) TokenExtractor {
+	return func(r *http.Request) string {
+		if c, err := r.Cookie(name); err == nil {
+			return c.Value
+		}
+		return ""
+	}
+}
+
+// TokenParser parses and verifies a OTVID for any of the audiences, Verifier implements it.
+type TokenParser interface {
+	ParseOTVID(token string, auds ...OTID) (*OTVID, error)
+}
+
+// ErrNoToken is returned by Authenticator if the request carries no OTVID.
+var ErrNoToken = errors.New("otgo: no OTVID token in request")
+
+// Authenticator authenticates requests with OTVIDs, it can be plugged into web frameworks:
+//
+//	auth := &otgo.Authenticator{Parser: verifier}
+//	// net/http, or Echo with echo.WrapMiddleware
+//	mux.Handle("/api", auth.Handler(apiHandler))
+//	// Gin, with per-route audience
+//	vid, err := auth.Authenticate(c.Request, aud)
+//	// Fiber (fasthttp), extract the token by yourself
+//	vid, err := auth.AuthenticateToken(strings.TrimPrefix(c.Get("Authorization"), "Bearer "), aud)
+type Authenticator struct {
+	Parser    TokenParser
+	Extractor TokenExtractor // HeaderTokenExtractor if nil
+}
+
+// Authenticate extracts and verifies the request's OTVID for any of the audiences (the Parser's audiences by default).
+func (a *Authenticator) Authenticate(r *http.Request, auds ...OTID) (*OTVID, error) {
+	extract := a.Extractor
+	if extract == nil {
+		extract = HeaderTokenExtractor
+	}
+	return a.AuthenticateToken(extract(r), auds...)
+}
+
+// AuthenticateToken verifies the OTVID token for any of the audiences (the Parser's audiences by default).
+func (a *Authenticator) AuthenticateToken(token string, auds ...OTID) (*OTVID, error) {
+	if token == "" {
+		return nil, ErrNoToken
+	}
+	return a.Parser.ParseOTVID(token, auds...)
+}
+
+// Handler returns a http.Handler that authenticates requests for any of the audiences before calling next,
+// the verified OTVID can be got by OTVIDFromContext. It responds 401 if the authentication failed.
+// The request's CorrelationHeaders are propagated to the OT-Auth requests made with the request's context.
+func (a *Authenticator) Handler(next http.Handler, auds ...OTID) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vid, err := a.Authenticate(r, auds...)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		ctx := WithCorrelationHeaders(ContextWithOTVID(r.Context(), vid), r.Header)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+type otvidCtxKey struct{}
+
+// ContextWithOTVID returns a copy of ctx with the verified OTVID.
+func ContextWithOTVID(ctx context.Context, vid *OTVID) context.Context {
+	return context.WithValue(ctx, otvidCtxKey{}, vid)
+}
+
+// OTVIDFromContext returns the verified OTVID in ctx.
+func OTVIDFromContext(ctx context.Context) (*OTVID, bool) {
+	vid, ok := ctx.Value(otvidCtxKey{}).(*OTVID)
+	return vid, ok
+}
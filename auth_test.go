@@ -0,0 +1,91 @@
+package otgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticator(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	key := otgo.MustPrivateKey("ES256")
+	aud := td.NewOTID("svc", "api")
+	signToken := func(aud otgo.OTID) string {
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud}
+		vid.Expiry = time.Now().Add(time.Hour)
+		token, err := vid.Sign(key)
+		if err != nil {
+			panic(err)
+		}
+		return token
+	}
+	v, err := otgo.NewVerifier(context.Background(), aud, nil, key)
+	if err != nil {
+		panic(err)
+	}
+
+	t.Run("Authenticator.Authenticate method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		auth := &otgo.Authenticator{Parser: v}
+		r := httptest.NewRequest("GET", "/", nil)
+		_, err := auth.Authenticate(r)
+		assert.Equal(otgo.ErrNoToken, err)
+
+		otgo.AddTokenToHeader(r.Header, signToken(aud))
+		vid, err := auth.Authenticate(r)
+		assert.Nil(err)
+		assert.True(vid.ID.Equal(td.NewOTID("user", "abc")))
+
+		other := td.NewOTID("svc", "admin")
+		_, err = auth.Authenticate(r, other)
+		assert.NotNil(err)
+		r = httptest.NewRequest("GET", "/", nil)
+		otgo.AddTokenToHeader(r.Header, signToken(other))
+		_, err = auth.Authenticate(r, other)
+		assert.Nil(err)
+
+		auth.Extractor = otgo.CookieTokenExtractor("otvid")
+		_, err = auth.Authenticate(r)
+		assert.Equal(otgo.ErrNoToken, err)
+		r.AddCookie(&http.Cookie{Name: "otvid", Value: signToken(aud)})
+		_, err = auth.Authenticate(r)
+		assert.Nil(err)
+
+		_, err = auth.AuthenticateToken(signToken(aud))
+		assert.Nil(err)
+	})
+
+	t.Run("Authenticator.Handler method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		auth := &otgo.Authenticator{Parser: v}
+		h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			vid, ok := otgo.OTVIDFromContext(r.Context())
+			if !ok {
+				panic("OTVID required")
+			}
+			w.Write([]byte(vid.ID.String()))
+		}))
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(401, w.Code)
+		assert.Contains(w.Header().Get("WWW-Authenticate"), "Bearer")
+
+		r := httptest.NewRequest("GET", "/", nil)
+		otgo.AddTokenToHeader(r.Header, signToken(aud))
+		w = httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(200, w.Code)
+		assert.Equal("otid:localhost:user:abc", w.Body.String())
+
+		_, ok := otgo.OTVIDFromContext(context.Background())
+		assert.False(ok)
+	})
+}
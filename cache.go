@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,48 +16,321 @@ type renewer interface {
 	Lock()
 	Unlock()
 	value() interface{}
+	cacheOp() Op
 	shouldRenew() bool
+	usable() bool
+	expired() bool
+	backoff() *renewBackoff
 	renew(context.Context, *OTClient) error
 }
 
+// cacheShards is the number of the cache's shards, a power of 2. The entries are sharded by the hash
+// of their OTIDs, so that the lookups of different audiences seldom contend for the same lock.
+const cacheShards = 32
+
 type cache struct {
-	mu  sync.RWMutex
-	kv  map[string]renewer
-	new func(OTID) renewer
+	shards   [cacheShards]cacheShard
+	mask     uint32 // the shards in use minus 1
+	size     int64  // the number of entries in all shards
+	new      func(OTID) renewer
+	max      func() int // the max number of entries, unlimited if <= 0
+	epoch    time.Time  // the monotonic clock's start of the entries' lastUsed
+	hits     uint64
+	misses   uint64
+	failures uint64
+	evicted  uint64
+}
+
+type cacheShard struct {
+	mu sync.RWMutex
+	kv map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	val      renewer
+	lastUsed uint64 // the nanoseconds since the cache's epoch of the last Get
+	pinned   bool   // never evicted
+}
+
+// CacheStats is the statistics of a OTClient's cache.
+type CacheStats struct {
+	Entries       int
+	Hits          uint64
+	Misses        uint64
+	RenewFailures uint64
+	Evictions     uint64 // entries removed by LRU eviction or expiration
+}
+
+// Stats is the statistics of the OTClient's caches.
+type Stats struct {
+	Domains CacheStats // trust domains' configurations
+	Tokens  CacheStats // OTVIDs for the audiences
+}
+
+func newCache(fn func(OTID) renewer, max func() int) *cache {
+	return newCacheShards(fn, max, cacheShards)
+}
+
+// newCacheShards creates a cache using the first n shards, n should be a power of 2 not greater than cacheShards.
+func newCacheShards(fn func(OTID) renewer, max func() int, n int) *cache {
+	r := &cache{
+		new:   fn,
+		max:   max,
+		mask:  uint32(n - 1),
+		epoch: time.Now(),
+	}
+	for i := range r.shards {
+		r.shards[i].kv = make(map[string]*cacheEntry)
+	}
+	return r
 }
 
-func newCache(fn func(OTID) renewer) *cache {
-	return &cache{
-		kv:  make(map[string]renewer),
-		new: fn,
+// shard returns the shard of the key, with the inlined 32-bit FNV-1a hash.
+func (r *cache) shard(key string) *cacheShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
 	}
+	return &r.shards[h&r.mask]
 }
 
 // Get ...
 func (r *cache) Get(id OTID) renewer {
 	key := id.String()
-	r.mu.RLock()
-	val, ok := r.kv[key]
-	r.mu.RUnlock()
+	sh := r.shard(key)
+	// the monotonic clock orders the uses without a counter shared by the shards
+	tick := uint64(time.Since(r.epoch))
+	sh.mu.RLock()
+	e, ok := sh.kv[key]
+	if ok {
+		atomic.StoreUint64(&e.lastUsed, tick)
+	}
+	sh.mu.RUnlock()
+	if ok {
+		return e.val
+	}
+
+	sh.mu.Lock()
+	e, ok = sh.kv[key]
 	if !ok {
-		r.mu.Lock()
-		defer r.mu.Unlock()
-		val, ok = r.kv[key]
-		if !ok {
-			val = r.new(id)
-			r.kv[key] = val
-		}
+		e = &cacheEntry{val: r.new(id), lastUsed: tick}
+		sh.kv[key] = e
+		atomic.AddInt64(&r.size, 1)
+	}
+	sh.mu.Unlock()
+	if !ok {
+		r.evict(key)
+	}
+	return e.val
+}
+
+// lookup returns the entry if it exists, without creating it.
+func (r *cache) lookup(id OTID) (renewer, bool) {
+	key := id.String()
+	sh := r.shard(key)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	e, ok := sh.kv[key]
+	if !ok {
+		return nil, false
+	}
+	return e.val, true
+}
+
+// pin gets the entry and never evicts it.
+func (r *cache) pin(id OTID) renewer {
+	val := r.Get(id)
+	key := id.String()
+	sh := r.shard(key)
+	sh.mu.Lock()
+	if e, ok := sh.kv[key]; ok {
+		e.pinned = true
 	}
+	sh.mu.Unlock()
 	return val
 }
 
+func (r *cache) full() (int, bool) {
+	max := 0
+	if r.max != nil {
+		max = r.max()
+	}
+	return max, max > 0 && int(atomic.LoadInt64(&r.size)) > max
+}
+
+// evict removes the expired entries and then the least recently used entries except the key
+// if the cache is full. The least recently used entry is found across the shards without
+// holding their locks together, it is skipped if it was used or removed meanwhile.
+func (r *cache) evict(key string) {
+	max, full := r.full()
+	if !full {
+		return
+	}
+	r.prune()
+	for int(atomic.LoadInt64(&r.size)) > max {
+		var lru string
+		var lruEntry *cacheEntry
+		var lruShard *cacheShard
+		var oldest uint64
+		for i := range r.shards {
+			sh := &r.shards[i]
+			sh.mu.RLock()
+			for k, e := range sh.kv {
+				if t := atomic.LoadUint64(&e.lastUsed); !e.pinned && k != key && (lruEntry == nil || t < oldest) {
+					lru, lruEntry, lruShard, oldest = k, e, sh, t
+				}
+			}
+			sh.mu.RUnlock()
+		}
+		if lruEntry == nil {
+			return // all pinned
+		}
+		lruShard.mu.Lock()
+		if lruShard.kv[lru] == lruEntry && atomic.LoadUint64(&lruEntry.lastUsed) == oldest {
+			delete(lruShard.kv, lru)
+			atomic.AddInt64(&r.size, -1)
+			atomic.AddUint64(&r.evicted, 1)
+		}
+		lruShard.mu.Unlock()
+	}
+}
+
+// prune removes the expired entries. The renewers are checked without holding the shards' locks,
+// so that a slow renewal does not block the cache.
+func (r *cache) prune() int {
+	n := 0
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.RLock()
+		entries := make(map[string]*cacheEntry, len(sh.kv))
+		for k, e := range sh.kv {
+			if !e.pinned {
+				entries[k] = e
+			}
+		}
+		sh.mu.RUnlock()
+
+		var expired []string
+		for k, e := range entries {
+			e.val.RLock()
+			if e.val.expired() {
+				expired = append(expired, k)
+			}
+			e.val.RUnlock()
+		}
+		if len(expired) == 0 {
+			continue
+		}
+
+		sh.mu.Lock()
+		for _, k := range expired {
+			if sh.kv[k] == entries[k] {
+				delete(sh.kv, k)
+				atomic.AddInt64(&r.size, -1)
+				n++
+			}
+		}
+		sh.mu.Unlock()
+	}
+	atomic.AddUint64(&r.evicted, uint64(n))
+	return n
+}
+
+func (r *cache) stats() CacheStats {
+	return CacheStats{
+		Entries:       int(atomic.LoadInt64(&r.size)),
+		Hits:          atomic.LoadUint64(&r.hits),
+		Misses:        atomic.LoadUint64(&r.misses),
+		RenewFailures: atomic.LoadUint64(&r.failures),
+		Evictions:     atomic.LoadUint64(&r.evicted),
+	}
+}
+
+// flush removes the entries, the OTVIDs of the pinned entries are dropped.
+func (r *cache) flush() {
+	var pinned []renewer
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		for k, e := range sh.kv {
+			if e.pinned {
+				pinned = append(pinned, e.val)
+				continue
+			}
+			delete(sh.kv, k)
+			atomic.AddInt64(&r.size, -1)
+		}
+		sh.mu.Unlock()
+	}
+	for _, val := range pinned {
+		if sr, ok := val.(*serviceRenewer); ok {
+			sr.Lock()
+			sr.vid, sr.endpoint = nil, ""
+			sr.Unlock()
+		}
+	}
+}
+
+func (r *cache) each(fn func(renewer)) {
+	var vals []renewer
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.RLock()
+		for _, e := range sh.kv {
+			vals = append(vals, e.val)
+		}
+		sh.mu.RUnlock()
+	}
+	for _, val := range vals {
+		fn(val)
+	}
+}
+
+// Stats returns the statistics of the OTClient's caches.
+func (oc *OTClient) Stats() Stats {
+	return Stats{
+		Domains: oc.domainCache.stats(),
+		Tokens:  oc.serviceCache.stats(),
+	}
+}
+
+// PruneCache removes the expired trust domains' configurations and OTVIDs from the caches,
+// it returns the number of removed entries. The client's own trust domain is never removed.
+func (oc *OTClient) PruneCache() int {
+	n := 0
+	for _, c := range []*cache{oc.domainCache, oc.serviceCache} {
+		n += c.prune()
+	}
+	return n
+}
+
+func (oc *OTClient) cacheOf(op Op) *cache {
+	if op == OpDomainCache {
+		return oc.domainCache
+	}
+	return oc.serviceCache
+}
+
 func resolve(ctx context.Context, obj renewer, oc *OTClient) (interface{}, error) {
+	in := instrumenterOf(oc.Instrumenter)
+	c := oc.cacheOf(obj.cacheOp())
 	obj.RLock()
 	v := obj.value()
 	if !obj.shouldRenew() {
 		obj.RUnlock()
+		atomic.AddUint64(&c.hits, 1)
+		in.CacheLookup(obj.cacheOp(), true)
+		return v, nil
+	}
+	if obj.usable() && oc.InMaintenance() {
+		obj.RUnlock()
+		atomic.AddUint64(&c.hits, 1)
+		in.CacheLookup(obj.cacheOp(), true)
 		return v, nil
 	}
+	atomic.AddUint64(&c.misses, 1)
+	in.CacheLookup(obj.cacheOp(), false)
 
 	obj.RUnlock()
 	obj.Lock()
@@ -65,7 +339,30 @@ func resolve(ctx context.Context, obj renewer, oc *OTClient) (interface{}, error
 	if !obj.shouldRenew() {
 		return v, nil
 	}
+	// back off after OT-Auth rate limited the renewal, the cached value is used if still usable
+	bo := obj.backoff()
+	if err := bo.active(); err != nil {
+		if obj.usable() {
+			return v, nil
+		}
+		return nil, err
+	}
+	if !oc.lifecycle.enter() {
+		return nil, &ClosedError{Op: obj.cacheOp()}
+	}
+	defer oc.lifecycle.leave()
 	if err := obj.renew(ctx, oc); err != nil {
+		atomic.AddUint64(&c.failures, 1)
+		log := loggerOf(oc.Logger)
+		if bo.update(err) {
+			log.Warn("otgo: renewal rate limited", "op", obj.cacheOp(), "retryAfter", clockUntil(bo.until), "error", err)
+			if obj.usable() {
+				return v, nil
+			}
+			return nil, err
+		}
+		// the caller gets the error, so it is not logged as an error on every failed request
+		log.Warn("otgo: renewal failed", "op", obj.cacheOp(), "error", err)
 		return nil, err
 	}
 	return obj.value(), nil
@@ -75,15 +372,29 @@ type domainRenewer struct {
 	sync.RWMutex
 	td        TrustDomain
 	ks        *JWKSet
+	issuers   map[string][]string
 	expiresAt time.Time
 	endpoint  string
+	doc       *domainConfigProxy // the last well-known document
+	// see DomainResolver.LastRefreshed
+	refreshedAt time.Time
+	bo          renewBackoff
+	// see DomainResolver.OnKeysChanged
+	onKeysChanged KeysChangedFunc
 }
 
 // DomainConfig ...
 type DomainConfig struct {
 	OTID     OTID
 	JWKSet   *JWKSet
-	Endpoint string
+	Endpoint string              // the selected one of ServiceEndpoints
+	Issuers  map[string][]string // delegated issuer OTID to its key IDs in JWKSet
+	// The rest fields are from the trust domain's well-known document,
+	// they are empty if the keys are set by OTClient.SetDomainKeys.
+	KeysRefreshHint  int64    // seconds
+	ServiceEndpoints []string // all OT-Auth service endpoints
+	ServiceTypes     []string // the subject types of services, e.g. "app", "svc"
+	UserTypes        []string // the subject types of users, e.g. "user"
 }
 
 // Resolve ...
@@ -96,57 +407,169 @@ func (r *domainRenewer) Resolve(ctx context.Context, oc *OTClient) (*DomainConfi
 }
 
 func (r *domainRenewer) value() interface{} {
-	return &DomainConfig{
+	cfg := &DomainConfig{
 		OTID:     r.td.OTID(),
 		JWKSet:   r.ks,
 		Endpoint: r.endpoint,
+		Issuers:  r.issuers,
 	}
+	if r.doc != nil {
+		cfg.KeysRefreshHint = r.doc.KeysRefreshHint
+		cfg.ServiceEndpoints = r.doc.ServiceEndpoints
+		cfg.ServiceTypes = r.doc.ServiceTypes
+		cfg.UserTypes = r.doc.UserTypes
+	}
+	return cfg
+}
+
+func (r *domainRenewer) backoff() *renewBackoff {
+	return &r.bo
+}
+
+func (r *domainRenewer) cacheOp() Op {
+	return OpDomainCache
 }
 
 func (r *domainRenewer) shouldRenew() bool {
-	return r.endpoint == "" || r.ks == nil || time.Now().After(r.expiresAt)
+	return r.endpoint == "" || r.ks == nil || clockNow().After(r.expiresAt)
+}
+
+func (r *domainRenewer) usable() bool {
+	return r.endpoint != "" && r.ks != nil
+}
+
+func (r *domainRenewer) expired() bool {
+	return r.endpoint != nullhost && !r.expiresAt.IsZero() && clockNow().After(r.expiresAt)
 }
 
 type domainConfigProxy struct {
-	OTID             OTID              `json:"otid"`
-	Keys             []json.RawMessage `json:"keys"`
-	KeysRefreshHint  int64             `json:"keysRefreshHint"`
-	ServiceEndpoints []string          `json:"serviceEndpoints"`
-	ks               JWKSet
+	OTID             OTID                `json:"otid"`
+	Keys             []json.RawMessage   `json:"keys"`
+	KeysRefreshHint  int64               `json:"keysRefreshHint"`
+	ServiceEndpoints []string            `json:"serviceEndpoints"`
+	ServiceTypes     []string            `json:"serviceTypes,omitempty"`
+	UserTypes        []string            `json:"userTypes,omitempty"`
+	Issuers          map[string][]string `json:"issuers"`
+	SignedConfig     string              `json:"signedConfig,omitempty"` // see PublishSignedConfig
+	signedConfigTimes
+	ks JWKSet
 }
 
-func (r *domainRenewer) renew(ctx context.Context, oc *OTClient) error {
-	res := &domainConfigProxy{}
-	err := oc.HTTPClient.Do(ctx, "GET", r.td.ConfigURL(), nil, nil, res)
-	if err != nil {
+func (r *domainRenewer) renew(ctx context.Context, oc *OTClient) (err error) {
+	ctx, end := instrumenterOf(oc.Instrumenter).Start(ctx, OpConfigFetch)
+	defer func() { end(err) }()
+
+	if err = oc.checkOnline(OpConfigFetch); err != nil {
 		return err
 	}
-	if !res.OTID.Equal(r.td.OTID()) {
-		return fmt.Errorf("invalid OT-Auth config with %s, need %s", res.OTID.String(), r.td.OTID().String())
+	file := oc.keysCacheFile(r.td)
+	res := oc.loadStoredDomainConfig(ctx, r.td)
+	if res != nil && oc.ConfigRootKeys != nil && r.doc != nil {
+		// a writer of a shared store may replay an older signed configuration,
+		// it is dropped and the fetched one replaces it in the store
+		if e := res.checkRollback(r.doc); e != nil {
+			loggerOf(oc.Logger).Warn("otgo: dropping the stored configuration", "trustDomain", r.td, "error", e)
+			res = nil
+		}
 	}
-	bs := make([][]byte, 0, len(res.Keys))
-	for _, b := range res.Keys {
-		bs = append(bs, []byte(b))
+	if res == nil {
+		if err = oc.allowCall(OpConfigFetch); err == nil {
+			res, err = fetchDomainConfig(ctx, oc.HTTPClient, r.td, oc.configURL(r.td), oc.MinValidKeys, oc.ConfigRootKeys)
+		}
+		if err == nil && oc.ConfigRootKeys != nil {
+			err = res.checkRollback(r.doc)
+		}
+		if err == nil {
+			oc.storeDomainConfig(ctx, r.td, res)
+		}
 	}
-
-	res.ks.Keys, err = ParseKeys(bs...)
 	if err != nil {
-		return err
+		if file == "" || r.ks != nil {
+			return err
+		}
+		// use the persisted configuration after restart if OT-Auth is unreachable
+		res, e := loadDomainConfig(file, r.td, oc.MinValidKeys, oc.ConfigRootKeys)
+		if e != nil || len(res.ServiceEndpoints) == 0 {
+			return err
+		}
+		loggerOf(oc.Logger).Warn("otgo: using the persisted configuration", "trustDomain", r.td, "error", err)
+		r.setDoc(res)
+		r.endpoint = res.ServiceEndpoints[0]
+		r.expiresAt = clockNow().Add(time.Minute)
+		return nil
+	}
+	if file != "" {
+		saveDomainConfig(file, res) // best effort
 	}
 	if r.endpoint == "" || !stringsHas(res.ServiceEndpoints, r.endpoint) {
-		endpoint, err := SelectEndpoints(ctx, res.ServiceEndpoints, oc.HTTPClient)
+		endpoint, err := oc.selectEndpoint(ctx, res.ServiceEndpoints)
 		if err != nil {
 			return err
 		}
 		r.endpoint = endpoint
 	}
+	r.setDoc(res)
+	r.expiresAt = clockNow().Add(res.refreshInterval())
+	return nil
+}
+
+func (r *domainRenewer) setDoc(res *domainConfigProxy) {
+	if fn, old := r.onKeysChanged, r.ks; fn != nil && old != nil && keysChanged(old, &res.ks) {
+		go fn(old, &res.ks)
+	}
 	r.ks = &res.ks
+	r.issuers = res.Issuers
+	r.doc = res
+	r.refreshedAt = clockNow()
+}
+
+func (res *domainConfigProxy) refreshInterval() time.Duration {
 	if res.KeysRefreshHint > 1 {
-		r.expiresAt = time.Now().Add(time.Duration(res.KeysRefreshHint) * time.Second)
+		return time.Duration(res.KeysRefreshHint) * time.Second
+	}
+	return time.Hour
+}
+
+// fetchDomainConfig fetches the trust domain's configuration and parses its public keys,
+// only the configuration signed by the root keys is trusted if roots is not nil.
+func fetchDomainConfig(ctx context.Context, cli HTTPClient, td TrustDomain, url string, minValidKeys int, roots *JWKSet) (*domainConfigProxy, error) {
+	res := &domainConfigProxy{}
+	err := cli.Do(ctx, "GET", url, nil, nil, res)
+	if err != nil {
+		return nil, err
+	}
+	if err = res.verifySigned(roots); err != nil {
+		return nil, err
+	}
+	if err = res.parseKeys(td, minValidKeys); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (res *domainConfigProxy) parseKeys(td TrustDomain, minValidKeys int) error {
+	var err error
+	if !res.OTID.Equal(td.OTID()) {
+		return fmt.Errorf("invalid OT-Auth config with %s, need %s", res.OTID.String(), td.OTID().String())
+	}
+	bs := make([][]byte, 0, len(res.Keys))
+	for _, b := range res.Keys {
+		bs = append(bs, []byte(b))
+	}
+
+	if minValidKeys > 0 {
+		var errs []error
+		res.ks.Keys, errs = ParseKeysLenient(bs...)
+		if len(res.ks.Keys) < minValidKeys {
+			if len(errs) > 0 {
+				return fmt.Errorf("%d valid keys in OT-Auth config, need %d: %v", len(res.ks.Keys), minValidKeys, errs[0])
+			}
+			return fmt.Errorf("%d valid keys in OT-Auth config, need %d", len(res.ks.Keys), minValidKeys)
+		}
 	} else {
-		r.expiresAt = time.Now().Add(time.Hour)
+		res.ks.Keys, err = ParseKeys(bs...)
 	}
-	return nil
+	return err
 }
 
 type serviceRenewer struct {
@@ -154,6 +577,13 @@ type serviceRenewer struct {
 	otid     OTID
 	vid      *OTVID
 	endpoint string
+	bo       renewBackoff
+	// renewBefore returns the renewal threshold, DefaultRenewBefore is used if nil.
+	renewBefore func() time.Duration
+	// endpointTTL returns the TTL of the selected endpoint, it never expires if nil or 0. See SetServiceTTL.
+	endpointTTL func(OTID) time.Duration
+	selectedAt  time.Time // when the endpoint was selected
+	stale       bool      // see InvalidateService
 }
 
 // ServiceConfig ...
@@ -179,28 +609,85 @@ func (r *serviceRenewer) value() interface{} {
 	}
 }
 
+func (r *serviceRenewer) backoff() *renewBackoff {
+	return &r.bo
+}
+
+func (r *serviceRenewer) cacheOp() Op {
+	return OpTokenCache
+}
+
 func (r *serviceRenewer) shouldRenew() bool {
-	return r.endpoint == "" || r.vid == nil || r.vid.ShouldRenew()
+	if r.endpoint == "" || r.vid == nil || r.stale || r.endpointExpired() {
+		return true
+	}
+	if r.renewBefore == nil {
+		return r.vid.ShouldRenew()
+	}
+	return r.vid.ShouldRenewBefore(r.renewBefore())
+}
+
+// endpointExpired reports whether the selected endpoint should be discovered again.
+func (r *serviceRenewer) endpointExpired() bool {
+	if r.endpointTTL == nil {
+		return false
+	}
+	ttl := r.endpointTTL(r.otid)
+	return ttl > 0 && !clockNow().Before(r.selectedAt.Add(ttl))
+}
+
+func (r *serviceRenewer) usable() bool {
+	return r.endpoint != "" && r.vid != nil && clockNow().Before(r.vid.Expiry)
+}
+
+func (r *serviceRenewer) expired() bool {
+	return r.vid != nil && !clockNow().Before(r.vid.Expiry)
 }
 
 func (r *serviceRenewer) renew(ctx context.Context, oc *OTClient) error {
-	output, err := oc.Sign(ctx, SignInput{
-		Subject:  oc.sub,
-		Audience: r.otid,
-	})
-	if err != nil {
+	return r.renewUntil(ctx, oc, time.Time{})
+}
+
+// renewUntil renews the OTVID, it requests the OTVID to expire at exp if exp is not zero.
+func (r *serviceRenewer) renewUntil(ctx context.Context, oc *OTClient, exp time.Time) error {
+	if err := oc.checkOnline(OpSign); err != nil {
 		return err
 	}
-	r.vid, err = ParseOTVIDInsecure(output.OTVID)
-	if err != nil {
-		return err
+	// the stored OTVID's endpoints may be as old as the selected endpoint
+	rediscover := r.stale || r.endpointExpired()
+	var vid *OTVID
+	var endpoints []string
+	if !rediscover {
+		vid, endpoints = oc.loadOTVID(ctx, r.otid)
+	}
+	if vid == nil || (!exp.IsZero() && vid.Expiry.Before(exp)) {
+		input := SignInput{
+			Subject:  oc.sub,
+			Audience: r.otid,
+		}
+		if !exp.IsZero() {
+			input.Expiry = exp.Unix()
+		}
+		output, err := oc.Sign(ctx, input)
+		if err != nil {
+			return err
+		}
+		if vid, err = oc.parseInsecure(output.OTVID); err != nil {
+			return err
+		}
+		endpoints = output.ServiceEndpoints
+		oc.storeOTVID(ctx, r.otid, vid, endpoints)
 	}
-	if r.endpoint == "" || !stringsHas(output.ServiceEndpoints, r.endpoint) {
-		r.endpoint, err = SelectEndpoints(ctx, output.ServiceEndpoints, oc.HTTPClient)
+	r.vid = vid
+	if rediscover || r.endpoint == "" || !stringsHas(endpoints, r.endpoint) {
+		endpoint, err := oc.selectEndpoint(ctx, endpoints)
 		if err != nil {
 			return err
 		}
+		r.endpoint = endpoint
+		r.selectedAt = clockNow()
 	}
+	r.stale = false
 	return nil
 }
 
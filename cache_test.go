@@ -0,0 +1,170 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheStats(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	var signs int32
+	ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), &signs)
+	defer ts.Close()
+
+	newClient := func() *otgo.OTClient {
+		cli := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		cli.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+		return cli
+	}
+
+	t.Run("OTClient.Stats method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		cli := newClient()
+		aud := td.NewOTID("svc", "a")
+		_, err := cli.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		_, err = cli.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+
+		st := cli.Stats()
+		assert.Equal(1, st.Domains.Entries)
+		assert.Equal(uint64(1), st.Domains.Misses)
+		assert.Equal(2, st.Tokens.Entries) // with the pinned OT-Auth service
+		assert.Equal(uint64(1), st.Tokens.Hits)
+		assert.Equal(uint64(1), st.Tokens.Misses)
+		assert.Equal(uint64(0), st.Tokens.RenewFailures)
+
+		cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = "http://127.0.0.1:1"
+		_, err = cli.Service(otgo.TrustDomain("other.com").NewOTID("svc", "b")).Resolve(context.Background())
+		assert.NotNil(err)
+		assert.Equal(uint64(1), cli.Stats().Tokens.RenewFailures)
+	})
+
+	t.Run("OTClient.MaxCacheEntries", func(t *testing.T) {
+		assert := assert.New(t)
+
+		cli := newClient()
+		cli.MaxCacheEntries = 3
+		a, b, c := td.NewOTID("svc", "a"), td.NewOTID("svc", "b"), td.NewOTID("svc", "c")
+		cli.Service(a)
+		cli.Service(b)
+		cli.Service(a) // b is the least recently used
+		assert.Equal(3, cli.Stats().Tokens.Entries)
+
+		cli.Service(c)
+		st := cli.Stats()
+		assert.Equal(3, st.Tokens.Entries)
+		assert.Equal(uint64(1), st.Tokens.Evictions)
+		cli.Service(a)
+		assert.Equal(uint64(1), cli.Stats().Tokens.Evictions)
+		cli.Service(b)
+		assert.Equal(uint64(2), cli.Stats().Tokens.Evictions)
+	})
+
+	t.Run("OTClient.PruneCache method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		other := otgo.TrustDomain("other.com")
+		key := otgo.MustPrivateKey("ES256")
+		ts2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"otid":             other.OTID(),
+				"keys":             otgo.LookupPublicKeys(otgo.MustKeys(key)).Keys,
+				"keysRefreshHint":  2,
+				"serviceEndpoints": []string{"http://" + r.Host},
+			})
+		}))
+		defer ts2.Close()
+
+		cli := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		cli.ConfigURLs = &otgo.ConfigURLs{}
+		cli.ConfigURLs.Set(other, ts2.URL)
+		_, err := cli.Domain(other).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(2, cli.Stats().Domains.Entries)
+		assert.Equal(0, cli.PruneCache())
+
+		time.Sleep(2100 * time.Millisecond)
+		assert.Equal(1, cli.PruneCache())
+		st := cli.Stats()
+		assert.Equal(1, st.Domains.Entries) // the client's trust domain is pinned
+		assert.Equal(uint64(1), st.Domains.Evictions)
+	})
+}
+
+func BenchmarkServiceCache(b *testing.B) {
+	td := otgo.TrustDomain("localhost")
+	domainKey := otgo.MustPrivateKey("ES256")
+	cli := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+	cli.SetDomainKeys(*otgo.LookupPublicKeys(otgo.MustKeys(domainKey)))
+	auds := make([]otgo.OTID, 256)
+	for i := range auds {
+		auds[i] = td.NewOTID("svc", fmt.Sprintf("s%d", i))
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: auds[i], Expiry: time.Now().Add(time.Hour)}
+		token, err := vid.Sign(domainKey)
+		if err == nil {
+			err = cli.AddAudience(token, "https://localhost")
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	// the cache hits of many audiences, at the goroutines of a busy gateway
+	benchParallel(b, func(i int) {
+		if _, err := cli.Service(auds[i%len(auds)]).Resolve(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	})
+}
+
+// BenchmarkCacheLookup compares the lookups of the sharded cache with a single-lock baseline.
+func BenchmarkCacheLookup(b *testing.B) {
+	td := otgo.TrustDomain("localhost")
+	auds := make([]otgo.OTID, 256)
+	for i := range auds {
+		auds[i] = td.NewOTID("svc", fmt.Sprintf("s%d", i))
+	}
+
+	for _, shards := range []int{32, 1} {
+		c := otgo.NewBenchCache(shards)
+		for _, aud := range auds {
+			c.Get(aud)
+		}
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			benchParallel(b, func(i int) {
+				c.Get(auds[i%len(auds)])
+			})
+		})
+	}
+}
+
+// benchParallel runs fn at 1, 64 and 256 goroutines, with the distinct starting indexes.
+func benchParallel(b *testing.B, fn func(i int)) {
+	for _, goroutines := range []int{1, 64, 256} {
+		b.Run(fmt.Sprintf("goroutines=%d", goroutines), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetParallelism((goroutines + runtime.GOMAXPROCS(0) - 1) / runtime.GOMAXPROCS(0))
+			var next uint32
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(atomic.AddUint32(&next, 7919))
+				for pb.Next() {
+					i++
+					fn(i)
+				}
+			})
+		})
+	}
+}
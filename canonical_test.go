@@ -0,0 +1,58 @@
+package otgo_test
+
+import (
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	newOTVID := func(claims map[string]interface{}) *otgo.OTVID {
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: td.NewOTID("app", "123")}
+		vid.IssuedAt = time.Unix(1600000000, 0)
+		vid.Expiry = time.Unix(1600000600, 0)
+		vid.JTI = "id1"
+		vid.Claims = claims
+		return vid
+	}
+
+	t.Run("OTVID.ToCanonicalJSON method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		vid := newOTVID(map[string]interface{}{
+			"z":          "<a> \n",
+			"\ufb02":     1,
+			"\U0001F600": true,
+			"num":        []interface{}{1e21, 1e-7, 0.5, -0, 100, nil},
+			"obj":        map[string]interface{}{"b": 2, "a": 1},
+		})
+		b, err := vid.ToCanonicalJSON()
+		assert.Nil(err)
+		assert.Equal(`{"aud":["otid:localhost:app:123"],"exp":1600000600,"iat":1600000000,"iss":"otid:localhost","jti":"id1",`+
+			`"num":[1e+21,1e-7,0.5,0,100,null],"obj":{"a":1,"b":2},"sub":"otid:localhost:user:abc","z":"<a>`+" "+`\n",`+
+			`"`+"\U0001F600"+`":true,"`+"\ufb02"+`":1}`, string(b))
+
+		for i := 0; i < 10; i++ {
+			b2, err := vid.ToCanonicalJSON()
+			assert.Nil(err)
+			assert.Equal(b, b2)
+		}
+	})
+
+	t.Run("OTVID.Hash method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		h1, err := newOTVID(map[string]interface{}{"a": 1, "b": "x"}).Hash()
+		assert.Nil(err)
+		assert.Equal(64, len(h1))
+		h2, err := newOTVID(map[string]interface{}{"b": "x", "a": 1.0}).Hash()
+		assert.Nil(err)
+		assert.Equal(h1, h2)
+		h3, err := newOTVID(map[string]interface{}{"a": 2, "b": "x"}).Hash()
+		assert.Nil(err)
+		assert.NotEqual(h1, h3)
+	})
+}
@@ -0,0 +1,64 @@
+package otgo
+
+// ClaimPolicy filters the private claims of the OTVIDs signed for an audience, so that sensitive claims
+// (e.g. email) are not disclosed to third-party audiences by accident. The delegation claim is always kept.
+type ClaimPolicy struct {
+	Allow []string // only the allowed claims are kept if not nil
+	Deny  []string // the denied claims are stripped
+}
+
+// Filter returns a copy of the claims without the claims not allowed by the policy.
+func (p *ClaimPolicy) Filter(claims map[string]interface{}) map[string]interface{} {
+	if p == nil || claims == nil {
+		return claims
+	}
+	rs := make(map[string]interface{}, len(claims))
+	for k, v := range claims {
+		if k == claimParent || ((p.Allow == nil || stringsHas(p.Allow, k)) && !stringsHas(p.Deny, k)) {
+			rs[k] = v
+		}
+	}
+	return rs
+}
+
+// SetClaimPolicy sets the claim policy of the OTVIDs signed by OTClient.Sign for the audience,
+// or for all audiences of a trust domain if aud is the trust domain's OTID. The policy is removed if p is nil.
+// It is safe for concurrent use.
+func (oc *OTClient) SetClaimPolicy(aud OTID, p *ClaimPolicy) {
+	oc.claimMu.Lock()
+	defer oc.claimMu.Unlock()
+	if oc.claimPolicies == nil {
+		oc.claimPolicies = make(map[string]*ClaimPolicy)
+	}
+	if p == nil {
+		delete(oc.claimPolicies, aud.String())
+	} else {
+		oc.claimPolicies[aud.String()] = p
+	}
+}
+
+// SetThirdPartyClaimPolicy sets the claim policy of the audiences outside the subject's trust domain
+// without their own policy, e.g. &ClaimPolicy{Allow: []string{}} strips all private claims by default.
+func (oc *OTClient) SetThirdPartyClaimPolicy(p *ClaimPolicy) {
+	oc.claimMu.Lock()
+	defer oc.claimMu.Unlock()
+	oc.thirdParty = p
+}
+
+// claimPolicy returns the claim policy of the audience: the audience's, its trust domain's,
+// and then the third-party policy if it is outside the subject's trust domain.
+func (oc *OTClient) claimPolicy(aud OTID) *ClaimPolicy {
+	oc.claimMu.RLock()
+	defer oc.claimMu.RUnlock()
+	if p, ok := oc.claimPolicies[aud.String()]; ok {
+		return p
+	}
+	td := aud.TrustDomain()
+	if p, ok := oc.claimPolicies[td.OTID().String()]; ok {
+		return p
+	}
+	if td != oc.td {
+		return oc.thirdParty
+	}
+	return nil
+}
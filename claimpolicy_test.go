@@ -0,0 +1,83 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimPolicy(t *testing.T) {
+	t.Run("ClaimPolicy.Filter method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		claims := map[string]interface{}{"name": "a", "email": "a@example.com", "role": "admin", "prt": "token"}
+		var p *otgo.ClaimPolicy
+		assert.Equal(claims, p.Filter(claims))
+		p = &otgo.ClaimPolicy{Deny: []string{"email"}}
+		assert.Equal(map[string]interface{}{"name": "a", "role": "admin", "prt": "token"}, p.Filter(claims))
+		p = &otgo.ClaimPolicy{Allow: []string{"name", "email"}, Deny: []string{"email"}}
+		assert.Equal(map[string]interface{}{"name": "a", "prt": "token"}, p.Filter(claims))
+		p = &otgo.ClaimPolicy{Allow: []string{}}
+		assert.Equal(map[string]interface{}{"prt": "token"}, p.Filter(claims))
+		assert.Equal(4, len(claims))
+	})
+
+	t.Run("OTClient.SetClaimPolicy method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		key := otgo.MustPrivateKey("ES256")
+		var mu sync.Mutex
+		var signed map[string]interface{}
+		var ts *httptest.Server
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if r.URL.Path == "/sign" {
+				input := otgo.SignInput{}
+				json.NewDecoder(r.Body).Decode(&input)
+				mu.Lock()
+				signed = input.Claims
+				mu.Unlock()
+				w.Write([]byte(`{"result":{}}`))
+				return
+			}
+			b, _ := json.Marshal(map[string]interface{}{
+				"otid":             td.OTID(),
+				"keys":             otgo.LookupPublicKeys(otgo.MustKeys(key)).Keys,
+				"serviceEndpoints": []string{ts.URL},
+			})
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		oc := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+		oc.SetClaimPolicy(td.NewOTID("svc", "a"), &otgo.ClaimPolicy{Deny: []string{"email"}})
+		oc.SetClaimPolicy(otgo.TrustDomain("partner.example").OTID(), &otgo.ClaimPolicy{Allow: []string{"name", "email"}})
+		oc.SetThirdPartyClaimPolicy(&otgo.ClaimPolicy{Allow: []string{"name"}})
+
+		claims := map[string]interface{}{"name": "a", "email": "a@example.com", "role": "admin"}
+		sign := func(aud otgo.OTID) map[string]interface{} {
+			_, err := oc.Sign(context.Background(), otgo.SignInput{Subject: td.NewOTID("user", "u"), Audience: aud, Claims: claims})
+			assert.Nil(err)
+			mu.Lock()
+			defer mu.Unlock()
+			return signed
+		}
+		assert.Equal(map[string]interface{}{"name": "a", "role": "admin"}, sign(td.NewOTID("svc", "a")))
+		assert.Equal(claims, sign(td.NewOTID("svc", "b")))
+		assert.Equal(map[string]interface{}{"name": "a", "email": "a@example.com"}, sign(otgo.TrustDomain("partner.example").NewOTID("svc", "a")))
+		assert.Equal(map[string]interface{}{"name": "a"}, sign(otgo.TrustDomain("other.example").NewOTID("svc", "a")))
+
+		oc.SetClaimPolicy(td.NewOTID("svc", "a"), nil)
+		assert.Equal(claims, sign(td.NewOTID("svc", "a")))
+		assert.Equal(3, len(claims))
+	})
+}
@@ -0,0 +1,79 @@
+package otgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrClaimsInvalid is the reason of ClaimsValidationError, it can be tested with errors.Is.
+var ErrClaimsInvalid = errors.New("invalid claims")
+
+// ClaimsValidator validates the claims of a OTVID after its signature and registered claims are verified,
+// e.g. the required scope, tenant or release channel of the application. See WithClaimsValidator.
+type ClaimsValidator func(vid *OTVID) error
+
+// ClaimsValidationError is returned by the Verifier if a ClaimsValidator rejects a OTVID,
+// so that it can be told from the signature and expiration errors.
+type ClaimsValidationError struct {
+	Subject OTID
+	Err     error // the ClaimsValidator's error
+}
+
+func (e *ClaimsValidationError) Error() string {
+	return fmt.Sprintf("otgo.Verifier: invalid claims of %s: %s", e.Subject.String(), e.Err.Error())
+}
+
+// Is ...
+func (e *ClaimsValidationError) Is(target error) bool {
+	return target == ErrClaimsInvalid
+}
+
+// Unwrap ...
+func (e *ClaimsValidationError) Unwrap() error {
+	return e.Err
+}
+
+// RequireClaims returns a ClaimsValidator that requires the private claims, e.g. "scp" or "tenant".
+// The "rid" claim is the OTVID's ReleaseID.
+func RequireClaims(names ...string) ClaimsValidator {
+	return func(vid *OTVID) error {
+		for _, name := range names {
+			if name == "rid" {
+				if vid.ReleaseID == "" {
+					return errors.New("claim 'rid' required")
+				}
+				continue
+			}
+			if v, ok := vid.Claims[name]; !ok || v == nil {
+				return fmt.Errorf("claim '%s' required", name)
+			}
+		}
+		return nil
+	}
+}
+
+func validateClaims(validators []ClaimsValidator, vid *OTVID) error {
+	for _, fn := range validators {
+		if err := fn(vid); err != nil {
+			return &ClaimsValidationError{Subject: vid.ID, Err: err}
+		}
+	}
+	return nil
+}
+
+// WithClaimsValidator validates the claims of the OTVIDs with the validator, it can be repeated
+// and the validators run in order. See AddClaimsValidator.
+func WithClaimsValidator(fn ClaimsValidator) VerifierOption {
+	return func(o *verifierOptions) {
+		o.validators = append(o.validators, fn)
+	}
+}
+
+// AddClaimsValidator adds a ClaimsValidator like WithClaimsValidator, the parse methods return
+// a *ClaimsValidationError if it rejects a OTVID.
+func (v *Verifier) AddClaimsValidator(fn ClaimsValidator) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// copy on write, the snapshots share the slice
+	v.validators = append(v.validators[:len(v.validators):len(v.validators)], fn)
+}
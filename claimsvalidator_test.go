@@ -0,0 +1,78 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimsValidator(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	key := otgo.MustPrivateKey("ES256")
+
+	sign := func(rid string, claims map[string]interface{}) string {
+		return mustSignOTVID(&otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud,
+			Expiry: time.Now().Add(time.Hour), ReleaseID: rid, Claims: claims}, key)
+	}
+	tenant := func(vid *otgo.OTVID) error {
+		if vid.Claims["tenant"] != "acme" {
+			return errors.New("tenant not allowed")
+		}
+		return nil
+	}
+
+	t.Run("RequireClaims func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fn := otgo.RequireClaims("scp", "rid")
+		assert.Nil(fn(&otgo.OTVID{ReleaseID: "v1", Claims: map[string]interface{}{"scp": "read"}}))
+		assert.NotNil(fn(&otgo.OTVID{Claims: map[string]interface{}{"scp": "read"}}))
+		assert.NotNil(fn(&otgo.OTVID{ReleaseID: "v1"}))
+		assert.NotNil(fn(&otgo.OTVID{ReleaseID: "v1", Claims: map[string]interface{}{"scp": nil}}))
+	})
+
+	t.Run("WithClaimsValidator and Verifier.AddClaimsValidator", func(t *testing.T) {
+		assert := assert.New(t)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key),
+			otgo.WithClaimsValidator(otgo.RequireClaims("tenant")), otgo.WithClaimsValidator(tenant))
+		assert.Nil(err)
+
+		good := sign("v1", map[string]interface{}{"tenant": "acme"})
+		_, err = v.ParseOTVID(good)
+		assert.Nil(err)
+
+		_, err = v.ParseOTVID(sign("", nil))
+		assert.True(errors.Is(err, otgo.ErrClaimsInvalid))
+		var ce *otgo.ClaimsValidationError
+		assert.True(errors.As(err, &ce))
+		assert.True(ce.Subject.Equal(td.NewOTID("app", "123")))
+		assert.Contains(err.Error(), "claim 'tenant' required")
+
+		other := sign("", map[string]interface{}{"tenant": "other"})
+		_, err = v.ParseOTVID(other)
+		assert.True(errors.Is(err, otgo.ErrClaimsInvalid))
+		assert.Contains(err.Error(), "tenant not allowed")
+		_, err = v.Verify(other)
+		assert.True(errors.Is(err, otgo.ErrClaimsInvalid))
+		_, errs := v.ParseOTVIDBatch([]string{good, other})
+		assert.Nil(errs[0])
+		assert.True(errors.Is(errs[1], otgo.ErrClaimsInvalid))
+
+		// a signature error is not a claims error
+		_, err = v.ParseOTVID(good[:len(good)-4] + "AAAA")
+		assert.NotNil(err)
+		assert.False(errors.Is(err, otgo.ErrClaimsInvalid))
+
+		v.AddClaimsValidator(otgo.RequireClaims("rid"))
+		_, err = v.ParseOTVID(good)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign("", map[string]interface{}{"tenant": "acme"}))
+		assert.True(errors.Is(err, otgo.ErrClaimsInvalid))
+	})
+}
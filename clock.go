@@ -0,0 +1,89 @@
+package otgo
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the current time. The package's clock is used for the expiration and renewal of OTVIDs,
+// trust domains' configurations, caches and stores, it can be replaced with SetClock to control time
+// in tests and simulations.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+type clockBox struct {
+	Clock
+}
+
+var clock atomic.Value // clockBox
+
+// SetClock replaces the package's clock and returns the previous one, the system clock is restored if c is nil.
+// It is safe for concurrent use, but it affects all OTClients and Verifiers in the process:
+//
+//	fc := otgo.NewFakeClock(time.Now())
+//	defer otgo.SetClock(otgo.SetClock(fc))
+func SetClock(c Clock) Clock {
+	if c == nil {
+		c = systemClock{}
+	}
+	prev := clockOf(clock.Load())
+	clock.Store(clockBox{c})
+	return prev
+}
+
+func clockOf(v interface{}) Clock {
+	if b, ok := v.(clockBox); ok {
+		return b.Clock
+	}
+	return systemClock{}
+}
+
+// clockNow returns the current time of the package's clock.
+func clockNow() time.Time {
+	return clockOf(clock.Load()).Now()
+}
+
+// clockUntil returns the duration until t with the package's clock.
+func clockUntil(t time.Time) time.Duration {
+	return t.Sub(clockNow())
+}
+
+// FakeClock is a Clock that only moves when it is told to, it is safe for concurrent use.
+type FakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+// NewFakeClock returns a FakeClock at t.
+func NewFakeClock(t time.Time) *FakeClock {
+	return &FakeClock{t: t}
+}
+
+// Now implements the Clock interface.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+}
+
+// Set moves the clock to t.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
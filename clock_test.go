@@ -0,0 +1,67 @@
+package otgo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClock(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+
+	t.Run("FakeClock", func(t *testing.T) {
+		assert := assert.New(t)
+
+		start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		fc := otgo.NewFakeClock(start)
+		assert.Equal(start, fc.Now())
+		fc.Advance(time.Minute)
+		assert.Equal(start.Add(time.Minute), fc.Now())
+		fc.Set(start)
+		assert.Equal(start, fc.Now())
+	})
+
+	t.Run("SetClock func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now().Add(-24 * time.Hour))
+		prev := otgo.SetClock(fc)
+		defer otgo.SetClock(prev)
+
+		key := otgo.MustPrivateKey("ES256")
+		ks := otgo.LookupPublicKeys(otgo.MustKeys(key))
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		assert.Equal(fc.Now().Truncate(time.Second).Unix(), vid.IssuedAt.Unix())
+		assert.Equal(vid.IssuedAt.Add(10*time.Minute), vid.Expiry)
+		assert.False(vid.ShouldRenew())
+
+		_, err = otgo.ParseOTVID(token, ks, td.OTID(), aud)
+		assert.Nil(err)
+		fc.Advance(9*time.Minute + 55*time.Second)
+		assert.True(vid.ShouldRenew())
+		fc.Advance(10 * time.Second)
+		_, err = otgo.ParseOTVID(token, ks, td.OTID(), aud)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "expiration time")
+
+		// the cached OTVID expires with the clock
+		fc.Set(vid.IssuedAt)
+		oc := otgo.NewOTClient(context.Background(), vid.ID)
+		assert.Nil(oc.AddAudience(token, "http://localhost:1234"))
+		_, err = oc.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		fc.Advance(11 * time.Minute)
+		_, err = oc.Service(aud).Resolve(context.Background())
+		assert.NotNil(err)
+
+		otgo.SetClock(nil)
+		_, err = otgo.ParseOTVID(token, ks, td.OTID(), aud)
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/subcommands"
+	otgo "github.com/open-trust/ot-go-lib"
+)
+
+type benchCmd struct {
+	ioGroup
+	alg         string
+	n           int
+	concurrency int
+}
+
+func (*benchCmd) Name() string { return "bench" }
+func (*benchCmd) Synopsis() string {
+	return "benchmark signing and verification of OTVIDs."
+}
+func (*benchCmd) Usage() string {
+	return `bench [-alg algorithms] [-n count] [-c concurrency]
+
+Sign and verify OTVIDs with the algorithms, and print the throughput and p50/p99 latency:
+	otgo bench -alg ES256,RS256,PS256 -n 1000 -c 4
+`
+}
+
+func (c *benchCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.alg, "alg", "ES256", "comma-separated algorithms, such as RS256, ES256, PS256.")
+	f.IntVar(&c.n, "n", 1000, "number of OTVIDs to sign and verify for each algorithm.")
+	f.IntVar(&c.concurrency, "c", 1, "number of concurrent workers.")
+	c.setOutputFlag(f)
+}
+
+// benchResult is the result of an operation with an algorithm, the latencies are in nanoseconds.
+type benchResult struct {
+	Algorithm string  `json:"alg"`
+	Operation string  `json:"op"` // sign or verify
+	Count     int     `json:"n"`
+	OpsPerSec float64 `json:"opsPerSec"`
+	P50       int64   `json:"p50"`
+	P99       int64   `json:"p99"`
+}
+
+func (c *benchCmd) Execute(_ context.Context, _ *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	err := c.checkFormat()
+	if err != nil {
+	} else if c.n < 1 {
+		err = usageError(errors.New("the -n value is invalid"))
+	} else if c.concurrency < 1 {
+		err = usageError(errors.New("the -c value is invalid"))
+	}
+	var results []*benchResult
+	if err == nil {
+		for _, alg := range strings.Split(c.alg, ",") {
+			var rs []*benchResult
+			if rs, err = c.bench(strings.TrimSpace(alg)); err != nil {
+				break
+			}
+			results = append(results, rs...)
+		}
+	}
+	if err == nil {
+		if c.structured() {
+			err = c.emit(c.Name(), "", nil, results)
+		} else {
+			c.print(results)
+		}
+	}
+	return c.exit(c.Name(), err)
+}
+
+func (c *benchCmd) bench(alg string) ([]*benchResult, error) {
+	key, err := otgo.NewPrivateKey(alg)
+	if err != nil {
+		return nil, usageError(err)
+	}
+	td := otgo.TrustDomain("localhost")
+	ks := otgo.LookupPublicKeys(otgo.MustKeys(key))
+	vid := otgo.OTVID{ID: td.NewOTID("app", "bench"), Issuer: td.OTID(), Audience: td.NewOTID("svc", "bench")}
+
+	tokens := make([]string, c.n)
+	sign := c.run(alg, "sign", func(i int) error {
+		v := vid // a new jti for each OTVID
+		v.Expiry = time.Now().Add(time.Hour)
+		token, err := v.Sign(key)
+		tokens[i] = token
+		return err
+	})
+	if sign.err != nil {
+		return nil, sign.err
+	}
+	verify := c.run(alg, "verify", func(i int) error {
+		_, err := otgo.ParseOTVID(tokens[i], ks, vid.Issuer, vid.Audience)
+		return err
+	})
+	if verify.err != nil {
+		return nil, verify.err
+	}
+	return []*benchResult{sign.benchResult, verify.benchResult}, nil
+}
+
+type benchRun struct {
+	*benchResult
+	err error
+}
+
+// run calls fn n times with the workers, and measures the latencies.
+func (c *benchCmd) run(alg, op string, fn func(i int) error) *benchRun {
+	latencies := make([]time.Duration, c.n)
+	errs := make([]error, c.concurrency)
+	next := make(chan int, c.n)
+	for i := 0; i < c.n; i++ {
+		next <- i
+	}
+	close(next)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := 0; w < c.concurrency; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := range next {
+				t := time.Now()
+				if err := fn(i); err != nil {
+					errs[w] = err
+					return
+				}
+				latencies[i] = time.Since(t)
+			}
+		}(w)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	for _, err := range errs {
+		if err != nil {
+			return &benchRun{err: fmt.Errorf("%s %s failed: %s", alg, op, err.Error())}
+		}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	return &benchRun{benchResult: &benchResult{
+		Algorithm: alg,
+		Operation: op,
+		Count:     c.n,
+		OpsPerSec: float64(c.n) / elapsed.Seconds(),
+		P50:       int64(percentile(latencies, 50)),
+		P99:       int64(percentile(latencies, 99)),
+	}}
+}
+
+// percentile returns the p-th percentile of the sorted durations with the nearest-rank method.
+func percentile(ds []time.Duration, p int) time.Duration {
+	i := (len(ds)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return ds[i]
+}
+
+func (c *benchCmd) print(results []*benchResult) {
+	w := tabwriter.NewWriter(c.ioOut, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ALG\tOP\tN\tOPS/S\tP50\tP99")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.0f\t%s\t%s\n", r.Algorithm, r.Operation, r.Count, r.OpsPerSec,
+			time.Duration(r.P50).Round(time.Microsecond), time.Duration(r.P99).Round(time.Microsecond))
+	}
+	w.Flush()
+}
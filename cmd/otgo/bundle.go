@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/subcommands"
+	otgo "github.com/open-trust/ot-go-lib"
+)
+
+type bundleCmd struct {
+	ioGroup
+	out    string
+	expiry time.Duration
+	roots  string
+}
+
+func (*bundleCmd) Name() string { return "bundle" }
+func (*bundleCmd) Synopsis() string {
+	return "manage a trust bundle: create, merge, inspect, verify."
+}
+func (*bundleCmd) Usage() string {
+	return `bundle [-out filename] [-expiry duration] [-roots rootKeys] <create|merge|inspect|verify> [args...]
+
+Create a trust bundle of the trust domains' live configurations, frozen for 30 days:
+	otgo bundle -out bundle.json -expiry 720h create ot.example.com allied.example.com
+
+Create a trust bundle of the configuration documents with the "file:" prefix, e.g. written by "otgo fetch -config":
+	otgo bundle -out bundle.json create file:config.json file:allied-config.json
+
+Merge trust bundles, the merged bundle expires at the earliest expiry:
+	otgo bundle -out bundle.json merge bundle1.json bundle2.json
+
+Inspect the trust domains, keys and expiry of a trust bundle:
+	otgo bundle inspect bundle.json
+
+Verify a trust bundle is valid and not expired, and optionally a OTVID with it ("-" reads stdin):
+	otgo bundle verify bundle.json eyJhbGciOiJFUzI1NiIsImtpZCI6InFLU0YyS...7xcp0xfcpU3cz8Nn244awnEBl_3Pwjy62nEywLDQ_g
+`
+}
+
+func (c *bundleCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.out, "out", "", "if exists, the result will be written to the file, otherwise to stdout.")
+	f.DurationVar(&c.expiry, "expiry", 0, `if exists, the created or merged bundle expires after the duration, such as "720h", but never later than its existing expiry.`)
+	f.StringVar(&c.roots, "roots", "", "rootKeys should be a local file path or a string that public JWK set of the configurations' root keys, the live configurations must be signed by them.")
+	c.setOutputFlag(f)
+}
+
+func (c *bundleCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	args := f.Args()
+	if err := c.checkFormat(); err != nil {
+		return c.exit(c.Name(), err)
+	}
+	if c.expiry < 0 {
+		return c.exit(c.Name(), usageError(errors.New("the -expiry value is invalid")))
+	}
+	if len(args) == 0 {
+		if c.structured() {
+			return c.exit(c.Name(), usageError(errors.New("bundle action required")))
+		}
+		fmt.Fprintln(c.ioOut, c.Usage())
+		return subcommands.ExitUsageError
+	}
+
+	var err error
+	var b *otgo.TrustBundle
+	switch args[0] {
+	case "create":
+		b, err = c.create(ctx, args[1:])
+	case "merge":
+		b, err = c.merge(args[1:])
+	case "inspect":
+		if len(args) != 2 {
+			return c.exit(c.Name(), usageError(errors.New("a bundle required")))
+		}
+		if b, err = loadBundle(args[1]); err == nil {
+			err = c.inspect(b)
+		}
+		return c.exit(c.Name(), err)
+	case "verify":
+		err = c.verify(args[1:])
+		return c.exit(c.Name(), err)
+	default:
+		err = usageError(fmt.Errorf("unknown bundle action '%s'", args[0]))
+	}
+	if err == nil {
+		if c.expiry > 0 {
+			if exp := time.Now().Add(c.expiry); b.Expiry.IsZero() || exp.Before(b.Expiry) {
+				b.Expiry = exp
+			}
+		}
+		var data []byte
+		if data, err = json.Marshal(b); err == nil {
+			err = c.emit(c.Name(), c.out, data, bundleSummary(b))
+		}
+	}
+	return c.exit(c.Name(), err)
+}
+
+// create creates a bundle of the configuration documents and the trust domains' live configurations.
+// A configuration document is a JSON string or a file path with the "file:" prefix, others are trust domains.
+func (c *bundleCmd) create(ctx context.Context, args []string) (*otgo.TrustBundle, error) {
+	if len(args) == 0 {
+		return nil, usageError(errors.New("trust domains or configuration documents required"))
+	}
+	var roots *otgo.JWKSet
+	if c.roots != "" {
+		var err error
+		if roots, err = parseSetInput(c.roots); err != nil {
+			return nil, parseError(err)
+		}
+	}
+	bundles := make([]*otgo.TrustBundle, 0, len(args))
+	for _, s := range args {
+		var doc []byte
+		if strings.HasPrefix(s, "file:") || strings.HasPrefix(s, "{") {
+			str, err := readInput(strings.TrimPrefix(s, "file:"))
+			if err != nil {
+				return nil, err
+			}
+			doc = []byte(str)
+		} else {
+			td := otgo.TrustDomain(s)
+			if err := td.Validate(); err != nil {
+				return nil, parseError(err)
+			}
+			var err error
+			if _, doc, err = (&fetchCmd{}).fetchConfig(ctx, td, roots); err != nil {
+				return nil, err
+			}
+		}
+		if roots != nil {
+			var err error
+			if doc, err = otgo.VerifySignedConfig(doc, roots); err != nil {
+				return nil, signatureError(err)
+			}
+		}
+		b, err := otgo.ParseTrustBundle([]byte(`{"domains":[` + string(doc) + `]}`))
+		if err != nil {
+			return nil, parseError(err)
+		}
+		bundles = append(bundles, b)
+	}
+	b, err := otgo.MergeTrustBundles(bundles...)
+	if err != nil {
+		return nil, parseError(err)
+	}
+	return b, nil
+}
+
+func (c *bundleCmd) merge(args []string) (*otgo.TrustBundle, error) {
+	if len(args) == 0 {
+		return nil, usageError(errors.New("bundles required"))
+	}
+	bundles := make([]*otgo.TrustBundle, 0, len(args))
+	for _, s := range args {
+		b, err := loadBundle(s)
+		if err != nil {
+			return nil, err
+		}
+		bundles = append(bundles, b)
+	}
+	b, err := otgo.MergeTrustBundles(bundles...)
+	if err != nil {
+		return nil, parseError(err)
+	}
+	return b, nil
+}
+
+func (c *bundleCmd) inspect(b *otgo.TrustBundle) error {
+	if c.structured() {
+		return c.writeEnvelope(&envelope{Command: c.Name(), OK: true, Result: bundleSummary(b)})
+	}
+	w := tabwriter.NewWriter(c.ioOut, 0, 0, 2, ' ', 0)
+	expiry := "never"
+	if !b.Expiry.IsZero() {
+		expiry = b.Expiry.UTC().Format(time.RFC3339)
+		if b.Expired() {
+			expiry += " (expired)"
+		}
+	}
+	fmt.Fprintf(w, "expiry\t%s\n", expiry)
+	for _, d := range b.Domains {
+		fmt.Fprintf(w, "%s\n", d.OTID.String())
+		for _, k := range d.Keys.Keys {
+			fmt.Fprintf(w, "  key\t%s\t%s\n", k.KeyID(), k.Algorithm())
+		}
+		for _, e := range d.ServiceEndpoints {
+			fmt.Fprintf(w, "  endpoint\t%s\n", e)
+		}
+		for iss, kids := range d.Issuers {
+			fmt.Fprintf(w, "  issuer\t%s\t%s\n", iss, strings.Join(kids, ","))
+		}
+	}
+	return w.Flush()
+}
+
+// verify validates the bundle and verifies the OTVID with its issuer's trust domain in the bundle.
+func (c *bundleCmd) verify(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		return usageError(errors.New("a bundle and an optional otvid required"))
+	}
+	b, err := loadBundle(args[0])
+	if err != nil {
+		return err
+	}
+	if b.Expired() {
+		return expiredError(b.Validate())
+	}
+	result := bundleSummary(b)
+	if len(args) == 2 {
+		token := args[1]
+		if token == "-" {
+			if token, err = c.readStdin(); err != nil {
+				return err
+			}
+		}
+		vid, err := otgo.ParseOTVIDInsecure(token)
+		if err != nil {
+			return parseError(err)
+		}
+		d := b.Domain(vid.Issuer.TrustDomain())
+		if d == nil {
+			return claimsError(fmt.Errorf("no trust domain %s of the issuer in the bundle", vid.Issuer.TrustDomain()))
+		}
+		if err = verifyOTVID(token, vid, d.Keys); err != nil {
+			return err
+		}
+		result["otvid"] = map[string]interface{}{
+			"sub": vid.ID,
+			"iss": vid.Issuer,
+			"aud": vid.Audience,
+			"exp": vid.Expiry.Unix(),
+		}
+	}
+	if c.structured() {
+		return c.writeEnvelope(&envelope{Command: c.Name(), OK: true, Result: result})
+	}
+	fmt.Fprintln(c.ioOut, "Verify success!")
+	return nil
+}
+
+// loadBundle parses the bundle file or JSON string.
+func loadBundle(s string) (*otgo.TrustBundle, error) {
+	s, err := readInput(s)
+	if err != nil {
+		return nil, err
+	}
+	b, err := otgo.ParseTrustBundle([]byte(s))
+	if err != nil {
+		return nil, parseError(err)
+	}
+	return b, nil
+}
+
+func bundleSummary(b *otgo.TrustBundle) map[string]interface{} {
+	domains := make([]map[string]interface{}, 0, len(b.Domains))
+	for _, d := range b.Domains {
+		kids := make([]string, 0, len(d.Keys.Keys))
+		for _, k := range d.Keys.Keys {
+			kids = append(kids, k.KeyID())
+		}
+		domains = append(domains, map[string]interface{}{
+			"otid":             d.OTID,
+			"kids":             kids,
+			"serviceEndpoints": d.ServiceEndpoints,
+		})
+	}
+	res := map[string]interface{}{"domains": domains, "expired": b.Expired()}
+	if !b.Expiry.IsZero() {
+		res["exp"] = b.Expiry.Unix()
+	}
+	return res
+}
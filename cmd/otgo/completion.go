@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/subcommands"
+)
+
+// argsCompleter is implemented by the commands with fixed positional arguments, e.g. the jwks actions,
+// they are completed by the shell completion scripts.
+type argsCompleter interface {
+	completionArgs() []string
+}
+
+func (*jwksCmd) completionArgs() []string {
+	return []string{"add", "remove", "public", "merge"}
+}
+
+func (*bundleCmd) completionArgs() []string {
+	return []string{"create", "merge", "inspect", "verify"}
+}
+
+type completionCmd struct {
+	ioGroup
+	cdr *subcommands.Commander
+}
+
+func (*completionCmd) Name() string { return "completion" }
+func (*completionCmd) Synopsis() string {
+	return "generate the shell completion script: bash, zsh, fish."
+}
+func (*completionCmd) Usage() string {
+	return `completion <bash|zsh|fish>
+
+Generate the completion script of the subcommands and their flags for the shell.
+
+Bash, add it to ~/.bashrc:
+	source <(otgo completion bash)
+
+Zsh, add it to ~/.zshrc after compinit:
+	source <(otgo completion zsh)
+
+Fish:
+	otgo completion fish > ~/.config/fish/completions/otgo.fish
+`
+}
+
+func (c *completionCmd) SetFlags(f *flag.FlagSet) {}
+
+func (c *completionCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	args := f.Args()
+	if len(args) != 1 {
+		return c.exit(c.Name(), usageError(errors.New("the shell argument required: bash, zsh or fish")))
+	}
+	spec := c.spec()
+	var buf bytes.Buffer
+	switch args[0] {
+	case "bash":
+		spec.bash(&buf)
+	case "zsh":
+		spec.zsh(&buf)
+	case "fish":
+		spec.fish(&buf)
+	default:
+		return c.exit(c.Name(), usageError(fmt.Errorf("unsupported shell %q", args[0])))
+	}
+	_, err := c.ioOut.Write(buf.Bytes())
+	return c.exit(c.Name(), err)
+}
+
+// completionFlag is a flag of the top level or a subcommand.
+type completionFlag struct {
+	name  string
+	usage string // the first line of the usage
+	value bool   // takes a value, i.e. not a boolean flag
+}
+
+type completionCommand struct {
+	name     string
+	synopsis string
+	flags    []completionFlag
+	args     []string
+}
+
+type completionSpec struct {
+	flags    []completionFlag // the top-level flags
+	commands []completionCommand
+}
+
+// spec collects the registered subcommands and the flags of the commander.
+func (c *completionCmd) spec() *completionSpec {
+	s := &completionSpec{}
+	c.cdr.VisitAll(func(f *flag.Flag) {
+		s.flags = append(s.flags, newCompletionFlag(f))
+	})
+	c.cdr.VisitCommands(func(_ *subcommands.CommandGroup, cmd subcommands.Command) {
+		cc := completionCommand{name: cmd.Name(), synopsis: cmd.Synopsis()}
+		// a new FlagSet, so that the flags of the running command are not touched
+		fs := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+		cmd.SetFlags(fs)
+		fs.VisitAll(func(f *flag.Flag) {
+			cc.flags = append(cc.flags, newCompletionFlag(f))
+		})
+		if ac, ok := cmd.(argsCompleter); ok {
+			cc.args = ac.completionArgs()
+		}
+		s.commands = append(s.commands, cc)
+	})
+	sort.Slice(s.commands, func(i, j int) bool { return s.commands[i].name < s.commands[j].name })
+	// the help command completes the command names
+	for i := range s.commands {
+		if s.commands[i].name == "help" {
+			s.commands[i].args = s.names()
+		}
+	}
+	return s
+}
+
+func newCompletionFlag(f *flag.Flag) completionFlag {
+	usage := strings.TrimSpace(strings.SplitN(f.Usage, "\n", 2)[0])
+	value := true
+	if bf, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && bf.IsBoolFlag() {
+		value = false
+	}
+	return completionFlag{name: "-" + f.Name, usage: usage, value: value}
+}
+
+func (s *completionSpec) names() []string {
+	names := make([]string, 0, len(s.commands))
+	for _, c := range s.commands {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// valueFlags returns the top-level flags that take a value, their values are skipped to find the subcommand.
+func (s *completionSpec) valueFlags() []string {
+	var names []string
+	for _, f := range s.flags {
+		if f.value {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+func flagNames(flags []completionFlag) []string {
+	names := make([]string, 0, len(flags))
+	for _, f := range flags {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func (s *completionSpec) bash(buf *bytes.Buffer) {
+	buf.WriteString("# bash completion for otgo, generated by `otgo completion bash`\n\n")
+	buf.WriteString("_otgo() {\n")
+	buf.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" cmd=\"\" i\n")
+	buf.WriteString("\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+	buf.WriteString("\t\tcase \"${COMP_WORDS[i]}\" in\n")
+	if vf := s.valueFlags(); len(vf) > 0 {
+		fmt.Fprintf(buf, "\t\t%s) ((i++)) ;;\n", strings.Join(vf, "|"))
+	}
+	buf.WriteString("\t\t-*) ;;\n")
+	buf.WriteString("\t\t*) cmd=\"${COMP_WORDS[i]}\"; break ;;\n")
+	buf.WriteString("\t\tesac\n")
+	buf.WriteString("\tdone\n\n")
+	buf.WriteString("\tcase \"$cmd\" in\n")
+	words := append(s.names(), flagNames(s.flags)...)
+	fmt.Fprintf(buf, "\t\"\") COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", strings.Join(words, " "))
+	for _, c := range s.commands {
+		words := append(flagNames(c.flags), c.args...)
+		fmt.Fprintf(buf, "\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", c.name, strings.Join(words, " "))
+	}
+	buf.WriteString("\tesac\n")
+	buf.WriteString("}\n\n")
+	buf.WriteString("complete -o default -F _otgo otgo\n")
+}
+
+func (s *completionSpec) zsh(buf *bytes.Buffer) {
+	buf.WriteString("#compdef otgo\n")
+	buf.WriteString("# zsh completion for otgo, generated by `otgo completion zsh`\n\n")
+	buf.WriteString("_otgo() {\n")
+	buf.WriteString("\tlocal cmd i\n")
+	buf.WriteString("\tlocal -a commands flags args\n")
+	buf.WriteString("\tfor ((i = 2; i < CURRENT; i++)); do\n")
+	buf.WriteString("\t\tcase ${words[i]} in\n")
+	if vf := s.valueFlags(); len(vf) > 0 {
+		fmt.Fprintf(buf, "\t\t%s) ((i++)) ;;\n", strings.Join(vf, "|"))
+	}
+	buf.WriteString("\t\t-*) ;;\n")
+	buf.WriteString("\t\t*) cmd=${words[i]}; break ;;\n")
+	buf.WriteString("\t\tesac\n")
+	buf.WriteString("\tdone\n\n")
+	buf.WriteString("\tcase $cmd in\n")
+	buf.WriteString("\t\"\")\n")
+	buf.WriteString("\t\tcommands=(\n")
+	for _, c := range s.commands {
+		fmt.Fprintf(buf, "\t\t\t%s\n", zshQuote(c.name+":"+zshEscape(c.synopsis)))
+	}
+	buf.WriteString("\t\t)\n")
+	writeZshFlags(buf, s.flags)
+	buf.WriteString("\t\t;;\n")
+	for _, c := range s.commands {
+		fmt.Fprintf(buf, "\t%s)\n", c.name)
+		writeZshFlags(buf, c.flags)
+		if len(c.args) > 0 {
+			quoted := make([]string, 0, len(c.args))
+			for _, a := range c.args {
+				quoted = append(quoted, zshQuote(a))
+			}
+			fmt.Fprintf(buf, "\t\targs=(%s)\n", strings.Join(quoted, " "))
+		}
+		buf.WriteString("\t\t;;\n")
+	}
+	buf.WriteString("\tesac\n\n")
+	buf.WriteString("\tif [[ $PREFIX == -* ]]; then\n")
+	buf.WriteString("\t\t_describe 'flag' flags\n")
+	buf.WriteString("\telif [[ -z $cmd ]]; then\n")
+	buf.WriteString("\t\t_describe 'command' commands\n")
+	buf.WriteString("\telse\n")
+	buf.WriteString("\t\t(( ${#args} )) && compadd -a args\n")
+	buf.WriteString("\t\t_files\n")
+	buf.WriteString("\tfi\n")
+	buf.WriteString("}\n\n")
+	buf.WriteString("if [[ \"$funcstack[1]\" == \"_otgo\" ]]; then\n")
+	buf.WriteString("\t_otgo \"$@\"\n")
+	buf.WriteString("else\n")
+	buf.WriteString("\tcompdef _otgo otgo\n")
+	buf.WriteString("fi\n")
+}
+
+func writeZshFlags(buf *bytes.Buffer, flags []completionFlag) {
+	if len(flags) == 0 {
+		return
+	}
+	buf.WriteString("\t\tflags=(\n")
+	for _, f := range flags {
+		fmt.Fprintf(buf, "\t\t\t%s\n", zshQuote(f.name+":"+zshEscape(f.usage)))
+	}
+	buf.WriteString("\t\t)\n")
+}
+
+// zshEscape escapes the colons of a _describe description.
+func zshEscape(s string) string {
+	return strings.ReplaceAll(s, ":", `\:`)
+}
+
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (s *completionSpec) fish(buf *bytes.Buffer) {
+	buf.WriteString("# fish completion for otgo, generated by `otgo completion fish`\n\n")
+	buf.WriteString("complete -c otgo -f\n")
+	for _, f := range s.flags {
+		fmt.Fprintf(buf, "complete -c otgo -n __fish_use_subcommand %s\n", fishFlag(f))
+	}
+	for _, c := range s.commands {
+		fmt.Fprintf(buf, "complete -c otgo -n __fish_use_subcommand -a %s -d %s\n", c.name, fishQuote(c.synopsis))
+	}
+	for _, c := range s.commands {
+		cond := fishQuote("__fish_seen_subcommand_from " + c.name)
+		for _, f := range c.flags {
+			fmt.Fprintf(buf, "complete -c otgo -n %s %s\n", cond, fishFlag(f))
+		}
+		if len(c.args) > 0 {
+			fmt.Fprintf(buf, "complete -c otgo -n %s -a %s\n", cond, fishQuote(strings.Join(c.args, " ")))
+		}
+	}
+}
+
+func fishFlag(f completionFlag) string {
+	s := "-o " + strings.TrimPrefix(f.name, "-")
+	if f.value {
+		s += " -r -F" // the value may be a file
+	}
+	if f.usage != "" {
+		s += " -d " + fishQuote(f.usage)
+	}
+	return s
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configFile is the otgo config file, e.g.
+//
+//	{
+//	  "profile": "dev",
+//	  "profiles": {
+//	    "dev": {"trustDomain": "localhost", "sub": "otid:localhost:app:123", "jwk": "~/.otgo/dev.jwk", "endpoint": "http://localhost:8080"},
+//	    "prod": {"trustDomain": "ot.example.com", "sub": "otid:ot.example.com:app:123", "jwk": "~/.otgo/prod.jwk"}
+//	  }
+//	}
+type configFile struct {
+	Profile  string              `json:"profile"` // the default profile
+	Profiles map[string]*profile `json:"profiles"`
+}
+
+// profile holds the defaults of the flags shared by sign, verify and renew commands,
+// the flags always take precedence over them.
+type profile struct {
+	TrustDomain string `json:"trustDomain"` // default issuer of sign, default public keys of verify
+	Subject     string `json:"sub"`         // default -sub of sign and renew
+	Audience    string `json:"aud"`         // default -aud of sign and renew
+	JWK         string `json:"jwk"`         // default -jwk of sign and renew, the private key
+	PublicJWK   string `json:"publicJwk"`   // default -jwk of verify
+	Endpoint    string `json:"endpoint"`    // default -endpoint of renew, the OT-Auth service
+}
+
+// the environment variables override the profile.
+var profileEnvs = map[string]func(p *profile) *string{
+	"OTGO_TRUST_DOMAIN": func(p *profile) *string { return &p.TrustDomain },
+	"OTGO_SUB":          func(p *profile) *string { return &p.Subject },
+	"OTGO_AUD":          func(p *profile) *string { return &p.Audience },
+	"OTGO_JWK":          func(p *profile) *string { return &p.JWK },
+	"OTGO_PUBLIC_JWK":   func(p *profile) *string { return &p.PublicJWK },
+	"OTGO_ENDPOINT":     func(p *profile) *string { return &p.Endpoint },
+}
+
+func defaultConfigPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".otgo", "config")
+}
+
+// loadProfile loads the profile from the config file and overrides it with OTGO_* environment variables.
+// The config file is the path (-config flag), OTGO_CONFIG or ~/.otgo/config, only the default file may be absent.
+// The profile is the name (-profile flag), OTGO_PROFILE, the config file's default profile or "default".
+func loadProfile(path, name string) (*profile, error) {
+	if path == "" {
+		path = os.Getenv("OTGO_CONFIG")
+	}
+	if name == "" {
+		name = os.Getenv("OTGO_PROFILE")
+	}
+	cfg := &configFile{}
+	if path != "" {
+		if err := cfg.load(path); err != nil {
+			return nil, err
+		}
+	} else if p := defaultConfigPath(); p != "" {
+		if err := cfg.load(p); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	explicit := name != ""
+	if name == "" {
+		name = cfg.Profile
+	}
+	if name == "" {
+		name = "default"
+	}
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		if explicit || cfg.Profile != "" {
+			return nil, fmt.Errorf("profile %q not found in the config file", name)
+		}
+		p = &profile{}
+	}
+	for env, field := range profileEnvs {
+		if v := os.Getenv(env); v != "" {
+			*field(p) = v
+		}
+	}
+	p.JWK = expandHome(p.JWK)
+	p.PublicJWK = expandHome(p.PublicJWK)
+	return p, nil
+}
+
+func (cfg *configFile) load(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(b, cfg); err != nil {
+		return fmt.Errorf("invalid config file %s: %s", path, err.Error())
+	}
+	return nil
+}
+
+// expandHome expands the leading "~/" of a file path.
+func expandHome(s string) string {
+	if !strings.HasPrefix(s, "~/") {
+		return s
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return s
+	}
+	return filepath.Join(home, s[2:])
+}
+
+// setDefault sets the flag's value to v if the flag is absent.
+func setDefault(flag *string, v string) {
+	if *flag == "" {
+		*flag = v
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/subcommands"
+	otgo "github.com/open-trust/ot-go-lib"
+)
+
+type fetchCmd struct {
+	ioGroup
+	td       string
+	endpoint string
+	keys     string
+	config   string
+	roots    string
+	watch    time.Duration
+}
+
+func (*fetchCmd) Name() string { return "fetch" }
+func (*fetchCmd) Synopsis() string {
+	return "download and cache the trust domain's configuration and public keys."
+}
+func (*fetchCmd) Usage() string {
+	return `fetch [-td trustDomain] [-endpoint url] [-keys filename] [-config filename] [-roots rootKeys] [-watch interval]
+
+Fetch the trust domain's well-known configuration, validate its OTID and keys,
+and write the public JWK set and the configuration document to the files:
+	otgo fetch -td ot.example.com -keys keys.json -config config.json
+
+Refresh the files on the interval for the deployments without a sidecar, a failed refresh keeps the files:
+	otgo fetch -td ot.example.com -keys /etc/otgo/keys.json -watch 10m
+
+Trust only the configuration signed by the offline root keys (see otgo.PublishSignedConfig):
+	otgo fetch -td ot.example.com -roots roots.json -keys keys.json
+`
+}
+
+func (c *fetchCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.td, "td", "", "trustDomain should be a domain name, default to the profile's trust domain.")
+	f.StringVar(&c.endpoint, "endpoint", "", "if exists, the configuration will be fetched from the endpoint instead of the trust domain, e.g. http://localhost:8080")
+	f.StringVar(&c.keys, "keys", "", "if exists, the public JWK set will be written to the file, otherwise to stdout.")
+	f.StringVar(&c.config, "config", "", "if exists, the configuration document will be written to the file.")
+	f.StringVar(&c.roots, "roots", "", "rootKeys should be a local file path or a string that public JWK set of the configuration's root keys.")
+	f.DurationVar(&c.watch, "watch", 0, `if exists, the files will be refreshed on the interval, such as "10m" or "1h". The -keys flag required.`)
+	c.setOutputFlag(f)
+}
+
+func (c *fetchCmd) Execute(ctx context.Context, _ *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	setDefault(&c.td, conf.TrustDomain)
+	setDefault(&c.endpoint, conf.Endpoint)
+
+	err := c.checkFormat()
+	if err != nil {
+	} else if c.td == "" {
+		err = usageError(errors.New("the -td flag required"))
+	} else if c.watch < 0 {
+		err = usageError(errors.New("the -watch value is invalid"))
+	} else if c.watch > 0 && c.keys == "" {
+		err = usageError(errors.New("the -watch flag requires the -keys flag"))
+	} else if c.watch > 0 && c.structured() {
+		err = usageError(errors.New("the -watch flag requires the text output format"))
+	}
+	if err == nil {
+		err = c.fetch(ctx)
+	}
+	return c.exit(c.Name(), err)
+}
+
+func (c *fetchCmd) fetch(ctx context.Context) error {
+	td := otgo.TrustDomain(c.td)
+	if err := td.Validate(); err != nil {
+		return parseError(err)
+	}
+	var roots *otgo.JWKSet
+	if c.roots != "" {
+		var err error
+		if roots, err = parseSetInput(c.roots); err != nil {
+			return parseError(err)
+		}
+	}
+
+	ks, doc, err := c.fetchConfig(ctx, td, roots)
+	if err != nil {
+		return err
+	}
+	if c.watch == 0 {
+		data, err := json.Marshal(ks)
+		if err != nil {
+			return err
+		}
+		if c.config != "" {
+			if err := ioutil.WriteFile(c.config, doc, 0644); err != nil {
+				return err
+			}
+		}
+		return c.emit(c.Name(), c.keys, data, map[string]interface{}{
+			"otid": td.OTID(),
+			"keys": ks,
+		})
+	}
+
+	for {
+		if err == nil {
+			err = c.writeFiles(ks, doc)
+		}
+		if err != nil {
+			fmt.Fprintf(c.ioErr, "%s refresh failed: %s\n", time.Now().Format(time.RFC3339), err.Error())
+		} else {
+			fmt.Fprintf(c.ioOut, "%s refreshed %d keys of %s\n", time.Now().Format(time.RFC3339), len(ks.Keys), td.OTID())
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.watch):
+		}
+		ks, doc, err = c.fetchConfig(ctx, td, roots)
+	}
+}
+
+// fetchConfig fetches the trust domain's configuration, verifies it with the root keys if exists,
+// and returns the public keys and the configuration document.
+func (c *fetchCmd) fetchConfig(ctx context.Context, td otgo.TrustDomain, roots *otgo.JWKSet) (*otgo.JWKSet, []byte, error) {
+	url := td.ConfigURL()
+	if c.endpoint != "" {
+		url = strings.TrimSuffix(c.endpoint, "/") + "/.well-known/open-trust-configuration"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var doc json.RawMessage
+	if err := cli.Do(ctx, "GET", url, nil, nil, &doc); err != nil {
+		return nil, nil, err
+	}
+	trusted := []byte(doc)
+	if roots != nil {
+		var err error
+		if trusted, err = otgo.VerifySignedConfig(doc, roots); err != nil {
+			return nil, nil, signatureError(err)
+		}
+	}
+
+	cfg := struct {
+		OTID string `json:"otid"`
+	}{}
+	if err := json.Unmarshal(trusted, &cfg); err != nil {
+		return nil, nil, parseError(err)
+	}
+	otid, err := otgo.ParseOTID(cfg.OTID)
+	if err != nil {
+		return nil, nil, parseError(err)
+	}
+	if !otid.Equal(td.OTID()) {
+		return nil, nil, claimsError(fmt.Errorf("the configuration's OTID %s not match the trust domain %s", otid, td))
+	}
+	ks, err := otgo.ParseSet(string(trusted))
+	if err == nil {
+		ks, err = otgo.NewKeys(ks.Keys...)
+	}
+	if err != nil {
+		return nil, nil, parseError(err)
+	}
+	return otgo.LookupPublicKeys(ks), doc, nil
+}
+
+// writeFiles writes the changed files, the sidecar-less services watching the files
+// see either the old or the new content.
+func (c *fetchCmd) writeFiles(ks *otgo.JWKSet, doc []byte) error {
+	data, err := json.Marshal(ks)
+	if err != nil {
+		return err
+	}
+	if err = writeFileAtomic(c.keys, data); err == nil && c.config != "" {
+		err = writeFileAtomic(c.config, doc)
+	}
+	return err
+}
+
+func writeFileAtomic(filename string, data []byte) error {
+	if b, err := ioutil.ReadFile(filename); err == nil && bytes.Equal(b, data) {
+		return nil
+	}
+	tmp := filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+
+	"github.com/google/subcommands"
+	otgo "github.com/open-trust/ot-go-lib"
+)
+
+type keygenCmd struct {
+	ioGroup
+	alg    string
+	bits   int
+	set    string
+	rotate bool
+	keep   int
+	out    string
+	pub    string
+}
+
+func (*keygenCmd) Name() string { return "keygen" }
+func (*keygenCmd) Synopsis() string {
+	return "generate or rotate a private JWK set for signing OTVIDs."
+}
+func (*keygenCmd) Usage() string {
+	return `keygen [-alg algorithm] [-bits size] [-set jwkSet] [-rotate] [-keep n] [-out filename] [-pub filename]
+
+The layout of the set follows otgo.LookupSigningKey: the first key is the pre-published next key,
+the second key is the signing key, and the rest are retired keys kept for verification.
+
+Generate a new private JWK set with the signing key and the next key:
+	otgo keygen -alg ES256 -out keys.json -pub pub.json
+
+Rotate the set, the next key becomes the signing key, and keys retired more than 2 rotations ago are dropped:
+	otgo keygen -rotate -set keys.json -keep 2 -out keys.json -pub pub.json
+
+Generate a set of 3072-bit RSA keys, the rotation keeps the signing key's size unless the -bits flag exists:
+	otgo keygen -alg PS256 -bits 3072 -out keys.json -pub pub.json
+`
+}
+
+func (c *keygenCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.alg, "alg", "", "algorithm of the new keys, default to the signing key's algorithm or ES256.")
+	f.IntVar(&c.bits, "bits", 0, "size of the new RSA keys, one of 2048, 3072, 4096, default to the signing key's size or 2048.")
+	f.StringVar(&c.set, "set", "", "jwkSet should be a local file path or a string that private JWK set represented by JWK [RFC7517].")
+	f.BoolVar(&c.rotate, "rotate", false, "rotate the JWK set of the -set flag.")
+	f.IntVar(&c.keep, "keep", 1, "number of rotations the retired keys are kept for verification.")
+	f.StringVar(&c.out, "out", "", "if exists, the private JWK set will be written to the file with mode 0600, otherwise to stdout.")
+	f.StringVar(&c.pub, "pub", "", "if exists, the public JWK set will be written to the file with mode 0600, otherwise to stdout.")
+	c.setOutputFlag(f)
+}
+
+func (c *keygenCmd) Execute(_ context.Context, _ *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	err := c.checkFormat()
+	if err != nil {
+	} else if c.rotate && c.set == "" {
+		err = usageError(errors.New("the -set flag required"))
+	} else if !c.rotate && c.set != "" {
+		err = usageError(errors.New("the -set flag requires the -rotate flag"))
+	} else if c.keep < 0 {
+		err = usageError(errors.New("the -keep value is invalid"))
+	}
+	if err == nil {
+		err = c.keygen()
+	}
+	return c.exit(c.Name(), err)
+}
+
+func (c *keygenCmd) keygen() error {
+	ks := &otgo.JWKSet{}
+	if c.rotate {
+		var err error
+		if ks, err = parseSetInput(c.set); err != nil {
+			return parseError(err)
+		}
+		signingKey, err := otgo.LookupSigningKey(ks)
+		if err != nil {
+			return parseError(err)
+		}
+		if c.alg == "" {
+			c.alg = signingKey.Algorithm()
+		}
+		if c.bits == 0 {
+			c.bits = rsaBits(signingKey)
+		}
+	}
+	if c.alg == "" {
+		c.alg = "ES256"
+	}
+
+	n := 1
+	if len(ks.Keys) == 0 {
+		n = 2 // the signing key and the next key
+	}
+	for i := 0; i < n; i++ {
+		key, err := otgo.NewPrivateKeyWithOptions(c.alg, otgo.WithRSABits(c.bits))
+		if err != nil {
+			return usageError(err)
+		}
+		ks = rotateSet(ks, key, c.keep)
+	}
+
+	pks := otgo.LookupPublicKeys(ks)
+	data, err := json.Marshal(ks)
+	if err != nil {
+		return err
+	}
+	pdata, err := json.Marshal(pks)
+	if err != nil {
+		return err
+	}
+	if c.structured() {
+		signingKey, _ := otgo.LookupSigningKey(ks)
+		if c.pub != "" {
+			if err := ioutil.WriteFile(c.pub, pdata, outputFileMode); err != nil {
+				return err
+			}
+		}
+		result := map[string]interface{}{
+			"publicKeys": pks,
+			"signingKid": signingKey.KeyID(),
+			"nextKid":    ks.Keys[0].KeyID(),
+		}
+		// the private set is printed only if it is not written to the -out file
+		if c.out == "" {
+			result["keys"] = ks
+		}
+		return c.emit(c.Name(), c.out, data, result)
+	}
+	if err := c.output(c.out, data); err != nil {
+		return err
+	}
+	return c.output(c.pub, pdata)
+}
+
+// rotateSet prepends the key as the next key, so the previous next key becomes the signing key,
+// and drops the keys retired more than keep rotations ago.
+func rotateSet(ks *otgo.JWKSet, key otgo.Key, keep int) *otgo.JWKSet {
+	keys := append([]otgo.Key{key}, ks.Keys...)
+	if max := keep + 2; len(keys) > max {
+		keys = keys[:max]
+	}
+	return &otgo.JWKSet{Keys: keys}
+}
+
+// rsaBits returns the size of the RSA key, or 0 if it is not a RSA key.
+func rsaBits(key otgo.Key) int {
+	var raw interface{}
+	if err := key.Raw(&raw); err != nil {
+		return 0
+	}
+	switch k := raw.(type) {
+	case *rsa.PrivateKey:
+		return k.N.BitLen()
+	case *rsa.PublicKey:
+		return k.N.BitLen()
+	}
+	return 0
+}
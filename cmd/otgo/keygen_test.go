@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func kids(ks *otgo.JWKSet) []string {
+	rs := make([]string, 0, len(ks.Keys))
+	for _, k := range ks.Keys {
+		rs = append(rs, k.KeyID())
+	}
+	return rs
+}
+
+func TestRotateSet(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		existing int // keys of the set before the rotations
+		keep     int
+		max      int // keys of the set after the rotations
+	}{
+		{"keep=1", 2, 1, 3},
+		{"keep=2", 2, 2, 4},
+		{"keep=N", 2, 5, 7},
+		{"fewer keys than keep", 1, 3, 5},
+		{"no retired keys", 2, 0, 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			ks := &otgo.JWKSet{}
+			for i := 0; i < tc.existing; i++ {
+				ks.Keys = append(ks.Keys, otgo.MustPrivateKey("ES256"))
+			}
+			want := kids(ks)
+			for i := 0; i < 6; i++ {
+				next := ks.Keys[0]
+				key := otgo.MustPrivateKey("ES256")
+				assert.NotEqual("", key.KeyID())
+				ks = rotateSet(ks, key, tc.keep)
+
+				want = append([]string{key.KeyID()}, want...)
+				if len(want) > tc.max {
+					want = want[:tc.max]
+				}
+				assert.Equal(want, kids(ks))
+
+				// the previous next key signs, the new key is pre-published
+				signingKey, err := otgo.LookupSigningKey(ks)
+				assert.Nil(err)
+				assert.Equal(next.KeyID(), signingKey.KeyID())
+				assert.Equal(key.KeyID(), ks.Keys[0].KeyID())
+			}
+			assert.Equal(tc.max, len(ks.Keys))
+		})
+	}
+}
+
+func TestKeygenCmd(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "keys.json")
+	pub := filepath.Join(dir, "pub.json")
+
+	keygen := func(rotate bool, format string) (*bytes.Buffer, error) {
+		buf := &bytes.Buffer{}
+		c := &keygenCmd{ioGroup: ioGroup{ioOut: buf, format: format}, keep: 1, out: out, pub: pub, rotate: rotate}
+		if rotate {
+			c.set = out
+		}
+		return buf, c.keygen()
+	}
+
+	t.Run("structured output", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var prev *otgo.JWKSet
+		for _, rotate := range []bool{false, true, true} {
+			buf, err := keygen(rotate, "json")
+			assert.Nil(err)
+
+			res := struct {
+				Result struct {
+					SigningKid string `json:"signingKid"`
+					NextKid    string `json:"nextKid"`
+				} `json:"result"`
+			}{}
+			assert.Nil(json.Unmarshal(buf.Bytes(), &res))
+			ks, err := parseSetInput(out)
+			assert.Nil(err)
+			signingKey, err := otgo.LookupSigningKey(ks)
+			assert.Nil(err)
+			assert.Equal(signingKey.KeyID(), res.Result.SigningKid)
+			assert.Equal(ks.Keys[0].KeyID(), res.Result.NextKid)
+			if prev != nil {
+				assert.Equal(prev.Keys[0].KeyID(), res.Result.SigningKid)
+			}
+			assert.True(len(ks.Keys) <= 3)
+			prev = ks
+		}
+	})
+
+	t.Run("file mode", func(t *testing.T) {
+		assert := assert.New(t)
+
+		for _, format := range []string{"text", "json"} {
+			os.Remove(out)
+			os.Remove(pub)
+			_, err := keygen(false, format)
+			assert.Nil(err)
+			for _, filename := range []string{out, pub} {
+				fi, err := os.Stat(filename)
+				assert.Nil(err)
+				assert.Equal(os.FileMode(outputFileMode), fi.Mode().Perm(), format+" "+filename)
+			}
+		}
+	})
+}
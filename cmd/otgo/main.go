@@ -2,15 +2,19 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/google/subcommands"
@@ -18,21 +22,38 @@ import (
 	otgo "github.com/open-trust/ot-go-lib"
 )
 
+// outputFileMode is the mode of the output files, they may be private keys or OTVIDs.
+const outputFileMode = 0600
+
 type ioGroup struct {
-	ioOut io.Writer
-	ioErr io.Writer
+	ioIn   io.Reader
+	ioOut  io.Writer
+	ioErr  io.Writer
+	format string // output format, see setOutputFlag
 }
 
 func (i *ioGroup) output(filename string, data []byte) error {
 	var err error
 	if filename != "" {
-		err = ioutil.WriteFile(filename, data, 0644)
+		err = ioutil.WriteFile(filename, data, outputFileMode)
 	} else {
 		fmt.Fprintln(i.ioOut, string(data))
 	}
 	return err
 }
 
+// readStdin reads all of stdin and trims the surrounding whitespace, so the commands compose in shell pipelines.
+func (i *ioGroup) readStdin() (string, error) {
+	if i.ioIn == nil {
+		return "", errors.New("stdin not available")
+	}
+	b, err := ioutil.ReadAll(i.ioIn)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
 type versionCmd struct {
 	ioGroup
 }
@@ -48,18 +69,30 @@ Print otgo version.
 `
 }
 
-func (c *versionCmd) SetFlags(_ *flag.FlagSet) {}
+func (c *versionCmd) SetFlags(f *flag.FlagSet) {
+	c.setOutputFlag(f)
+}
 
 func (c *versionCmd) Execute(_ context.Context, _ *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	fmt.Fprintln(c.ioOut, fmt.Sprintf("otgo version %s %s/%s", otgo.Version, runtime.GOOS, runtime.GOARCH))
-	return subcommands.ExitSuccess
+	err := c.checkFormat()
+	if err == nil {
+		data := fmt.Sprintf("otgo version %s %s/%s", otgo.Version, runtime.GOOS, runtime.GOARCH)
+		err = c.emit(c.Name(), "", []byte(data), map[string]string{
+			"version": otgo.Version,
+			"os":      runtime.GOOS,
+			"arch":    runtime.GOARCH,
+		})
+	}
+	return c.exit(c.Name(), err)
 }
 
 type keyCmd struct {
 	ioGroup
-	alg string
-	jwk string
-	out string
+	alg     string
+	bits    int
+	jwk     string
+	out     string
+	encrypt bool
 }
 
 func (*keyCmd) Name() string { return "key" }
@@ -67,82 +100,128 @@ func (*keyCmd) Synopsis() string {
 	return "generate a new private key or generate a public key from a private key."
 }
 func (*keyCmd) Usage() string {
-	return `key [-alg algorithm] [-jwk privateKey] [-out filename]
+	return `key [-alg algorithm] [-bits size] [-jwk privateKey] [-out filename] [-encrypt]
 
 Generate a new private key:
 	otgo key -alg ES256 -out key.jwk
 
+Generate a new 3072-bit RSA private key:
+	otgo key -alg PS256 -bits 3072 -out key.jwk
+
+Generate a new private key encrypted with a passphrase (JWE, PBES2-HS512+A256KW and A256GCM):
+	otgo key -alg ES256 -encrypt -out key.jwe
+
 Generate a public key from a private key:
 	otgo key -jwk key.jwk -out pub.jwk
 	otgo key -jwk '{"kty":"EC","alg":"ES256","crv":"P-256", ...i20cxb248khaEA5PYmeB9Z4YBY"}'
+
+The encrypted private key files are accepted by the -jwk flag of key, sign, renew and jwks add commands.
+The passphrase is read from $OTGO_PASSPHRASE, or from the file descriptor $OTGO_PASSPHRASE_FD,
+otherwise it is prompted on the terminal.
 `
 }
 
 func (c *keyCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.alg, "alg", "", "algorithm should be one of RS256, RS384, RS512, ES256, ES384, ES512, PS256, PS384, PS512")
+	f.IntVar(&c.bits, "bits", 0, "size of the new RSA private key, one of 2048, 3072, 4096, default to 2048.")
 	f.StringVar(&c.jwk, "jwk", "", "privateKey should be a local file path or a string that private key represented by JWK [RFC7517].\nIf this flag exists, the -alg flag will be ignored.")
 	f.StringVar(&c.out, "out", "", "if exists, the result will be written to the file, otherwise to stdout.")
+	f.BoolVar(&c.encrypt, "encrypt", false, "encrypt the new private key with a passphrase. The -out flag required.")
+	c.setOutputFlag(f)
 }
 
 func (c *keyCmd) Execute(_ context.Context, _ *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	var err error
+	err := c.checkFormat()
 	switch {
+	case err != nil:
+	case c.encrypt && (c.jwk != "" || c.out == ""):
+		err = usageError(errors.New("the -encrypt flag requires the -alg and -out flags"))
 	case c.jwk != "":
 		err = c.genPublicKey()
 	case c.alg != "":
 		err = c.genPrivateKey()
+	case c.structured():
+		err = usageError(errors.New("the -alg or -jwk flag required"))
 	default:
 		fmt.Fprintln(c.ioOut, c.Usage())
 	}
-	if err != nil {
-		fmt.Fprintln(c.ioErr, err)
-		return subcommands.ExitFailure
+	return c.exit(c.Name(), err)
+}
+
+// keyResult returns the result of the generated private key in the envelope, it is the public key
+// if the private key is written to the -out file, so that it is never printed to stdout.
+func (c *keyCmd) keyResult(key otgo.Key) interface{} {
+	if c.out == "" {
+		return key
 	}
-	return subcommands.ExitSuccess
+	if pub, err := otgo.ToPublicKey(key); err == nil {
+		return pub
+	}
+	return nil
 }
 
 func (c *keyCmd) genPrivateKey() error {
-	key, err := otgo.NewPrivateKey(c.alg)
-	if err == nil {
-		var data []byte
-		if data, err = json.Marshal(key); err == nil {
-			err = c.output(c.out, data)
+	key, err := otgo.NewPrivateKeyWithOptions(c.alg, otgo.WithRSABits(c.bits))
+	if err != nil {
+		return usageError(err)
+	}
+	data, err := json.Marshal(key)
+	if err != nil || !c.encrypt {
+		if err == nil {
+			err = c.emit(c.Name(), c.out, data, c.keyResult(key))
 		}
+		return err
+	}
+
+	p, err := readPassphrase(true)
+	if err != nil {
+		return err
+	}
+	if data, err = otgo.EncryptKeyFile(data, p); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(c.out, data, 0600); err != nil {
+		return err
+	}
+	// the private key is never printed in the envelope of an encrypted key
+	pub, err := otgo.ToPublicKey(key)
+	if err == nil && c.structured() {
+		err = c.writeEnvelope(&envelope{Command: c.Name(), OK: true, Result: pub})
 	}
 	return err
 }
 
 func (c *keyCmd) genPublicKey() error {
-	s := c.jwk
-	if !strings.HasPrefix(s, "{") {
-		b, err := ioutil.ReadFile(s)
-		if err != nil {
-			return err
-		}
-		s = string(b)
+	s, err := readKeyInput(c.jwk)
+	if err != nil {
+		return err
 	}
 
 	key, err := otgo.ParseKey(s)
 	if err == nil {
 		key, err = otgo.ToPublicKey(key)
-		if err == nil {
-			var data []byte
-			if data, err = json.Marshal(key); err == nil {
-				err = c.output(c.out, data)
-			}
-		}
+	}
+	if err != nil {
+		return parseError(err)
+	}
+	data, err := json.Marshal(key)
+	if err == nil {
+		err = c.emit(c.Name(), c.out, data, key)
 	}
 	return err
 }
 
 type signCmd struct {
 	ioGroup
-	jwk string
-	out string
-	sub string
-	iss string
-	aud string
-	exp time.Duration
+	jwk        string
+	out        string
+	sub        string
+	iss        string
+	aud        string
+	exp        time.Duration
+	claims     string
+	claimsFile string
+	claim      claimFlags
 }
 
 func (*signCmd) Name() string { return "sign" }
@@ -150,10 +229,16 @@ func (*signCmd) Synopsis() string {
 	return "sign a OTVID with the given private key and payload."
 }
 func (*signCmd) Usage() string {
-	return `sign [-jwk privateKey] [-out filename] [-sub subject] [-iss issuer] [-aud audience] [-exp expiry]
+	return `sign [-jwk privateKey] [-out filename] [-sub subject] [-iss issuer] [-aud audience] [-exp expiry] [-claims claims] [-claims-file filename] [-claim key=value]...
 
 Sign a OTVID with the given private key and payload:
 	otgo sign -jwk key.jwk -sub otid:localhost:test:123 -iss otid:localhost -aud otid:localhost:svc:auth -exp 24h
+
+Sign a OTVID with the release id and private claims:
+	otgo sign -jwk key.jwk -sub otid:localhost:test:123 -iss otid:localhost -aud otid:localhost:svc:auth -claims-file claims.json -claim rid=v1 -claim scp='["read","write"]'
+
+Sign a OTVID with private claims read from stdin, and verify it:
+	echo '{"name":"tester"}' | otgo sign -jwk key.jwk -sub otid:localhost:test:123 -iss otid:localhost -aud otid:localhost:svc:auth -claims - | otgo verify -jwk pub.jwk
 `
 }
 
@@ -164,61 +249,141 @@ func (c *signCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.iss, "iss", "", "issuer should be a OTID")
 	f.StringVar(&c.aud, "aud", "", "audience should be a OTID")
 	f.DurationVar(&c.exp, "exp", time.Minute*10, `expiry should be a duration string, such as "30m", "1.5h" or "2h45m". Valid time units are "s", "m", "h".`)
+	f.StringVar(&c.claims, "claims", "", `claims should be a JSON object of private claims, or "-" to read it from stdin.`)
+	f.StringVar(&c.claimsFile, "claims-file", "", "read a JSON object of private claims from the file.")
+	f.Var(&c.claim, "claim", "a private claim in the form key=value, it can be repeated and overrides the -claims and -claims-file flags.\nThe value is parsed as JSON if possible, otherwise as a string. The \"rid\" claim sets the release id.")
+	c.setOutputFlag(f)
 }
 
 func (c *signCmd) Execute(_ context.Context, _ *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	var err error
-	if c.jwk == "" {
-		err = errors.New("the -jwk flag required")
+	setDefault(&c.jwk, conf.JWK)
+	setDefault(&c.sub, conf.Subject)
+	setDefault(&c.aud, conf.Audience)
+	if conf.TrustDomain != "" {
+		setDefault(&c.iss, otgo.TrustDomain(conf.TrustDomain).OTID().String())
+	}
+
+	err := c.checkFormat()
+	if err != nil {
+	} else if c.jwk == "" {
+		err = usageError(errors.New("the -jwk flag required"))
 	} else if c.sub == "" {
-		err = errors.New("the -sub flag required")
+		err = usageError(errors.New("the -sub flag required"))
 	} else if c.iss == "" {
-		err = errors.New("the -iss flag required")
+		err = usageError(errors.New("the -iss flag required"))
 	} else if c.aud == "" {
-		err = errors.New("the -aud flag required")
+		err = usageError(errors.New("the -aud flag required"))
 	} else if c.exp < 1 {
-		err = errors.New("the -exp value is invalid")
+		err = usageError(errors.New("the -exp value is invalid"))
 	}
 	if err == nil {
 		err = c.sign()
 	}
+	return c.exit(c.Name(), err)
+}
+
+func (c *signCmd) sign() error {
+	s, err := readKeyInput(c.jwk)
+	if err != nil {
+		return err
+	}
+
+	key, err := otgo.ParseKey(s)
+	if err != nil {
+		return parseError(err)
+	}
+	ids, err := otgo.ParseOTIDs(c.sub, c.iss, c.aud)
+	if err != nil {
+		return parseError(err)
+	}
+	vid := otgo.OTVID{
+		ID:       ids[0],
+		Issuer:   ids[1],
+		Audience: ids[2],
+		Expiry:   time.Now().UTC().Add(c.exp).Truncate(time.Second),
+	}
+	if err = c.setClaims(&vid); err != nil {
+		return err
+	}
+	token, err := vid.Sign(key)
 	if err != nil {
-		fmt.Fprintln(c.ioErr, err)
-		return subcommands.ExitFailure
+		return err
 	}
-	return subcommands.ExitSuccess
+	return c.emit(c.Name(), c.out, []byte(token), map[string]interface{}{
+		"otvid":  token,
+		"kid":    key.KeyID(),
+		"sub":    vid.ID,
+		"iss":    vid.Issuer,
+		"aud":    vid.Audience,
+		"exp":    vid.Expiry.Unix(),
+		"rid":    vid.ReleaseID,
+		"claims": vid.Claims,
+	})
 }
 
-func (c *signCmd) sign() error {
-	s := c.jwk
-	if !strings.HasPrefix(s, "{") {
-		b, err := ioutil.ReadFile(s)
+// setClaims sets the private claims of the -claims-file, -claims and -claim flags to the OTVID, in that order.
+func (c *signCmd) setClaims(vid *otgo.OTVID) error {
+	claims := make(map[string]interface{})
+	if c.claimsFile != "" {
+		b, err := ioutil.ReadFile(c.claimsFile)
 		if err != nil {
 			return err
 		}
-		s = string(b)
+		if err = json.Unmarshal(b, &claims); err != nil {
+			return parseError(fmt.Errorf("invalid claims file: %s", err.Error()))
+		}
 	}
-
-	key, err := otgo.ParseKey(s)
-	if err == nil {
-		var ids otgo.OTIDs
-		ids, err = otgo.ParseOTIDs(c.sub, c.iss, c.aud)
-		if err == nil {
-			vid := otgo.OTVID{
-				ID:       ids[0],
-				Issuer:   ids[1],
-				Audience: ids[2],
-				Expiry:   time.Now().UTC().Add(c.exp).Truncate(time.Second),
-			}
-
-			var token string
-			token, err = vid.Sign(key)
-			if err == nil {
-				err = c.output(c.out, []byte(token))
+	if s := c.claims; s != "" {
+		if s == "-" {
+			var err error
+			if s, err = c.readStdin(); err != nil {
+				return err
 			}
 		}
+		if err := json.Unmarshal([]byte(s), &claims); err != nil {
+			return parseError(fmt.Errorf("invalid claims: %s", err.Error()))
+		}
 	}
-	return err
+	for k, v := range c.claim {
+		claims[k] = v
+	}
+	if rid, ok := claims["rid"]; ok {
+		if vid.ReleaseID, ok = rid.(string); !ok {
+			return usageError(errors.New("the rid claim should be a string"))
+		}
+		delete(claims, "rid")
+	}
+	if len(claims) == 0 {
+		return nil
+	}
+	if err := vid.SetClaims(claims); err != nil {
+		return usageError(err)
+	}
+	return nil
+}
+
+// claimFlags is a repeatable flag of key=value claims.
+type claimFlags map[string]interface{}
+
+func (f claimFlags) String() string {
+	b, _ := json.Marshal(map[string]interface{}(f))
+	return string(b)
+}
+
+func (f *claimFlags) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return fmt.Errorf("invalid claim %q, it should be key=value", s)
+	}
+	if *f == nil {
+		*f = make(claimFlags)
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(s[i+1:]), &v); err != nil {
+		v = s[i+1:]
+	}
+	(*f)[s[:i]] = v
+	return nil
 }
 
 type verifyCmd struct {
@@ -235,36 +400,47 @@ func (*verifyCmd) Usage() string {
 	return `verify [-jwk publicKey] [-out filename] [otvid]
 
 Parse and verify a OTVID with the given public key(s).
+The OTVID is read from stdin if the argument is "-" or absent.
 
 Parse and verify a OTVID:
 	otgo verify -jwk pub.jwk eyJhbGciOiJFUzI1NiIsImtpZCI6InFLU0YyS...7xcp0xfcpU3cz8Nn244awnEBl_3Pwjy62nEywLDQ_g
 
 Parse and verify a OTVID with remote public keys:
 	otgo verify -jwk https://my-trust-domain/.well-known/open-trust-configuration eyJhbGciOiJFUzI1NiIsImtpZCI6InFLU0YyS...7xcp0xfcpU3cz8Nn244awnEBl_3Pwjy62nEywLDQ_g
+
+Parse and verify a OTVID from stdin:
+	cat token.txt | otgo verify -jwk pub.jwk
 `
 }
 
 func (c *verifyCmd) SetFlags(f *flag.FlagSet) {
 	f.StringVar(&c.jwk, "jwk", "", "publicKey should be a local file path or a JWK Set Url or a string that public key represented by JWK [RFC7517].")
 	f.StringVar(&c.out, "out", "", "if exists, the result will be written to the file, otherwise to stdout.")
+	c.setOutputFlag(f)
 }
 
 func (c *verifyCmd) Execute(ctx context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
-	var err error
-	args := f.Args()
-	if c.jwk == "" {
-		err = errors.New("the -jwk flag required")
-	} else if len(args) == 0 {
-		err = errors.New("otvid required")
-	}
-	if err == nil {
-		err = c.verify(ctx, args[0])
+	setDefault(&c.jwk, conf.PublicJWK)
+	if conf.Endpoint != "" {
+		setDefault(&c.jwk, strings.TrimSuffix(conf.Endpoint, "/")+"/.well-known/open-trust-configuration")
+	} else if conf.TrustDomain != "" {
+		setDefault(&c.jwk, otgo.TrustDomain(conf.TrustDomain).ConfigURL())
 	}
+
+	var token string
+	err := c.checkFormat()
 	if err != nil {
-		fmt.Fprintln(c.ioErr, err)
-		return subcommands.ExitFailure
+	} else if c.jwk == "" {
+		err = usageError(errors.New("the -jwk flag required"))
+	} else if args := f.Args(); len(args) > 0 && args[0] != "-" {
+		token = args[0]
+	} else if token, err = c.readStdin(); err == nil && token == "" {
+		err = usageError(errors.New("otvid required"))
 	}
-	return subcommands.ExitSuccess
+	if err == nil {
+		err = c.verify(ctx, token)
+	}
+	return c.exit(c.Name(), err)
 }
 
 func (c *verifyCmd) verify(ctx context.Context, token string) error {
@@ -288,42 +464,594 @@ func (c *verifyCmd) verify(ctx context.Context, token string) error {
 		ks, err = otgo.ParseSet(s)
 	}
 
+	if err != nil {
+		return parseError(err)
+	}
+
+	vid, err := otgo.ParseOTVIDInsecure(token)
+	if err != nil {
+		return parseError(err)
+	}
+	data, err := json.Marshal(vid.Claims)
+	if err != nil {
+		return err
+	}
+	if !c.structured() {
+		if err = c.output(c.out, data); err != nil {
+			return err
+		}
+	}
+	if err = verifyOTVID(token, vid, ks); err != nil {
+		if !c.structured() {
+			return fmt.Errorf("\nVerify failed: %w", err)
+		}
+		return err
+	}
+	if !c.structured() {
+		fmt.Fprintln(c.ioOut, "\nVerify success!")
+		return nil
+	}
+	return c.emit(c.Name(), c.out, data, map[string]interface{}{
+		"sub":    vid.ID,
+		"iss":    vid.Issuer,
+		"aud":    vid.Audience,
+		"exp":    vid.Expiry.Unix(),
+		"claims": vid.Claims,
+	})
+}
+
+// verifyOTVID verifies the OTVID's signature and expiry, the error tells the failure reason.
+func verifyOTVID(token string, vid *otgo.OTVID, ks *otgo.JWKSet) error {
+	_, err := otgo.ParseOTVID(token, ks, vid.Issuer, vid.Audience)
 	if err == nil {
-		var vid *otgo.OTVID
-		vid, err = otgo.ParseOTVIDInsecure(token)
-		if err == nil {
-			var data []byte
-			if data, err = json.Marshal(vid.Claims); err == nil {
-				err = c.output(c.out, data)
+		return nil
+	}
+	// the signature is verified before the claims
+	if errors.Is(err, otgo.ErrExpired) {
+		return expiredError(err)
+	}
+	if errors.Is(err, otgo.ErrInvalidIssuer) || errors.Is(err, otgo.ErrInvalidAudience) {
+		return claimsError(err)
+	}
+	return signatureError(err)
+}
+
+type inspectCmd struct {
+	ioGroup
+	file   string
+	asJSON bool
+}
+
+func (*inspectCmd) Name() string { return "inspect" }
+func (*inspectCmd) Synopsis() string {
+	return "decode a OTVID without verification."
+}
+func (*inspectCmd) Usage() string {
+	return `inspect [-file filename] [-json] [otvid]
+
+Decode a OTVID without verification, print its header, claims, expiry and size.
+The OTVID is read from the argument, the -file flag or stdin (if the argument is "-" or absent):
+	otgo inspect eyJhbGciOiJFUzI1NiIsImtpZCI6InFLU0YyS...7xcp0xfcpU3cz8Nn244awnEBl_3Pwjy62nEywLDQ_g
+	otgo inspect -file token.txt -json
+	cat token.txt | otgo inspect
+`
+}
+
+func (c *inspectCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.file, "file", "", "read the OTVID from the file.")
+	f.BoolVar(&c.asJSON, "json", false, "output as JSON instead of table.")
+	c.setOutputFlag(f)
+}
+
+func (c *inspectCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	err := c.checkFormat()
+	var token string
+	if err == nil {
+		token, err = c.readToken(f.Args())
+	}
+	if err == nil {
+		err = c.inspect(token)
+	}
+	return c.exit(c.Name(), err)
+}
+
+func (c *inspectCmd) readToken(args []string) (string, error) {
+	var b []byte
+	var err error
+	switch {
+	case c.file != "":
+		b, err = ioutil.ReadFile(c.file)
+	case len(args) > 0 && args[0] != "-":
+		b = []byte(args[0])
+	default:
+		b, err = ioutil.ReadAll(c.ioIn)
+	}
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", usageError(errors.New("otvid required"))
+	}
+	return token, nil
+}
+
+type inspectResult struct {
+	Header    map[string]interface{} `json:"header"`
+	Claims    map[string]interface{} `json:"claims"`
+	KeyID     string                 `json:"kid"`
+	Size      int                    `json:"size"`
+	ExpiresIn string                 `json:"expiresIn"`
+}
+
+func (c *inspectCmd) inspect(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return parseError(errors.New("invalid OTVID, it should have 3 parts"))
+	}
+	res := inspectResult{Size: len(token)}
+	if err := decodeSegment(parts[0], &res.Header); err != nil {
+		return parseError(fmt.Errorf("invalid OTVID header: %s", err.Error()))
+	}
+	if err := decodeSegment(parts[1], &res.Claims); err != nil {
+		return parseError(fmt.Errorf("invalid OTVID claims: %s", err.Error()))
+	}
+	res.KeyID, _ = res.Header["kid"].(string)
+	if exp, ok := res.Claims["exp"].(float64); ok {
+		d := time.Until(time.Unix(int64(exp), 0)).Truncate(time.Second)
+		if d > 0 {
+			res.ExpiresIn = d.String()
+		} else {
+			res.ExpiresIn = "expired " + (-d).String() + " ago"
+		}
+	}
+
+	if c.structured() {
+		return c.emit(c.Name(), "", nil, res)
+	}
+	if c.asJSON {
+		data, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			return err
+		}
+		return c.output("", data)
+	}
+
+	w := tabwriter.NewWriter(c.ioOut, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "HEADER\t")
+	writeFields(w, res.Header)
+	fmt.Fprintln(w, "CLAIMS\t")
+	writeFields(w, res.Claims)
+	fmt.Fprintln(w, "\t")
+	fmt.Fprintf(w, "kid\t%s\n", res.KeyID)
+	fmt.Fprintf(w, "size\t%d bytes\n", res.Size)
+	fmt.Fprintf(w, "expires in\t%s\n", res.ExpiresIn)
+	return w.Flush()
+}
+
+func decodeSegment(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func writeFields(w io.Writer, m map[string]interface{}) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := m[k]
+		switch val := v.(type) {
+		case string:
+			fmt.Fprintf(w, "  %s\t%s\n", k, val)
+		default:
+			b, _ := json.Marshal(val)
+			fmt.Fprintf(w, "  %s\t%s\n", k, string(b))
+		}
+	}
+}
+
+type jwksCmd struct {
+	ioGroup
+	set string
+	out string
+}
+
+func (*jwksCmd) Name() string { return "jwks" }
+func (*jwksCmd) Synopsis() string {
+	return "manage a JWK set: add, remove, public, merge."
+}
+func (*jwksCmd) Usage() string {
+	return `jwks [-set jwkSet] [-out filename] <add|remove|public|merge> [args...]
+
+Add keys to a JWK set, the set will be created if not exists, keys with the same kid will be replaced:
+	otgo jwks -set keys.json -out keys.json add key1.jwk key2.jwk
+
+Remove keys from a JWK set by kid:
+	otgo jwks -set keys.json -out keys.json remove qKSF2H_0rOrOqy8FZRySntVhOyAqNAxesETiHtZo3SU
+
+Convert a private JWK set to a public JWK set:
+	otgo jwks -set keys.json -out pub.json public
+
+Merge JWK sets:
+	otgo jwks -out keys.json merge keys1.json keys2.json
+`
+}
+
+func (c *jwksCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.set, "set", "", "jwkSet should be a local file path or a string that JWK set represented by JWK [RFC7517].")
+	f.StringVar(&c.out, "out", "", "if exists, the result will be written to the file, otherwise to stdout.")
+	c.setOutputFlag(f)
+}
+
+func (c *jwksCmd) Execute(_ context.Context, f *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	args := f.Args()
+	if err := c.checkFormat(); err != nil {
+		return c.exit(c.Name(), err)
+	}
+	if len(args) == 0 {
+		if c.structured() {
+			return c.exit(c.Name(), usageError(errors.New("jwks action required")))
+		}
+		fmt.Fprintln(c.ioOut, c.Usage())
+		return subcommands.ExitUsageError
+	}
+
+	var err error
+	var ks *otgo.JWKSet
+	switch args[0] {
+	case "add":
+		if ks, err = c.loadSet(true); err == nil {
+			err = c.add(ks, args[1:])
+		}
+	case "remove":
+		if ks, err = c.loadSet(false); err == nil {
+			err = c.remove(ks, args[1:])
+		}
+	case "public":
+		if ks, err = c.loadSet(false); err == nil {
+			ks = otgo.LookupPublicKeys(ks)
+		}
+	case "merge":
+		ks = &otgo.JWKSet{}
+		for _, s := range args[1:] {
+			var mks *otgo.JWKSet
+			if mks, err = parseSetInput(s); err != nil {
+				break
 			}
+			putKeys(ks, mks.Keys...)
 		}
-		if err == nil {
-			err = vid.Verify(ks, vid.Issuer, vid.Audience)
-			if err == nil {
-				fmt.Fprintln(c.ioOut, fmt.Sprintf("\nVerify success!"))
-			} else {
-				err = fmt.Errorf("\nVerify failed: %s", err.Error())
+	default:
+		err = usageError(fmt.Errorf("unknown jwks action '%s'", args[0]))
+	}
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(ks); err == nil {
+			err = c.emit(c.Name(), c.out, data, ks)
+		}
+	}
+	return c.exit(c.Name(), err)
+}
+
+func (c *jwksCmd) loadSet(allowEmpty bool) (*otgo.JWKSet, error) {
+	if c.set == "" {
+		return nil, errors.New("the -set flag required")
+	}
+	if allowEmpty && !strings.HasPrefix(c.set, "{") {
+		if _, err := os.Stat(c.set); os.IsNotExist(err) {
+			return &otgo.JWKSet{}, nil
+		}
+	}
+	return parseSetInput(c.set)
+}
+
+func (c *jwksCmd) add(ks *otgo.JWKSet, args []string) error {
+	if len(args) == 0 {
+		return errors.New("keys required")
+	}
+	for _, s := range args {
+		s, err := readKeyInput(s)
+		if err != nil {
+			return err
+		}
+		key, err := otgo.ParseKey(s)
+		if err != nil {
+			return err
+		}
+		putKeys(ks, key)
+	}
+	return nil
+}
+
+func (c *jwksCmd) remove(ks *otgo.JWKSet, kids []string) error {
+	if len(kids) == 0 {
+		return errors.New("kids required")
+	}
+	keys := ks.Keys[:0]
+	for _, k := range ks.Keys {
+		if !stringsHas(kids, k.KeyID()) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == len(ks.Keys) {
+		return fmt.Errorf("no keys found with kid %v", kids)
+	}
+	ks.Keys = keys
+	return nil
+}
+
+// putKeys adds keys to the set, keys with the same kid will be replaced.
+func putKeys(ks *otgo.JWKSet, keys ...otgo.Key) {
+	for _, key := range keys {
+		replaced := false
+		for i, k := range ks.Keys {
+			if k.KeyID() == key.KeyID() {
+				ks.Keys[i] = key
+				replaced = true
+				break
 			}
 		}
+		if !replaced {
+			ks.Keys = append(ks.Keys, key)
+		}
 	}
-	return err
+}
+
+// readInput returns s if it is a JSON string, otherwise reads the file s.
+func readInput(s string) (string, error) {
+	if strings.HasPrefix(s, "{") {
+		return s, nil
+	}
+	b, err := ioutil.ReadFile(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func parseSetInput(s string) (*otgo.JWKSet, error) {
+	s, err := readInput(s)
+	if err != nil {
+		return nil, err
+	}
+	return otgo.ParseSet(s)
+}
+
+func stringsHas(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+type serveJWKSCmd struct {
+	ioGroup
+	td          string
+	jwks        string
+	port        int
+	tlsCert     string
+	tlsKey      string
+	refreshHint int64
+	endpoints   string
+}
+
+func (*serveJWKSCmd) Name() string { return "serve-jwks" }
+func (*serveJWKSCmd) Synopsis() string {
+	return "serve a local well-known open-trust-configuration endpoint for development."
+}
+func (*serveJWKSCmd) Usage() string {
+	return `serve-jwks [-td trustDomain] [-jwks jwkSet] [-port port] [-tls-cert certFile] [-tls-key keyFile] [-refresh-hint seconds] [-endpoints serviceEndpoints]
+
+Serve /.well-known/open-trust-configuration of the trust domain with the public keys of the JWK set:
+	otgo serve-jwks -td localhost -jwks keys.json -port 8080 -endpoints http://localhost:8081,http://localhost:8082
+`
+}
+
+func (c *serveJWKSCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.td, "td", "", "trust domain, e.g. localhost")
+	f.StringVar(&c.jwks, "jwks", "", "jwkSet should be a local file path or a string that JWK set represented by JWK [RFC7517], private keys will be converted to public keys.")
+	f.IntVar(&c.port, "port", 8080, "port to listen on.")
+	f.StringVar(&c.tlsCert, "tls-cert", "", "TLS certificate file, serve with HTTPS if exists.")
+	f.StringVar(&c.tlsKey, "tls-key", "", "TLS private key file.")
+	f.Int64Var(&c.refreshHint, "refresh-hint", 3600, "keysRefreshHint in seconds.")
+	f.StringVar(&c.endpoints, "endpoints", "", "comma-separated serviceEndpoints.")
+	c.setOutputFlag(f)
+}
+
+func (c *serveJWKSCmd) Execute(_ context.Context, _ *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	err := c.checkFormat()
+	var handler http.Handler
+	if err == nil {
+		handler, err = c.handler()
+	}
+	if err == nil {
+		addr := fmt.Sprintf(":%d", c.port)
+		if !c.structured() {
+			fmt.Fprintf(c.ioOut, "Serving /.well-known/open-trust-configuration of %s on %s\n", c.td, addr)
+		}
+		if c.tlsCert != "" {
+			err = http.ListenAndServeTLS(addr, c.tlsCert, c.tlsKey, handler)
+		} else {
+			err = http.ListenAndServe(addr, handler)
+		}
+	}
+	return c.exit(c.Name(), err)
+}
+
+func (c *serveJWKSCmd) handler() (http.Handler, error) {
+	td := otgo.TrustDomain(c.td)
+	if err := td.Validate(); err != nil {
+		return nil, usageError(err)
+	}
+	if c.jwks == "" {
+		return nil, usageError(errors.New("the -jwks flag required"))
+	}
+	ks, err := parseSetInput(c.jwks)
+	if err != nil {
+		return nil, parseError(err)
+	}
+	endpoints := []string{}
+	if c.endpoints != "" {
+		endpoints = strings.Split(c.endpoints, ",")
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"otid":             td.OTID(),
+		"keys":             otgo.LookupPublicKeys(ks).Keys,
+		"keysRefreshHint":  c.refreshHint,
+		"serviceEndpoints": endpoints,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/open-trust-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	})
+	return mux, nil
+}
+
+type renewCmd struct {
+	ioGroup
+	jwk      string
+	out      string
+	sub      string
+	aud      string
+	exp      time.Duration
+	endpoint string
+}
+
+func (*renewCmd) Name() string { return "renew" }
+func (*renewCmd) Synopsis() string {
+	return "request a OTVID from the trust domain's OT-Auth service."
+}
+func (*renewCmd) Usage() string {
+	return `renew [-jwk privateKey] [-sub subject] [-aud audience] [-exp expiry] [-out filename] [-endpoint url]
+
+Sign a self OTVID with the subject's private key, request a OTVID for the audience from the trust domain's OT-Auth /sign endpoint:
+	otgo renew -jwk key.jwk -sub otid:localhost:app:123 -aud otid:localhost:svc:auth -out token.txt
+`
+}
+
+func (c *renewCmd) SetFlags(f *flag.FlagSet) {
+	f.StringVar(&c.jwk, "jwk", "", "privateKey should be a local file path or a string that subject's private key represented by JWK [RFC7517].")
+	f.StringVar(&c.out, "out", "", "if exists, the result will be written to the file, otherwise to stdout.")
+	f.StringVar(&c.sub, "sub", "", "subject should be a OTID")
+	f.StringVar(&c.aud, "aud", "", "audience should be a OTID, default to the trust domain's OTID")
+	f.DurationVar(&c.exp, "exp", 0, `expiry should be a duration string, such as "30m", "1.5h" or "2h45m". OT-Auth's default expiry is used if absent.`)
+	f.StringVar(&c.endpoint, "endpoint", "", "if exists, all requests will be sent to the endpoint instead of the trust domain, e.g. http://localhost:8080")
+	c.setOutputFlag(f)
+}
+
+func (c *renewCmd) Execute(ctx context.Context, _ *flag.FlagSet, _ ...interface{}) subcommands.ExitStatus {
+	setDefault(&c.jwk, conf.JWK)
+	setDefault(&c.sub, conf.Subject)
+	setDefault(&c.aud, conf.Audience)
+	setDefault(&c.endpoint, conf.Endpoint)
+
+	err := c.checkFormat()
+	if err != nil {
+	} else if c.jwk == "" {
+		err = usageError(errors.New("the -jwk flag required"))
+	} else if c.sub == "" {
+		err = usageError(errors.New("the -sub flag required"))
+	} else if c.exp < 0 {
+		err = usageError(errors.New("the -exp value is invalid"))
+	}
+	if err == nil {
+		err = c.renew(ctx)
+	}
+	return c.exit(c.Name(), err)
+}
+
+func (c *renewCmd) renew(ctx context.Context) error {
+	s, err := readKeyInput(c.jwk)
+	if err != nil {
+		return err
+	}
+	key, err := otgo.ParseKey(s)
+	if err != nil {
+		return parseError(err)
+	}
+	sub, err := otgo.ParseOTID(c.sub)
+	if err != nil {
+		return parseError(err)
+	}
+	aud := sub.TrustDomain().OTID()
+	if c.aud != "" {
+		if aud, err = otgo.ParseOTID(c.aud); err != nil {
+			return parseError(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	oc := otgo.NewOTClient(ctx, sub)
+	hc := otgo.NewClient(cli.Client)
+	hc.Header = cli.Header
+	hc.ConstraintEndpoint = c.endpoint
+	oc.HTTPClient = hc
+	oc.SetPrivateKeys(*otgo.MustKeys(key))
+
+	input := otgo.SignInput{Subject: sub, Audience: aud}
+	if c.exp > 0 {
+		input.Expiry = time.Now().Add(c.exp).Unix()
+	}
+	output, err := oc.Sign(ctx, input)
+	if err != nil {
+		return err
+	}
+	return c.emit(c.Name(), c.out, []byte(output.OTVID), output)
 }
 
 var cli = otgo.DefaultHTTPClient
 
+// conf is the profile loaded from the config file and OTGO_* environment variables.
+var conf = &profile{}
+
 func main() {
 	subcommands.Register(subcommands.HelpCommand(), "")
 	subcommands.Register(subcommands.FlagsCommand(), "")
 	subcommands.Register(subcommands.CommandsCommand(), "")
 
-	iog := ioGroup{ioOut: subcommands.DefaultCommander.Output, ioErr: subcommands.DefaultCommander.Error}
+	iog := ioGroup{ioIn: os.Stdin, ioOut: subcommands.DefaultCommander.Output, ioErr: subcommands.DefaultCommander.Error}
 	subcommands.Register(&versionCmd{ioGroup: iog}, "")
 	subcommands.Register(&keyCmd{ioGroup: iog}, "")
+	subcommands.Register(&keygenCmd{ioGroup: iog}, "")
 	subcommands.Register(&signCmd{ioGroup: iog}, "")
 	subcommands.Register(&verifyCmd{ioGroup: iog}, "")
+	subcommands.Register(&inspectCmd{ioGroup: iog}, "")
+	subcommands.Register(&jwksCmd{ioGroup: iog}, "")
+	subcommands.Register(&serveJWKSCmd{ioGroup: iog}, "")
+	subcommands.Register(&renewCmd{ioGroup: iog}, "")
+	subcommands.Register(&fetchCmd{ioGroup: iog}, "")
+	subcommands.Register(&bundleCmd{ioGroup: iog}, "")
+	subcommands.Register(&benchCmd{ioGroup: iog}, "")
+	subcommands.Register(&completionCmd{ioGroup: iog, cdr: subcommands.DefaultCommander}, "")
 
+	configPath := flag.String("config", "", "config file, default to $OTGO_CONFIG or ~/.otgo/config.")
+	profileName := flag.String("profile", "", "profile in the config file, default to $OTGO_PROFILE or the config file's default profile.")
 	flag.Parse()
+	var err error
+	if conf, err = loadProfile(*configPath, *profileName); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(int(subcommands.ExitUsageError))
+	}
 	ctx := context.Background()
+	// the default transport uses no proxy, honor HTTPS_PROXY and NO_PROXY
+	if err = cli.SetProxy(""); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(int(subcommands.ExitFailure))
+	}
 	cli.Header.Set("User-Agent", fmt.Sprintf("Go/%v otgo/%s %s/%s", runtime.Version(), otgo.Version, runtime.GOOS, runtime.GOARCH))
 
 	os.Exit(int(subcommands.Execute(ctx)))
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/google/subcommands"
+	"gopkg.in/yaml.v3"
+)
+
+// Exit codes, CI pipelines can tell the failures apart by them.
+const (
+	exitParse     subcommands.ExitStatus = 3 // malformed OTVID, key or OTID
+	exitSignature subcommands.ExitStatus = 4 // the OTVID's signature is invalid or no matching key
+	exitExpired   subcommands.ExitStatus = 5 // the OTVID is expired
+	exitClaims    subcommands.ExitStatus = 6 // the OTVID's issuer or audience is not satisfied
+)
+
+// cliError is a error with the code and exit status in the structured output.
+type cliError struct {
+	code   string
+	status subcommands.ExitStatus
+	err    error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func usageError(err error) error {
+	return &cliError{code: "usage", status: subcommands.ExitUsageError, err: err}
+}
+
+func parseError(err error) error {
+	return &cliError{code: "parse", status: exitParse, err: err}
+}
+
+func signatureError(err error) error {
+	return &cliError{code: "signature", status: exitSignature, err: err}
+}
+
+func expiredError(err error) error {
+	return &cliError{code: "expired", status: exitExpired, err: err}
+}
+
+func claimsError(err error) error {
+	return &cliError{code: "claims", status: exitClaims, err: err}
+}
+
+// envelope is the stable schema of the structured output:
+//
+//	{"command": "verify", "ok": false, "exitCode": 5, "error": {"code": "expired", "message": "..."}}
+type envelope struct {
+	Command  string         `json:"command"`
+	OK       bool           `json:"ok"`
+	ExitCode int            `json:"exitCode"`
+	Result   interface{}    `json:"result,omitempty"`
+	Error    *envelopeError `json:"error,omitempty"`
+}
+
+type envelopeError struct {
+	Code    string `json:"code"` // usage, parse, signature, expired, claims or error
+	Message string `json:"message"`
+}
+
+// setOutputFlag registers the -o flag.
+func (i *ioGroup) setOutputFlag(f *flag.FlagSet) {
+	f.StringVar(&i.format, "o", "text", "output format, one of text, json, yaml. json and yaml print a stable envelope with the result or error.")
+}
+
+func (i *ioGroup) structured() bool {
+	return i.format == "json" || i.format == "yaml"
+}
+
+func (i *ioGroup) checkFormat() error {
+	switch i.format {
+	case "", "text", "json", "yaml":
+		return nil
+	}
+	return usageError(fmt.Errorf("invalid output format '%s'", i.format))
+}
+
+// emit writes data to the file or stdout in text format, in json or yaml format it writes data to the file
+// if filename exists, and prints the envelope with the result to stdout.
+func (i *ioGroup) emit(cmd, filename string, data []byte, result interface{}) error {
+	if !i.structured() {
+		return i.output(filename, data)
+	}
+	if filename != "" {
+		if err := ioutil.WriteFile(filename, data, outputFileMode); err != nil {
+			return err
+		}
+	}
+	return i.writeEnvelope(&envelope{Command: cmd, OK: true, Result: result})
+}
+
+// exit reports the error and returns the exit status.
+func (i *ioGroup) exit(cmd string, err error) subcommands.ExitStatus {
+	if err == nil {
+		return subcommands.ExitSuccess
+	}
+	code, status := "error", subcommands.ExitFailure
+	var ce *cliError
+	if errors.As(err, &ce) {
+		code, status = ce.code, ce.status
+	}
+	if !i.structured() {
+		fmt.Fprintln(i.ioErr, err)
+		return status
+	}
+	msg := strings.TrimSpace(err.Error())
+	if e := i.writeEnvelope(&envelope{Command: cmd, ExitCode: int(status), Error: &envelopeError{Code: code, Message: msg}}); e != nil {
+		fmt.Fprintln(i.ioErr, err)
+	}
+	return status
+}
+
+func (i *ioGroup) writeEnvelope(e *envelope) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if i.format == "yaml" {
+		// encode the JSON representation, so that both formats have the same schema
+		var v interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err = dec.Decode(&v); err == nil {
+			data, err = yaml.Marshal(yamlValue(v))
+		}
+		if err != nil {
+			return err
+		}
+		_, err = i.ioOut.Write(data)
+		return err
+	}
+	_, err = fmt.Fprintln(i.ioOut, string(data))
+	return err
+}
+
+// yamlValue converts the json.Numbers to int64 or float64, so that integers are not printed in exponent notation.
+func yamlValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range val {
+			val[k] = yamlValue(e)
+		}
+	case []interface{}:
+		for k, e := range val {
+			val[k] = yamlValue(e)
+		}
+	}
+	return v
+}
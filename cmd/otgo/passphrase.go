@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	otgo "github.com/open-trust/ot-go-lib"
+)
+
+// readKeyInput reads the private key input like readInput, the encrypted key file is decrypted
+// with the passphrase, see readPassphrase.
+func readKeyInput(s string) (string, error) {
+	s, err := readInput(s)
+	if err != nil || !otgo.IsEncryptedKeyFile([]byte(s)) {
+		return s, err
+	}
+	p, err := readPassphrase(false)
+	if err != nil {
+		return "", err
+	}
+	b, err := otgo.DecryptKeyFile([]byte(s), p)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readPassphrase reads the passphrase of the key files from $OTGO_PASSPHRASE, or from the file descriptor
+// $OTGO_PASSPHRASE_FD, otherwise prompts for it on the terminal, twice if confirm is true.
+func readPassphrase(confirm bool) ([]byte, error) {
+	if _, ok := os.LookupEnv("OTGO_PASSPHRASE"); ok {
+		return otgo.PassphraseFromEnv("OTGO_PASSPHRASE")()
+	}
+	if s := os.Getenv("OTGO_PASSPHRASE_FD"); s != "" {
+		fd, err := strconv.ParseUint(s, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTGO_PASSPHRASE_FD %q", s)
+		}
+		return otgo.PassphraseFromFD(uintptr(fd))()
+	}
+
+	p, err := promptPassphrase("Passphrase: ")
+	if err != nil || !confirm {
+		return p, err
+	}
+	p2, err := promptPassphrase("Confirm passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+	if string(p) != string(p2) {
+		return nil, errors.New("passphrases do not match")
+	}
+	return p, nil
+}
+
+// promptPassphrase reads a line from the terminal with the echo disabled, it fails if the echo
+// can not be disabled, e.g. without stty.
+func promptPassphrase(prompt string) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, errors.New("passphrase required, set $OTGO_PASSPHRASE or $OTGO_PASSPHRASE_FD")
+	}
+	defer tty.Close()
+
+	// never read the passphrase with the echo on
+	if err := stty(tty, "-echo"); err != nil {
+		return nil, fmt.Errorf("failed to disable the terminal echo: %s, set $OTGO_PASSPHRASE or $OTGO_PASSPHRASE_FD", err.Error())
+	}
+	defer func() {
+		stty(tty, "echo")
+		fmt.Fprintln(tty)
+	}()
+	fmt.Fprint(tty, prompt)
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, err
+	}
+	p := strings.TrimRight(line, "\r\n")
+	if p == "" {
+		return nil, errors.New("empty passphrase")
+	}
+	return []byte(p), nil
+}
+
+func stty(tty *os.File, args ...string) error {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
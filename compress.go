@@ -0,0 +1,119 @@
+package otgo
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// claimCompressed is the claim of the DEFLATE compressed private claims, base64url encoded.
+const claimCompressed = "zcl"
+
+// SignCompressed signs the OTVID like Sign, the private claims are DEFLATE compressed into the 'zcl' claim
+// if it makes the OTVID smaller. They are decompressed transparently by ParseOTVID, FromJWT, etc.
+func (o *OTVID) SignCompressed(key Key) (string, error) {
+	return o.sign(key, &signOptions{compress: true})
+}
+
+// EstimateSize returns the serialized size of the OTVID if it is signed with the key, without signing.
+// It can be used to budget the private claims before signing.
+func (o *OTVID) EstimateSize(key Key, compressed bool) (int, error) {
+	c := *o
+	hdrs, t, err := c.prepare(key, &signOptions{compress: compressed})
+	if err != nil {
+		return 0, err
+	}
+	if err = hdrs.Set("typ", "JWT"); err != nil {
+		return 0, err
+	}
+	h, err := json.Marshal(hdrs)
+	if err != nil {
+		return 0, err
+	}
+	p, err := json.Marshal(t)
+	if err != nil {
+		return 0, err
+	}
+	var raw interface{}
+	if err = key.Raw(&raw); err != nil {
+		return 0, err
+	}
+	var sig int
+	switch k := raw.(type) {
+	case *rsa.PrivateKey:
+		sig = k.Size()
+	case *ecdsa.PrivateKey:
+		sig = (k.Curve.Params().BitSize + 7) / 8 * 2
+	case []byte:
+		sig = hmacSize(key.Algorithm())
+	default:
+		return 0, fmt.Errorf("otgo.OTVID.EstimateSize: invalid key type '%T'", raw)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodedLen(len(h)) + 1 + enc.EncodedLen(len(p)) + 1 + enc.EncodedLen(sig), nil
+}
+
+// deflateClaims returns the claims compressed into the 'zcl' claim if it is smaller.
+func deflateClaims(claims map[string]interface{}) (map[string]interface{}, error) {
+	if len(claims) == 0 {
+		return claims, nil
+	}
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w, _ := flate.NewWriter(&buf, flate.BestCompression)
+	if _, err = w.Write(b); err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	s := base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	if len(s)+len(claimCompressed)+5 >= len(b) {
+		return claims, nil
+	}
+	return map[string]interface{}{claimCompressed: s}, nil
+}
+
+// inflateClaims decompresses the 'zcl' claim and merges it into the claims,
+// the numbers are decoded as json.Number if useNumber is true.
+// The decompressed claims must not exceed the limits' ClaimsMaxSize.
+func inflateClaims(claims map[string]interface{}, useNumber bool, limits *Limits) (map[string]interface{}, error) {
+	v, ok := claims[claimCompressed]
+	if !ok {
+		return claims, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid '%s' field, must be a string", claimCompressed)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		max := limits.claimsMaxSize()
+		b, err = ioutil.ReadAll(io.LimitReader(flate.NewReader(bytes.NewReader(b)), int64(max)+1))
+		if err == nil && len(b) > max {
+			err = fmt.Errorf("decompressed size exceeds %d bytes", max)
+		}
+	}
+	rs := make(map[string]interface{})
+	if err == nil {
+		err = unmarshalClaims(b, &rs, useNumber)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid '%s' field: %s", claimCompressed, err.Error())
+	}
+	for k, v := range claims {
+		if k != claimCompressed {
+			rs[k] = v
+		}
+	}
+	return rs, nil
+}
@@ -0,0 +1,94 @@
+package otgo_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwt"
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompress(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	newOTVID := func(claims map[string]interface{}) *otgo.OTVID {
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: td.NewOTID("app", "123")}
+		vid.Expiry = time.Now().Add(time.Hour)
+		vid.Claims = claims
+		return vid
+	}
+
+	t.Run("OTVID.SignCompressed method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		pubKeys := otgo.LookupPublicKeys(otgo.MustKeys(key))
+		vid := newOTVID(map[string]interface{}{"roles": strings.Repeat("admin,", 400)})
+		_, err := vid.Sign(key)
+		assert.NotNil(err)
+
+		token, err := vid.SignCompressed(key)
+		assert.Nil(err)
+		assert.True(len(token) < 2048)
+
+		vid2, err := otgo.ParseOTVID(token, pubKeys, vid.Issuer, vid.Audience)
+		assert.Nil(err)
+		assert.Equal(vid.Claims, vid2.Claims)
+		assert.Nil(vid2.Claims["zcl"])
+
+		jt, err := jwt.ParseString(token)
+		assert.Nil(err)
+		assert.NotNil(jt.PrivateClaims()["zcl"])
+		vid3, err := otgo.FromJWT(token, jt)
+		assert.Nil(err)
+		assert.Equal(vid.Claims, vid3.Claims)
+
+		// the claims are not compressed if it is not smaller
+		vid = newOTVID(map[string]interface{}{"a": 1})
+		token, err = vid.SignCompressed(key)
+		assert.Nil(err)
+		jt, err = jwt.ParseString(token)
+		assert.Nil(err)
+		assert.Nil(jt.PrivateClaims()["zcl"])
+
+		vid = newOTVID(nil)
+		_, err = vid.SignCompressed(key)
+		assert.Nil(err)
+
+		assert.NotNil(newOTVID(nil).SetClaims(map[string]interface{}{"zcl": "abc"}))
+
+		// the decompressed claims are limited
+		vid = newOTVID(map[string]interface{}{"roles": strings.Repeat("a", 100000)})
+		token, err = vid.SignCompressed(key)
+		assert.Nil(err)
+		_, err = otgo.ParseOTVID(token, pubKeys, vid.Issuer, vid.Audience)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "decompressed size exceeds 16384 bytes")
+	})
+
+	t.Run("OTVID.EstimateSize method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		for _, alg := range []string{"ES256", "ES384", "ES512", "RS256", "PS256"} {
+			key := otgo.MustPrivateKey(alg)
+			vid := newOTVID(map[string]interface{}{"name": "test", "roles": strings.Repeat("admin,", 100)})
+			for _, compressed := range []bool{false, true} {
+				size, err := vid.EstimateSize(key, compressed)
+				assert.Nil(err)
+				var token string
+				if compressed {
+					token, err = vid.SignCompressed(key)
+				} else {
+					token, err = vid.Sign(key)
+				}
+				assert.Nil(err)
+				assert.Equal(len(token), size, alg)
+			}
+		}
+
+		pub, _ := otgo.ToPublicKey(otgo.MustPrivateKey("ES256"))
+		_, err := newOTVID(nil).EstimateSize(pub, false)
+		assert.NotNil(err)
+	})
+}
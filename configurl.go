@@ -0,0 +1,40 @@
+package otgo
+
+import "sync"
+
+// ConfigURLs is a registry of per trust domain configuration URL overrides,
+// for trust domains that serve the configuration behind a gateway path prefix or alternate port.
+// It is set on an OTClient with its ConfigURLs field and on a Verifier with WithConfigURLs.
+type ConfigURLs struct {
+	mu sync.RWMutex
+	m  map[TrustDomain]string
+}
+
+// Set overrides the trust domain's configuration URL, e.g. https://gateway.example.org:8443/ot/.well-known/open-trust-configuration.
+// An empty url removes the override.
+func (c *ConfigURLs) Set(td TrustDomain, url string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if url == "" {
+		delete(c.m, td)
+		return
+	}
+	if c.m == nil {
+		c.m = make(map[TrustDomain]string)
+	}
+	c.m[td] = url
+}
+
+// Lookup returns the trust domain's configuration URL, the override if exists, otherwise td.ConfigURL().
+// A nil ConfigURLs has no overrides.
+func (c *ConfigURLs) Lookup(td TrustDomain) string {
+	if c != nil {
+		c.mu.RLock()
+		url, ok := c.m[td]
+		c.mu.RUnlock()
+		if ok {
+			return url
+		}
+	}
+	return td.ConfigURL()
+}
@@ -0,0 +1,74 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigURLs(t *testing.T) {
+	t.Run("ConfigURLs.Set & ConfigURLs.Lookup method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("ot.example.com")
+		urls := &otgo.ConfigURLs{}
+		assert.Equal(td.ConfigURL(), urls.Lookup(td))
+
+		urls.Set(td, "https://gateway.example.com:8443/ot/.well-known/open-trust-configuration")
+		assert.Equal("https://gateway.example.com:8443/ot/.well-known/open-trust-configuration", urls.Lookup(td))
+		assert.Equal(otgo.TrustDomain("example.com").ConfigURL(), urls.Lookup("example.com"))
+
+		urls.Set(td, "")
+		assert.Equal(td.ConfigURL(), urls.Lookup(td))
+	})
+
+	t.Run("OTClient & Verifier with overridden config URL", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		pk := otgo.MustPrivateKey("ES256")
+		pub, err := otgo.ToPublicKey(pk)
+		assert.Nil(err)
+
+		var ts *httptest.Server
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if r.URL.Path != "/gateway/config" {
+				w.WriteHeader(200)
+				w.Write([]byte(`{"result": "ok"}`))
+				return
+			}
+			b, _ := json.Marshal(map[string]interface{}{
+				"otid":             td.OTID(),
+				"keys":             []otgo.Key{pub},
+				"serviceEndpoints": []string{ts.URL},
+			})
+			w.WriteHeader(200)
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		cli := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		cli.ConfigURLs = &otgo.ConfigURLs{}
+		cli.ConfigURLs.Set(td, ts.URL+"/gateway/config")
+		cfg, err := cli.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(ts.URL, cfg.Endpoint)
+		assert.Equal(pk.KeyID(), cfg.JWKSet.Keys[0].KeyID())
+
+		_, err = otgo.NewVerifier(context.Background(), td.NewOTID("app", "123"), nil)
+		assert.NotNil(err)
+		v, err := otgo.NewVerifierWithOptions(context.Background(), td.NewOTID("app", "123"), otgo.WithConfigURLs(cli.ConfigURLs))
+		assert.Nil(err)
+		v.Shutdown(context.Background())
+
+		ks, err := otgo.FetchKeys(context.Background(), cli.ConfigURLs.Lookup(td), nil)
+		assert.Nil(err)
+		assert.Equal(pk.KeyID(), ks.Keys[0].KeyID())
+	})
+}
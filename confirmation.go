@@ -0,0 +1,158 @@
+package otgo
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Confirmation is the confirmation method of a OTVID as present in the 'cnf' claim (RFC 7800), it binds
+// the OTVID to a key of the presenter, so that a stolen OTVID can not be replayed as a bearer token.
+type Confirmation struct {
+	// JKT is the RFC 7638 SHA-256 thumbprint of the presenter's public key, e.g. proven by a DPoP proof
+	JKT string `json:"jkt,omitempty"`
+	// X5TS256 is the SHA-256 thumbprint of the presenter's TLS client certificate (RFC 8705)
+	X5TS256 string `json:"x5t#S256,omitempty"`
+}
+
+// NewKeyConfirmation returns the confirmation binding a OTVID to the key (or the public key of the private key).
+func NewKeyConfirmation(key Key) (*Confirmation, error) {
+	pub, err := ToPublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.NewKeyConfirmation: %s", err.Error())
+	}
+	jkt, err := Thumbprint(pub)
+	if err != nil {
+		return nil, err
+	}
+	return &Confirmation{JKT: jkt}, nil
+}
+
+// NewCertificateConfirmation returns the confirmation binding a OTVID to the TLS client certificate.
+func NewCertificateConfirmation(cert *x509.Certificate) *Confirmation {
+	return &Confirmation{X5TS256: certThumbprint(cert)}
+}
+
+func certThumbprint(cert *x509.Certificate) string {
+	h := sha256.Sum256(cert.Raw)
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+func (c *Confirmation) empty() bool {
+	return c == nil || (c.JKT == "" && c.X5TS256 == "")
+}
+
+// Binding is the key or the TLS channel presented with a OTVID, see OTVID.VerifyBinding.
+type Binding struct {
+	// Key is the public key the presenter proved the possession of, e.g. the DPoP proof's key
+	Key Key
+	// Certificate is the client certificate of the mutual TLS connection
+	Certificate *x509.Certificate
+}
+
+// BindingFromTLS returns the binding of the mutual TLS connection's client certificate.
+func BindingFromTLS(cs *tls.ConnectionState) Binding {
+	b := Binding{}
+	if cs != nil && len(cs.PeerCertificates) > 0 {
+		b.Certificate = cs.PeerCertificates[0]
+	}
+	return b
+}
+
+// VerifyBinding verifies that the OTVID is bound to the presented key or TLS channel: the OTVID must
+// have a confirmation ('cnf' claim), and every confirmation method in it must match the binding.
+func (o *OTVID) VerifyBinding(b Binding) error {
+	cnf := o.Confirmation
+	if cnf.empty() {
+		return errors.New("otgo.OTVID.VerifyBinding: confirmation claim required")
+	}
+	if cnf.JKT != "" {
+		if b.Key == nil {
+			return errors.New("otgo.OTVID.VerifyBinding: the bound key not presented")
+		}
+		c, err := NewKeyConfirmation(b.Key)
+		if err != nil {
+			return fmt.Errorf("otgo.OTVID.VerifyBinding: %s", err.Error())
+		}
+		if subtle.ConstantTimeCompare([]byte(c.JKT), []byte(cnf.JKT)) != 1 {
+			return errors.New("otgo.OTVID.VerifyBinding: the presented key not match")
+		}
+	}
+	if cnf.X5TS256 != "" {
+		if b.Certificate == nil {
+			return errors.New("otgo.OTVID.VerifyBinding: the bound certificate not presented")
+		}
+		if subtle.ConstantTimeCompare([]byte(certThumbprint(b.Certificate)), []byte(cnf.X5TS256)) != 1 {
+			return errors.New("otgo.OTVID.VerifyBinding: the presented certificate not match")
+		}
+	}
+	return nil
+}
+
+// parseConfirmation parses the 'cnf' claim.
+func parseConfirmation(v interface{}) (*Confirmation, error) {
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, errors.New("invalid 'cnf' field, must be a object")
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	cnf := &Confirmation{}
+	if err = json.Unmarshal(b, cnf); err != nil {
+		return nil, fmt.Errorf("invalid 'cnf' field: %s", err.Error())
+	}
+	return cnf, nil
+}
+
+// checkBinding verifies the OTVID's binding if the binding is presented. A OTVID without confirmation
+// is accepted as a bearer token unless the binding is required, a OTVID with confirmation is never
+// accepted without the binding. See WithBindingRequired.
+func checkBinding(required bool, b *Binding, vid *OTVID) error {
+	if b == nil {
+		if required || !vid.Confirmation.empty() {
+			return errors.New("otgo.Verifier.ParseOTVID: binding required, see ParseBoundOTVID")
+		}
+		return nil
+	}
+	if !required && vid.Confirmation.empty() {
+		return nil
+	}
+	return vid.VerifyBinding(*b)
+}
+
+// WithBindingRequired requires every OTVID to be bound to a key or a TLS channel (the 'cnf' claim)
+// and verified against the presented binding by ParseBoundOTVID, the other parse methods reject
+// all OTVIDs. See SetBindingRequired.
+func WithBindingRequired() VerifierOption {
+	return func(o *verifierOptions) {
+		o.bindingRequired = true
+	}
+}
+
+// SetBindingRequired requires the binding of the OTVIDs like WithBindingRequired.
+func (v *Verifier) SetBindingRequired(required bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.bindingRequired = required
+}
+
+// ParseBoundOTVID parses and fully verifies a OTVID like ParseOTVID, and verifies that it is bound to
+// the presented key or TLS channel if it has a confirmation ('cnf' claim), see OTVID.VerifyBinding.
+// The OTVIDs without confirmation are accepted as bearer tokens unless the binding is required.
+// The OTVIDs with confirmation are accepted by ParseBoundOTVID only, the other parse methods reject them.
+func (v *Verifier) ParseBoundOTVID(token string, b Binding, auds ...OTID) (_ *OTVID, err error) {
+	end := v.observe()
+	defer func() { end(err) }()
+	d, err := v.decode(token)
+	if err != nil {
+		return nil, err
+	}
+	d.binding = &b
+	return v.parse(d, v.audience(d, auds))
+}
@@ -0,0 +1,123 @@
+package otgo_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfirmation(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	key := otgo.MustPrivateKey("ES256")
+	pubs := otgo.LookupPublicKeys(otgo.MustKeys(key))
+	clientKey := otgo.MustPrivateKey("ES256")
+	ca, caKey := newTestCA()
+	clientCert, _ := newTestLeaf(ca, caKey, "")
+
+	newVID := func(cnf *otgo.Confirmation) *otgo.OTVID {
+		return &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud,
+			Expiry: time.Now().Add(time.Hour), Confirmation: cnf}
+	}
+
+	t.Run("OTVID.VerifyBinding method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		cnf, err := otgo.NewKeyConfirmation(clientKey)
+		assert.Nil(err)
+		pub, err := otgo.ToPublicKey(clientKey)
+		assert.Nil(err)
+		cnf2, err := otgo.NewKeyConfirmation(pub)
+		assert.Nil(err)
+		assert.Equal(cnf.JKT, cnf2.JKT)
+
+		vid := newVID(cnf)
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		vid, err = otgo.ParseOTVID(token, pubs, td.OTID(), aud)
+		assert.Nil(err)
+		assert.Equal(cnf.JKT, vid.Confirmation.JKT)
+		assert.Nil(vid.Claims["cnf"])
+		assert.Nil(vid.VerifyBinding(otgo.Binding{Key: pub}))
+		assert.NotNil(vid.VerifyBinding(otgo.Binding{Key: otgo.MustPrivateKey("ES256")}))
+		assert.NotNil(vid.VerifyBinding(otgo.Binding{}))
+
+		vid = newVID(otgo.NewCertificateConfirmation(clientCert))
+		token, err = vid.Sign(key)
+		assert.Nil(err)
+		vid, err = otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Nil(vid.VerifyBinding(otgo.BindingFromTLS(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCert}})))
+		assert.NotNil(vid.VerifyBinding(otgo.BindingFromTLS(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{ca}})))
+		assert.NotNil(vid.VerifyBinding(otgo.BindingFromTLS(nil)))
+
+		assert.NotNil(newVID(nil).VerifyBinding(otgo.Binding{Key: pub}))
+		assert.NotNil(newVID(nil).SetClaims(map[string]interface{}{"cnf": cnf}))
+
+		// CWT
+		data, err := newVID(cnf).SignCWT(key)
+		assert.Nil(err)
+		vid, err = otgo.ParseOTVIDCWT(data, pubs, td.OTID(), aud)
+		assert.Nil(err)
+		assert.Nil(vid.VerifyBinding(otgo.Binding{Key: clientKey}))
+	})
+
+	t.Run("Verifier.ParseBoundOTVID method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key))
+		assert.Nil(err)
+		cnf, err := otgo.NewKeyConfirmation(clientKey)
+		assert.Nil(err)
+		bound, err := newVID(cnf).Sign(key)
+		assert.Nil(err)
+		bearer, err := newVID(nil).Sign(key)
+		assert.Nil(err)
+
+		vid, err := v.ParseBoundOTVID(bound, otgo.Binding{Key: clientKey})
+		assert.Nil(err)
+		assert.Equal(cnf.JKT, vid.Confirmation.JKT)
+		_, err = v.ParseBoundOTVID(bound, otgo.Binding{Key: otgo.MustPrivateKey("ES256")})
+		assert.NotNil(err)
+		_, err = v.ParseBoundOTVID(bearer, otgo.Binding{Key: clientKey})
+		assert.Nil(err)
+		_, err = v.ParseOTVID(bearer)
+		assert.Nil(err)
+		// the bound OTVID is not a bearer token
+		_, err = v.ParseOTVID(bound)
+		assert.NotNil(err)
+		_, err = v.VerifyToken(bound)
+		assert.NotNil(err)
+		_, err = v.Verify(bound)
+		assert.NotNil(err)
+		v.SetMode(otgo.VerifyDegraded, 0)
+		_, err = v.Verify(bound)
+		assert.NotNil(err)
+		v.SetMode(otgo.VerifyFull, 0)
+
+		v.SetBindingRequired(true)
+		_, err = v.ParseBoundOTVID(bound, otgo.Binding{Key: clientKey})
+		assert.Nil(err)
+		_, err = v.ParseBoundOTVID(bearer, otgo.Binding{Key: clientKey})
+		assert.NotNil(err)
+		_, err = v.ParseOTVID(bound)
+		assert.NotNil(err)
+		_, err = v.Verify(bound)
+		assert.NotNil(err)
+		_, errs := v.ParseOTVIDBatch([]string{bound, bearer})
+		assert.NotNil(errs[0])
+		assert.NotNil(errs[1])
+
+		v, err = otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key), otgo.WithBindingRequired())
+		assert.Nil(err)
+		_, err = v.ParseOTVID(bearer)
+		assert.NotNil(err)
+		_, err = v.ParseBoundOTVID(bound, otgo.Binding{Key: clientKey})
+		assert.Nil(err)
+	})
+}
@@ -0,0 +1,48 @@
+package otgo
+
+import (
+	"context"
+	"net/http"
+)
+
+// HeaderRequestID is the request ID header.
+const HeaderRequestID = "X-Request-Id"
+
+// CorrelationHeaders are the headers that correlate an incoming request with the OT-Auth requests
+// made while serving it, they are propagated by WithCorrelationHeaders and Authenticator.Handler.
+var CorrelationHeaders = []string{HeaderRequestID, "Traceparent", "Tracestate"}
+
+// WithHeader returns a copy of ctx with the header, Client.Do adds it to the outgoing requests.
+// The header is merged into the header already in ctx, the values of the same key are replaced.
+func WithHeader(ctx context.Context, h http.Header) context.Context {
+	header := make(http.Header)
+	copyHeader(header, HeaderFromContext(ctx))
+	copyHeader(header, h)
+	return context.WithValue(ctx, CtxHeaderKey, header)
+}
+
+// WithRequestID returns a copy of ctx with the X-Request-Id header.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithHeader(ctx, http.Header{HeaderRequestID: []string{id}})
+}
+
+// WithCorrelationHeaders returns a copy of ctx with the CorrelationHeaders of the incoming request's header,
+// other headers (e.g. Authorization, Cookie) are not propagated.
+func WithCorrelationHeaders(ctx context.Context, h http.Header) context.Context {
+	header := make(http.Header)
+	for _, k := range CorrelationHeaders {
+		if vv := h.Values(k); len(vv) > 0 {
+			header[http.CanonicalHeaderKey(k)] = vv
+		}
+	}
+	if len(header) == 0 {
+		return ctx
+	}
+	return WithHeader(ctx, header)
+}
+
+// HeaderFromContext returns the header in ctx set by WithHeader, or nil.
+func HeaderFromContext(ctx context.Context) http.Header {
+	h, _ := ctx.Value(CtxHeaderKey).(http.Header)
+	return h
+}
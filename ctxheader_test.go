@@ -0,0 +1,75 @@
+package otgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextHeader(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		w.Write([]byte(`{"result": "ok"}`))
+	}))
+	defer ts.Close()
+
+	t.Run("WithHeader & WithRequestID func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Nil(otgo.HeaderFromContext(context.Background()))
+		ctx := otgo.WithHeader(context.Background(), http.Header{"X-Foo": []string{"1"}})
+		ctx2 := otgo.WithRequestID(ctx, "req-1")
+		assert.Equal("", otgo.HeaderFromContext(ctx).Get("X-Request-Id"))
+		assert.Equal("1", otgo.HeaderFromContext(ctx2).Get("X-Foo"))
+
+		cli := otgo.NewClient(nil)
+		assert.Nil(cli.Do(ctx2, "GET", ts.URL, nil, nil, nil))
+		assert.Equal("req-1", got.Get("X-Request-Id"))
+		assert.Equal("1", got.Get("X-Foo"))
+	})
+
+	t.Run("WithCorrelationHeaders func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		h := http.Header{}
+		h.Set("X-Request-Id", "req-2")
+		h.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		h.Set("Authorization", "Bearer secret")
+		ctx := otgo.WithCorrelationHeaders(context.Background(), h)
+		assert.Equal("req-2", otgo.HeaderFromContext(ctx).Get("X-Request-Id"))
+		assert.Equal("", otgo.HeaderFromContext(ctx).Get("Authorization"))
+
+		ctx = otgo.WithCorrelationHeaders(context.Background(), http.Header{})
+		assert.Nil(otgo.HeaderFromContext(ctx))
+	})
+
+	t.Run("Authenticator.Handler propagates correlation headers", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		key := otgo.MustPrivateKey("ES256")
+		aud := td.NewOTID("svc", "api")
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, key)
+		assert.Nil(err)
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+
+		cli := otgo.NewClient(nil)
+		auth := &otgo.Authenticator{Parser: v}
+		h := auth.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cli.Do(r.Context(), "GET", ts.URL, nil, nil, nil)
+		}))
+		r := httptest.NewRequest("GET", "/", nil)
+		otgo.AddTokenToHeader(r.Header, token)
+		r.Header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		assert.Equal("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", got.Get("Traceparent"))
+		assert.Equal("", got.Get("Authorization"))
+	})
+}
@@ -0,0 +1,266 @@
+package otgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jws/sign"
+)
+
+// The COSE algorithm identifiers (RFC 8152, RFC 8230) of the signing algorithms.
+var coseAlgorithms = map[string]int64{
+	"ES256": -7, "ES384": -35, "ES512": -36,
+	"PS256": -37, "PS384": -38, "PS512": -39,
+	"RS256": -257, "RS384": -258, "RS512": -259,
+}
+
+// The CWT claim keys (RFC 8392) of the registered claims, the private claims and 'rid' use text keys.
+var cwtClaimKeys = map[string]int64{"iss": 1, "sub": 2, "aud": 3, "exp": 4, "nbf": 5, "iat": 6, "jti": 7}
+
+const (
+	coseHeaderAlg   = 1
+	coseHeaderKid   = 4
+	coseSign1Tag    = 18
+	coseSign1Format = "Signature1"
+)
+
+var (
+	cwtEncMode, _ = cbor.CoreDetEncOptions().EncMode()
+	cwtDecMode, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]interface{}(nil))}.DecMode()
+)
+
+// coseSign1 is the COSE_Sign1 structure.
+type coseSign1 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected map[int64]interface{}
+	Payload     []byte
+	Signature   []byte
+}
+
+// toBeSigned returns the Sig_structure of the COSE_Sign1 without external AAD.
+func (s *coseSign1) toBeSigned() ([]byte, error) {
+	return cwtEncMode.Marshal([]interface{}{coseSign1Format, s.Protected, []byte{}, s.Payload})
+}
+
+// SignCWT signs the OTVID as a CBOR Web Token (RFC 8392) with a tagged COSE_Sign1 structure,
+// it is much smaller than the JWT for constrained devices. The claims are mapped like Sign,
+// the registered claims use the CWT claim keys, the 'jti' is the 'cti' byte string.
+// The HMAC algorithms are not supported.
+func (o *OTVID) SignCWT(key Key) ([]byte, error) {
+	if _, _, err := o.prepare(key, &signOptions{}); err != nil {
+		return nil, err
+	}
+	alg, ok := coseAlgorithms[key.Algorithm()]
+	if !ok {
+		return nil, fmt.Errorf("otgo.OTVID.SignCWT: algorithm '%s' not supported", key.Algorithm())
+	}
+	payload, err := o.cwtClaims()
+	if err != nil {
+		return nil, fmt.Errorf("otgo.OTVID.SignCWT: %s", err.Error())
+	}
+
+	s := &coseSign1{Unprotected: map[int64]interface{}{}, Payload: payload}
+	s.Protected, err = cwtEncMode.Marshal(map[int64]interface{}{coseHeaderAlg: alg, coseHeaderKid: []byte(key.KeyID())})
+	if err != nil {
+		return nil, err
+	}
+	tbs, err := s.toBeSigned()
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err = key.Raw(&raw); err != nil {
+		return nil, err
+	}
+	signer, err := sign.New(jwa.SignatureAlgorithm(key.Algorithm()))
+	if err == nil {
+		s.Signature, err = signer.Sign(tbs, raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("otgo.OTVID.SignCWT: %s", err.Error())
+	}
+	return cwtEncMode.Marshal(cbor.Tag{Number: coseSign1Tag, Content: s})
+}
+
+// cwtClaims returns the encoded CWT claims set, the private claims are normalized with JSON
+// so that they are parsed the same as the JWT's.
+func (o *OTVID) cwtClaims() ([]byte, error) {
+	claims := make(map[interface{}]interface{}, len(o.Claims)+7)
+	if len(o.Claims) > 0 {
+		b, err := json.Marshal(o.Claims)
+		if err != nil {
+			return nil, err
+		}
+		private := make(map[string]interface{})
+		if err = json.Unmarshal(b, &private); err != nil {
+			return nil, err
+		}
+		for k, v := range private {
+			if _, ok := cwtClaimKeys[k]; ok {
+				return nil, fmt.Errorf("reserved claim '%s' not allowed", k)
+			}
+			claims[k] = v
+		}
+	}
+	claims[cwtClaimKeys["sub"]] = o.ID.String()
+	claims[cwtClaimKeys["iss"]] = o.Issuer.String()
+	claims[cwtClaimKeys["aud"]] = o.Audience.String()
+	claims[cwtClaimKeys["iat"]] = o.IssuedAt.Unix()
+	claims[cwtClaimKeys["exp"]] = o.Expiry.Unix()
+	if o.JTI != "" {
+		claims[cwtClaimKeys["jti"]] = []byte(o.JTI)
+	}
+	if o.ReleaseID != "" {
+		claims["rid"] = o.ReleaseID
+	}
+	if !o.Confirmation.empty() {
+		claims["cnf"] = o.Confirmation
+	}
+	return cwtEncMode.Marshal(claims)
+}
+
+// ParseOTVIDCWT parses a OTVID from a CBOR Web Token signed by SignCWT, both tagged and untagged
+// COSE_Sign1 structures are accepted. The signature is verified using the JWK set like ParseOTVID.
+// The returned OTVID's Token is empty.
+func ParseOTVIDCWT(data []byte, ks *JWKSet, issuer, audience OTID) (*OTVID, error) {
+	if ks == nil {
+		return nil, errors.New("otgo.ParseOTVIDCWT: public keys required")
+	}
+	d, err := decodeCWT(data)
+	if err != nil {
+		return nil, err
+	}
+	return d.parse(ks, issuer, audience)
+}
+
+// decodeCWT decodes the COSE_Sign1 structure and its claims to a decodedOTVID,
+// its signature is verified by verify like a JWT's.
+func decodeCWT(data []byte) (*decodedOTVID, error) {
+	if l := len(data); l > (*Limits)(nil).otvidMaxSize() {
+		return nil, fmt.Errorf("invalid CWT with length %d", l)
+	}
+	var tag cbor.RawTag
+	if err := cwtDecMode.Unmarshal(data, &tag); err == nil {
+		if tag.Number != coseSign1Tag {
+			return nil, fmt.Errorf("otgo.ParseOTVIDCWT: invalid tag %d", tag.Number)
+		}
+		data = tag.Content
+	}
+	s := &coseSign1{}
+	if err := cwtDecMode.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("otgo.ParseOTVIDCWT: invalid COSE_Sign1: %s", err.Error())
+	}
+
+	var hdrs map[int64]cbor.RawMessage
+	if err := cwtDecMode.Unmarshal(s.Protected, &hdrs); err != nil {
+		return nil, fmt.Errorf("otgo.ParseOTVIDCWT: invalid protected header: %s", err.Error())
+	}
+	var alg int64
+	var kid []byte
+	if err := cwtDecMode.Unmarshal(hdrs[coseHeaderAlg], &alg); err != nil {
+		return nil, fmt.Errorf("otgo.ParseOTVIDCWT: invalid alg header: %s", err.Error())
+	}
+	if err := cwtDecMode.Unmarshal(hdrs[coseHeaderKid], &kid); err != nil {
+		return nil, fmt.Errorf("otgo.ParseOTVIDCWT: invalid kid header: %s", err.Error())
+	}
+	d := &decodedOTVID{sig: s.Signature}
+	d.header.Kid = string(kid)
+	for name, v := range coseAlgorithms {
+		if v == alg {
+			d.header.Alg = name
+		}
+	}
+	if d.header.Alg == "" {
+		return nil, fmt.Errorf("otgo.ParseOTVIDCWT: algorithm %d not supported", alg)
+	}
+	tbs, err := s.toBeSigned()
+	if err != nil {
+		return nil, err
+	}
+	d.input = string(tbs)
+
+	claims, err := jsonClaims(s.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.ParseOTVIDCWT: invalid claims: %s", err.Error())
+	}
+	if d.vid, err = claimsToOTVID("", claims, false, nil, false); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// jsonClaims maps the CWT claims set to the JWT claims, as decoded from JSON.
+func jsonClaims(payload []byte) (map[string]interface{}, error) {
+	var raw map[interface{}]cbor.RawMessage
+	if err := cwtDecMode.Unmarshal(payload, &raw); err != nil {
+		return nil, err
+	}
+	private := make(map[string]interface{}, len(raw))
+	claims := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		switch key := k.(type) {
+		case string:
+			if _, ok := cwtClaimKeys[key]; ok {
+				return nil, fmt.Errorf("reserved claim '%s' not allowed", key)
+			}
+			var val interface{}
+			if err := cwtDecMode.Unmarshal(v, &val); err != nil {
+				return nil, err
+			}
+			private[key] = val
+		case uint64:
+			name := ""
+			for n, i := range cwtClaimKeys {
+				if uint64(i) == key {
+					name = n
+				}
+			}
+			if err := cwtClaim(claims, name, v); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid claim key %v", k)
+		}
+	}
+	// normalize the private claims as decoded from JSON
+	b, err := json.Marshal(private)
+	if err == nil {
+		err = json.Unmarshal(b, &private)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range private {
+		claims[k] = v
+	}
+	return claims, nil
+}
+
+func cwtClaim(claims map[string]interface{}, name string, v cbor.RawMessage) error {
+	var err error
+	switch name {
+	case "iss", "sub", "aud":
+		var s string
+		err = cwtDecMode.Unmarshal(v, &s)
+		claims[name] = s
+	case "exp", "nbf", "iat":
+		var t int64
+		err = cwtDecMode.Unmarshal(v, &t)
+		claims[name] = float64(t)
+	case "jti":
+		var b []byte
+		err = cwtDecMode.Unmarshal(v, &b)
+		claims[name] = string(b)
+	default:
+		return nil // ignore the unknown registered claims
+	}
+	if err != nil {
+		return fmt.Errorf("invalid '%s' claim: %s", name, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package otgo_test
+
+import (
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCWT(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "iot")
+
+	t.Run("OTVID.SignCWT & ParseOTVIDCWT", func(t *testing.T) {
+		for _, alg := range []string{"ES256", "ES384", "RS256", "PS256"} {
+			assert := assert.New(t)
+
+			key := otgo.MustPrivateKey(alg)
+			ks := otgo.LookupPublicKeys(otgo.MustKeys(key))
+			vid := &otgo.OTVID{ID: td.NewOTID("device", "abc"), Issuer: td.OTID(), Audience: aud,
+				Expiry: time.Now().Add(time.Hour), ReleaseID: "r1"}
+			assert.Nil(vid.SetClaims(map[string]interface{}{"name": "sensor", "level": 3, "tags": []string{"a"},
+				"meta": map[string]interface{}{"v": 1.5}}))
+
+			data, err := vid.SignCWT(key)
+			assert.Nil(err, alg)
+			assert.Equal(byte(0xd2), data[0]) // tag 18
+			token, err := vid.Sign(key)
+			assert.Nil(err)
+			assert.True(len(data) < len(token))
+
+			// interop with the JWT path
+			v1, err := otgo.ParseOTVIDCWT(data, ks, td.OTID(), aud)
+			assert.Nil(err, alg)
+			v2, err := otgo.ParseOTVID(token, ks, td.OTID(), aud)
+			assert.Nil(err)
+			assert.Equal(v2.ID, v1.ID)
+			assert.Equal(v2.Issuer, v1.Issuer)
+			assert.Equal(v2.Audience, v1.Audience)
+			assert.Equal(v2.Expiry.Unix(), v1.Expiry.Unix())
+			assert.Equal(v2.IssuedAt.Unix(), v1.IssuedAt.Unix())
+			assert.Equal(v2.JTI, v1.JTI)
+			assert.Equal("r1", v1.ReleaseID)
+			assert.Equal(v2.Claims, v1.Claims)
+			assert.Equal("", v1.Token())
+
+			_, err = otgo.ParseOTVIDCWT(data, ks, td.OTID(), td.NewOTID("svc", "other"))
+			assert.NotNil(err)
+			_, err = otgo.ParseOTVIDCWT(data, otgo.LookupPublicKeys(otgo.MustKeys(otgo.MustPrivateKey(alg))), td.OTID(), aud)
+			assert.NotNil(err)
+			_, err = otgo.ParseOTVIDCWT(data, nil, td.OTID(), aud)
+			assert.NotNil(err)
+
+			tampered := append([]byte{}, data...)
+			tampered[len(tampered)/2] ^= 0x01
+			_, err = otgo.ParseOTVIDCWT(tampered, ks, td.OTID(), aud)
+			assert.NotNil(err)
+		}
+	})
+
+	t.Run("OTVID.SignCWT with expired OTVID", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		vid := &otgo.OTVID{ID: td.NewOTID("device", "abc"), Issuer: td.OTID(), Audience: aud,
+			Expiry: time.Now().Add(-time.Minute)}
+		data, err := vid.SignCWT(key)
+		assert.Nil(err)
+		_, err = otgo.ParseOTVIDCWT(data, otgo.LookupPublicKeys(otgo.MustKeys(key)), td.OTID(), aud)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "expiration time")
+
+		_, err = otgo.ParseOTVIDCWT([]byte{0x01, 0x02}, otgo.LookupPublicKeys(otgo.MustKeys(key)), td.OTID(), aud)
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,248 @@
+package otgo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws/verify"
+)
+
+// The reasons of the signature verification errors of OTVIDs, they can be tested with errors.Is.
+var (
+	ErrUnknownKey       = errors.New("no matching key")
+	ErrInvalidSignature = errors.New("failed to verify signature")
+)
+
+// decodedOTVID is a OTVID token decoded in one pass without an intermediate jwt.Token,
+// its signature is not verified until verify is called.
+type decodedOTVID struct {
+	vid    *OTVID
+	header OTVIDHeader
+	input  string // signing input, "header.payload"
+	sig    []byte
+	// laxKeyUsage ignores the key's "use" and "key_ops" parameters, for legacy key sets.
+	laxKeyUsage bool
+	leeway      time.Duration // the clock skew tolerated for the expiration time
+	iat         *issuedAtCheck
+	binding     *Binding // the presented binding, see ParseBoundOTVID
+	spiffeSub   bool     // SPIFFE IDs are accepted for the subject and the x5c certificate's SAN
+	key         Key      // the key that verified the signature
+}
+
+// decodeOTVID decodes the token's header and claims, the 'sub' claim can be a SPIFFE ID if spiffeSub is true.
+// DefaultLimits is used if limits is nil. The raw claims are preserved if rawClaims is true, see OTVID.RawClaims.
+func decodeOTVID(token string, spiffeSub bool, limits *Limits, rawClaims bool) (*decodedOTVID, error) {
+	if l := len(token); l < 64 || l > limits.otvidMaxSize() {
+		return nil, fmt.Errorf("invalid OTVID token with length %d", l)
+	}
+	d := &decodedOTVID{spiffeSub: spiffeSub}
+	payload, err := d.split(token)
+	if err != nil {
+		return nil, err
+	}
+	claims := make(map[string]interface{})
+	if err = unmarshalClaims(payload, &claims, rawClaims); err != nil {
+		return nil, fmt.Errorf("otgo.decodeOTVID: invalid claims: %s", err.Error())
+	}
+	if d.vid, err = claimsToOTVID(token, claims, spiffeSub, limits, rawClaims); err != nil {
+		return nil, err
+	}
+	if rawClaims {
+		d.vid.RawClaims = payload
+	}
+	return d, nil
+}
+
+// unmarshalClaims unmarshals the JSON claims, the numbers are decoded as json.Number if useNumber is true.
+func unmarshalClaims(data []byte, v interface{}, useNumber bool) error {
+	if !useNumber {
+		return json.Unmarshal(data, v)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+// split decodes the compact serialized token's header and signature, and returns the decoded payload.
+func (d *decodedOTVID) split(token string) ([]byte, error) {
+	i := strings.IndexByte(token, '.')
+	j := strings.LastIndexByte(token, '.')
+	if i < 0 || i == j {
+		return nil, errors.New("otgo.decodeOTVID: invalid compact serialization format")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token[:i])
+	if err == nil {
+		err = json.Unmarshal(b, &d.header)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("otgo.decodeOTVID: invalid header: %s", err.Error())
+	}
+	if d.sig, err = base64.RawURLEncoding.DecodeString(token[j+1:]); err != nil {
+		return nil, fmt.Errorf("otgo.decodeOTVID: invalid signature: %s", err.Error())
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(token[i+1 : j])
+	if err != nil {
+		return nil, fmt.Errorf("otgo.decodeOTVID: invalid payload: %s", err.Error())
+	}
+	d.input = token[:j]
+	return payload, nil
+}
+
+// verify verifies the signature with the key in the JWK set that matches the token's kid.
+func (d *decodedOTVID) verify(ks *JWKSet) error {
+	if ks == nil {
+		return errors.New("otgo.ParseOTVID: public keys required")
+	}
+	if d.header.Kid == "" {
+		return errors.New("otgo.ParseOTVID: no key ID specified in token")
+	}
+	keys := ks.LookupKeyID(d.header.Kid)
+	if len(keys) == 0 {
+		return fmt.Errorf("otgo.ParseOTVID: %w for key ID %q", ErrUnknownKey, d.header.Kid)
+	}
+	if alg := keys[0].Algorithm(); alg != "" && alg != d.header.Alg {
+		return fmt.Errorf("otgo.ParseOTVID: algorithm '%s' not match the key's algorithm '%s'", d.header.Alg, alg)
+	}
+	if !d.laxKeyUsage {
+		if err := checkKeyUsage(keys[0], jwk.KeyOpVerify); err != nil {
+			return fmt.Errorf("otgo.ParseOTVID: %s", err.Error())
+		}
+	}
+	var raw interface{}
+	if err := keys[0].Raw(&raw); err != nil {
+		return fmt.Errorf("otgo.ParseOTVID: invalid key %q: %s", d.header.Kid, err.Error())
+	}
+	if err := d.verifyWith(raw); err != nil {
+		return err
+	}
+	d.key = keys[0]
+	return nil
+}
+
+// verifyWith verifies the signature with the raw public key.
+func (d *decodedOTVID) verifyWith(key interface{}) error {
+	if !ValidateAlgorithm(d.header.Alg) {
+		return fmt.Errorf("otgo.ParseOTVID: invalid algorithm '%s'", d.header.Alg)
+	}
+	v, err := verify.New(jwa.SignatureAlgorithm(d.header.Alg))
+	if err == nil {
+		err = v.Verify([]byte(d.input), d.sig, key)
+	}
+	if err != nil {
+		return fmt.Errorf("otgo.ParseOTVID: %w: %s", ErrInvalidSignature, err.Error())
+	}
+	return nil
+}
+
+// parse verifies the signature with the JWK set and the claims.
+func (d *decodedOTVID) parse(ks *JWKSet, issuer, audience OTID) (*OTVID, error) {
+	if err := d.verify(ks); err != nil {
+		return nil, err
+	}
+	if err := d.vid.verifyClaims(issuer, audience, d.leeway, d.iat); err != nil {
+		return nil, err
+	}
+	return d.vid, nil
+}
+
+var registeredClaims = []string{"sub", "iss", "aud", "exp", "iat", "nbf", "jti", "cnf"}
+
+// claimsToOTVID returns a OTVID from the decoded claims like fromJWT,
+// the registered claims are removed from the claims, the rest are the OTVID's private claims.
+func claimsToOTVID(token string, claims map[string]interface{}, spiffeSub bool, limits *Limits, useNumber bool) (*OTVID, error) {
+	var err error
+	var opts *parseOptions
+	if limits != nil {
+		opts = &parseOptions{limits: limits}
+	}
+	vid := &OTVID{token: token}
+	sub, _ := claims["sub"].(string)
+	if spiffeSub && strings.HasPrefix(sub, spiffeScheme) {
+		vid.ID, err = ParseSPIFFE(sub)
+	} else {
+		vid.ID, err = parseOTID(sub, opts)
+	}
+	if err == nil {
+		iss, _ := claims["iss"].(string)
+		vid.Issuer, err = parseOTID(iss, opts)
+	}
+	if err == nil {
+		switch aud := claims["aud"].(type) {
+		case string:
+			vid.Audience, err = parseOTID(aud, opts)
+		case []interface{}:
+			if len(aud) > 0 {
+				s, _ := aud[0].(string)
+				vid.Audience, err = parseOTID(s, opts)
+			}
+		}
+	}
+	if err == nil {
+		if rid, ok := claims["rid"]; ok {
+			if vid.ReleaseID, ok = rid.(string); !ok {
+				return nil, fmt.Errorf("invalid 'rid' field, must be a string")
+			}
+		}
+	}
+	if err == nil {
+		if jti, ok := claims["jti"]; ok {
+			if vid.JTI, ok = jti.(string); !ok {
+				return nil, fmt.Errorf("invalid 'jti' field, must be a string")
+			}
+		}
+		vid.Expiry, err = numericDate(claims, "exp")
+	}
+	if err == nil {
+		if cnf, ok := claims["cnf"]; ok {
+			vid.Confirmation, err = parseConfirmation(cnf)
+		}
+	}
+	if err == nil {
+		vid.IssuedAt, err = numericDate(claims, "iat")
+	}
+	if err == nil {
+		for _, k := range registeredClaims {
+			delete(claims, k)
+		}
+		vid.Claims, err = inflateClaims(claims, useNumber, limits)
+	}
+	if err == nil {
+		err = vid.validate(limits)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return vid, nil
+}
+
+// numericDate returns the claim's NumericDate, RFC 7519 section 2, the strings are rejected.
+func numericDate(claims map[string]interface{}, key string) (time.Time, error) {
+	switch v := claims[key].(type) {
+	case nil:
+		return time.Time{}, nil
+	case float64:
+		return time.Unix(int64(v), 0).UTC(), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return time.Unix(i, 0).UTC(), nil
+		}
+		if f, err := v.Float64(); err == nil {
+			return time.Unix(int64(f), 0).UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid '%s' field, must be a numeric date", key)
+}
@@ -0,0 +1,186 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwt"
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func newBenchOTVID() (string, *otgo.OTVID, otgo.Key) {
+	td := otgo.TrustDomain("localhost")
+	vid := &otgo.OTVID{}
+	vid.ID = td.NewOTID("user", "abc")
+	vid.Issuer = td.OTID()
+	vid.Audience = td.NewOTID("app", "123")
+	vid.Expiry = time.Now().Add(time.Hour)
+	vid.Claims = map[string]interface{}{"name": "test", "level": 3}
+	key := otgo.MustPrivateKey("ES256")
+	token, err := vid.Sign(key)
+	if err != nil {
+		panic(err)
+	}
+	return token, vid, key
+}
+
+func TestDecodeOTVID(t *testing.T) {
+	t.Run("same as jwt.ParseString", func(t *testing.T) {
+		assert := assert.New(t)
+
+		token, vid, key := newBenchOTVID()
+		pubKeys := otgo.LookupPublicKeys(otgo.MustKeys(key))
+		vid1, err := otgo.ParseOTVID(token, pubKeys, vid.Issuer, vid.Audience)
+		assert.Nil(err)
+
+		jt, err := jwt.ParseString(token, jwt.WithKeySet(pubKeys))
+		assert.Nil(err)
+		vid2, err := otgo.FromJWT(token, jt)
+		assert.Nil(err)
+		assert.Equal(vid2, vid1)
+	})
+
+	t.Run("invalid tokens", func(t *testing.T) {
+		assert := assert.New(t)
+
+		token, vid, key := newBenchOTVID()
+		pubKeys := otgo.LookupPublicKeys(otgo.MustKeys(key))
+		parts := strings.Split(token, ".")
+
+		_, err := otgo.ParseOTVID(parts[0]+"."+parts[1]+"."+parts[1], pubKeys, vid.Issuer, vid.Audience)
+		assert.NotNil(err)
+		_, err = otgo.ParseOTVID(parts[0]+"."+parts[1], pubKeys, vid.Issuer, vid.Audience)
+		assert.NotNil(err)
+		_, err = otgo.ParseOTVID(parts[0]+"."+parts[0]+"."+parts[2], pubKeys, vid.Issuer, vid.Audience)
+		assert.NotNil(err)
+
+		// alg in header must match the key's alg
+		pk, _ := otgo.ToPublicKey(key)
+		assert.Nil(pk.Set("alg", "ES384"))
+		_, err = otgo.ParseOTVID(token, otgo.MustKeys(pk), vid.Issuer, vid.Audience)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "algorithm")
+	})
+
+	t.Run("the non-numeric dates", func(t *testing.T) {
+		assert := assert.New(t)
+
+		enc := base64.RawURLEncoding.EncodeToString
+		header := enc([]byte(`{"alg":"ES256","kid":"abc"}`))
+		claims := `{"sub":"otid:localhost:user:abc","iss":"otid:localhost","aud":"otid:localhost:app:123","exp":%s}`
+		vid, err := otgo.ParseOTVIDInsecure(header + "." + enc([]byte(fmt.Sprintf(claims, "4102444800"))) + "." + enc([]byte("sig")))
+		assert.Nil(err)
+		assert.Equal(int64(4102444800), vid.Expiry.Unix())
+
+		_, err = otgo.ParseOTVIDInsecure(header + "." + enc([]byte(fmt.Sprintf(claims, `"4102444800"`))) + "." + enc([]byte("sig")))
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "must be a numeric date")
+	})
+
+	t.Run("with raw claims", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		aud := td.NewOTID("app", "123")
+		key := otgo.MustPrivateKey("ES256")
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud}
+		vid.Claims = map[string]interface{}{"bid": json.Number("9007199254740993"), "name": "test"}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key))
+		assert.Nil(err)
+		vid2, err := v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Nil(vid2.RawClaims)
+		assert.Equal(float64(9007199254740992), vid2.Claims["bid"])
+
+		v.SetRawClaims(true)
+		vid2, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Equal(json.Number("9007199254740993"), vid2.Claims["bid"])
+		assert.Equal("test", vid2.Claims["name"])
+		assert.True(vid.Expiry.Equal(vid2.Expiry))
+		assert.Contains(string(vid2.RawClaims), `"bid":9007199254740993`)
+		assert.Contains(string(vid2.RawClaims), `"sub":"otid:localhost:user:abc"`)
+
+		// the compressed claims
+		token, err = vid.SignCompressed(key)
+		assert.Nil(err)
+		vid2, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Equal(json.Number("9007199254740993"), vid2.Claims["bid"])
+
+		oc := otgo.NewOTClient(context.Background(), aud)
+		oc.SetDomainKeys(*otgo.LookupPublicKeys(otgo.MustKeys(key)))
+		oc.PreserveRawClaims = true
+		vid2, err = oc.ParseOTVID(context.Background(), token)
+		assert.Nil(err)
+		assert.Equal(json.Number("9007199254740993"), vid2.Claims["bid"])
+		assert.NotNil(vid2.RawClaims)
+
+		v, err = otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key), otgo.WithRawClaims())
+		assert.Nil(err)
+		vid2, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Equal(json.Number("9007199254740993"), vid2.Claims["bid"])
+	})
+}
+
+func BenchmarkParseOTVID(b *testing.B) {
+	token, vid, key := newBenchOTVID()
+	pubKeys := otgo.LookupPublicKeys(otgo.MustKeys(key))
+
+	b.Run("ParseOTVID", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := otgo.ParseOTVID(token, pubKeys, vid.Issuer, vid.Audience); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// the baseline that parses the token twice with an intermediate jwt.Token
+	b.Run("jwt.ParseString", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			t, err := jwt.ParseString(token, jwt.WithKeySet(pubKeys))
+			if err == nil {
+				_, err = otgo.FromJWT(token, t)
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ParseOTVIDInsecure", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := otgo.ParseOTVIDInsecure(token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Verifier.ParseOTVID", func(b *testing.B) {
+		v, err := otgo.NewVerifier(context.Background(), vid.Audience, nil, key)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := v.ParseOTVID(token); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,177 @@
+package otgo
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// claimParent is the claim of the parent OTVID's reference in a delegation chain.
+const claimParent = "prt"
+
+// MaxDelegationDepth is the max number of parent OTVIDs in a delegation chain.
+const MaxDelegationDepth = 5
+
+// parentRef is a parent OTVID as present in the "prt" claim: the parent's subject, issuer and audience,
+// the SHA-256 hash of its token and its own parent. The parent's token is not embedded, so the OTVID's
+// audience can not replay it.
+type parentRef struct {
+	Sub    string     `json:"sub"`
+	Iss    string     `json:"iss"`
+	Aud    string     `json:"aud"`
+	JTI    string     `json:"jti,omitempty"`
+	Hash   string     `json:"ths"`
+	Parent *parentRef `json:"prt,omitempty"`
+}
+
+func (r *parentRef) depth() int {
+	n := 0
+	for ; r != nil; r = r.Parent {
+		n++
+	}
+	return n
+}
+
+// tokenHash returns the base64url encoded SHA-256 hash of the token.
+func tokenHash(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+// SetParent references the parent OTVID in the OTVID's "prt" claim, so that the OTVID's subject
+// can prove it acts on behalf of the parent's subject, e.g. service A calls service B for user U with
+// a OTVID (sub A, aud B) referencing U's OTVID (sub U, aud A). The parent must be signed, and its audience
+// must be the OTVID's subject. It should be called after SetClaims, which replaces the private claims.
+// The claim carries the parent's subject, issuer, audience and token hash, and the parent's own parents,
+// but not the parent's token, see VerifyChain and VerifyParent.
+func (o *OTVID) SetParent(parent *OTVID) error {
+	if parent.Token() == "" {
+		return errors.New("otgo.OTVID.SetParent: the parent OTVID is not signed")
+	}
+	if !parent.Audience.Equal(o.ID) {
+		return fmt.Errorf("otgo.OTVID.SetParent: the parent's audience %s is not the subject %s",
+			parent.Audience.String(), o.ID.String())
+	}
+	grand, err := parent.parentRef()
+	if err != nil {
+		return fmt.Errorf("otgo.OTVID.SetParent: %s", err.Error())
+	}
+	if grand.depth() >= MaxDelegationDepth {
+		return fmt.Errorf("otgo.OTVID.SetParent: the chain is deeper than %d", MaxDelegationDepth)
+	}
+	ref := &parentRef{Sub: parent.ID.String(), Iss: parent.Issuer.String(), Aud: parent.Audience.String(),
+		JTI: parent.JTI, Hash: tokenHash(parent.Token()), Parent: grand}
+	// stored as the decoded JSON, the same as the parsed OTVIDs' claim
+	var claim map[string]interface{}
+	b, err := json.Marshal(ref)
+	if err == nil {
+		err = json.Unmarshal(b, &claim)
+	}
+	if err != nil {
+		return fmt.Errorf("otgo.OTVID.SetParent: %s", err.Error())
+	}
+	if o.Claims == nil {
+		o.Claims = make(map[string]interface{})
+	}
+	o.Claims[claimParent] = claim
+	return nil
+}
+
+// parentRef returns the parent reference in the "prt" claim, or nil if absent.
+func (o *OTVID) parentRef() (*parentRef, error) {
+	v, ok := o.Claims[claimParent]
+	if !ok {
+		return nil, nil
+	}
+	if _, ok = v.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("invalid '%s' claim, must be a object", claimParent)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	ref := &parentRef{}
+	if err = json.Unmarshal(b, ref); err != nil {
+		return nil, fmt.Errorf("invalid '%s' claim: %s", claimParent, err.Error())
+	}
+	return ref, nil
+}
+
+// VerifyChain verifies the OTVID's delegation chain, and returns the parent OTVIDs from the direct
+// parent to the origin. The parent OTVIDs carry the subject, issuer, audience and 'jti' only.
+// Each parent's issuer must be a trusted trust domain, and its audience must be the subject of the OTVID
+// it is referenced by. The chain is asserted by the OTVID's issuer, the OTVID itself is not verified,
+// it should be parsed with ParseOTVID or a Verifier first.
+func (o *OTVID) VerifyChain(trusted ...TrustDomain) ([]*OTVID, error) {
+	ref, err := o.parentRef()
+	if err != nil {
+		return nil, fmt.Errorf("otgo.OTVID.VerifyChain: %s", err.Error())
+	}
+	if ref.depth() > MaxDelegationDepth {
+		return nil, fmt.Errorf("otgo.OTVID.VerifyChain: the chain is deeper than %d", MaxDelegationDepth)
+	}
+	var chain []*OTVID
+	child := o
+	for ; ref != nil; ref = ref.Parent {
+		parent, err := ref.verify(child.ID, trusted)
+		if err != nil {
+			return nil, fmt.Errorf("otgo.OTVID.VerifyChain: hop %d: %s", len(chain)+1, err.Error())
+		}
+		chain = append(chain, parent)
+		child = parent
+	}
+	return chain, nil
+}
+
+func (r *parentRef) verify(aud OTID, trusted []TrustDomain) (*OTVID, error) {
+	if r.Hash == "" {
+		return nil, errors.New("token hash required")
+	}
+	vid := &OTVID{JTI: r.JTI}
+	var err error
+	if vid.ID, err = ParseOTID(r.Sub); err != nil {
+		return nil, err
+	}
+	if vid.Issuer, err = ParseOTID(r.Iss); err != nil {
+		return nil, err
+	}
+	if vid.Audience, err = ParseOTID(r.Aud); err != nil {
+		return nil, err
+	}
+	if !vid.Issuer.IsDomainID() || !trustDomainsHas(trusted, vid.Issuer.TrustDomain()) {
+		return nil, fmt.Errorf("issuer %s not trusted", vid.Issuer.String())
+	}
+	if !vid.Audience.Equal(aud) {
+		return nil, errors.New("audience not satisfied")
+	}
+	return vid, nil
+}
+
+// VerifyParent verifies that the parent OTVID is the direct parent referenced by the OTVID, e.g. by the
+// OTVID's subject that received the parent's token.
+func (o *OTVID) VerifyParent(parent *OTVID) error {
+	ref, err := o.parentRef()
+	if err != nil {
+		return fmt.Errorf("otgo.OTVID.VerifyParent: %s", err.Error())
+	}
+	if ref == nil {
+		return errors.New("otgo.OTVID.VerifyParent: no parent")
+	}
+	h := tokenHash(parent.Token())
+	if parent.Token() == "" || subtle.ConstantTimeCompare([]byte(h), []byte(ref.Hash)) != 1 {
+		return errors.New("otgo.OTVID.VerifyParent: the parent's token not match")
+	}
+	return nil
+}
+
+func trustDomainsHas(tds []TrustDomain, td TrustDomain) bool {
+	for _, t := range tds {
+		if t == td {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package otgo_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelegation(t *testing.T) {
+	td1 := otgo.TrustDomain("localhost")
+	td2 := otgo.TrustDomain("partner.example")
+	key1 := otgo.MustPrivateKey("ES256")
+	key2 := otgo.MustPrivateKey("ES256")
+	ks := map[otgo.TrustDomain]*otgo.JWKSet{
+		td1: otgo.LookupPublicKeys(otgo.MustKeys(key1)),
+		td2: otgo.LookupPublicKeys(otgo.MustKeys(key2)),
+	}
+	user := td2.NewOTID("user", "u")
+	svcA := td1.NewOTID("svc", "a")
+	svcB := td1.NewOTID("svc", "b")
+	svcC := td1.NewOTID("svc", "c")
+
+	newVID := func(sub, iss, aud otgo.OTID) *otgo.OTVID {
+		return &otgo.OTVID{ID: sub, Issuer: iss, Audience: aud, Expiry: time.Now().Add(time.Hour)}
+	}
+
+	t.Run("OTVID.SetParent & OTVID.VerifyChain method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pu := newVID(user, td2.OTID(), svcA)
+		_, err := pu.Sign(key2)
+		assert.Nil(err)
+
+		pa := newVID(svcA, td1.OTID(), svcB)
+		assert.NotNil(newVID(svcC, td1.OTID(), svcB).SetParent(pu))
+		assert.NotNil(pa.SetParent(newVID(user, td2.OTID(), svcA)))
+		assert.Nil(pa.SetParent(pu))
+		_, err = pa.Sign(key1)
+		assert.Nil(err)
+
+		pb := newVID(svcB, td1.OTID(), svcC)
+		assert.Nil(pb.SetParent(pa))
+		token, err := pb.Sign(key1)
+		assert.Nil(err)
+		// the parents' tokens are not embedded
+		assert.NotContains(token, strings.Split(pa.Token(), ".")[2])
+
+		vid, err := otgo.ParseOTVID(token, ks[td1], td1.OTID(), svcC)
+		assert.Nil(err)
+		assert.Nil(vid.VerifyParent(pa))
+		assert.NotNil(vid.VerifyParent(pu))
+		assert.NotNil(pu.VerifyParent(pa))
+
+		chain, err := vid.VerifyChain(td1, td2)
+		assert.Nil(err)
+		assert.Equal(2, len(chain))
+		assert.Equal(svcA, chain[0].ID)
+		assert.Equal(svcB, chain[0].Audience)
+		assert.Equal(user, chain[1].ID)
+		assert.Equal(td2.OTID(), chain[1].Issuer)
+		assert.Equal("", chain[1].Token())
+
+		_, err = vid.VerifyChain(td1)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "hop 2")
+
+		chain, err = pu.VerifyChain(td1, td2)
+		assert.Nil(err)
+		assert.Equal(0, len(chain))
+	})
+
+	t.Run("OTVID.VerifyChain with invalid hops", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pu := newVID(user, td2.OTID(), svcA)
+		_, err := pu.Sign(key2)
+		assert.Nil(err)
+		vid := newVID(svcA, td1.OTID(), svcB)
+		assert.Nil(vid.SetParent(pu))
+		prt := vid.Claims["prt"].(map[string]interface{})
+
+		// the parent's audience is not the subject
+		vid.ID = svcC
+		_, err = vid.VerifyChain(td1, td2)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "audience not satisfied")
+		vid.ID = svcA
+
+		// the parent's issuer is not a trust domain
+		prt["iss"] = td2.NewOTID("issuer", "x").String()
+		_, err = vid.VerifyChain(td1, td2)
+		assert.NotNil(err)
+		prt["iss"] = td2.OTID().String()
+
+		delete(prt, "ths")
+		_, err = vid.VerifyChain(td1, td2)
+		assert.NotNil(err)
+
+		vid.Claims["prt"] = pu.Token()
+		_, err = vid.VerifyChain(td1, td2)
+		assert.NotNil(err)
+
+		assert.NotNil(vid.SetClaims(map[string]interface{}{"prt": prt}))
+	})
+
+	t.Run("MaxDelegationDepth", func(t *testing.T) {
+		assert := assert.New(t)
+
+		parent := newVID(user, td2.OTID(), svcA)
+		_, err := parent.Sign(key2)
+		assert.Nil(err)
+		subs := []otgo.OTID{svcA, svcB}
+		for i := 0; i < otgo.MaxDelegationDepth; i++ {
+			vid := newVID(subs[i%2], td1.OTID(), subs[(i+1)%2])
+			assert.Nil(vid.SetParent(parent))
+			_, err = vid.Sign(key1)
+			assert.Nil(err)
+			parent = vid
+		}
+		vid := newVID(subs[otgo.MaxDelegationDepth%2], td1.OTID(), svcC)
+		assert.NotNil(vid.SetParent(parent))
+	})
+}
@@ -0,0 +1,47 @@
+package otgo
+
+import "time"
+
+// SetServiceTTL sets the TTL of the audience's selected service endpoint instead of OTClient.ServiceTTL,
+// 0 to use OTClient.ServiceTTL and a negative ttl to keep the endpoint as long as OT-Auth still lists it.
+// It is safe for concurrent use.
+func (oc *OTClient) SetServiceTTL(aud OTID, ttl time.Duration) {
+	oc.ttlMu.Lock()
+	defer oc.ttlMu.Unlock()
+	if ttl == 0 {
+		delete(oc.serviceTTLs, aud.String())
+		return
+	}
+	if oc.serviceTTLs == nil {
+		oc.serviceTTLs = make(map[string]time.Duration)
+	}
+	oc.serviceTTLs[aud.String()] = ttl
+}
+
+// serviceTTL returns the TTL of the audience's selected service endpoint, it never expires if <= 0.
+func (oc *OTClient) serviceTTL(aud OTID) time.Duration {
+	oc.ttlMu.RLock()
+	ttl, ok := oc.serviceTTLs[aud.String()]
+	oc.ttlMu.RUnlock()
+	if ok {
+		return ttl
+	}
+	return oc.ServiceTTL
+}
+
+// InvalidateService discovers the audience's service endpoints again on the next call to the service,
+// e.g. after the service's endpoints changed: a new OTVID is requested from OT-Auth and the endpoint is
+// selected from its service endpoints. The cached OTVID and endpoint are still used if the discovery fails
+// while OT-Auth is rate limiting or in a maintenance window. It is safe for concurrent use.
+func (oc *OTClient) InvalidateService(aud OTID) {
+	obj, ok := oc.serviceCache.lookup(aud)
+	if !ok {
+		return
+	}
+	r := obj.(*serviceRenewer)
+	r.Lock()
+	defer r.Unlock()
+	if r.vid != nil {
+		r.stale = true
+	}
+}
@@ -0,0 +1,114 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceDiscovery(t *testing.T) {
+	t.Run("OTClient.InvalidateService and OTClient.SetServiceTTL method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		newService := func() *httptest.Server {
+			return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.Write([]byte(`{"result": "ok"}`))
+			}))
+		}
+		svcA, svcB := newService(), newService()
+		defer svcA.Close()
+		defer svcB.Close()
+
+		td := otgo.TrustDomain("localhost")
+		domainKey := otgo.MustPrivateKey("ES256")
+		var mu sync.Mutex
+		endpoints := []string{svcA.URL}
+		setEndpoints := func(urls ...string) {
+			mu.Lock()
+			defer mu.Unlock()
+			endpoints = urls
+		}
+		var signs int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&signs, 1)
+			input := otgo.SignInput{}
+			json.NewDecoder(r.Body).Decode(&input)
+			vid := &otgo.OTVID{ID: input.Subject, Issuer: td.OTID(), Audience: input.Audience, Expiry: fc.Now().Add(time.Hour)}
+			token, _ := vid.Sign(domainKey)
+			mu.Lock()
+			urls := endpoints
+			mu.Unlock()
+			b, _ := json.Marshal(map[string]interface{}{"result": otgo.SignOutput{
+				Issuer:           td.OTID(),
+				Audience:         input.Audience,
+				Expiry:           vid.Expiry.Unix(),
+				OTVID:            token,
+				ServiceEndpoints: urls,
+			}})
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"), otgo.WithOTAuth(ts.URL))
+		assert.Nil(err)
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+		aud := td.NewOTID("svc", "a")
+		resolve := func() string {
+			cfg, err := oc.Service(aud).Resolve(context.Background())
+			assert.Nil(err)
+			if cfg == nil {
+				return ""
+			}
+			return cfg.Endpoint
+		}
+
+		oc.InvalidateService(aud) // not cached yet
+		assert.Equal(svcA.URL, resolve())
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+
+		// the selected endpoint is cached
+		setEndpoints(svcB.URL)
+		assert.Equal(svcA.URL, resolve())
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+
+		oc.InvalidateService(aud)
+		assert.Equal(svcB.URL, resolve())
+		assert.Equal(int32(2), atomic.LoadInt32(&signs))
+		assert.Equal(svcB.URL, resolve())
+		assert.Equal(int32(2), atomic.LoadInt32(&signs))
+
+		oc.SetServiceTTL(aud, time.Minute)
+		setEndpoints(svcA.URL, svcB.URL)
+		fc.Advance(30 * time.Second)
+		assert.Equal(svcB.URL, resolve())
+		assert.Equal(int32(2), atomic.LoadInt32(&signs))
+		setEndpoints(svcA.URL)
+		fc.Advance(30 * time.Second)
+		assert.Equal(svcA.URL, resolve())
+		assert.Equal(int32(3), atomic.LoadInt32(&signs))
+
+		// the per-service TTL overrides OTClient.ServiceTTL
+		oc.ServiceTTL = time.Second
+		oc.SetServiceTTL(aud, -1)
+		setEndpoints(svcB.URL)
+		fc.Advance(time.Minute)
+		assert.Equal(svcA.URL, resolve())
+		assert.Equal(int32(3), atomic.LoadInt32(&signs))
+		oc.SetServiceTTL(aud, 0)
+		assert.Equal(svcB.URL, resolve())
+		assert.Equal(int32(4), atomic.LoadInt32(&signs))
+	})
+}
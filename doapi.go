@@ -0,0 +1,108 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrAPIResponse is the reason of APIError, it can be tested with errors.Is.
+var ErrAPIResponse = errors.New("error response")
+
+// APIError is returned by DoAPI if the response envelope (see Response) has an error,
+// regardless of the HTTP status code.
+type APIError struct {
+	Path    string      // the API path or URL
+	Code    string      // the "code" member of the envelope's error object, if any
+	Message string      // the envelope's error message
+	Detail  interface{} // the envelope's error as decoded
+	Err     error       // the HTTP error, e.g. a non-success status code, nil if the status code is 2xx
+}
+
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("otgo.DoAPI: %s responded with error %s: %s", e.Path, e.Code, e.Message)
+	}
+	return fmt.Sprintf("otgo.DoAPI: %s responded with error: %s", e.Path, e.Message)
+}
+
+// Is ...
+func (e *APIError) Is(target error) bool {
+	return target == ErrAPIResponse
+}
+
+// Unwrap ...
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// newAPIError returns the APIError of the envelope's error, or nil if there is no error.
+func newAPIError(path string, detail interface{}, err error) *APIError {
+	e := &APIError{Path: path, Detail: detail, Err: err}
+	switch v := detail.(type) {
+	case nil:
+		return nil
+	case string:
+		if v == "" {
+			return nil
+		}
+		e.Message = v
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return nil
+		}
+		e.Code = firstOf(v, "code", "error")
+		if e.Message = firstOf(v, "message", "error_description", "msg"); e.Message == "" {
+			e.Message = fmt.Sprint(v)
+		}
+	case bool:
+		if !v {
+			return nil
+		}
+		e.Message = "true"
+	default:
+		e.Message = fmt.Sprint(v)
+	}
+	return e
+}
+
+// firstOf returns the first non-empty member of the keys as a string.
+func firstOf(m map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := m[k]; ok && v != nil && v != "" {
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
+
+// doAPI calls do with the response envelope of the result, and surfaces the envelope's error as a *APIError.
+func doAPI(path string, result interface{}, do func(output interface{}) error) error {
+	res := &Response{Result: result}
+	err := do(res)
+	if e := newAPIError(path, res.Error, err); e != nil {
+		return e
+	}
+	return err
+}
+
+// DoAPI calls the API of the service like Do, the response is a envelope (see Response): its result is
+// decoded into result, and its error is returned as a *APIError even if the HTTP status code is 2xx.
+func (sc *ServiceClient) DoAPI(ctx context.Context, method, path string, input, result interface{}) error {
+	return doAPI(path, result, func(output interface{}) error {
+		return sc.Do(ctx, method, path, nil, input, output)
+	})
+}
+
+// DoAPI calls the API of the OT-Auth service with the subject's OTVID like ServiceClient.DoAPI.
+func (oc *OTClient) DoAPI(ctx context.Context, method, path string, input, result interface{}) error {
+	return oc.otClient.DoAPI(ctx, method, path, input, result)
+}
+
+// doAPIWith calls the API at the URL with the header and the HTTPClient like ServiceClient.DoAPI.
+func doAPIWith(ctx context.Context, cli HTTPClient, method, url string, h http.Header, input, result interface{}) error {
+	return doAPI(url, result, func(output interface{}) error {
+		return cli.Do(ctx, method, url, h, input, output)
+	})
+}
@@ -0,0 +1,92 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDoAPI(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	sub := td.NewOTID("app", "123")
+	aud := td.NewOTID("svc", "tester")
+	domainKey := otgo.MustPrivateKey("ES256")
+	var signs int32
+	ts := newTestOTAuth(td, domainKey, &signs)
+	defer ts.Close()
+
+	svc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"result": {"name": "tester"}}`))
+		case "/error-string":
+			w.Write([]byte(`{"error": "something wrong", "result": null}`))
+		case "/error-object":
+			w.Write([]byte(`{"error": {"code": "NotFound", "message": "user not found"}}`))
+		case "/error-400":
+			w.WriteHeader(400)
+			w.Write([]byte(`{"error": {"error": "invalid_request"}}`))
+		default:
+			w.WriteHeader(500)
+			w.Write([]byte(`internal error`))
+		}
+	}))
+	defer svc.Close()
+
+	oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL))
+	assert.Nil(t, err)
+	oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+	token, err := (&otgo.OTVID{ID: sub, Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}).Sign(domainKey)
+	assert.Nil(t, err)
+	assert.Nil(t, oc.AddAudience(token, svc.URL))
+
+	t.Run("ServiceClient.DoAPI method", func(t *testing.T) {
+		assert := assert.New(t)
+		sc := oc.Service(aud)
+
+		res := struct {
+			Name string `json:"name"`
+		}{}
+		assert.Nil(sc.DoAPI(context.Background(), "GET", "/ok", nil, &res))
+		assert.Equal("tester", res.Name)
+		assert.Nil(sc.DoAPI(context.Background(), "GET", "/ok", nil, nil))
+
+		var ae *otgo.APIError
+		err := sc.DoAPI(context.Background(), "GET", "/error-string", nil, &res)
+		assert.True(errors.Is(err, otgo.ErrAPIResponse))
+		assert.True(errors.As(err, &ae))
+		assert.Equal("/error-string", ae.Path)
+		assert.Equal("something wrong", ae.Message)
+		assert.Nil(ae.Err)
+
+		err = sc.DoAPI(context.Background(), "GET", "/error-object", nil, &res)
+		assert.True(errors.As(err, &ae))
+		assert.Equal("NotFound", ae.Code)
+		assert.Equal("user not found", ae.Message)
+		assert.Contains(err.Error(), "NotFound")
+
+		err = sc.DoAPI(context.Background(), "GET", "/error-400", nil, &res)
+		assert.True(errors.As(err, &ae))
+		assert.Equal("invalid_request", ae.Code)
+		assert.NotNil(ae.Err)
+
+		err = sc.DoAPI(context.Background(), "GET", "/unknown", nil, &res)
+		assert.NotNil(err)
+		assert.False(errors.Is(err, otgo.ErrAPIResponse))
+	})
+
+	t.Run("OTClient.DoAPI method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var res string
+		assert.Nil(oc.DoAPI(context.Background(), "GET", "/status", nil, &res))
+		assert.Equal("ok", res)
+	})
+}
@@ -0,0 +1,142 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DefaultEndpointHealthTTL is the default EndpointSelector.TTL.
+const DefaultEndpointHealthTTL = 30 * time.Second
+
+// EndpointSelector selects a healthy service endpoint. The health and latency of the probed endpoints
+// are cached for TTL, so that a renewal only probes the endpoints without fresh health.
+// The previously selected healthy endpoint is preferred, then the one with the lowest latency.
+// The zero value is ready to use, it is safe for concurrent use.
+type EndpointSelector struct {
+	TTL          time.Duration // DefaultEndpointHealthTTL if 0
+	RequireHTTPS bool          // ignore the endpoints that are not HTTPS or Unix domain sockets
+	mu           sync.Mutex
+	health       map[string]*endpointHealth
+}
+
+type endpointHealth struct {
+	healthy    bool
+	latency    time.Duration
+	checkedAt  time.Time
+	selectedAt time.Time
+}
+
+func (s *EndpointSelector) ttl() time.Duration {
+	if s.TTL > 0 {
+		return s.TTL
+	}
+	return DefaultEndpointHealthTTL
+}
+
+// Select returns a healthy endpoint of the serviceEndpoints, the endpoints without fresh health are probed
+// concurrently with GET requests until the first healthy one responds, ctx's deadline is honored and
+// the probing is limited to 5 seconds.
+func (s *EndpointSelector) Select(ctx context.Context, serviceEndpoints []string, cli HTTPClient) (string, error) {
+	endpoints := make([]string, 0, len(serviceEndpoints))
+	for _, url := range serviceEndpoints {
+		if isEndpointURL(url, s.RequireHTTPS) {
+			endpoints = append(endpoints, url)
+		}
+	}
+	if len(endpoints) == 0 {
+		return "", errors.New("no service endpoints")
+	}
+	if cli == nil {
+		cli = DefaultHTTPClient
+	}
+
+	url, stale := s.cached(endpoints)
+	if url != "" {
+		return url, nil
+	}
+	if len(stale) == 0 {
+		return "", errors.New("no valid service endpoints")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	ch := make(chan string, len(stale))
+	for _, url := range stale {
+		go func(url string) {
+			start := time.Now()
+			err := cli.Do(ctx, "GET", url, nil, nil, nil)
+			if ctx.Err() == nil || err == nil {
+				// the endpoints still probing when Select returns are not recorded
+				s.record(url, err == nil, time.Since(start))
+			}
+			if err != nil {
+				url = ""
+			}
+			ch <- url
+		}(url)
+	}
+	for range stale {
+		select {
+		case url := <-ch:
+			if url != "" {
+				s.selected(url)
+				return url, nil
+			}
+		case <-ctx.Done():
+			return "", errors.New("no valid service endpoints")
+		}
+	}
+	return "", errors.New("no valid service endpoints")
+}
+
+// cached returns the preferred healthy endpoint with fresh health if exists,
+// otherwise the endpoints without fresh health.
+func (s *EndpointSelector) cached(endpoints []string) (string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	var best *endpointHealth
+	var url string
+	var stale []string
+	for _, u := range endpoints {
+		h := s.health[u]
+		switch {
+		case h == nil || now.Sub(h.checkedAt) >= s.ttl():
+			stale = append(stale, u)
+		case !h.healthy:
+		case best == nil || h.selectedAt.After(best.selectedAt) ||
+			(h.selectedAt.Equal(best.selectedAt) && h.latency < best.latency):
+			best, url = h, u
+		}
+	}
+	if best != nil {
+		best.selectedAt = now
+	}
+	return url, stale
+}
+
+func (s *EndpointSelector) record(url string, healthy bool, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.health == nil {
+		s.health = make(map[string]*endpointHealth)
+	}
+	h := s.health[url]
+	if h == nil {
+		h = &endpointHealth{}
+		s.health[url] = h
+	}
+	h.healthy = healthy
+	h.latency = latency
+	h.checkedAt = time.Now()
+}
+
+func (s *EndpointSelector) selected(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if h := s.health[url]; h != nil {
+		h.selectedAt = time.Now()
+	}
+}
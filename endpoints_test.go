@@ -0,0 +1,111 @@
+package otgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointSelector(t *testing.T) {
+	newServer := func(delay time.Duration, status int, probes *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(probes, 1)
+			time.Sleep(delay)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"result": "ok"}`))
+		}))
+	}
+
+	t.Run("EndpointSelector.Select method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var p0, p1, p2 int32
+		ts0 := newServer(50*time.Millisecond, 200, &p0)
+		defer ts0.Close()
+		ts1 := newServer(0, 200, &p1)
+		defer ts1.Close()
+		ts2 := newServer(0, 500, &p2)
+		defer ts2.Close()
+
+		s := &otgo.EndpointSelector{TTL: time.Hour}
+		_, err := s.Select(context.Background(), []string{ts2.URL}, nil)
+		assert.NotNil(err)
+		url, err := s.Select(context.Background(), []string{ts0.URL, ts1.URL}, nil)
+		assert.Nil(err)
+		assert.Equal(ts1.URL, url)
+		time.Sleep(100 * time.Millisecond) // ts0 was cancelled, not recorded
+
+		// the cached healthy endpoint is selected without probing, the cached unhealthy one is skipped
+		url, err = s.Select(context.Background(), []string{ts2.URL, ts1.URL}, nil)
+		assert.Nil(err)
+		assert.Equal(ts1.URL, url)
+		assert.Equal(int32(1), atomic.LoadInt32(&p1))
+		assert.Equal(int32(1), atomic.LoadInt32(&p2))
+
+		// ts0 is probed only if no fresh healthy endpoint
+		url, err = s.Select(context.Background(), []string{ts0.URL, ts2.URL}, nil)
+		assert.Nil(err)
+		assert.Equal(ts0.URL, url)
+		assert.Equal(int32(2), atomic.LoadInt32(&p0))
+		assert.Equal(int32(1), atomic.LoadInt32(&p2))
+
+		// the previously selected healthy endpoint is preferred over the lower latency one
+		url, err = s.Select(context.Background(), []string{ts1.URL, ts0.URL}, nil)
+		assert.Nil(err)
+		assert.Equal(ts0.URL, url)
+
+		_, err = s.Select(context.Background(), []string{ts2.URL}, nil)
+		assert.NotNil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&p2))
+		_, err = s.Select(context.Background(), []string{"abc"}, nil)
+		assert.NotNil(err)
+	})
+
+	t.Run("EndpointSelector.TTL", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var p0 int32
+		ts0 := newServer(0, 200, &p0)
+		defer ts0.Close()
+
+		s := &otgo.EndpointSelector{TTL: 50 * time.Millisecond}
+		for i := 0; i < 3; i++ {
+			url, err := s.Select(context.Background(), []string{ts0.URL}, nil)
+			assert.Nil(err)
+			assert.Equal(ts0.URL, url)
+		}
+		assert.Equal(int32(1), atomic.LoadInt32(&p0))
+		time.Sleep(60 * time.Millisecond)
+		_, err := s.Select(context.Background(), []string{ts0.URL}, nil)
+		assert.Nil(err)
+		assert.Equal(int32(2), atomic.LoadInt32(&p0))
+	})
+
+	t.Run("EndpointSelector.RequireHTTPS & deadline", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var p0 int32
+		ts0 := newServer(200*time.Millisecond, 200, &p0)
+		defer ts0.Close()
+
+		s := &otgo.EndpointSelector{RequireHTTPS: true}
+		_, err := s.Select(context.Background(), []string{ts0.URL}, nil)
+		assert.NotNil(err)
+		assert.Equal(int32(0), atomic.LoadInt32(&p0))
+
+		s = &otgo.EndpointSelector{}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = s.Select(ctx, []string{ts0.URL}, nil)
+		assert.NotNil(err)
+		assert.True(time.Since(start) < 150*time.Millisecond)
+	})
+}
@@ -0,0 +1,118 @@
+package otgo
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// NewEncodedOTID returns a Open Trust ID like NewOTID, but the subjectID is percent-encoded by EncodeSubjectID,
+// so that email addresses, UUIDs with uppercase characters, etc. can be embedded.
+// The OTID should be checked with ValidateEncoded() method before using, and parsed with WithEncodedSubjectID.
+func (td TrustDomain) NewEncodedOTID(subjectType, subjectID string) OTID {
+	return td.NewOTID(subjectType, EncodeSubjectID(subjectID))
+}
+
+// DecodedID returns the OTID's subject ID decoded by DecodeSubjectID,
+// it is the same as ID() if the subject ID is not encoded.
+func (id OTID) DecodedID() string {
+	s, err := DecodeSubjectID(id.subjectID)
+	if err != nil {
+		return id.subjectID
+	}
+	return s
+}
+
+// EncodeSubjectID percent-encodes the bytes that are not allowed in a subject ID with lowercase hex digits,
+// e.g. "Alice@example.com" to "%41lice%40example.com". The allowed bytes are never encoded,
+// so that every subject ID has one encoded form.
+func EncodeSubjectID(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if subjectByteAllowed(c, i == 0) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(lowerHex[c>>4])
+			b.WriteByte(lowerHex[c&15])
+		}
+	}
+	return b.String()
+}
+
+// DecodeSubjectID decodes a subject ID encoded by EncodeSubjectID.
+func DecodeSubjectID(s string) (string, error) {
+	if qr := checkSubjectID(s); qr != "" {
+		return "", fmt.Errorf("otgo.DecodeSubjectID: %s", qr)
+	}
+	if strings.IndexByte(s, '%') < 0 {
+		return s, nil
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' {
+			b = append(b, unhex(s[i+1])<<4|unhex(s[i+2]))
+			i += 2
+		} else {
+			b = append(b, s[i])
+		}
+	}
+	return string(b), nil
+}
+
+const lowerHex = "0123456789abcdef"
+
+// Lower ALPHA / DIGIT, and "." / "-" / "_" except the first byte.
+func subjectByteAllowed(c byte, first bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		return true
+	case c == '.' || c == '-' || c == '_':
+		return !first
+	}
+	return false
+}
+
+// checkSubjectID checks the subject ID like checkRunes, but accepts the percent-encoded bytes in canonical form:
+// lowercase hex digits, only for the bytes that are not allowed, and valid UTF-8 after decoding.
+func checkSubjectID(s string) string {
+	if strings.IndexByte(s, '%') < 0 {
+		return checkRunes(s)
+	}
+	decoded := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '%' {
+			if !subjectByteAllowed(c, i == 0) {
+				return checkRunes(s[i:])
+			}
+			decoded = append(decoded, c)
+			continue
+		}
+		if i+2 >= len(s) || !isLowerHex(s[i+1]) || !isLowerHex(s[i+2]) {
+			return fmt.Sprintf("invalid percent-encoding at %d", i)
+		}
+		d := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if subjectByteAllowed(d, i == 0) {
+			return fmt.Sprintf("unnecessary percent-encoding %q", s[i:i+3])
+		}
+		decoded = append(decoded, d)
+		i += 2
+	}
+	if !utf8.Valid(decoded) {
+		return "invalid UTF-8 after percent-decoding"
+	}
+	return ""
+}
+
+func isLowerHex(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f'
+}
+
+func unhex(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
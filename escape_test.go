@@ -0,0 +1,82 @@
+package otgo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectIDEncoding(t *testing.T) {
+	t.Run("EncodeSubjectID & DecodeSubjectID func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		for raw, encoded := range map[string]string{
+			"abc":               "abc",
+			"Alice@example.com": "%41lice%40example.com",
+			"9EEBCCD2-12BF":     "9%45%45%42%43%43%442-12%42%46",
+			".a:b":              "%2ea%3ab",
+			"例":                 "%e4%be%8b",
+			"":                  "",
+		} {
+			assert.Equal(encoded, otgo.EncodeSubjectID(raw))
+			s, err := otgo.DecodeSubjectID(encoded)
+			assert.Nil(err)
+			assert.Equal(raw, s)
+		}
+
+		for _, s := range []string{
+			"%40%4",      // truncated
+			"%4A",        // uppercase hex digits
+			"%61",        // "a" must not be encoded
+			"a%2e",       // "." must not be encoded except the first byte
+			"%ff",        // invalid UTF-8
+			"A%40",       // raw byte not allowed
+			"-%40",       // start with "-"
+			"%e4%be%8bZ", // raw byte not allowed
+		} {
+			_, err := otgo.DecodeSubjectID(s)
+			assert.NotNil(err, s)
+		}
+	})
+
+	t.Run("TrustDomain.NewEncodedOTID method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		id := td.NewEncodedOTID("user", "Alice@example.com")
+		assert.Nil(id.ValidateEncoded())
+		assert.NotNil(id.Validate())
+		assert.Equal("otid:localhost:user:%41lice%40example.com", id.String())
+		assert.Equal("%41lice%40example.com", id.ID())
+		assert.Equal("Alice@example.com", id.DecodedID())
+		assert.Equal("abc", td.NewOTID("user", "abc").DecodedID())
+
+		// the percent-encoding is opt-in
+		_, err := otgo.ParseOTID(id.String())
+		assert.NotNil(err)
+		id2, err := otgo.ParseOTID(id.String(), otgo.WithEncodedSubjectID())
+		assert.Nil(err)
+		assert.True(id.Equal(id2))
+		assert.Equal("Alice@example.com", id2.DecodedID())
+
+		_, err = json.Marshal(id)
+		assert.NotNil(err)
+		var id3 otgo.OTID
+		assert.NotNil(json.Unmarshal([]byte(`"`+id.String()+`"`), &id3))
+
+		id4, err := otgo.NewOTIDBuilder(td).Type("user").EncodedID("Alice@example.com").Build()
+		assert.Nil(err)
+		assert.True(id.Equal(id4))
+		_, err = otgo.NewOTIDBuilder(td).Type("user").ID("%41lice%40example.com").Build()
+		assert.NotNil(err)
+
+		// the wire format is still strict
+		assert.NotNil(td.NewOTID("user", "Alice@example.com").Validate())
+		_, err = otgo.ParseOTID("otid:localhost:user:%4A", otgo.WithEncodedSubjectID())
+		assert.NotNil(err)
+		_, err = otgo.ParseOTID("otid:localhost:us%40er:abc", otgo.WithEncodedSubjectID())
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,15 @@
+package otgo
+
+// BenchCache exports the cache for the benchmarks in package otgo_test.
+type BenchCache = cache
+
+// NewBenchCache creates a cache of serviceRenewers using n shards.
+func NewBenchCache(n int) *BenchCache {
+	return newCacheShards(func(id OTID) renewer { return &serviceRenewer{otid: id} }, nil, n)
+}
+
+// AESKeyWrap and AESKeyUnwrap export the RFC 3394 key wrap for the known answer tests in package otgo_test.
+var (
+	AESKeyWrap   = aesKeyWrap
+	AESKeyUnwrap = aesKeyUnwrap
+)
@@ -0,0 +1,120 @@
+package otgo
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileStore is a CacheStore persisted to a file, optionally encrypted with AES-GCM.
+// Set OTClient.Store to a FileStore so that short-lived CLI tools and sidecars reuse the OTVIDs
+// issued in previous invocations instead of requesting them on every invocation.
+//
+// A FileStore is for a single process: it is safe for concurrent use in the process, but the file is
+// not locked. The file is read only by NewFileStore and rewritten atomically by every Set, so processes
+// sharing the file never see it corrupted, but the last writer wins and the others' entries are lost.
+// Give every process its own file, or use a shared CacheStore across processes.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+	aead cipher.AEAD
+	kv   map[string]fileEntry
+}
+
+type fileEntry struct {
+	Value     []byte `json:"value"`
+	ExpiresAt int64  `json:"exp"` // Unix time in seconds
+}
+
+// NewFileStore returns a FileStore persisted to the file, the entries in the file are restored.
+// The file is encrypted with the key if it is not empty, the key must be 16, 24 or 32 bytes for AES-128, AES-192
+// or AES-256. It returns an error if the file exists but can not be decrypted or decoded, e.g. the key changed.
+func NewFileStore(path string, key []byte) (*FileStore, error) {
+	s := &FileStore{path: path, kv: make(map[string]fileEntry)}
+	if len(key) > 0 {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("otgo.NewFileStore: %s", err.Error())
+		}
+		if s.aead, err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("otgo.NewFileStore: %s", err.Error())
+		}
+	}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("otgo.NewFileStore: %s", err.Error())
+	}
+	return s, nil
+}
+
+// Get implements the CacheStore interface.
+func (s *FileStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.kv[key]
+	if !ok || clockNow().Unix() >= e.ExpiresAt {
+		return nil, nil
+	}
+	return e.Value, nil
+}
+
+// Set implements the CacheStore interface, the expired entries are removed and the file is rewritten.
+func (s *FileStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := clockNow()
+	for k, e := range s.kv {
+		if now.Unix() >= e.ExpiresAt {
+			delete(s.kv, k)
+		}
+	}
+	if ttl > 0 {
+		s.kv[key] = fileEntry{Value: value, ExpiresAt: now.Add(ttl).Unix()}
+	} else {
+		delete(s.kv, key)
+	}
+	return s.save()
+}
+
+func (s *FileStore) load() error {
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.aead != nil {
+		n := s.aead.NonceSize()
+		if len(b) < n {
+			return errors.New("invalid encrypted file")
+		}
+		if b, err = s.aead.Open(nil, b[:n], b[n:], nil); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(b, &s.kv)
+}
+
+func (s *FileStore) save() error {
+	b, err := json.Marshal(s.kv)
+	if err != nil {
+		return err
+	}
+	if s.aead != nil {
+		nonce := make([]byte, s.aead.NonceSize())
+		if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+			return err
+		}
+		b = s.aead.Seal(nonce, nonce, b, nil)
+	}
+	return writeFileAtomic(s.path, b)
+}
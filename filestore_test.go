@@ -0,0 +1,112 @@
+package otgo_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otgo")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	t.Run("NewFileStore func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		file := filepath.Join(dir, "plain.json")
+		s, err := otgo.NewFileStore(file, nil)
+		assert.Nil(err)
+		v, err := s.Get(ctx, "a")
+		assert.Nil(err)
+		assert.Nil(v)
+
+		assert.Nil(s.Set(ctx, "a", []byte("secret"), time.Hour))
+		assert.Nil(s.Set(ctx, "b", []byte("2"), -time.Second))
+		s, err = otgo.NewFileStore(file, nil)
+		assert.Nil(err)
+		v, err = s.Get(ctx, "a")
+		assert.Nil(err)
+		assert.Equal([]byte("secret"), v)
+		v, err = s.Get(ctx, "b")
+		assert.Nil(err)
+		assert.Nil(v)
+
+		_, err = otgo.NewFileStore(file, []byte("short"))
+		assert.NotNil(err)
+	})
+
+	t.Run("encrypted FileStore", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := bytes.Repeat([]byte{1}, 32)
+		file := filepath.Join(dir, "encrypted.bin")
+		s, err := otgo.NewFileStore(file, key)
+		assert.Nil(err)
+		assert.Nil(s.Set(ctx, "a", []byte("secret"), time.Hour))
+
+		b, err := ioutil.ReadFile(file)
+		assert.Nil(err)
+		assert.False(bytes.Contains(b, []byte("c2VjcmV0"))) // base64 of "secret"
+		fi, err := os.Stat(file)
+		assert.Nil(err)
+		assert.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+		s, err = otgo.NewFileStore(file, key)
+		assert.Nil(err)
+		v, err := s.Get(ctx, "a")
+		assert.Nil(err)
+		assert.Equal([]byte("secret"), v)
+
+		_, err = otgo.NewFileStore(file, bytes.Repeat([]byte{2}, 32))
+		assert.NotNil(err)
+		_, err = otgo.NewFileStore(file, nil)
+		assert.NotNil(err)
+	})
+
+	t.Run("OTClient.Store with FileStore", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		var signs int32
+		ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), &signs)
+		defer ts.Close()
+
+		file := filepath.Join(dir, "tokens.bin")
+		key := bytes.Repeat([]byte{3}, 16)
+		sub := td.NewOTID("app", "123")
+		aud := td.NewOTID("svc", "tester")
+		newClient := func() *otgo.OTClient {
+			cli := otgo.NewOTClient(ctx, sub)
+			cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+			cli.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+			store, err := otgo.NewFileStore(file, key)
+			if err != nil {
+				panic(err)
+			}
+			cli.Store = store
+			return cli
+		}
+
+		cfg1, err := newClient().Service(aud).Resolve(ctx)
+		assert.Nil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+
+		// a new process restores the OTVID from the file
+		cfg2, err := newClient().Service(aud).Resolve(ctx)
+		assert.Nil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+		assert.Equal(cfg1.OTVID.Token(), cfg2.OTVID.Token())
+	})
+}
@@ -0,0 +1,82 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Reasons of ForwardError, they can be tested with errors.Is.
+var (
+	ErrForwardedInvalid   = errors.New("invalid forwarded OTVID")
+	ErrForwardedNotSelf   = errors.New("forwarded OTVID is not self-signed by the subject")
+	ErrForwardedAudience  = errors.New("forwarded OTVID's audience is not the subject's trust domain")
+	ErrForwardedExpired   = errors.New("forwarded OTVID is expired")
+	ErrForwardedToProxy   = errors.New("forwarded OTVID's subject is the proxy itself")
+	ErrForwardedUntrusted = errors.New("forwarded OTVID's subject is not in a trusted domain")
+)
+
+// ForwardError is returned by OTClient.SignForSubject if the subject's self-signed OTVID is rejected locally.
+type ForwardError struct {
+	Subject OTID // the forwarded OTVID's subject, empty if the token is malformed
+	Err     error
+}
+
+func (e *ForwardError) Error() string {
+	if e.Subject.String() == "" {
+		return fmt.Sprintf("otgo.OTClient.SignForSubject: %s", e.Err.Error())
+	}
+	return fmt.Sprintf("otgo.OTClient.SignForSubject: %s: %s", e.Subject.String(), e.Err.Error())
+}
+
+// Unwrap ...
+func (e *ForwardError) Unwrap() error {
+	return e.Err
+}
+
+// SignForSubject requests a OTVID for the subject as a proxy (agent), the subject proves its consent with
+// subjectSelfToken, the OTVID it self-signed for its trust domain (see OTClient.SignSelf).
+// The forwarded token's signature is verified by the OT-Auth service, SignForSubject only checks its claims
+// locally and returns a *ForwardError without calling the service if they are not satisfied.
+func (oc *OTClient) SignForSubject(ctx context.Context, subjectSelfToken string, aud OTID, claims map[string]interface{}) (*SignOutput, error) {
+	vid, err := oc.parseInsecure(subjectSelfToken)
+	if err != nil {
+		return nil, &ForwardError{Err: fmt.Errorf("%w: %s", ErrForwardedInvalid, err.Error())}
+	}
+	ferr := &ForwardError{Subject: vid.ID}
+	switch {
+	case !vid.Issuer.Equal(vid.ID):
+		ferr.Err = ErrForwardedNotSelf
+	case !vid.Audience.Equal(vid.ID.TrustDomain().OTID()):
+		ferr.Err = ErrForwardedAudience
+	case vid.Expiry.Before(clockNow()):
+		ferr.Err = ErrForwardedExpired
+	case vid.ID.Equal(oc.sub):
+		ferr.Err = ErrForwardedToProxy
+	case !oc.trusts(vid.ID.TrustDomain()):
+		ferr.Err = ErrForwardedUntrusted
+	}
+	if ferr.Err != nil {
+		return nil, ferr
+	}
+	if err = aud.Validate(); err != nil {
+		return nil, fmt.Errorf("otgo.OTClient.SignForSubject: invalid audience: %s", err.Error())
+	}
+	return oc.Sign(ctx, SignInput{
+		Subject:        vid.ID,
+		Audience:       aud,
+		Claims:         claims,
+		ForwardedOTVID: subjectSelfToken,
+	})
+}
+
+// trusts returns true if the trust domain is the client's trust domain or a federated trust domain.
+func (oc *OTClient) trusts(td TrustDomain) bool {
+	if td == oc.td {
+		return true
+	}
+	oc.fedMu.RLock()
+	defer oc.fedMu.RUnlock()
+	_, ok := oc.federated[td]
+	return ok
+}
@@ -0,0 +1,86 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignForSubject(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	var signs int32
+	ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), &signs)
+	defer ts.Close()
+
+	proxy := otgo.NewOTClient(context.Background(), td.NewOTID("agent", "proxy"))
+	proxy.ConfigURLs = &otgo.ConfigURLs{}
+	proxy.ConfigURLs.Set(td, ts.URL+"/.well-known/open-trust-configuration")
+	proxy.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+	userKey := otgo.MustPrivateKey("ES256")
+	user := td.NewOTID("user", "abc")
+	aud := td.NewOTID("svc", "api")
+	selfToken := func(f func(vid *otgo.OTVID)) string {
+		vid := &otgo.OTVID{ID: user, Issuer: user, Audience: td.OTID()}
+		vid.Expiry = time.Now().Add(time.Minute)
+		if f != nil {
+			f(vid)
+		}
+		token, err := vid.Sign(userKey)
+		if err != nil {
+			panic(err)
+		}
+		return token
+	}
+
+	t.Run("OTClient.SignForSubject method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		output, err := proxy.SignForSubject(context.Background(), selfToken(nil), aud, nil)
+		assert.Nil(err)
+		vid, err := otgo.ParseOTVIDInsecure(output.OTVID)
+		assert.Nil(err)
+		assert.True(vid.ID.Equal(user))
+		assert.True(vid.Audience.Equal(aud))
+		assert.Equal(int32(1), signs)
+	})
+
+	t.Run("ForwardError", func(t *testing.T) {
+		assert := assert.New(t)
+
+		cases := []struct {
+			token  string
+			reason error
+		}{
+			{"invalid", otgo.ErrForwardedInvalid},
+			{selfToken(func(vid *otgo.OTVID) { vid.Issuer = td.OTID() }), otgo.ErrForwardedNotSelf},
+			{selfToken(func(vid *otgo.OTVID) { vid.Audience = aud }), otgo.ErrForwardedAudience},
+			{selfToken(func(vid *otgo.OTVID) { vid.Expiry = time.Now().Add(-time.Minute) }), otgo.ErrForwardedExpired},
+			{selfToken(func(vid *otgo.OTVID) {
+				vid.ID = td.NewOTID("agent", "proxy")
+				vid.Issuer = vid.ID
+			}), otgo.ErrForwardedToProxy},
+			{selfToken(func(vid *otgo.OTVID) {
+				vid.ID = otgo.TrustDomain("allied.com").NewOTID("user", "abc")
+				vid.Issuer = vid.ID
+				vid.Audience = vid.ID.TrustDomain().OTID()
+			}), otgo.ErrForwardedUntrusted},
+		}
+		for _, c := range cases {
+			_, err := proxy.SignForSubject(context.Background(), c.token, aud, nil)
+			var fe *otgo.ForwardError
+			assert.True(errors.As(err, &fe))
+			assert.True(errors.Is(err, c.reason), err.Error())
+		}
+		assert.Equal(int32(1), signs)
+
+		assert.Nil(proxy.AddFederatedDomain(otgo.TrustDomain("allied.com")))
+		_, err := proxy.SignForSubject(context.Background(), selfToken(nil), otgo.OTID{}, nil)
+		assert.NotNil(err)
+		assert.False(errors.As(err, new(*otgo.ForwardError)))
+	})
+}
@@ -1,9 +1,24 @@
 module github.com/open-trust/ot-go-lib
 
-go 1.15
+go 1.17
 
 require (
+	github.com/fxamacker/cbor/v2 v2.5.0
 	github.com/google/subcommands v1.2.0
 	github.com/lestrrat-go/jwx v1.0.5
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/crypto v0.14.0
+	golang.org/x/net v0.17.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
+	github.com/lestrrat-go/iter v0.0.0-20200422075355-fc1769541911 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/text v0.13.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 )
@@ -11,6 +11,15 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// mustSignOTVID signs the OTVID with the key for the tests, it panics on error.
+func mustSignOTVID(vid *otgo.OTVID, key otgo.Key) string {
+	token, err := vid.Sign(key)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
 func TestHelper(t *testing.T) {
 	t.Run("ExtractTokenFromHeader & AddTokenToHeader func", func(t *testing.T) {
 		assert := assert.New(t)
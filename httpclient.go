@@ -6,22 +6,38 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 type ctxKey int
 
 const (
-	// CtxHeaderKey ...
-	CtxHeaderKey ctxKey = 0
+	// CtxHeaderKey is the context key of the http.Header added to Client's requests, see WithHeader.
+	CtxHeaderKey  ctxKey = 0
+	ctxTimeoutKey ctxKey = 1
 )
 
+// DefaultMaxResponseBytes is the default Client.MaxResponseBytes.
+const DefaultMaxResponseBytes = 1 << 20
+
+// ErrResponseTooLarge is returned by Client.Do if the response body exceeds Client.MaxResponseBytes.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// WithRequestTimeout returns a copy of ctx that overrides the http.Client's Timeout for Client.Do,
+// e.g. a longer timeout for a slow endpoint or a shorter one for a health check.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, ctxTimeoutKey, timeout)
+}
+
 var tr = &http.Transport{
 	TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
 	DialContext: (&net.Dialer{
@@ -41,7 +57,60 @@ var tr = &http.Transport{
 type Client struct {
 	*http.Client
 	Header             http.Header
-	ConstraintEndpoint string // set it for testing purposes only
+	ConstraintEndpoint string       // set it for testing purposes only
+	Retry              *RetryPolicy // retry is disabled if nil
+	Instrumenter       Instrumenter // optional, receives a OpHTTP span for every request
+	// Logger logs every request and response at debug level, optional. The Authorization and Cookie headers,
+	// the OTVIDs and the private members of JWKs are redacted, the response body is truncated to 4096 bytes.
+	Logger Logger
+	// MaxResponseBytes limits the size of the (decompressed) response body,
+	// DefaultMaxResponseBytes is used if 0, unlimited if negative.
+	MaxResponseBytes int64
+	// GzipRequestBytes compresses the request bodies of at least GzipRequestBytes bytes with gzip,
+	// disabled if 0. The servers that respond 415 Unsupported Media Type are sent plain bodies then.
+	GzipRequestBytes int
+	middlewares      []Middleware
+	noGzip           sync.Map // the hosts that do not accept gzip request bodies
+}
+
+// clone returns a copy of the client that shares the http.Client, the header and the middlewares are copied.
+func (c *Client) clone() *Client {
+	h := c.Header.Clone()
+	if h == nil {
+		h = http.Header{}
+	}
+	return &Client{
+		Client:             c.Client,
+		Header:             h,
+		ConstraintEndpoint: c.ConstraintEndpoint,
+		Retry:              c.Retry,
+		Instrumenter:       c.Instrumenter,
+		Logger:             c.Logger,
+		MaxResponseBytes:   c.MaxResponseBytes,
+		GzipRequestBytes:   c.GzipRequestBytes,
+		middlewares:        append([]Middleware(nil), c.middlewares...),
+	}
+}
+
+// RoundTripFunc sends a HTTP request and returns its response.
+type RoundTripFunc func(*http.Request) (*http.Response, error)
+
+// Middleware wraps a RoundTripFunc, it can inspect or modify the request and the response.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// Use appends middlewares to the client, the first one is the outermost.
+// It is not safe to call Use concurrently with Do.
+func (c *Client) Use(mws ...Middleware) *Client {
+	c.middlewares = append(c.middlewares, mws...)
+	return c
+}
+
+func (c *Client) roundTrip(hc *http.Client) RoundTripFunc {
+	rt := RoundTripFunc(hc.Do)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		rt = c.middlewares[i](rt)
+	}
+	return rt
 }
 
 // HTTPClient ...
@@ -60,9 +129,79 @@ func NewClient(client *http.Client) *Client {
 	return &Client{Client: client, Header: http.Header{}}
 }
 
-// Do ...
-func (c *Client) Do(ctx context.Context, method, api string, h http.Header, input, output interface{}) error {
-	err := ctx.Err()
+// NewClientWithTLS creates a Client with the TLS config, e.g. to present client certificates
+// and pin OT-Auth CA roots for mTLS.
+func NewClientWithTLS(cfg *tls.Config) *Client {
+	c := NewClient(nil)
+	c.SetTLSConfig(cfg) // never fails with the default transport
+	return c
+}
+
+// SetTLSConfig sets the TLS config of the client's transport, the transport must be a *http.Transport.
+// The transport is cloned so that the shared transport is not modified.
+func (c *Client) SetTLSConfig(cfg *tls.Config) error {
+	return c.updateTransport("SetTLSConfig", func(t *http.Transport) {
+		t.TLSClientConfig = cfg
+	})
+}
+
+// SetProxy sets the proxy URL of the client's transport, e.g. http://proxy.example.com:3128.
+// The HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are honored if proxyURL is empty.
+func (c *Client) SetProxy(proxyURL string) error {
+	proxy := http.ProxyFromEnvironment
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("otgo.Client.SetProxy: %s", err.Error())
+		}
+		proxy = http.ProxyURL(u)
+	}
+	return c.updateTransport("SetProxy", func(t *http.Transport) {
+		t.Proxy = proxy
+	})
+}
+
+// SetDialContext sets the dial function of the client's transport.
+func (c *Client) SetDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) error {
+	return c.updateTransport("SetDialContext", func(t *http.Transport) {
+		t.DialContext = dial
+	})
+}
+
+// SetResolver sets the DNS resolver of the client's transport, it replaces the dial function.
+func (c *Client) SetResolver(r *net.Resolver) error {
+	return c.SetDialContext((&net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 25 * time.Second,
+		Resolver:  r,
+	}).DialContext)
+}
+
+// updateTransport updates a clone of the client's transport, so that the shared transport is not modified.
+func (c *Client) updateTransport(method string, fn func(*http.Transport)) error {
+	var t *http.Transport
+	switch rt := c.Client.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = rt.Clone()
+	default:
+		return fmt.Errorf("otgo.Client.%s: unsupported transport %T", method, rt)
+	}
+	fn(t)
+	cli := *c.Client
+	cli.Transport = t
+	c.Client = &cli
+	return nil
+}
+
+// Do sends the JSON request and decodes the JSON response into output. The api is a http://, https://,
+// unix:// or h2c:// URL (see SchemeUnix and SchemeH2C), the latter two ignore the client's transport.
+func (c *Client) Do(ctx context.Context, method, api string, h http.Header, input, output interface{}) (err error) {
+	ctx, end := instrumenterOf(c.Instrumenter).Start(ctx, OpHTTP)
+	defer func() { end(err) }()
+
+	err = ctx.Err()
 	if err != nil {
 		return fmt.Errorf("context.Context error: %v", err)
 	}
@@ -86,53 +225,230 @@ func (c *Client) Do(ctx context.Context, method, api string, h http.Header, inpu
 		}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, api, &b)
-	if err != nil {
-		return fmt.Errorf("create http request error: %v", err)
+	header := make(http.Header)
+	copyHeader(header, c.Header)
+	copyHeader(header, HeaderFromContext(ctx))
+	if h != nil {
+		copyHeader(header, h)
 	}
 
-	copyHeader(req.Header, c.Header)
-	if val := ctx.Value(CtxHeaderKey); val != nil {
-		copyHeader(req.Header, val.(http.Header))
+	header.Set("Accept", "application/json")
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("Accept-Encoding", "gzip")
+
+	raw := b.Bytes()
+	body := raw
+	if c.shouldGzip(api, len(raw)) {
+		if body, err = gzipBytes(raw); err != nil {
+			return fmt.Errorf("gzip input data error: %v", err)
+		}
+		header.Set("Content-Encoding", "gzip")
 	}
-	if h != nil {
-		copyHeader(req.Header, h)
+	do := func() (*attemptResult, error) {
+		start := time.Now()
+		res, err := c.do(ctx, method, api, header, body, output)
+		if c.Logger != nil {
+			c.logExchange(method, api, header, raw, res, err, time.Since(start))
+		}
+		return res, err
+	}
+	send := func() (*attemptResult, error) {
+		res, err := do()
+		if err != nil && header.Get("Content-Encoding") == "gzip" && res.rejectsGzip() {
+			// the server does not accept gzip request bodies, resend the plain body
+			c.noGzip.Store(requestHost(api), true)
+			header.Del("Content-Encoding")
+			body = raw
+			res, err = do()
+		}
+		return res, err
+	}
+
+	rp := c.Retry
+	attempts := 1
+	if rp != nil && rp.canRetry(method, header) {
+		attempts = rp.maxAttempts()
+	}
+	for attempt := 1; ; attempt++ {
+		var res *attemptResult
+		res, err = send()
+		if err == nil || attempt >= attempts || !rp.shouldRetry(res) {
+			return err
+		}
+
+		timer := time.NewTimer(rp.backoff(attempt, res.retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err // the last attempt's error
+		case <-timer.C:
+		}
 	}
+}
+
+type attemptResult struct {
+	statusCode     int           // 0 if the request did not get a response
+	retryAfter     time.Duration // parsed from the Retry-After response header
+	acceptEncoding string        // the Accept-Encoding response header
+	head           []byte        // the head of the response body, see responseHeadSize
+}
+
+// rejectsGzip returns true if the server rejected the gzip request body with 415 Unsupported Media Type,
+// and did not advertise gzip in the Accept-Encoding response header (RFC 7694).
+func (r *attemptResult) rejectsGzip() bool {
+	return r.statusCode == http.StatusUnsupportedMediaType && !strings.Contains(r.acceptEncoding, "gzip")
+}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Content-Type", "application/json; charset=utf-8")
-	req.Header.Set("Accept-Encoding", "gzip")
+func (c *Client) do(ctx context.Context, method, api string, h http.Header, body []byte, output interface{}) (*attemptResult, error) {
+	res := &attemptResult{}
+	api, rt, err := endpointTransport(api)
+	if err != nil {
+		return res, fmt.Errorf("create http request error: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, api, bytes.NewReader(body))
+	if err != nil {
+		return res, fmt.Errorf("create http request error: %v", err)
+	}
+	copyHeader(req.Header, h)
 
-	resp, err := c.Client.Do(req)
+	hc := c.Client
+	if timeout, ok := ctx.Value(ctxTimeoutKey).(time.Duration); ok {
+		cli := *hc
+		cli.Timeout = timeout
+		hc = &cli
+	}
+	if rt != nil {
+		cli := *hc
+		cli.Transport = rt
+		hc = &cli
+	}
+	resp, err := c.roundTrip(hc)(req)
 	if err != nil {
-		return fmt.Errorf("do http request error: %v", err)
+		return res, fmt.Errorf("do http request error: %v", err)
 	}
 
 	defer resp.Body.Close()
-	body := resp.Body
+	res.statusCode = resp.StatusCode
+	res.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	res.acceptEncoding = resp.Header.Get("Accept-Encoding")
+	rb := resp.Body
 	if resp.Header.Get("Content-Encoding") == "gzip" {
-		body, err = gzip.NewReader(body)
+		rb, err = gzip.NewReader(rb)
 		if err != nil {
-			return fmt.Errorf("gzip reader error: %v", err)
+			return res, fmt.Errorf("gzip reader error: %v", err)
 		}
-		defer body.Close()
+		defer rb.Close()
 	}
-	data, err := ioutil.ReadAll(body)
-	if err != nil {
-		return fmt.Errorf("read response error: %s, status code: %v", err.Error(), resp.StatusCode)
-	}
-
-	if output != nil {
-		if err := json.Unmarshal(data, output); err != nil {
-			return fmt.Errorf("decoding json error: %s, status code: %v, response: %s", err.Error(), resp.StatusCode, string(data))
+	rbody := &responseBody{r: rb, n: c.maxResponseBytes()}
+	defer func() { res.head = rbody.head }()
+	// the body of a rejected gzip request body is kept in the error, the request is resent
+	gzipRejected := h.Get("Content-Encoding") == "gzip" && res.rejectsGzip()
+	if output != nil && !isRateLimitStatus(resp.StatusCode) && !gzipRejected {
+		// decode the body while reading it, only the head of it is kept for error messages
+		if err = json.NewDecoder(rbody).Decode(output); err != nil {
+			if errors.Is(err, ErrResponseTooLarge) {
+				return res, fmt.Errorf("read response error: %w, status code: %v", err, resp.StatusCode)
+			}
+			if resp.StatusCode >= 300 {
+				// not a JSON error response, e.g. the 404 page of a proxy
+				io.Copy(ioutil.Discard, rbody)
+				return res, &HTTPError{StatusCode: resp.StatusCode, Response: string(rbody.head)}
+			}
+			return res, fmt.Errorf("decoding json error: %s, status code: %v, response: %s", err.Error(), resp.StatusCode, rbody.head)
 		}
 	}
+	// read the rest for the error message and connection reuse
+	if _, err = io.Copy(ioutil.Discard, rbody); err != nil {
+		return res, fmt.Errorf("read response error: %w, status code: %v", err, resp.StatusCode)
+	}
 
+	if isRateLimitStatus(resp.StatusCode) {
+		return res, &RateLimitError{StatusCode: resp.StatusCode, RetryAfter: res.retryAfter, Response: string(rbody.head)}
+	}
 	if resp.StatusCode >= 300 {
-		return fmt.Errorf("non-success response, status code: %v, response: %s",
-			resp.StatusCode, string(data))
+		return res, &HTTPError{StatusCode: resp.StatusCode, Response: string(rbody.head)}
 	}
-	return nil
+	return res, nil
+}
+
+// HTTPError is returned by Client.Do if the server responds with a non-success status code,
+// except the rate limited responses, see RateLimitError.
+type HTTPError struct {
+	StatusCode int
+	Response   string // the head of the response body
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("non-success response, status code: %v, response: %s", e.StatusCode, e.Response)
+}
+
+func (c *Client) shouldGzip(api string, size int) bool {
+	if c.GzipRequestBytes <= 0 || size < c.GzipRequestBytes {
+		return false
+	}
+	_, rejected := c.noGzip.Load(requestHost(api))
+	return !rejected
+}
+
+func requestHost(api string) string {
+	if u, err := url.Parse(api); err == nil {
+		return u.Host
+	}
+	return api
+}
+
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *Client) maxResponseBytes() int64 {
+	if c.MaxResponseBytes == 0 {
+		return DefaultMaxResponseBytes
+	}
+	return c.MaxResponseBytes
+}
+
+// responseHeadSize is the max size of the response head kept for error messages.
+const responseHeadSize = 4096
+
+// responseBody reads at most n bytes (unlimited if n < 0) from r, and keeps the head of them.
+type responseBody struct {
+	r    io.Reader
+	n    int64
+	head []byte
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	if b.n == 0 {
+		// probe one byte to tell EOF from exceeding the limit
+		var one [1]byte
+		if n, _ := b.r.Read(one[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, io.EOF
+	}
+	if b.n > 0 && int64(len(p)) > b.n {
+		p = p[:b.n]
+	}
+	n, err := b.r.Read(p)
+	if b.n > 0 {
+		b.n -= int64(n)
+	}
+	if rest := responseHeadSize - len(b.head); rest > 0 {
+		if rest > n {
+			rest = n
+		}
+		b.head = append(b.head, p[:rest]...)
+	}
+	return n, err
 }
 
 func copyHeader(dst http.Header, src http.Header) {
@@ -1,12 +1,20 @@
 package otgo_test
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	otgo "github.com/open-trust/ot-go-lib"
 	"github.com/stretchr/testify/assert"
@@ -73,4 +81,216 @@ func TestHTTPClient(t *testing.T) {
 		assert.Equal("UA123", res["User-Agent"])
 		assert.Equal("Bearer token456", res["Authorization"])
 	})
+
+	t.Run("Client.Use method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(200)
+			w.Write([]byte(`{"result": "` + r.Header.Get("X-Test") + `"}`))
+		}))
+		defer ts.Close()
+
+		calls := []string{}
+		mw := func(name string) otgo.Middleware {
+			return func(next otgo.RoundTripFunc) otgo.RoundTripFunc {
+				return func(req *http.Request) (*http.Response, error) {
+					calls = append(calls, name)
+					req.Header.Set("X-Test", req.Header.Get("X-Test")+name)
+					return next(req)
+				}
+			}
+		}
+
+		cli := otgo.NewClient(nil).Use(mw("a"), mw("b"))
+		res := map[string]string{}
+		err := cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res)
+		assert.Nil(err)
+		assert.Equal("ab", res["result"])
+		assert.Equal([]string{"a", "b"}, calls)
+
+		cli.Use(func(next otgo.RoundTripFunc) otgo.RoundTripFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				return nil, errors.New("chaos")
+			}
+		})
+		err = cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "chaos")
+	})
+
+	t.Run("NewClientWithTLS & Client.SetTLSConfig", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(200)
+			w.Write([]byte(`{"result": "` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}`))
+		}))
+		ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		ts.StartTLS()
+		defer ts.Close()
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ts.Certificate())
+
+		res := map[string]string{}
+		err := otgo.NewClientWithTLS(&tls.Config{RootCAs: roots}).Do(context.Background(), "GET", ts.URL, nil, nil, &res)
+		assert.NotNil(err)
+
+		cert := ts.TLS.Certificates[0]
+		cli := otgo.NewClientWithTLS(&tls.Config{RootCAs: roots, Certificates: []tls.Certificate{cert}})
+		err = cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res)
+		assert.Nil(err)
+		leaf, _ := x509.ParseCertificate(cert.Certificate[0])
+		assert.Equal(leaf.Subject.CommonName, res["result"])
+
+		// the default client is not affected
+		err = otgo.DefaultHTTPClient.Do(context.Background(), "GET", ts.URL, nil, nil, &res)
+		assert.NotNil(err)
+
+		cli = otgo.NewClient(&http.Client{})
+		assert.Nil(cli.SetTLSConfig(&tls.Config{RootCAs: roots, Certificates: []tls.Certificate{cert}}))
+		err = cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res)
+		assert.Nil(err)
+
+		cli = otgo.NewClient(&http.Client{Transport: roundTripper{}})
+		assert.NotNil(cli.SetTLSConfig(&tls.Config{}))
+	})
+
+	t.Run("Client.SetProxy & Client.SetDialContext & Client.SetResolver", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(200)
+			w.Write([]byte(`{"result": "` + r.URL.String() + `"}`))
+		}))
+		defer ts.Close()
+
+		// the test server acts as a proxy, the request URL is absolute
+		cli := otgo.NewClient(nil)
+		assert.NotNil(cli.SetProxy("://"))
+		assert.Nil(cli.SetProxy(ts.URL))
+		res := map[string]string{}
+		err := cli.Do(context.Background(), "GET", "http://ot.example.com/abc", nil, nil, &res)
+		assert.Nil(err)
+		assert.Equal("http://ot.example.com/abc", res["result"])
+
+		cli = otgo.NewClient(nil)
+		dials := 0
+		assert.Nil(cli.SetDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials++
+			return (&net.Dialer{}).DialContext(ctx, network, ts.Listener.Addr().String())
+		}))
+		err = cli.Do(context.Background(), "GET", "http://ot.example.com/abc", nil, nil, &res)
+		assert.Nil(err)
+		assert.Equal("/abc", res["result"])
+		assert.Equal(1, dials)
+
+		cli = otgo.NewClient(nil)
+		assert.Nil(cli.SetResolver(&net.Resolver{PreferGo: true}))
+		err = cli.Do(context.Background(), "GET", ts.URL+"/xyz", nil, nil, &res)
+		assert.Nil(err)
+		assert.Equal("/xyz", res["result"])
+
+		cli = otgo.NewClient(&http.Client{Transport: roundTripper{}})
+		assert.NotNil(cli.SetProxy(""))
+	})
+
+	t.Run("Client.MaxResponseBytes & WithRequestTimeout", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if d, err := time.ParseDuration(r.URL.Query().Get("sleep")); err == nil {
+				time.Sleep(d)
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if r.URL.Path == "/error" {
+				w.WriteHeader(500)
+			}
+			w.Write([]byte(`{"result": "` + strings.Repeat("a", 2000) + `"}`))
+		}))
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		res := map[string]string{}
+		assert.Nil(cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res))
+		assert.Equal(2000, len(res["result"]))
+
+		cli.MaxResponseBytes = 1000
+		err := cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res)
+		assert.True(errors.Is(err, otgo.ErrResponseTooLarge))
+		err = cli.Do(context.Background(), "GET", ts.URL, nil, nil, nil)
+		assert.True(errors.Is(err, otgo.ErrResponseTooLarge))
+		cli.MaxResponseBytes = 2014
+		assert.Nil(cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res))
+		cli.MaxResponseBytes = -1
+		assert.Nil(cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res))
+		err = cli.Do(context.Background(), "GET", ts.URL+"/error", nil, nil, nil)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "status code: 500")
+
+		cli = otgo.NewClient(&http.Client{Timeout: 50 * time.Millisecond})
+		err = cli.Do(context.Background(), "GET", ts.URL+"?sleep=200ms", nil, nil, &res)
+		assert.NotNil(err)
+		ctx := otgo.WithRequestTimeout(context.Background(), time.Second)
+		assert.Nil(cli.Do(ctx, "GET", ts.URL+"?sleep=200ms", nil, nil, &res))
+		ctx = otgo.WithRequestTimeout(context.Background(), 10*time.Millisecond)
+		assert.NotNil(cli.Do(ctx, "GET", ts.URL+"?sleep=30ms", nil, nil, &res))
+	})
+
+	t.Run("Client.GzipRequestBytes", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var encodings []string
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			enc := r.Header.Get("Content-Encoding")
+			encodings = append(encodings, enc)
+			var body io.Reader = r.Body
+			if enc == "gzip" {
+				if r.URL.Path == "/plain" {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					w.Write([]byte(`{"error":"gzip not supported"}`))
+					return
+				}
+				gr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					w.WriteHeader(400)
+					return
+				}
+				body = gr
+			}
+			b, _ := ioutil.ReadAll(body)
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		input := map[string]string{"claims": strings.Repeat("a", 1000)}
+		cli := otgo.NewClient(nil)
+		cli.GzipRequestBytes = 512
+		res := map[string]string{}
+		assert.Nil(cli.Do(context.Background(), "POST", ts.URL, nil, input, &res))
+		assert.Equal(input, res)
+		assert.Nil(cli.Do(context.Background(), "POST", ts.URL, nil, map[string]string{"a": "b"}, nil))
+		assert.Equal([]string{"gzip", ""}, encodings)
+
+		encodings = nil
+		res = map[string]string{}
+		assert.Nil(cli.Do(context.Background(), "POST", ts.URL+"/plain", nil, input, &res))
+		assert.Equal(input, res) // without the 415 response's error
+		assert.Equal([]string{"gzip", ""}, encodings)
+		// the host is remembered
+		encodings = nil
+		assert.Nil(cli.Do(context.Background(), "POST", ts.URL+"/plain", nil, input, &res))
+		assert.Equal([]string{""}, encodings)
+	})
+}
+
+type roundTripper struct{}
+
+func (roundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
 }
@@ -0,0 +1,100 @@
+package otgo
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ParseOption ...
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	idn           bool
+	thumbprintKID bool
+	encodedID     bool
+	limits        *Limits
+}
+
+// WithIDN converts the Unicode labels of trust domain to their A-label (punycode) form
+// instead of rejecting them, e.g. "例子.测试" to "xn--fsqu00a.xn--0zwm56d".
+func WithIDN() ParseOption {
+	return func(o *parseOptions) {
+		o.idn = true
+	}
+}
+
+// WithThumbprintKeyID assigns the RFC 7638 thumbprint as kid to the keys without kid
+// instead of rejecting them, many external IdPs publish JWKs without kid.
+func WithThumbprintKeyID() ParseOption {
+	return func(o *parseOptions) {
+		o.thumbprintKID = true
+	}
+}
+
+// WithEncodedSubjectID accepts the subject IDs percent-encoded by EncodeSubjectID,
+// e.g. "otid:localhost:user:%41lice%40example.com". They are rejected by default.
+func WithEncodedSubjectID() ParseOption {
+	return func(o *parseOptions) {
+		o.encodedID = true
+	}
+}
+
+func newParseOptions(opts []ParseOption) *parseOptions {
+	o := &parseOptions{}
+	for _, fn := range opts {
+		fn(o)
+	}
+	return o
+}
+
+// ParseTrustDomain parses and validates a trust domain from a string.
+func ParseTrustDomain(s string, opts ...ParseOption) (TrustDomain, error) {
+	td, err := newParseOptions(opts).trustDomain(s)
+	if err != nil {
+		return "", err
+	}
+	if err = td.Validate(); err != nil {
+		return "", err
+	}
+	return td, nil
+}
+
+func (o *parseOptions) trustDomain(s string) (TrustDomain, error) {
+	if !o.idn || isASCII(s) {
+		return TrustDomain(s), nil
+	}
+	a, err := idna.Lookup.ToASCII(s)
+	if err != nil {
+		return "", fmt.Errorf("otgo.ParseTrustDomain: invalid IDN trust domain '%s': %s", s, err.Error())
+	}
+	return TrustDomain(strings.ToLower(a)), nil
+}
+
+func (o *parseOptions) assignKeyIDs(keys ...Key) error {
+	if !o.thumbprintKID {
+		return nil
+	}
+	for _, k := range keys {
+		if k.KeyID() == "" {
+			kid, err := Thumbprint(k)
+			if err == nil {
+				err = k.Set("kid", kid)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
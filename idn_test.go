@@ -0,0 +1,48 @@
+package otgo_test
+
+import (
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIDN(t *testing.T) {
+	t.Run("ParseTrustDomain func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td, err := otgo.ParseTrustDomain("ot.example.com")
+		assert.Nil(err)
+		assert.Equal(otgo.TrustDomain("ot.example.com"), td)
+
+		_, err = otgo.ParseTrustDomain("例子.测试")
+		assert.NotNil(err)
+
+		td, err = otgo.ParseTrustDomain("例子.测试", otgo.WithIDN())
+		assert.Nil(err)
+		assert.Equal(otgo.TrustDomain("xn--fsqu00a.xn--0zwm56d"), td)
+
+		td, err = otgo.ParseTrustDomain("Bücher.example", otgo.WithIDN())
+		assert.Nil(err)
+		assert.Equal(otgo.TrustDomain("xn--bcher-kva.example"), td)
+
+		_, err = otgo.ParseTrustDomain("例子!.测试", otgo.WithIDN())
+		assert.NotNil(err)
+	})
+
+	t.Run("ParseOTID func with IDN", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := otgo.ParseOTID("otid:例子.测试:user:abc")
+		assert.NotNil(err)
+
+		id, err := otgo.ParseOTID("otid:例子.测试:user:abc", otgo.WithIDN())
+		assert.Nil(err)
+		assert.Equal("otid:xn--fsqu00a.xn--0zwm56d:user:abc", id.String())
+		assert.Equal(otgo.TrustDomain("xn--fsqu00a.xn--0zwm56d"), id.TrustDomain())
+
+		id, err = otgo.ParseOTID("otid:ot.example.com", otgo.WithIDN())
+		assert.Nil(err)
+		assert.Equal("otid:ot.example.com", id.String())
+	})
+}
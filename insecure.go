@@ -0,0 +1,49 @@
+package otgo
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+// InsecureAlgorithms reports whether the symmetric HMAC algorithms (HS256, HS384 and HS512) are allowed.
+// They are only allowed if the package is built with the "otgo_insecure" build tag, e.g.
+//
+//	go test -tags otgo_insecure ./...
+//
+// so that hermetic unit tests of downstream services can sign and verify OTVIDs with a shared secret,
+// and the insecure algorithms can't leak to production builds.
+func InsecureAlgorithms() bool {
+	return insecureAlgorithms
+}
+
+// hmacSize returns the hash size of the HMAC algorithm, or 0 if it is not a HMAC algorithm.
+func hmacSize(alg string) int {
+	switch jwa.SignatureAlgorithm(alg) {
+	case jwa.HS256:
+		return 32
+	case jwa.HS384:
+		return 48
+	case jwa.HS512:
+		return 64
+	}
+	return 0
+}
+
+// newSymmetricKey generates a secret with the algorithm's hash size.
+func newSymmetricKey(alg string) (Key, error) {
+	if !insecureAlgorithms {
+		return nil, fmt.Errorf("otgo.NewPrivateKey: insecure algorithm '%s' requires the otgo_insecure build tag", alg)
+	}
+	secret := make([]byte, hmacSize(alg))
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := jwk.NewSymmetricKey()
+	if err := key.FromRaw(secret); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,6 @@
+//go:build !otgo_insecure
+// +build !otgo_insecure
+
+package otgo
+
+const insecureAlgorithms = false
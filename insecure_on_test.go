@@ -0,0 +1,50 @@
+//go:build otgo_insecure
+// +build otgo_insecure
+
+package otgo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsecureAlgorithmsEnabled(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(otgo.InsecureAlgorithms())
+
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "api")
+	for _, alg := range []string{"HS256", "HS384", "HS512"} {
+		assert.True(otgo.ValidateAlgorithm(alg))
+		key, err := otgo.NewPrivateKey(alg)
+		assert.Nil(err)
+		sk, err := otgo.LookupSigningKey(otgo.MustKeys(key))
+		assert.Nil(err)
+
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud}
+		vid.Expiry = time.Now().Add(time.Hour)
+		size, err := vid.EstimateSize(sk, false)
+		assert.Nil(err)
+		token, err := vid.Sign(sk)
+		assert.Nil(err)
+		assert.Equal(len(token), size)
+
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, key)
+		assert.Nil(err)
+		vid2, err := v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.True(vid2.ID.Equal(vid.ID))
+
+		// signed with another secret
+		other := otgo.MustPrivateKey(alg)
+		assert.Nil(other.Set("kid", key.KeyID()))
+		token, err = vid.Sign(other)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+	}
+}
@@ -0,0 +1,24 @@
+package otgo_test
+
+import (
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsecureAlgorithms(t *testing.T) {
+	if otgo.InsecureAlgorithms() {
+		t.Skip("built with the otgo_insecure build tag")
+	}
+	assert := assert.New(t)
+
+	for _, alg := range []string{"HS256", "HS384", "HS512"} {
+		assert.False(otgo.ValidateAlgorithm(alg))
+		_, err := otgo.NewPrivateKey(alg)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "otgo_insecure")
+	}
+	_, err := otgo.ParseKey(`{"kty":"oct","alg":"HS256","kid":"k1","k":"c2VjcmV0"}`)
+	assert.NotNil(err)
+}
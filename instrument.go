@@ -0,0 +1,54 @@
+package otgo
+
+import (
+	"context"
+)
+
+// Op is the name of an instrumented operation.
+type Op string
+
+// Instrumented operations.
+const (
+	OpSign        Op = "sign"         // OTClient.Sign call to OT-Auth
+	OpVerify      Op = "verify"       // OTClient.Verify call to OT-Auth
+	OpConfigFetch Op = "config_fetch" // trust domain's configuration fetch
+	OpKeyFetch    Op = "key_fetch"    // FetchKeys call
+	OpHTTP        Op = "http"         // every otgo.Client request
+	OpDomainCache Op = "domain_cache" // DomainResolver cache lookup
+	OpTokenCache  Op = "token_cache"  // ServiceClient OTVID cache lookup
+	OpParse       Op = "parse"        // local OTVID verification of OTClient.ParseOTVID and Verifier
+	OpKeyRefresh  Op = "key_refresh"  // Verifier keys refresh
+)
+
+// Instrumenter receives spans and cache events of OT-Auth calls.
+// It can be used to wire OpenTelemetry, Prometheus (see the metrics subpackage) or other custom sinks.
+type Instrumenter interface {
+	// Start is called when an operation starts. The returned context is used for the operation,
+	// the returned function is called with the operation's result error when it ends.
+	Start(ctx context.Context, op Op) (context.Context, func(error))
+	// CacheLookup is called on every cache lookup.
+	CacheLookup(op Op, hit bool)
+}
+
+type noopInstrumenter struct{}
+
+func (noopInstrumenter) Start(ctx context.Context, _ Op) (context.Context, func(error)) {
+	return ctx, func(error) {}
+}
+
+func (noopInstrumenter) CacheLookup(Op, bool) {}
+
+func instrumenterOf(in Instrumenter) Instrumenter {
+	if in == nil {
+		return noopInstrumenter{}
+	}
+	return in
+}
+
+// httpInstrumenter returns the Instrumenter of the HTTPClient if it is an otgo.Client.
+func httpInstrumenter(cli HTTPClient) Instrumenter {
+	if c, ok := cli.(*Client); ok {
+		return instrumenterOf(c.Instrumenter)
+	}
+	return noopInstrumenter{}
+}
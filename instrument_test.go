@@ -0,0 +1,133 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type testInstrumenter struct {
+	mu     sync.Mutex
+	ops    []otgo.Op
+	errs   []error
+	hits   int
+	misses int
+}
+
+func (ti *testInstrumenter) Start(ctx context.Context, op otgo.Op) (context.Context, func(error)) {
+	return ctx, func(err error) {
+		ti.mu.Lock()
+		defer ti.mu.Unlock()
+		ti.ops = append(ti.ops, op)
+		ti.errs = append(ti.errs, err)
+	}
+}
+
+func (ti *testInstrumenter) CacheLookup(op otgo.Op, hit bool) {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	if hit {
+		ti.hits++
+	} else {
+		ti.misses++
+	}
+}
+
+func TestInstrumenter(t *testing.T) {
+	t.Run("Client.Do", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(500)
+			w.Write([]byte(`{"error": "error"}`))
+		}))
+		defer ts.Close()
+
+		ti := &testInstrumenter{}
+		cli := otgo.NewClient(nil)
+		cli.Instrumenter = ti
+		_, err := otgo.FetchKeys(context.Background(), ts.URL, cli)
+		assert.NotNil(err)
+		assert.Equal([]otgo.Op{otgo.OpHTTP, otgo.OpKeyFetch}, ti.ops)
+		assert.NotNil(ti.errs[0])
+		assert.NotNil(ti.errs[1])
+	})
+
+	t.Run("FetchKeys with invalid keys", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pub, err := otgo.ToPublicKey(otgo.MustPrivateKey("ES256"))
+		assert.Nil(err)
+		assert.Nil(pub.Set("kid", ""))
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			json.NewEncoder(w).Encode(map[string]interface{}{"keys": []otgo.Key{pub}})
+		}))
+		defer ts.Close()
+
+		ti := &testInstrumenter{}
+		cli := otgo.NewClient(nil)
+		cli.Instrumenter = ti
+		_, err = otgo.FetchKeys(context.Background(), ts.URL, cli)
+		assert.NotNil(err)
+		assert.Equal([]otgo.Op{otgo.OpHTTP, otgo.OpKeyFetch}, ti.ops)
+		assert.Nil(ti.errs[0])
+		assert.Equal(err, ti.errs[1])
+	})
+
+	t.Run("OTClient", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(200)
+			w.Write([]byte(`{"result": "ok"}`))
+		}))
+		defer ts.Close()
+
+		td := otgo.TrustDomain("localhost")
+		ti := &testInstrumenter{}
+		cli := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		cli.Instrumenter = ti
+
+		_, err := cli.Domain(td).Resolve(context.Background())
+		assert.NotNil(err)
+		assert.Equal([]otgo.Op{otgo.OpConfigFetch}, ti.ops)
+		assert.Equal(0, ti.hits)
+		assert.Equal(1, ti.misses)
+
+		cli.SetDomainKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+		_, err = cli.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(1, ti.hits)
+	})
+	t.Run("Verifier", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		key := otgo.MustPrivateKey("ES256")
+		aud := td.NewOTID("svc", "tester")
+		ti := &testInstrumenter{}
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key), otgo.WithInstrumenter(ti))
+		assert.Nil(err)
+
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		_, err = v.Verify(token, td.NewOTID("svc", "other"))
+		assert.NotNil(err)
+		assert.Equal([]otgo.Op{otgo.OpParse, otgo.OpParse}, ti.ops)
+		assert.Nil(ti.errs[0])
+		assert.Equal(err, ti.errs[1])
+	})
+}
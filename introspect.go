@@ -0,0 +1,81 @@
+package otgo
+
+import (
+	"time"
+)
+
+// Keys returns a snapshot of the trust domain's public keys that the Verifier currently trusts,
+// the set is a copy but the keys are shared and should not be modified.
+func (v *Verifier) Keys() *JWKSet {
+	if v.kp != nil {
+		ks, err := v.kp.VerificationKeys()
+		if err != nil || ks == nil {
+			return &JWKSet{}
+		}
+		return copyKeys(ks)
+	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return copyKeys(v.ks)
+}
+
+// LastRefreshed returns the time of the last successful fetch of the trust domain's public keys,
+// it is zero if the Verifier uses static keys or a KeyProvider.
+func (v *Verifier) LastRefreshed() time.Time {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.refreshedAt
+}
+
+// NextRefresh returns the time of the next background fetch of the trust domain's public keys,
+// it is zero if the keys are not refreshed in background or the refreshing context is done.
+func (v *Verifier) NextRefresh() time.Time {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.nextRefresh
+}
+
+// scheduleRefresh records the next background fetch after d, and returns d.
+func (v *Verifier) scheduleRefresh(d time.Duration) time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.nextRefresh = clockNow().Add(d)
+	return d
+}
+
+// Keys returns a snapshot of the cached trust domain's public keys without resolving them,
+// it is nil if the trust domain's configuration is not resolved yet.
+func (dr *DomainResolver) Keys() *JWKSet {
+	dr.RLock()
+	defer dr.RUnlock()
+	if dr.ks == nil {
+		return nil
+	}
+	return copyKeys(dr.ks)
+}
+
+// LastRefreshed returns the time that the cached trust domain's configuration was fetched or set,
+// it is zero if the configuration is not resolved yet.
+func (dr *DomainResolver) LastRefreshed() time.Time {
+	dr.RLock()
+	defer dr.RUnlock()
+	return dr.refreshedAt
+}
+
+// NextRefresh returns the time after which the next resolution fetches the trust domain's configuration,
+// it is zero if the configuration is not resolved yet or the keys are set by OTClient.SetDomainKeys.
+func (dr *DomainResolver) NextRefresh() time.Time {
+	dr.RLock()
+	defer dr.RUnlock()
+	if dr.endpoint == nullhost {
+		return time.Time{}
+	}
+	return dr.expiresAt
+}
+
+func copyKeys(ks *JWKSet) *JWKSet {
+	if ks == nil {
+		return &JWKSet{}
+	}
+	return &JWKSet{Keys: append(make([]Key, 0, len(ks.Keys)), ks.Keys...)}
+}
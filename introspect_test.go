@@ -0,0 +1,83 @@
+package otgo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIntrospection(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	key := otgo.MustPrivateKey("ES256")
+
+	var signs int32
+	ts := newTestOTAuth(td, key, &signs)
+	defer ts.Close()
+
+	t.Run("Verifier.Keys method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		ctx, cancel := context.WithCancel(context.Background())
+		v, err := otgo.NewVerifierWithOptions(ctx, aud, otgo.WithHTTPClient(cli), otgo.WithAutoRefresh(time.Hour))
+		assert.Nil(err)
+
+		start := fc.Now()
+		ks := v.Keys()
+		assert.Equal(1, len(ks.Keys))
+		assert.Equal(key.KeyID(), ks.Keys[0].KeyID())
+		ks.Keys = nil
+		assert.Equal(1, len(v.Keys().Keys))
+
+		assert.Eventually(func() bool { return !v.NextRefresh().IsZero() }, time.Second, time.Millisecond)
+		next := v.NextRefresh()
+		// jittered in [0.9, 1.1) of the interval from the start
+		assert.False(next.Before(start.Add(54 * time.Minute)))
+		assert.True(next.Before(start.Add(66 * time.Minute)))
+
+		assert.Equal(start, v.LastRefreshed())
+		fc.Advance(time.Minute)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		assert.Equal(fc.Now(), v.LastRefreshed())
+		cancel()
+		assert.Eventually(func() bool { return v.NextRefresh().IsZero() }, time.Second, time.Millisecond)
+
+		v, err = otgo.NewVerifier(context.Background(), aud, nil, key)
+		assert.Nil(err)
+		assert.Equal(1, len(v.Keys().Keys))
+		assert.True(v.LastRefreshed().IsZero())
+		assert.True(v.NextRefresh().IsZero())
+	})
+
+	t.Run("DomainResolver.Keys method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		oc := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		dr := oc.Domain(td)
+		assert.Nil(dr.Keys())
+		assert.True(dr.LastRefreshed().IsZero())
+		assert.True(dr.NextRefresh().IsZero())
+
+		_, err := dr.Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(key.KeyID(), dr.Keys().Keys[0].KeyID())
+		assert.Equal(fc.Now(), dr.LastRefreshed())
+		assert.Equal(fc.Now().Add(time.Hour), dr.NextRefresh())
+
+		oc.SetDomainKeys(*otgo.LookupPublicKeys(otgo.MustKeys(key)))
+		assert.Equal(fc.Now(), dr.LastRefreshed())
+		assert.True(dr.NextRefresh().IsZero())
+	})
+}
@@ -0,0 +1,59 @@
+package otgo
+
+import (
+	"errors"
+	"time"
+)
+
+// VerifyIssuedAt checks the sanity of the OTVID's issued-at time ('iat' claim): it must be present,
+// not in the future beyond the leeway for clock skew, and not older than maxAge plus the leeway
+// if maxAge > 0. It catches the OTVIDs minted by a issuer with a broken or abused clock and
+// the stale OTVIDs replayed long after they were issued, see OTVID.VerifyWithIssuedAt.
+func (o *OTVID) VerifyIssuedAt(leeway, maxAge time.Duration) error {
+	if o.IssuedAt.IsZero() {
+		return errors.New(`otgo.OTVID.Verify: issued-at time required`)
+	}
+	now := clockNow()
+	if o.IssuedAt.After(now.Add(leeway)) {
+		return errors.New(`otgo.OTVID.Verify: issued-at time in the future`)
+	}
+	if maxAge > 0 && o.IssuedAt.Before(now.Add(-maxAge-leeway).Truncate(time.Second)) {
+		return errors.New(`otgo.OTVID.Verify: issued-at time too old`)
+	}
+	return nil
+}
+
+// issuedAtCheck is the Verifier's issued-at time check, it is disabled if nil. See WithIssuedAtCheck.
+type issuedAtCheck struct {
+	maxAge time.Duration
+}
+
+func newIssuedAtCheck(maxAge time.Duration) *issuedAtCheck {
+	if maxAge < 0 {
+		return nil
+	}
+	return &issuedAtCheck{maxAge: maxAge}
+}
+
+func (c *issuedAtCheck) check(vid *OTVID, leeway time.Duration) error {
+	if c == nil {
+		return nil
+	}
+	return vid.VerifyIssuedAt(leeway, c.maxAge)
+}
+
+// WithIssuedAtCheck rejects the OTVIDs issued in the future beyond the leeway (see WithLeeway),
+// or issued more than maxAge ago if maxAge > 0, see OTVID.VerifyIssuedAt and SetIssuedAtCheck.
+func WithIssuedAtCheck(maxAge time.Duration) VerifierOption {
+	return func(o *verifierOptions) {
+		o.iat = newIssuedAtCheck(maxAge)
+	}
+}
+
+// SetIssuedAtCheck checks the issued-at time of the OTVIDs like WithIssuedAtCheck,
+// the check is disabled if maxAge is negative.
+func (v *Verifier) SetIssuedAtCheck(maxAge time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.iat = newIssuedAtCheck(maxAge)
+}
@@ -0,0 +1,98 @@
+package otgo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuedAt(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	key := otgo.MustPrivateKey("ES256")
+
+	t.Run("OTVID.VerifyIssuedAt method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		vid := &otgo.OTVID{}
+		assert.NotNil(vid.VerifyIssuedAt(0, 0))
+		vid.IssuedAt = fc.Now()
+		assert.Nil(vid.VerifyIssuedAt(0, 0))
+		assert.Nil(vid.VerifyIssuedAt(0, time.Minute))
+
+		vid.IssuedAt = fc.Now().Add(time.Minute)
+		assert.NotNil(vid.VerifyIssuedAt(0, 0))
+		assert.NotNil(vid.VerifyIssuedAt(30*time.Second, 0))
+		assert.Nil(vid.VerifyIssuedAt(time.Minute, 0))
+
+		vid.IssuedAt = fc.Now().Add(-time.Hour)
+		assert.Nil(vid.VerifyIssuedAt(0, 0))
+		assert.NotNil(vid.VerifyIssuedAt(0, time.Minute))
+		assert.Nil(vid.VerifyIssuedAt(0, 2*time.Hour))
+		assert.Nil(vid.VerifyIssuedAt(time.Hour, time.Minute))
+	})
+
+	t.Run("OTVID.VerifyWithIssuedAt method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		ks := otgo.LookupPublicKeys(otgo.MustKeys(key))
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud, Expiry: fc.Now().Add(3 * time.Hour)}
+		vid, err := otgo.ParseOTVID(mustSignOTVID(vid, key), ks, td.OTID(), aud)
+		assert.Nil(err)
+
+		// issued by a issuer whose clock is 2 hours ahead
+		fc.Advance(-2 * time.Hour)
+		assert.Nil(vid.Verify(ks, td.OTID(), aud))
+		assert.Nil(vid.VerifyWithIssuedAt(ks, td.OTID(), aud, time.Minute, -1))
+		err = vid.VerifyWithIssuedAt(ks, td.OTID(), aud, time.Minute, 0)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "issued-at time in the future")
+		assert.Nil(vid.VerifyWithIssuedAt(ks, td.OTID(), aud, 3*time.Hour, 0))
+
+		fc.Advance(4 * time.Hour)
+		assert.NotNil(vid.VerifyWithIssuedAt(ks, td.OTID(), aud, time.Minute, time.Hour))
+		assert.Nil(vid.VerifyWithIssuedAt(ks, td.OTID(), aud, time.Minute, 3*time.Hour))
+	})
+
+	t.Run("Verifier with issued-at time check", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key),
+			otgo.WithLeeway(time.Minute), otgo.WithIssuedAtCheck(time.Hour))
+		assert.Nil(err)
+
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud, Expiry: fc.Now().Add(3 * time.Hour)}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+
+		fc.Advance(2 * time.Hour)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+		v.SetIssuedAtCheck(-1)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+
+		// issued by a issuer whose clock is 2 hours ahead
+		fc.Advance(-4 * time.Hour)
+		v.SetIssuedAtCheck(0)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+		fc.Advance(2*time.Hour - 30*time.Second)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+	})
+}
@@ -0,0 +1,59 @@
+package otgo
+
+import (
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+// parseDelegated verifies a OTVID issued by the trust domain or one of its delegated issuers.
+// A delegated issuer's OTVID is verified only with the keys mapped to the issuer,
+// the trust domain's OTVID only with the keys not mapped to any issuer.
+func (d *decodedOTVID) parseDelegated(ks *JWKSet, td TrustDomain, issuers map[string][]string, delegated OTIDs, aud OTID) (*OTVID, error) {
+	iss := d.vid.Issuer
+	if len(delegated) == 0 || iss.Equal(td.OTID()) {
+		return d.parse(domainKeys(ks, issuers), td.OTID(), aud)
+	}
+	if !iss.MemberOf(td) || !delegated.Has(iss) {
+		return nil, fmt.Errorf("otgo.ParseOTVID: issuer %s not accepted", iss.String())
+	}
+	iks := issuerKeys(ks, issuers[iss.String()])
+	if len(iks.Keys) == 0 {
+		return nil, fmt.Errorf("otgo.ParseOTVID: no keys for issuer %s", iss.String())
+	}
+	return d.parse(iks, iss, aud)
+}
+
+func issuerKeys(ks *JWKSet, kids []string) *JWKSet {
+	rs := &jwk.Set{Keys: make([]Key, 0, len(kids))}
+	if ks != nil {
+		for _, k := range ks.Keys {
+			if stringsHas(kids, k.KeyID()) {
+				rs.Keys = append(rs.Keys, k)
+			}
+		}
+	}
+	return rs
+}
+
+// domainKeys returns the keys of the JWK set that are not mapped to any delegated issuer,
+// so that a delegated issuer can not sign the trust domain's OTVIDs.
+func domainKeys(ks *JWKSet, issuers map[string][]string) *JWKSet {
+	if ks == nil || len(issuers) == 0 {
+		return ks
+	}
+	rs := &jwk.Set{Keys: make([]Key, 0, len(ks.Keys))}
+	for _, k := range ks.Keys {
+		mapped := false
+		for _, kids := range issuers {
+			if stringsHas(kids, k.KeyID()) {
+				mapped = true
+				break
+			}
+		}
+		if !mapped {
+			rs.Keys = append(rs.Keys, k)
+		}
+	}
+	return rs
+}
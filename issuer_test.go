@@ -0,0 +1,110 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelegatedIssuers(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	domainKey := otgo.MustPrivateKey("ES256")
+	euKey := otgo.MustPrivateKey("ES256")
+	eu := td.NewOTID("issuer", "eu")
+	us := td.NewOTID("issuer", "us")
+	aud := td.NewOTID("app", "123")
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(200)
+		if r.URL.Path != "/.well-known/open-trust-configuration" {
+			w.Write([]byte(`{"result": "ok"}`))
+			return
+		}
+		ks := otgo.LookupPublicKeys(otgo.MustKeys(domainKey, euKey))
+		b, _ := json.Marshal(map[string]interface{}{
+			"otid":             td.OTID(),
+			"keys":             ks.Keys,
+			"serviceEndpoints": []string{ts.URL},
+			"issuers":          map[string][]string{eu.String(): {euKey.KeyID()}},
+		})
+		w.Write(b)
+	}))
+	defer ts.Close()
+
+	sign := func(key otgo.Key, iss otgo.OTID) string {
+		return mustSignOTVID(&otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: iss, Audience: aud, Expiry: time.Now().Add(time.Hour)}, key)
+	}
+
+	t.Run("Verifier.SetDelegatedIssuers method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		v, err := otgo.NewVerifier(ctx, aud, cli)
+		assert.Nil(err)
+
+		_, err = v.ParseOTVID(sign(euKey, eu))
+		assert.NotNil(err)
+		// the eu key can not sign the trust domain's OTVIDs
+		_, err = v.ParseOTVID(sign(euKey, td.OTID()))
+		assert.NotNil(err)
+		_, err = v.ParseOTVID(sign(domainKey, td.OTID()))
+		assert.Nil(err)
+
+		v.SetDelegatedIssuers(eu, us)
+		vid, err := v.ParseOTVID(sign(euKey, eu))
+		assert.Nil(err)
+		assert.True(vid.Issuer.Equal(eu))
+
+		_, err = v.ParseOTVID(sign(domainKey, td.OTID()))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(euKey, td.OTID()))
+		assert.NotNil(err)
+		// the domain key is not mapped to the eu issuer
+		_, err = v.ParseOTVID(sign(domainKey, eu))
+		assert.NotNil(err)
+		// no keys mapped to the us issuer
+		_, err = v.ParseOTVID(sign(euKey, us))
+		assert.NotNil(err)
+		_, err = v.ParseOTVID(sign(euKey, otgo.TrustDomain("localhost1").NewOTID("issuer", "eu")))
+		assert.NotNil(err)
+	})
+
+	t.Run("OTClient.DelegatedIssuers", func(t *testing.T) {
+		assert := assert.New(t)
+
+		cli := otgo.NewOTClient(context.Background(), aud)
+		cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		_, err := cli.ParseOTVID(context.Background(), sign(euKey, eu))
+		assert.NotNil(err)
+
+		cli.DelegatedIssuers = otgo.OTIDs{eu}
+		vid, err := cli.ParseOTVID(context.Background(), sign(euKey, eu))
+		assert.Nil(err)
+		assert.True(vid.Issuer.Equal(eu))
+	})
+
+	t.Run("SignInput.Issuer", func(t *testing.T) {
+		assert := assert.New(t)
+
+		input := otgo.SignInput{Subject: td.NewOTID("user", "abc"), Audience: aud}
+		b, err := json.Marshal(input)
+		assert.Nil(err)
+		assert.NotContains(string(b), `"iss"`)
+
+		input.Issuer = &eu
+		b, err = json.Marshal(input)
+		assert.Nil(err)
+		assert.Contains(string(b), `"iss":"otid:localhost:issuer:eu"`)
+	})
+}
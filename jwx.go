@@ -2,10 +2,12 @@ package otgo
 
 import (
 	"context"
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"strings"
@@ -31,9 +33,18 @@ func NewToken() Token {
 
 // ParseKeys ...
 func ParseKeys(bs ...[]byte) ([]Key, error) {
+	return ParseKeysWith(bs)
+}
+
+// ParseKeysWith parses keys like ParseKeys with options, e.g. WithThumbprintKeyID.
+func ParseKeysWith(bs [][]byte, opts ...ParseOption) ([]Key, error) {
+	o := newParseOptions(opts)
 	keys := make([]Key, 0, len(bs))
 	for _, b := range bs {
 		k, err := jwk.ParseKey(b)
+		if err == nil {
+			err = o.assignKeyIDs(k)
+		}
 		if err == nil {
 			err = validateKeys(k)
 		}
@@ -45,9 +56,43 @@ func ParseKeys(bs ...[]byte) ([]Key, error) {
 	return keys, nil
 }
 
-// ParseKey ...
-func ParseKey(s string) (Key, error) {
-	keys, err := ParseKeys([]byte(s))
+// KeyError reports a key that failed to parse or validate in a batch.
+type KeyError struct {
+	Index int // the key's index in the batch
+	Err   error
+}
+
+func (e *KeyError) Error() string {
+	return fmt.Sprintf("otgo.KeyError: key #%d: %s", e.Index, e.Err.Error())
+}
+
+// Unwrap ...
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// ParseKeysLenient parses keys like ParseKeys, but skips the bad keys instead of aborting.
+// It returns the good keys and a *KeyError for every bad key.
+func ParseKeysLenient(bs ...[]byte) ([]Key, []error) {
+	keys := make([]Key, 0, len(bs))
+	var errs []error
+	for i, b := range bs {
+		k, err := jwk.ParseKey(b)
+		if err == nil {
+			err = validateKeys(k)
+		}
+		if err != nil {
+			errs = append(errs, &KeyError{Index: i, Err: err})
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys, errs
+}
+
+// ParseKey parses a key from a string with the options, e.g. WithThumbprintKeyID.
+func ParseKey(s string, opts ...ParseOption) (Key, error) {
+	keys, err := ParseKeysWith([][]byte{[]byte(s)}, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -57,8 +102,13 @@ func ParseKey(s string) (Key, error) {
 	return keys[0], nil
 }
 
-// ParseSet ...
+// ParseSet parses a JWK set string, or the keys' strings.
 func ParseSet(ss ...string) (*JWKSet, error) {
+	return ParseSetWith(ss)
+}
+
+// ParseSetWith parses keys like ParseSet with options, e.g. WithThumbprintKeyID.
+func ParseSetWith(ss []string, opts ...ParseOption) (*JWKSet, error) {
 	if len(ss) == 0 {
 		return nil, errors.New("otgo.ParseSet: empty string")
 	}
@@ -67,7 +117,10 @@ func ParseSet(ss ...string) (*JWKSet, error) {
 	if strings.Contains(ss[0], `"keys"`) {
 		k, err := jwk.ParseString(ss[0])
 		if err == nil {
-			err = validateKeys(ks.Keys...)
+			err = newParseOptions(opts).assignKeyIDs(k.Keys...)
+		}
+		if err == nil {
+			err = validateKeys(k.Keys...)
 		}
 		if err != nil {
 			return nil, err
@@ -78,7 +131,7 @@ func ParseSet(ss ...string) (*JWKSet, error) {
 		for _, s := range ss {
 			bs = append(bs, []byte(s))
 		}
-		keys, err := ParseKeys(bs...)
+		keys, err := ParseKeysWith(bs, opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -92,15 +145,21 @@ func ParseSet(ss ...string) (*JWKSet, error) {
 }
 
 // FetchKeys ...
-func FetchKeys(ctx context.Context, jwkurl string, cli HTTPClient) (*JWKSet, error) {
+func FetchKeys(ctx context.Context, jwkurl string, cli HTTPClient, opts ...ParseOption) (*JWKSet, error) {
 	ks := &jwk.Set{}
 	if cli == nil {
 		cli = DefaultHTTPClient
 	}
+	ctx, end := httpInstrumenter(cli).Start(ctx, OpKeyFetch)
 	err := cli.Do(ctx, "GET", jwkurl, nil, nil, &ks)
+	if err == nil {
+		err = newParseOptions(opts).assignKeyIDs(ks.Keys...)
+	}
 	if err == nil {
 		err = validateKeys(ks.Keys...)
 	}
+	// the span ends with the validation error, the fetched keys may be invalid
+	end(err)
 	if err != nil {
 		return nil, err
 	}
@@ -124,6 +183,15 @@ func MustKeys(keys ...Key) *JWKSet {
 	return ks
 }
 
+// Thumbprint returns the base64url encoded RFC 7638 SHA-256 thumbprint of the key.
+func Thumbprint(k Key) (string, error) {
+	h, err := k.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", fmt.Errorf("otgo.Thumbprint: %s", err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(h), nil
+}
+
 // ToPublicKey ...
 func ToPublicKey(k Key) (Key, error) {
 	switch key := k.(type) {
@@ -132,6 +200,9 @@ func ToPublicKey(k Key) (Key, error) {
 		if err == nil {
 			err = copyParams(key, pub, "alg", "kid", "use", "key_ops")
 		}
+		if err == nil {
+			err = publicKeyOps(pub)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -141,12 +212,20 @@ func ToPublicKey(k Key) (Key, error) {
 		if err == nil {
 			err = copyParams(key, pub, "alg", "kid", "use", "key_ops")
 		}
+		if err == nil {
+			err = publicKeyOps(pub)
+		}
 		if err != nil {
 			return nil, err
 		}
 		return pub, nil
 	case jwk.RSAPublicKey, jwk.ECDSAPublicKey:
 		return key, nil
+	case jwk.SymmetricKey:
+		if insecureAlgorithms && hmacSize(key.Algorithm()) > 0 {
+			return key, nil // the secret is required to verify
+		}
+		return nil, fmt.Errorf("otgo.ToPublicKey: invalid key type %T", key)
 	default:
 		return nil, fmt.Errorf("otgo.ToPublicKey: invalid key type %T", key)
 	}
@@ -177,6 +256,10 @@ func LookupSigningKey(ks *JWKSet) (Key, error) {
 	switch key.(type) {
 	case jwk.RSAPrivateKey, jwk.ECDSAPrivateKey:
 		return key, nil
+	case jwk.SymmetricKey:
+		if insecureAlgorithms {
+			return key, nil
+		}
 	}
 	return nil, fmt.Errorf(`otgo.LookupSigningKey: invalid key type '%T'`, key)
 }
@@ -190,19 +273,26 @@ func MustPrivateKey(alg string) Key {
 	return key
 }
 
-// NewPrivateKey ...
+// NewPrivateKey generates a private key for the algorithm, the RSA keys are DefaultRSABits bits.
+// See NewPrivateKeyWithOptions.
 func NewPrivateKey(alg string) (Key, error) {
+	return newPrivateKey(alg, &keyOptions{})
+}
+
+func newPrivateKey(alg string, o *keyOptions) (Key, error) {
 	var key Key
 	var err error
 	switch jwa.SignatureAlgorithm(alg) {
 	case jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512:
-		key, err = newRSAPrivateKey()
+		key, err = newRSAPrivateKey(o.rsaBitsOrDefault())
 	case jwa.ES256:
 		key, err = newECDSAPrivateKey(elliptic.P256())
 	case jwa.ES384:
 		key, err = newECDSAPrivateKey(elliptic.P384())
 	case jwa.ES512:
 		key, err = newECDSAPrivateKey(elliptic.P521())
+	case jwa.HS256, jwa.HS384, jwa.HS512:
+		key, err = newSymmetricKey(alg)
 	default:
 		err = fmt.Errorf("otgo.NewPrivateKey: invalid algorithm '%s'", alg)
 	}
@@ -213,7 +303,8 @@ func NewPrivateKey(alg string) (Key, error) {
 	if err = key.Set("alg", alg); err != nil {
 		return nil, err
 	}
-	if err = jwk.AssignKeyID(key); err != nil {
+	// the thumbprint is the kid WithThumbprintKeyID assigns, so the key's kid-less copies match it
+	if err = (&parseOptions{thumbprintKID: true}).assignKeyIDs(key); err != nil {
 		return nil, err
 	}
 	return key, nil
@@ -224,6 +315,8 @@ func ValidateAlgorithm(alg string) bool {
 	switch jwa.SignatureAlgorithm(alg) {
 	case jwa.RS256, jwa.RS384, jwa.RS512, jwa.ES256, jwa.ES384, jwa.ES512, jwa.PS256, jwa.PS384, jwa.PS512:
 		return true
+	case jwa.HS256, jwa.HS384, jwa.HS512:
+		return insecureAlgorithms
 	}
 	return false
 }
@@ -240,9 +333,9 @@ func validateKeys(keys ...Key) error {
 	return nil
 }
 
-// The recommended RSA key-length is 2048 bits.
-func newRSAPrivateKey() (Key, error) {
-	pk, err := rsa.GenerateKey(rand.Reader, 2048)
+// newRSAPrivateKey generates a RSA key of the bits, see DefaultRSABits.
+func newRSAPrivateKey(bits int) (Key, error) {
+	pk, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
 		return nil, err
 	}
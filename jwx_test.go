@@ -2,6 +2,7 @@ package otgo_test
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/lestrrat-go/jwx/jwk"
@@ -87,6 +88,71 @@ func TestJWX(t *testing.T) {
 		assert.Equal(keys.Keys[1].KeyID(), priKey2.KeyID())
 	})
 
+	t.Run("ParseKeysLenient func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		priKey1 := otgo.MustPrivateKey("RS256")
+		priKey2 := otgo.MustPrivateKey("ES256")
+		keys, errs := otgo.ParseKeysLenient([]byte(mustMarshal(priKey1)), []byte(`{"kty":"EC"}`), []byte(mustMarshal(priKey2)))
+		assert.Equal(2, len(keys))
+		assert.Equal(priKey1.KeyID(), keys[0].KeyID())
+		assert.Equal(priKey2.KeyID(), keys[1].KeyID())
+		assert.Equal(1, len(errs))
+		var ke *otgo.KeyError
+		assert.True(errors.As(errs[0], &ke))
+		assert.Equal(1, ke.Index)
+
+		keys, errs = otgo.ParseKeysLenient([]byte(mustMarshal(priKey1)))
+		assert.Equal(1, len(keys))
+		assert.Nil(errs)
+	})
+
+	t.Run("ParseKeysWith func & Thumbprint func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		priKey := otgo.MustPrivateKey("ES256")
+		pubKey, err := otgo.ToPublicKey(priKey)
+		assert.Nil(err)
+		m := map[string]interface{}{}
+		assert.Nil(json.Unmarshal([]byte(mustMarshal(pubKey)), &m))
+		delete(m, "kid")
+		b := []byte(mustMarshal(m))
+
+		_, err = otgo.ParseKeys(b)
+		assert.NotNil(err)
+
+		keys, err := otgo.ParseKeysWith([][]byte{b}, otgo.WithThumbprintKeyID())
+		assert.Nil(err)
+		assert.Equal(1, len(keys))
+		kid, err := otgo.Thumbprint(keys[0])
+		assert.Nil(err)
+		assert.Equal(kid, keys[0].KeyID())
+		kid2, err := otgo.Thumbprint(priKey)
+		assert.Nil(err)
+		assert.Equal(kid, kid2)
+
+		keys, err = otgo.ParseKeysWith([][]byte{[]byte(mustMarshal(priKey))}, otgo.WithThumbprintKeyID())
+		assert.Nil(err)
+		assert.Equal(priKey.KeyID(), keys[0].KeyID())
+		// the generated keys' kid is the thumbprint
+		assert.Equal(kid, priKey.KeyID())
+
+		_, err = otgo.ParseKey(string(b))
+		assert.NotNil(err)
+		key, err := otgo.ParseKey(string(b), otgo.WithThumbprintKeyID())
+		assert.Nil(err)
+		assert.Equal(kid, key.KeyID())
+
+		_, err = otgo.ParseSet(`{"keys":[` + string(b) + `]}`)
+		assert.NotNil(err)
+		ks, err := otgo.ParseSetWith([]string{`{"keys":[` + string(b) + `]}`}, otgo.WithThumbprintKeyID())
+		assert.Nil(err)
+		assert.Equal(kid, ks.Keys[0].KeyID())
+		ks, err = otgo.ParseSetWith([]string{string(b)}, otgo.WithThumbprintKeyID())
+		assert.Nil(err)
+		assert.Equal(kid, ks.Keys[0].KeyID())
+	})
+
 	t.Run("LookupPublicKeys func", func(t *testing.T) {
 		assert := assert.New(t)
 
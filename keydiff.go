@@ -0,0 +1,62 @@
+package otgo
+
+// KeysChangedFunc is called when a refresh changes the trust domain's public keys,
+// e.g. to alert on unexpected key rotations. Use DiffKeys to find the added and removed keys.
+type KeysChangedFunc func(old, new *JWKSet)
+
+// DiffKeys returns the keys of the new set that are not in the old set and the keys of the old set
+// that are not in the new set. The keys are identified by their kid and RFC 7638 thumbprint,
+// so a key replaced under the same kid is both removed and added.
+func DiffKeys(old, new *JWKSet) (added, removed []Key) {
+	oldIDs, newIDs := keyIdentities(old), keyIdentities(new)
+	added = keysNotIn(new, newIDs, oldIDs)
+	removed = keysNotIn(old, oldIDs, newIDs)
+	return added, removed
+}
+
+// keysChanged reports whether the key sets have different keys.
+func keysChanged(old, new *JWKSet) bool {
+	added, removed := DiffKeys(old, new)
+	return len(added) > 0 || len(removed) > 0
+}
+
+// keyIdentities returns the "kid thumbprint" identities of the keys in order.
+func keyIdentities(ks *JWKSet) []string {
+	if ks == nil {
+		return nil
+	}
+	ids := make([]string, len(ks.Keys))
+	for i, k := range ks.Keys {
+		tp, _ := Thumbprint(k)
+		ids[i] = k.KeyID() + " " + tp
+	}
+	return ids
+}
+
+func keysNotIn(ks *JWKSet, ids, others []string) []Key {
+	var keys []Key
+	for i, id := range ids {
+		if !stringsHas(others, id) {
+			keys = append(keys, ks.Keys[i])
+		}
+	}
+	return keys
+}
+
+// OnKeysChanged sets the callback of the key refreshes that change the trust domain's public keys,
+// it is not called for the initial keys. It is called synchronously by the refreshing goroutine.
+func (v *Verifier) OnKeysChanged(fn KeysChangedFunc) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.onKeysChanged = fn
+}
+
+// OnKeysChanged sets the callback of the renewals that change the trust domain's public keys,
+// it is not called for the initial keys nor for SetDomainKeys. It is called in a new goroutine
+// because the renewal holds the trust domain's cache entry lock.
+// The callback is lost if the trust domain's cache entry is evicted, see OTClient.MaxCacheEntries.
+func (dr *DomainResolver) OnKeysChanged(fn KeysChangedFunc) {
+	dr.Lock()
+	defer dr.Unlock()
+	dr.onKeysChanged = fn
+}
@@ -0,0 +1,126 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysChanged(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	key1 := otgo.MustPrivateKey("ES256")
+	key2 := otgo.MustPrivateKey("ES256")
+
+	var mu sync.Mutex
+	keys := []otgo.Key{key1}
+	setKeys := func(ks ...otgo.Key) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = ks
+	}
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		b, _ := json.Marshal(map[string]interface{}{
+			"otid":             td.OTID(),
+			"keys":             otgo.LookupPublicKeys(otgo.MustKeys(keys...)).Keys,
+			"serviceEndpoints": []string{ts.URL},
+		})
+		w.Write(b)
+	}))
+	defer ts.Close()
+
+	t.Run("DiffKeys func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ks1 := otgo.LookupPublicKeys(otgo.MustKeys(key1))
+		ks12 := otgo.LookupPublicKeys(otgo.MustKeys(key1, key2))
+		added, removed := otgo.DiffKeys(ks1, ks12)
+		assert.Equal(1, len(added))
+		assert.Equal(key2.KeyID(), added[0].KeyID())
+		assert.Equal(0, len(removed))
+
+		added, removed = otgo.DiffKeys(ks12, ks1)
+		assert.Equal(0, len(added))
+		assert.Equal(key2.KeyID(), removed[0].KeyID())
+
+		added, removed = otgo.DiffKeys(ks1, otgo.LookupPublicKeys(otgo.MustKeys(key1)))
+		assert.Equal(0, len(added)+len(removed))
+
+		// the same kid with another key
+		key3 := otgo.MustPrivateKey("ES256")
+		assert.Nil(key3.Set("kid", key1.KeyID()))
+		added, removed = otgo.DiffKeys(ks1, otgo.LookupPublicKeys(otgo.MustKeys(key3)))
+		assert.Equal(1, len(added))
+		assert.Equal(1, len(removed))
+
+		added, removed = otgo.DiffKeys(nil, ks1)
+		assert.Equal(1, len(added))
+		assert.Equal(0, len(removed))
+	})
+
+	t.Run("Verifier.OnKeysChanged method", func(t *testing.T) {
+		assert := assert.New(t)
+		setKeys(key1)
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		v, err := otgo.NewVerifierWithOptions(context.Background(), td.NewOTID("svc", "tester"),
+			otgo.WithHTTPClient(cli), otgo.WithAutoRefresh(-1))
+		assert.Nil(err)
+		var changes [][2]*otgo.JWKSet
+		v.OnKeysChanged(func(old, new *otgo.JWKSet) {
+			changes = append(changes, [2]*otgo.JWKSet{old, new})
+		})
+
+		assert.Nil(v.RefreshKeys(context.Background()))
+		assert.Equal(0, len(changes))
+
+		setKeys(key1, key2)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		assert.Equal(1, len(changes))
+		assert.Equal(1, len(changes[0][0].Keys))
+		assert.Equal(2, len(changes[0][1].Keys))
+	})
+
+	t.Run("DomainResolver.OnKeysChanged method", func(t *testing.T) {
+		assert := assert.New(t)
+		setKeys(key1)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		oc := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		changed := make(chan []otgo.Key, 1)
+		oc.Domain(td).OnKeysChanged(func(old, new *otgo.JWKSet) {
+			added, _ := otgo.DiffKeys(old, new)
+			changed <- added
+		})
+
+		_, err := oc.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		setKeys(key2)
+		fc.Advance(2 * time.Hour)
+		cfg, err := oc.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(key2.KeyID(), cfg.JWKSet.Keys[0].KeyID())
+
+		select {
+		case added := <-changed:
+			assert.Equal(1, len(added))
+			assert.Equal(key2.KeyID(), added[0].KeyID())
+		case <-time.After(time.Second):
+			assert.Fail("OnKeysChanged not called")
+		}
+	})
+}
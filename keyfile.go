@@ -0,0 +1,400 @@
+package otgo
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// DefaultKeyFileIterations is the PBKDF2 iteration count of EncryptKeyFile.
+const DefaultKeyFileIterations = 210000
+
+// maxKeyFileIterations bounds the PBKDF2 iteration count of DecryptKeyFile, so that a crafted
+// key file can not burn the CPU.
+const maxKeyFileIterations = 10000000
+
+// keyFileAlgorithms are the supported PBES2 key management algorithms, RFC 7518 section 4.8.
+var keyFileAlgorithms = map[string]struct {
+	hash   func() hash.Hash
+	keyLen int
+}{
+	"PBES2-HS256+A128KW": {sha256.New, 16},
+	"PBES2-HS384+A192KW": {sha512.New384, 24},
+	"PBES2-HS512+A256KW": {sha512.New, 32},
+}
+
+// keyFileEncryptions are the supported content encryption algorithms and their key lengths, RFC 7518 section 5.
+var keyFileEncryptions = map[string]int{
+	"A128CBC-HS256": 32,
+	"A192CBC-HS384": 48,
+	"A256CBC-HS512": 64,
+	"A128GCM":       16,
+	"A192GCM":       24,
+	"A256GCM":       32,
+}
+
+type keyFileHeader struct {
+	Alg string `json:"alg"`
+	Enc string `json:"enc"`
+	Cty string `json:"cty,omitempty"`
+	P2S string `json:"p2s"`
+	P2C int    `json:"p2c"`
+}
+
+// EncryptKeyFile encrypts the JSON private JWK or JWK set with the passphrase into a compact JWE
+// (PBES2-HS512+A256KW and A256GCM, RFC 7518), so that the private keys at rest are not plaintext.
+// The result can be decrypted by DecryptKeyFile or any JWE implementation, see ReadKeyFile.
+func EncryptKeyFile(data, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("otgo.EncryptKeyFile: passphrase required")
+	}
+	if _, err := ParseSet(string(data)); err != nil {
+		return nil, fmt.Errorf("otgo.EncryptKeyFile: %s", err.Error())
+	}
+	cty := "jwk+json"
+	if strings.Contains(string(data), `"keys"`) {
+		cty = "jwk-set+json"
+	}
+
+	salt := make([]byte, 16)
+	cek := make([]byte, keyFileEncryptions["A256GCM"])
+	iv := make([]byte, 12)
+	for _, b := range [][]byte{salt, cek, iv} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+	hdr := keyFileHeader{Alg: "PBES2-HS512+A256KW", Enc: "A256GCM", Cty: cty,
+		P2S: base64.RawURLEncoding.EncodeToString(salt), P2C: DefaultKeyFileIterations}
+	b, err := json.Marshal(hdr)
+	if err != nil {
+		return nil, err
+	}
+	protected := base64.RawURLEncoding.EncodeToString(b)
+
+	kek := hdr.deriveKey(passphrase, salt)
+	encryptedKey, err := aesKeyWrap(kek, cek)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.EncryptKeyFile: %s", err.Error())
+	}
+	aead, err := newGCM(cek)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.EncryptKeyFile: %s", err.Error())
+	}
+	sealed := aead.Seal(nil, iv, data, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+
+	enc := base64.RawURLEncoding.EncodeToString
+	return []byte(strings.Join([]string{protected, enc(encryptedKey), enc(iv), enc(ciphertext), enc(tag)}, ".")), nil
+}
+
+// IsEncryptedKeyFile reports whether data is a compact JWE, e.g. encrypted by EncryptKeyFile,
+// instead of a plaintext JWK or JWK set.
+func IsEncryptedKeyFile(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && data[0] != '{' && bytes.Count(data, []byte{'.'}) == 4
+}
+
+// DecryptKeyFile decrypts the JWE encrypted private JWK or JWK set with the passphrase,
+// see EncryptKeyFile. It supports the PBES2 key management algorithms, the AES GCM and the AES CBC HMAC SHA2
+// content encryptions.
+func DecryptKeyFile(data, passphrase []byte) ([]byte, error) {
+	parts := strings.Split(string(bytes.TrimSpace(data)), ".")
+	if len(parts) != 5 {
+		return nil, errors.New("otgo.DecryptKeyFile: invalid compact serialization format")
+	}
+	raw := make([][]byte, 5)
+	for i, p := range parts {
+		b, err := base64.RawURLEncoding.DecodeString(p)
+		if err != nil {
+			return nil, fmt.Errorf("otgo.DecryptKeyFile: invalid part %d: %s", i, err.Error())
+		}
+		raw[i] = b
+	}
+	hdr := keyFileHeader{}
+	if err := json.Unmarshal(raw[0], &hdr); err != nil {
+		return nil, fmt.Errorf("otgo.DecryptKeyFile: invalid header: %s", err.Error())
+	}
+	if _, ok := keyFileAlgorithms[hdr.Alg]; !ok {
+		return nil, fmt.Errorf("otgo.DecryptKeyFile: unsupported algorithm '%s'", hdr.Alg)
+	}
+	cekLen, ok := keyFileEncryptions[hdr.Enc]
+	if !ok {
+		return nil, fmt.Errorf("otgo.DecryptKeyFile: unsupported encryption '%s'", hdr.Enc)
+	}
+	if hdr.P2C < 1000 || hdr.P2C > maxKeyFileIterations {
+		return nil, fmt.Errorf("otgo.DecryptKeyFile: invalid iteration count %d", hdr.P2C)
+	}
+	salt, err := base64.RawURLEncoding.DecodeString(hdr.P2S)
+	if err != nil || len(salt) < 8 {
+		return nil, errors.New("otgo.DecryptKeyFile: invalid salt")
+	}
+
+	cek, err := aesKeyUnwrap(hdr.deriveKey(passphrase, salt), raw[1])
+	if err != nil || len(cek) != cekLen {
+		return nil, errors.New("otgo.DecryptKeyFile: wrong passphrase or corrupted key file")
+	}
+	aead, err := newContentCipher(hdr.Enc, cek)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.DecryptKeyFile: %s", err.Error())
+	}
+	if len(raw[2]) != aead.NonceSize() || len(raw[4]) != aead.Overhead() {
+		return nil, errors.New("otgo.DecryptKeyFile: invalid IV or authentication tag")
+	}
+	plaintext, err := aead.Open(nil, raw[2], append(raw[3], raw[4]...), []byte(parts[0]))
+	if err != nil {
+		return nil, errors.New("otgo.DecryptKeyFile: wrong passphrase or corrupted key file")
+	}
+	return plaintext, nil
+}
+
+// deriveKey derives the key encryption key from the passphrase, RFC 7518 section 4.8.1.1.
+func (h keyFileHeader) deriveKey(passphrase, salt []byte) []byte {
+	a := keyFileAlgorithms[h.Alg]
+	s := append(append([]byte(h.Alg), 0), salt...)
+	return pbkdf2.Key(passphrase, s, h.P2C, a.keyLen, a.hash)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// newContentCipher creates the AEAD of the content encryption algorithm with the CEK.
+func newContentCipher(enc string, cek []byte) (cipher.AEAD, error) {
+	if strings.HasSuffix(enc, "GCM") {
+		return newGCM(cek)
+	}
+	return newCBCHMAC(cek)
+}
+
+// cbcHMAC is the AES CBC HMAC SHA2 authenticated encryption, RFC 7518 section 5.2.
+// The first half of the key is the MAC key, the second half the encryption key.
+type cbcHMAC struct {
+	block  cipher.Block
+	macKey []byte
+	hash   func() hash.Hash
+}
+
+func newCBCHMAC(key []byte) (cipher.AEAD, error) {
+	var h func() hash.Hash
+	switch len(key) {
+	case 32:
+		h = sha256.New
+	case 48:
+		h = sha512.New384
+	case 64:
+		h = sha512.New
+	default:
+		return nil, errors.New("invalid AES CBC HMAC SHA2 key length")
+	}
+	block, err := aes.NewCipher(key[len(key)/2:])
+	if err != nil {
+		return nil, err
+	}
+	return &cbcHMAC{block: block, macKey: key[:len(key)/2], hash: h}, nil
+}
+
+func (c *cbcHMAC) NonceSize() int { return aes.BlockSize }
+
+// Overhead returns the length of the authentication tag, the MAC truncated to the MAC key length.
+func (c *cbcHMAC) Overhead() int { return len(c.macKey) }
+
+func (c *cbcHMAC) tag(nonce, ciphertext, additionalData []byte) []byte {
+	m := hmac.New(c.hash, c.macKey)
+	m.Write(additionalData)
+	m.Write(nonce)
+	m.Write(ciphertext)
+	al := make([]byte, 8)
+	binary.BigEndian.PutUint64(al, uint64(len(additionalData))*8)
+	m.Write(al)
+	return m.Sum(nil)[:c.Overhead()]
+}
+
+func (c *cbcHMAC) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	n := aes.BlockSize - len(plaintext)%aes.BlockSize
+	ciphertext := append(append([]byte{}, plaintext...), bytes.Repeat([]byte{byte(n)}, n)...)
+	cipher.NewCBCEncrypter(c.block, nonce).CryptBlocks(ciphertext, ciphertext)
+	return append(append(dst, ciphertext...), c.tag(nonce, ciphertext, additionalData)...)
+}
+
+func (c *cbcHMAC) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != c.NonceSize() || len(ciphertext) < c.Overhead() {
+		return nil, errors.New("message authentication failed")
+	}
+	ciphertext, tag := ciphertext[:len(ciphertext)-c.Overhead()], ciphertext[len(ciphertext)-c.Overhead():]
+	if subtle.ConstantTimeCompare(tag, c.tag(nonce, ciphertext, additionalData)) != 1 {
+		return nil, errors.New("message authentication failed")
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid ciphertext length")
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(c.block, nonce).CryptBlocks(plaintext, ciphertext)
+	n := int(plaintext[len(plaintext)-1])
+	if n == 0 || n > aes.BlockSize || !bytes.Equal(plaintext[len(plaintext)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, errors.New("invalid padding")
+	}
+	return append(dst, plaintext[:len(plaintext)-n]...), nil
+}
+
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// aesKeyWrap wraps the key with the key encryption key, RFC 3394 section 2.2.1.
+// The jwe package of jwx v1.0.5 does not implement the PBES2 algorithms and its key wrap is internal.
+func aesKeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key)%8 != 0 || len(key) < 16 {
+		return nil, errors.New("invalid key length to wrap")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	r := make([]byte, len(key))
+	copy(r, key)
+	a := make([]byte, 8)
+	copy(a, keyWrapIV)
+	b := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 0; i < n; i++ {
+			copy(b, a)
+			copy(b[8:], r[i*8:])
+			block.Encrypt(b, b)
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(a, binary.BigEndian.Uint64(b[:8])^t)
+			copy(r[i*8:], b[8:])
+		}
+	}
+	return append(a, r...), nil
+}
+
+// aesKeyUnwrap unwraps the wrapped key with the key encryption key, RFC 3394 section 2.2.2.
+func aesKeyUnwrap(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped)%8 != 0 || len(wrapped) < 24 {
+		return nil, errors.New("invalid wrapped key length")
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	r := make([]byte, n*8)
+	copy(r, wrapped[8:])
+	a := make([]byte, 8)
+	copy(a, wrapped[:8])
+	b := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n - 1; i >= 0; i-- {
+			t := uint64(n*j + i + 1)
+			binary.BigEndian.PutUint64(b, binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], r[i*8:i*8+8])
+			block.Decrypt(b, b)
+			copy(a, b[:8])
+			copy(r[i*8:], b[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, errors.New("integrity check failed")
+	}
+	return r, nil
+}
+
+// PassphraseFunc returns the passphrase of the encrypted key files, see ReadKeyFile.
+type PassphraseFunc func() ([]byte, error)
+
+// PassphraseFromEnv reads the passphrase from the environment variable.
+func PassphraseFromEnv(name string) PassphraseFunc {
+	return func() ([]byte, error) {
+		s, ok := os.LookupEnv(name)
+		if !ok || s == "" {
+			return nil, fmt.Errorf("passphrase environment variable %s not set", name)
+		}
+		return []byte(s), nil
+	}
+}
+
+// PassphraseFromFD reads the passphrase from the first line of the file descriptor, e.g. a pipe
+// passed by the process manager, so that it is not in the environment. The file descriptor is closed
+// after reading, it must not be used by the caller anymore.
+func PassphraseFromFD(fd uintptr) PassphraseFunc {
+	return func() ([]byte, error) {
+		f := os.NewFile(fd, "passphrase")
+		if f == nil {
+			return nil, fmt.Errorf("invalid passphrase file descriptor %d", fd)
+		}
+		defer f.Close()
+		line, err := bufio.NewReader(f).ReadString('\n')
+		if err != nil && line == "" {
+			return nil, fmt.Errorf("failed to read passphrase from file descriptor %d: %s", fd, err.Error())
+		}
+		return []byte(strings.TrimRight(line, "\r\n")), nil
+	}
+}
+
+// ReadKeyFile reads the private JWK or JWK set from the file, it is decrypted with the passphrase
+// if it is encrypted (see EncryptKeyFile). The passphrase is not called for a plaintext file.
+func ReadKeyFile(filename string, passphrase PassphraseFunc) (*JWKSet, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyFile(data, passphrase)
+}
+
+// ParseKeyFile parses the private JWK or JWK set like ReadKeyFile.
+func ParseKeyFile(data []byte, passphrase PassphraseFunc) (*JWKSet, error) {
+	if IsEncryptedKeyFile(data) {
+		if passphrase == nil {
+			return nil, errors.New("otgo.ReadKeyFile: the key file is encrypted, passphrase required")
+		}
+		p, err := passphrase()
+		if err != nil {
+			return nil, fmt.Errorf("otgo.ReadKeyFile: %s", err.Error())
+		}
+		if data, err = DecryptKeyFile(data, p); err != nil {
+			return nil, err
+		}
+	}
+	ks, err := ParseSet(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(ks.Keys) == 0 {
+		return nil, errors.New("otgo.ReadKeyFile: no keys")
+	}
+	return ks, nil
+}
+
+// WithKeyFile signs the subject's OTVIDs with the private keys of the key file, it is decrypted with
+// the passphrase if it is encrypted, see ReadKeyFile.
+func WithKeyFile(filename string, passphrase PassphraseFunc) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		ks, err := ReadKeyFile(filename, passphrase)
+		if err != nil {
+			return err
+		}
+		oc.SetPrivateKeys(*ks)
+		return nil
+	})
+}
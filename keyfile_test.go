@@ -0,0 +1,246 @@
+package otgo_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyFile(t *testing.T) {
+	key := otgo.MustPrivateKey("ES256")
+	keyData, _ := json.Marshal(key)
+	passphrase := []byte("correct horse battery staple")
+
+	t.Run("EncryptKeyFile and DecryptKeyFile func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		data, err := otgo.EncryptKeyFile(keyData, passphrase)
+		assert.Nil(err)
+		assert.True(otgo.IsEncryptedKeyFile(data))
+		assert.False(otgo.IsEncryptedKeyFile(keyData))
+		assert.NotContains(string(data), key.KeyID())
+
+		plaintext, err := otgo.DecryptKeyFile(data, passphrase)
+		assert.Nil(err)
+		assert.Equal(keyData, plaintext)
+		// trailing newline of the file
+		plaintext, err = otgo.DecryptKeyFile(append(data, '\n'), passphrase)
+		assert.Nil(err)
+		assert.Equal(keyData, plaintext)
+
+		_, err = otgo.DecryptKeyFile(data, []byte("wrong"))
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "wrong passphrase")
+
+		parts := strings.Split(string(data), ".")
+		if parts[3][0] == 'A' {
+			parts[3] = "B" + parts[3][1:]
+		} else {
+			parts[3] = "A" + parts[3][1:]
+		}
+		_, err = otgo.DecryptKeyFile([]byte(strings.Join(parts, ".")), passphrase)
+		assert.NotNil(err)
+
+		_, err = otgo.DecryptKeyFile(keyData, passphrase)
+		assert.NotNil(err)
+		_, err = otgo.EncryptKeyFile(keyData, nil)
+		assert.NotNil(err)
+		_, err = otgo.EncryptKeyFile([]byte("{}"), passphrase)
+		assert.NotNil(err)
+	})
+
+	t.Run("ParseKeyFile func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ks := otgo.MustKeys(key, otgo.MustPrivateKey("PS256"))
+		setData, _ := json.Marshal(ks)
+		data, err := otgo.EncryptKeyFile(setData, passphrase)
+		assert.Nil(err)
+
+		os.Setenv("OTGO_TEST_PASSPHRASE", string(passphrase))
+		defer os.Unsetenv("OTGO_TEST_PASSPHRASE")
+		res, err := otgo.ParseKeyFile(data, otgo.PassphraseFromEnv("OTGO_TEST_PASSPHRASE"))
+		assert.Nil(err)
+		assert.Equal(2, len(res.Keys))
+		assert.Equal(key.KeyID(), res.Keys[0].KeyID())
+
+		_, err = otgo.ParseKeyFile(data, nil)
+		assert.NotNil(err)
+		_, err = otgo.ParseKeyFile(data, otgo.PassphraseFromEnv("OTGO_TEST_PASSPHRASE_UNSET"))
+		assert.NotNil(err)
+
+		// a plaintext file doesn't need the passphrase
+		res, err = otgo.ParseKeyFile(keyData, nil)
+		assert.Nil(err)
+		assert.Equal(1, len(res.Keys))
+	})
+
+	t.Run("ReadKeyFile func and WithKeyFile", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dir, err := ioutil.TempDir("", "otgo")
+		assert.Nil(err)
+		defer os.RemoveAll(dir)
+		filename := filepath.Join(dir, "key.jwe")
+		data, err := otgo.EncryptKeyFile(keyData, passphrase)
+		assert.Nil(err)
+		assert.Nil(ioutil.WriteFile(filename, data, 0600))
+
+		pf := func() ([]byte, error) { return passphrase, nil }
+		ks, err := otgo.ReadKeyFile(filename, pf)
+		assert.Nil(err)
+		assert.Equal(key.KeyID(), ks.Keys[0].KeyID())
+		_, err = otgo.ReadKeyFile(filepath.Join(dir, "none"), pf)
+		assert.NotNil(err)
+
+		td := otgo.TrustDomain("localhost")
+		var signs int32
+		ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), &signs)
+		defer ts.Close()
+		sub := td.NewOTID("app", "123")
+
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL),
+			otgo.WithKeyFile(filename, pf))
+		assert.Nil(err)
+		_, err = oc.DomainToken(context.Background())
+		assert.Nil(err)
+
+		_, err = otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL),
+			otgo.WithKeyFile(filename, func() ([]byte, error) { return []byte("wrong"), nil }))
+		assert.NotNil(err)
+	})
+
+	t.Run("AES key wrap RFC 3394 test vectors", func(t *testing.T) {
+		assert := assert.New(t)
+
+		kek := "000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F"
+		data := "00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F"
+		for _, v := range []struct {
+			section, kek, data, wrapped string
+		}{
+			{"4.1", kek[:32], data[:32], "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"},
+			{"4.2", kek[:48], data[:32], "96778B25AE6CA435F92B5B97C050AED2468AB8A17AD84E5D"},
+			{"4.3", kek, data[:32], "64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7"},
+			{"4.4", kek[:48], data[:48], "031D33264E15D33268F24EC260743EDCE1C6C7DDEE725A936BA814915C6762D2"},
+			{"4.5", kek, data[:48], "A8F9BC1612C68B3FF6E6F4FBE30E71E4769C8B80A32CB8958CD5D17D6B254DA1"},
+			{"4.6", kek, data, "28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21"},
+		} {
+			kek, _ := hex.DecodeString(v.kek)
+			key, _ := hex.DecodeString(v.data)
+			wrapped, _ := hex.DecodeString(v.wrapped)
+
+			b, err := otgo.AESKeyWrap(kek, key)
+			assert.Nil(err, v.section)
+			assert.Equal(wrapped, b, v.section)
+			b, err = otgo.AESKeyUnwrap(kek, wrapped)
+			assert.Nil(err, v.section)
+			assert.Equal(key, b, v.section)
+
+			wrapped[len(wrapped)-1] ^= 1
+			_, err = otgo.AESKeyUnwrap(kek, wrapped)
+			assert.NotNil(err, v.section)
+		}
+	})
+
+	t.Run("DecryptKeyFile func with RFC 7517 Appendix C example", func(t *testing.T) {
+		assert := assert.New(t)
+
+		// PBES2-HS256+A128KW and A128CBC-HS256, https://tools.ietf.org/html/rfc7517#appendix-C.7
+		data := []byte(strings.Join(strings.Fields(rfc7517KeyFile), ""))
+		plaintext, err := otgo.DecryptKeyFile(data, []byte("Thus from my lips, by yours, my sin is purged."))
+		assert.Nil(err)
+		assert.Equal(strings.Join(strings.Fields(rfc7517Key), ""), string(plaintext))
+
+		_, err = otgo.DecryptKeyFile(data, []byte("Thus from my lips, by yours, my sin is purged"))
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "wrong passphrase")
+
+		// tampered ciphertext
+		i := bytes.LastIndexByte(data, '.') - 1
+		data[i] ^= 'A' ^ 'B'
+		_, err = otgo.DecryptKeyFile(data, []byte("Thus from my lips, by yours, my sin is purged."))
+		assert.NotNil(err)
+	})
+}
+
+// rfc7517Key is the plaintext private JWK of RFC 7517 Appendix C.1, the white spaces are for display only.
+const rfc7517Key = `{"kty":"RSA","kid":"juliet@capulet.lit","use":"enc",
+	"n":"t6Q8PWSi1dkJj9hTP8hNYFlvadM7DflW9mWepOJhJ66w7nyoK1gPNqFMSQRy
+	O125Gp-TEkodhWr0iujjHVx7BcV0llS4w5ACGgPrcAd6ZcSR0-Iqom-QFcNP
+	8Sjg086MwoqQU_LYywlAGZ21WSdS_PERyGFiNnj3QQlO8Yns5jCtLCRwLHL0
+	Pb1fEv45AuRIuUfVcPySBWYnDyGxvjYGDSM-AqWS9zIQ2ZilgT-GqUmipg0X
+	OC0Cc20rgLe2ymLHjpHciCKVAbY5-L32-lSeZO-Os6U15_aXrk9Gw8cPUaX1
+	_I8sLGuSiVdt3C_Fn2PZ3Z8i744FPFGGcG1qs2Wz-Q",
+	"e":"AQAB",
+	"d":"GRtbIQmhOZtyszfgKdg4u_N-R_mZGU_9k7JQ_jn1DnfTuMdSNprTeaSTyWfS
+	NkuaAwnOEbIQVy1IQbWVV25NY3ybc_IhUJtfri7bAXYEReWaCl3hdlPKXy9U
+	vqPYGR0kIXTQRqns-dVJ7jahlI7LyckrpTmrM8dWBo4_PMaenNnPiQgO0xnu
+	ToxutRZJfJvG4Ox4ka3GORQd9CsCZ2vsUDmsXOfUENOyMqADC6p1M3h33tsu
+	rY15k9qMSpG9OX_IJAXmxzAh_tWiZOwk2K4yxH9tS3Lq1yX8C1EWmeRDkK2a
+	hecG85-oLKQt5VEpWHKmjOi_gJSdSgqcN96X52esAQ",
+	"p":"2rnSOV4hKSN8sS4CgcQHFbs08XboFDqKum3sc4h3GRxrTmQdl1ZK9uw-PIHf
+	QP0FkxXVrx-WE-ZEbrqivH_2iCLUS7wAl6XvARt1KkIaUxPPSYB9yk31s0Q8
+	UK96E3_OrADAYtAJs-M3JxCLfNgqh56HDnETTQhH3rCT5T3yJws",
+	"q":"1u_RiFDP7LBYh3N4GXLT9OpSKYP0uQZyiaZwBtOCBNJgQxaj10RWjsZu0c6I
+	edis4S7B_coSKB0Kj9PaPaBzg-IySRvvcQuPamQu66riMhjVtG6TlV8CLCYK
+	rYl52ziqK0E_ym2QnkwsUX7eYTB7LbAHRK9GqocDE5B0f808I4s",
+	"dp":"KkMTWqBUefVwZ2_Dbj1pPQqyHSHjj90L5x_MOzqYAJMcLMZtbUtwKqvVDq3
+	tbEo3ZIcohbDtt6SbfmWzggabpQxNxuBpoOOf_a_HgMXK_lhqigI4y_kqS1w
+	Y52IwjUn5rgRrJ-yYo1h41KR-vz2pYhEAeYrhttWtxVqLCRViD6c",
+	"dq":"AvfS0-gRxvn0bwJoMSnFxYcK1WnuEjQFluMGfwGitQBWtfZ1Er7t1xDkbN9
+	GQTB9yqpDoYaN06H7CFtrkxhJIBQaj6nkF5KKS3TQtQ5qCzkOkmxIe3KRbBy
+	mXxkb5qwUpX5ELD5xFc6FeiafWYY63TmmEAu_lRFCOJ3xDea-ots",
+	"qi":"lSQi-w9CpyUReMErP1RsBLk7wNtOvs5EQpPqmuMvqW57NBUczScEoPwmUqq
+	abu9V0-Py4dQ57_bapoKRu1R90bvuFnU63SHWEFglZQvJDMeAvmj4sm-Fp0o
+	Yu_neotgQ0hzbI5gry7ajdYy9-2lNx_76aBZoOUu9HCJ-UsfSOI8"}`
+
+// rfc7517KeyFile is the JWE encrypted rfc7517Key of RFC 7517 Appendix C.7.
+const rfc7517KeyFile = `eyJhbGciOiJQQkVTMi1IUzI1NitBMTI4S1ciLCJwMnMiOiIyV0NUY0paMVJ2ZF9DSn
+	VKcmlwUTF3IiwicDJjIjo0MDk2LCJlbmMiOiJBMTI4Q0JDLUhTMjU2IiwiY3R5Ijoi
+	andrK2pzb24ifQ.
+	TrqXOwuNUfDV9VPTNbyGvEJ9JMjefAVn-TR1uIxR9p6hsRQh9Tk7BA.
+	Ye9j1qs22DmRSAddIh-VnA.
+	AwhB8lxrlKjFn02LGWEqg27H4Tg9fyZAbFv3p5ZicHpj64QyHC44qqlZ3JEmnZTgQo
+	wIqZJ13jbyHB8LgePiqUJ1hf6M2HPLgzw8L-mEeQ0jvDUTrE07NtOerBk8bwBQyZ6g
+	0kQ3DEOIglfYxV8-FJvNBYwbqN1Bck6d_i7OtjSHV-8DIrp-3JcRIe05YKy3Oi34Z_
+	GOiAc1EK21B11c_AE11PII_wvvtRiUiG8YofQXakWd1_O98Kap-UgmyWPfreUJ3lJP
+	nbD4Ve95owEfMGLOPflo2MnjaTDCwQokoJ_xplQ2vNPz8iguLcHBoKllyQFJL2mOWB
+	wqhBo9Oj-O800as5mmLsvQMTflIrIEbbTMzHMBZ8EFW9fWwwFu0DWQJGkMNhmBZQ-3
+	lvqTc-M6-gWA6D8PDhONfP2Oib2HGizwG1iEaX8GRyUpfLuljCLIe1DkGOewhKuKkZ
+	h04DKNM5Nbugf2atmU9OP0Ldx5peCUtRG1gMVl7Qup5ZXHTjgPDr5b2N731UooCGAU
+	qHdgGhg0JVJ_ObCTdjsH4CF1SJsdUhrXvYx3HJh2Xd7CwJRzU_3Y1GxYU6-s3GFPbi
+	rfqqEipJDBTHpcoCmyrwYjYHFgnlqBZRotRrS95g8F95bRXqsaDY7UgQGwBQBwy665
+	d0zpvTasvfXf_c0MWAl-neFaKOW_Px6g4EUDjG1GWSXV9cLStLw_0ovdApDIFLHYHe
+	PyagyHjouQUuGiq7BsYwYrwaF06tgB8hV8omLNfMEmDPJaZUzMuHw6tBDwGkzD-tS_
+	ub9hxrpJ4UsOWnt5rGUyoN2N_c1-TQlXxm5oto14MxnoAyBQBpwIEgSH3Y4ZhwKBhH
+	PjSo0cdwuNdYbGPpb-YUvF-2NZzODiQ1OvWQBRHSbPWYz_xbGkgD504LRtqRwCO7CC
+	_CyyURi1sEssPVsMJRX_U4LFEOc82TiDdqjKOjRUfKK5rqLi8nBE9soQ0DSaOoFQZi
+	GrBrqxDsNYiAYAmxxkos-i3nX4qtByVx85sCE5U_0MqG7COxZWMOPEFrDaepUV-cOy
+	rvoUIng8i8ljKBKxETY2BgPegKBYCxsAUcAkKamSCC9AiBxA0UOHyhTqtlvMksO7AE
+	hNC2-YzPyx1FkhMoS4LLe6E_pFsMlmjA6P1NSge9C5G5tETYXGAn6b1xZbHtmwrPSc
+	ro9LWhVmAaA7_bxYObnFUxgWtK4vzzQBjZJ36UTk4OTB-JvKWgfVWCFsaw5WCHj6Oo
+	4jpO7d2yN7WMfAj2hTEabz9wumQ0TMhBduZ-QON3pYObSy7TSC1vVme0NJrwF_cJRe
+	hKTFmdlXGVldPxZCplr7ZQqRQhF8JP-l4mEQVnCaWGn9ONHlemczGOS-A-wwtnmwjI
+	B1V_vgJRf4FdpV-4hUk4-QLpu3-1lWFxrtZKcggq3tWTduRo5_QebQbUUT_VSCgsFc
+	OmyWKoj56lbxthN19hq1XGWbLGfrrR6MWh23vk01zn8FVwi7uFwEnRYSafsnWLa1Z5
+	TpBj9GvAdl2H9NHwzpB5NqHpZNkQ3NMDj13Fn8fzO0JB83Etbm_tnFQfcb13X3bJ15
+	Cz-Ww1MGhvIpGGnMBT_ADp9xSIyAM9dQ1yeVXk-AIgWBUlN5uyWSGyCxp0cJwx7HxM
+	38z0UIeBu-MytL-eqndM7LxytsVzCbjOTSVRmhYEMIzUAnS1gs7uMQAGRdgRIElTJE
+	SGMjb_4bZq9s6Ve1LKkSi0_QDsrABaLe55UY0zF4ZSfOV5PMyPtocwV_dcNPlxLgNA
+	D1BFX_Z9kAdMZQW6fAmsfFle0zAoMe4l9pMESH0JB4sJGdCKtQXj1cXNydDYozF7l8
+	H00BV_Er7zd6VtIw0MxwkFCTatsv_R-GsBCH218RgVPsfYhwVuT8R4HarpzsDBufC4
+	r8_c8fc9Z278sQ081jFjOja6L2x0N_ImzFNXU6xwO-Ska-QeuvYZ3X_L31ZOX4Llp-
+	7QSfgDoHnOxFv1Xws-D5mDHD3zxOup2b2TppdKTZb9eW2vxUVviM8OI9atBfPKMGAO
+	v9omA-6vv5IxUH0-lWMiHLQ_g8vnswp-Jav0c4t6URVUzujNOoNd_CBGGVnHiJTCHl
+	88LQxsqLHHIu4Fz-U2SGnlxGTj0-ihit2ELGRv4vO8E1BosTmf0cx3qgG0Pq0eOLBD
+	IHsrdZ_CCAiTc0HVkMbyq1M6qEhM-q5P6y1QCIrwg.
+	0HFmhOzsQ98nNWJjIHkR7A`
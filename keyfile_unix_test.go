@@ -0,0 +1,35 @@
+//go:build !windows
+// +build !windows
+
+package otgo_test
+
+import (
+	"encoding/json"
+	"os"
+	"syscall"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPassphraseFromFD(t *testing.T) {
+	assert := assert.New(t)
+
+	passphrase := []byte("correct horse battery staple")
+	keyData, _ := json.Marshal(otgo.MustPrivateKey("ES256"))
+	data, err := otgo.EncryptKeyFile(keyData, passphrase)
+	assert.Nil(err)
+
+	r, w, err := os.Pipe()
+	assert.Nil(err)
+	defer r.Close()
+	w.Write(append(passphrase, '\n'))
+	w.Close()
+	// PassphraseFromFD closes the file descriptor, pass a duplicate so that r is not closed twice
+	fd, err := syscall.Dup(int(r.Fd()))
+	assert.Nil(err)
+	ks, err := otgo.ParseKeyFile(data, otgo.PassphraseFromFD(uintptr(fd)))
+	assert.Nil(err)
+	assert.Equal(1, len(ks.Keys))
+}
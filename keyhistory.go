@@ -0,0 +1,85 @@
+package otgo
+
+import (
+	"fmt"
+	"time"
+)
+
+// keyHistory keeps the previously fetched JWK sets replaced by the key refreshes, newest first.
+type keyHistory struct {
+	size      int
+	retention time.Duration
+	sets      []retiredKeys
+}
+
+type retiredKeys struct {
+	ks        *JWKSet
+	retiredAt time.Time
+}
+
+func (h *keyHistory) push(ks *JWKSet, now time.Time) {
+	if h.size <= 0 || h.retention <= 0 {
+		return
+	}
+	h.sets = append([]retiredKeys{{ks: ks, retiredAt: now}}, h.sets...)
+	if len(h.sets) > h.size {
+		h.sets = h.sets[:h.size]
+	}
+}
+
+// active returns the key sets retired in the retention, newest first.
+func (h *keyHistory) active(now time.Time) []retiredKeys {
+	var sets []retiredKeys
+	for _, r := range h.sets {
+		if now.Sub(r.retiredAt) >= h.retention {
+			break
+		}
+		sets = append(sets, r)
+	}
+	return sets
+}
+
+// WithKeyHistory keeps the previous n fetched JWK sets for the retention, see SetKeyHistory.
+func WithKeyHistory(n int, retention time.Duration) VerifierOption {
+	return func(o *verifierOptions) {
+		o.historySize, o.historyRetention = n, retention
+	}
+}
+
+// SetKeyHistory keeps the previous n fetched JWK sets for the retention after a refresh replaces them,
+// the OTVIDs signed with a key ID not in the current JWK set are verified with the retained sets,
+// so the OTVIDs signed moments before a key rotation are not rejected. A retained set only verifies
+// the OTVIDs with 'iat' claim issued before it was replaced, so that a removed key can not sign new OTVIDs.
+// The history is disabled by default and if n or retention is 0, it only applies to the keys fetched
+// from the trust domain's configuration. The pinned keys (see PinKeys) are still required.
+func (v *Verifier) SetKeyHistory(n int, retention time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.history.size, v.history.retention = n, retention
+	if n <= 0 || retention <= 0 {
+		v.history.sets = nil
+	} else if len(v.history.sets) > n {
+		v.history.sets = v.history.sets[:n]
+	}
+}
+
+// parseHistorical verifies the OTVID with the newest retained JWK set that has its key ID,
+// it returns err if no set has. The OTVID should be issued before the set was replaced.
+func (s *verifierKeys) parseHistorical(td TrustDomain, d *decodedOTVID, aud OTID, err error) (*OTVID, error) {
+	if hasKeyID(s.ks, d.header.Kid) {
+		return nil, err
+	}
+	for _, r := range s.history {
+		if hasKeyID(r.ks, d.header.Kid) {
+			if iat := d.vid.IssuedAt; iat.IsZero() || iat.After(r.retiredAt) {
+				return nil, fmt.Errorf("otgo.ParseOTVID: key %q was retired before the OTVID was issued", d.header.Kid)
+			}
+			return d.parseDelegated(r.ks, td, s.issuers, s.delegated, aud)
+		}
+	}
+	return nil, err
+}
+
+func hasKeyID(ks *JWKSet, kid string) bool {
+	return ks != nil && kid != "" && len(ks.LookupKeyID(kid)) > 0
+}
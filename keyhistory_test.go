@@ -0,0 +1,126 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyHistory(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	key1 := otgo.MustPrivateKey("ES256")
+	key2 := otgo.MustPrivateKey("ES256")
+	key3 := otgo.MustPrivateKey("ES256")
+
+	var mu sync.Mutex
+	keys := []otgo.Key{key1}
+	setKeys := func(ks ...otgo.Key) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = ks
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		b, _ := json.Marshal(map[string]interface{}{
+			"otid": td.OTID(),
+			"keys": otgo.LookupPublicKeys(otgo.MustKeys(keys...)).Keys,
+		})
+		w.Write(b)
+	}))
+	defer ts.Close()
+	cli := otgo.NewClient(nil)
+	cli.ConstraintEndpoint = ts.URL
+
+	fc := otgo.NewFakeClock(time.Now())
+	defer otgo.SetClock(otgo.SetClock(fc))
+	sign := func(key otgo.Key) string {
+		return mustSignOTVID(&otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud, Expiry: fc.Now().Add(3 * time.Hour)}, key)
+	}
+
+	t.Run("Verifier with key history", func(t *testing.T) {
+		assert := assert.New(t)
+		setKeys(key1)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithHTTPClient(cli),
+			otgo.WithAutoRefresh(-1), otgo.WithKeyHistory(1, time.Hour))
+		assert.Nil(err)
+		token1 := sign(key1)
+		_, err = v.ParseOTVID(token1)
+		assert.Nil(err)
+
+		setKeys(key2)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token1)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(key2))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(key3))
+		assert.NotNil(err)
+
+		// the retired key can not sign new OTVIDs
+		fc.Advance(time.Minute)
+		_, err = v.ParseOTVID(sign(key1))
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "retired")
+		_, err = v.ParseOTVID(token1)
+		assert.Nil(err)
+
+		// out of the retention
+		fc.Advance(time.Hour)
+		_, err = v.ParseOTVID(token1)
+		assert.NotNil(err)
+
+		// out of the size
+		token2 := sign(key2)
+		setKeys(key3)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token2)
+		assert.Nil(err)
+		setKeys(key1)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token2)
+		assert.NotNil(err)
+
+		v.SetKeyHistory(0, 0)
+		_, err = v.ParseOTVID(sign(key3))
+		assert.NotNil(err)
+	})
+
+	t.Run("Verifier without key history", func(t *testing.T) {
+		assert := assert.New(t)
+		setKeys(key1)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithHTTPClient(cli), otgo.WithAutoRefresh(-1))
+		assert.Nil(err)
+		token1 := sign(key1)
+		setKeys(key2)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token1)
+		assert.NotNil(err)
+
+		// no history without retention
+		token2 := sign(key2)
+		v.SetKeyHistory(2, 0)
+		setKeys(key3)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token2)
+		assert.NotNil(err)
+
+		token3 := sign(key3)
+		v.SetKeyHistory(2, time.Hour)
+		setKeys(key1)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token3)
+		assert.Nil(err)
+	})
+}
@@ -0,0 +1,77 @@
+package otgo
+
+import (
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/jwa"
+)
+
+// DefaultRSABits is the size of the RSA keys generated by NewPrivateKey, the recommended RSA key-length.
+const DefaultRSABits = 2048
+
+// KeyOption configures NewPrivateKeyWithOptions.
+type KeyOption func(*keyOptions)
+
+type keyOptions struct {
+	rsaBits int
+	curve   string
+}
+
+// WithRSABits generates the RSA keys of the bits instead of DefaultRSABits, one of 2048, 3072 and 4096,
+// e.g. 3072 for the environments that require it for compliance. It is ignored by the other algorithms.
+func WithRSABits(bits int) KeyOption {
+	return func(o *keyOptions) {
+		o.rsaBits = bits
+	}
+}
+
+// WithCurve generates the ECDSA key on the named curve, one of "P-256", "P-384" and "P-521".
+// The algorithm must be the one for the curve (ES256, ES384 and ES512 respectively),
+// or it is chosen by the curve if empty.
+func WithCurve(crv string) KeyOption {
+	return func(o *keyOptions) {
+		o.curve = crv
+	}
+}
+
+// curveAlgorithms are the ECDSA algorithms of the named curves, RFC 7518 section 3.4.
+var curveAlgorithms = map[string]jwa.SignatureAlgorithm{
+	"P-256": jwa.ES256,
+	"P-384": jwa.ES384,
+	"P-521": jwa.ES512,
+}
+
+func (o *keyOptions) rsaBitsOrDefault() int {
+	if o.rsaBits > 0 {
+		return o.rsaBits
+	}
+	return DefaultRSABits
+}
+
+// NewPrivateKeyWithOptions generates a private key for the algorithm like NewPrivateKey with the options,
+// e.g. a 3072-bit RSA key:
+//
+//	key, err := otgo.NewPrivateKeyWithOptions("PS256", otgo.WithRSABits(3072))
+func NewPrivateKeyWithOptions(alg string, opts ...KeyOption) (Key, error) {
+	o := &keyOptions{}
+	for _, fn := range opts {
+		fn(o)
+	}
+	switch o.rsaBits {
+	case 0, 2048, 3072, 4096:
+	default:
+		return nil, fmt.Errorf("otgo.NewPrivateKey: invalid RSA key size %d", o.rsaBits)
+	}
+	if o.curve != "" {
+		calg, ok := curveAlgorithms[o.curve]
+		if !ok {
+			return nil, fmt.Errorf("otgo.NewPrivateKey: invalid curve '%s'", o.curve)
+		}
+		if alg == "" {
+			alg = calg.String()
+		} else if alg != calg.String() {
+			return nil, fmt.Errorf("otgo.NewPrivateKey: algorithm '%s' not match the curve '%s'", alg, o.curve)
+		}
+	}
+	return newPrivateKey(alg, o)
+}
@@ -0,0 +1,57 @@
+package otgo_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyOptions(t *testing.T) {
+	t.Run("NewPrivateKeyWithOptions func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key, err := otgo.NewPrivateKeyWithOptions("PS256", otgo.WithRSABits(3072))
+		assert.Nil(err)
+		assert.Equal("PS256", key.Algorithm())
+		assert.NotEqual("", key.KeyID())
+		var rk rsa.PrivateKey
+		assert.Nil(key.Raw(&rk))
+		assert.Equal(3072, rk.N.BitLen())
+
+		key, err = otgo.NewPrivateKeyWithOptions("RS256")
+		assert.Nil(err)
+		assert.Nil(key.Raw(&rk))
+		assert.Equal(otgo.DefaultRSABits, rk.N.BitLen())
+
+		_, err = otgo.NewPrivateKeyWithOptions("RS256", otgo.WithRSABits(1024))
+		assert.NotNil(err)
+
+		// the RSA key size is ignored by the other algorithms
+		key, err = otgo.NewPrivateKeyWithOptions("ES256", otgo.WithRSABits(3072))
+		assert.Nil(err)
+		assert.Equal("ES256", key.Algorithm())
+
+		key, err = otgo.NewPrivateKeyWithOptions("", otgo.WithCurve("P-384"))
+		assert.Nil(err)
+		assert.Equal("ES384", key.Algorithm())
+		var ek ecdsa.PrivateKey
+		assert.Nil(key.Raw(&ek))
+		assert.Equal("P-384", ek.Curve.Params().Name)
+
+		key, err = otgo.NewPrivateKeyWithOptions("ES512", otgo.WithCurve("P-521"))
+		assert.Nil(err)
+		assert.Equal("ES512", key.Algorithm())
+
+		_, err = otgo.NewPrivateKeyWithOptions("ES256", otgo.WithCurve("P-384"))
+		assert.NotNil(err)
+		_, err = otgo.NewPrivateKeyWithOptions("RS256", otgo.WithCurve("P-256"))
+		assert.NotNil(err)
+		_, err = otgo.NewPrivateKeyWithOptions("", otgo.WithCurve("secp256k1"))
+		assert.NotNil(err)
+		_, err = otgo.NewPrivateKeyWithOptions("")
+		assert.NotNil(err)
+	})
+}
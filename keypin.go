@@ -0,0 +1,90 @@
+package otgo
+
+import (
+	"fmt"
+	"sync"
+)
+
+// keyPins are the keys pinned by Verifier.PinKeys and Verifier.PinThumbprints.
+type keyPins struct {
+	kids        []string
+	thumbprints []string
+
+	mu     sync.Mutex
+	ks     *JWKSet      // the key set that cached is computed for
+	cached map[Key]bool // whether the keys of the key sets are pinned
+}
+
+// allows reports whether the key that verified a OTVID is pinned, the key is pinned if its kid is pinned
+// or its thumbprint is pinned. The thumbprints of the keys of the key sets (ks and the retained previous
+// key sets) are computed once for every key set, the others' (e.g. the x5c certificates' keys) every time.
+func (p *keyPins) allows(ks *JWKSet, key Key, cacheable bool) bool {
+	if p == nil {
+		return true
+	}
+	if kid := key.KeyID(); kid != "" && stringsHas(p.kids, kid) {
+		return true
+	}
+	if len(p.thumbprints) == 0 {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cached == nil || p.ks != ks {
+		p.ks, p.cached = ks, make(map[Key]bool)
+	}
+	pinned, ok := p.cached[key]
+	if !ok {
+		tp, err := Thumbprint(key)
+		pinned = err == nil && stringsHas(p.thumbprints, tp)
+		if cacheable {
+			p.cached[key] = pinned
+		}
+	}
+	return pinned
+}
+
+// checkPinned checks the key that verified the OTVID's signature.
+func (s *verifierKeys) checkPinned(d *decodedOTVID) error {
+	if d.key == nil || !s.pins.allows(s.ks, d.key, len(d.header.X5C) == 0 || s.roots == nil) {
+		return fmt.Errorf("otgo.Verifier.ParseOTVID: key %q not pinned", d.header.Kid)
+	}
+	return nil
+}
+
+// PinKeys requires that the OTVIDs are signed by the keys with the kids, even if the trust domain's
+// JWK set contains others, e.g. to guard against a compromised secondary key.
+// It replaces the pinned kids, the pinning is disabled if no kid and no thumbprint are pinned.
+func (v *Verifier) PinKeys(kids ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.setPins(kids, v.pins.pinned(true))
+}
+
+// PinThumbprints requires that the OTVIDs are signed by the keys with the RFC 7638 thumbprints (see Thumbprint)
+// like PinKeys, so that the pinning survives a kid change of the same key.
+// It replaces the pinned thumbprints, the pinning is disabled if no kid and no thumbprint are pinned.
+func (v *Verifier) PinThumbprints(thumbprints ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.setPins(v.pins.pinned(false), thumbprints)
+}
+
+func (v *Verifier) setPins(kids, thumbprints []string) {
+	if len(kids) == 0 && len(thumbprints) == 0 {
+		v.pins = nil
+		return
+	}
+	v.pins = &keyPins{kids: kids, thumbprints: thumbprints}
+}
+
+func (p *keyPins) pinned(thumbprints bool) []string {
+	switch {
+	case p == nil:
+		return nil
+	case thumbprints:
+		return p.thumbprints
+	default:
+		return p.kids
+	}
+}
@@ -0,0 +1,101 @@
+package otgo_test
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyPinning(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	key1 := otgo.MustPrivateKey("ES256")
+	key2 := otgo.MustPrivateKey("ES256")
+
+	sign := func(key otgo.Key) string {
+		return mustSignOTVID(&otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud}, key)
+	}
+	token1, token2 := sign(key1), sign(key2)
+
+	t.Run("Verifier.PinKeys method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, key1, key2)
+		assert.Nil(err)
+		v.PinKeys(key1.KeyID())
+		_, err = v.ParseOTVID(token1)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token2)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "not pinned")
+
+		vids, errs := v.ParseOTVIDBatch([]string{token1, token2})
+		assert.NotNil(vids[0])
+		assert.Nil(errs[0])
+		assert.NotNil(errs[1])
+
+		v.PinKeys()
+		_, err = v.ParseOTVID(token2)
+		assert.Nil(err)
+	})
+
+	t.Run("Verifier.PinThumbprints method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, key1, key2)
+		assert.Nil(err)
+		tp, err := otgo.Thumbprint(key2)
+		assert.Nil(err)
+		v.PinThumbprints(tp)
+		_, err = v.ParseOTVID(token1)
+		assert.NotNil(err)
+		_, err = v.ParseOTVID(token2)
+		assert.Nil(err)
+
+		v.PinKeys(key1.KeyID())
+		_, err = v.ParseOTVID(token1)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token2)
+		assert.Nil(err)
+
+		v.PinThumbprints()
+		_, err = v.ParseOTVID(token2)
+		assert.NotNil(err)
+		v.PinKeys()
+		_, err = v.ParseOTVID(token2)
+		assert.Nil(err)
+	})
+
+	t.Run("pinning with x5c certificates", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ca, caKey := newTestCA()
+		leaf, leafKey := newTestLeaf(ca, caKey, "otid:localhost", x509.ExtKeyUsageClientAuth)
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, key1, key2)
+		assert.Nil(err)
+		v.SetRoots(roots)
+		v.AcceptX5C(true)
+		v.PinKeys(key1.KeyID())
+
+		// the x5c certificate's key is not pinned even if the token names a pinned kid
+		leafKey.Set("kid", key1.KeyID())
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}
+		token, err := vid.SignWithX5C(leafKey, []*x509.Certificate{leaf})
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "not pinned")
+
+		tp, err := otgo.Thumbprint(leafKey)
+		assert.Nil(err)
+		v.PinThumbprints(tp)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+	})
+}
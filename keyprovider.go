@@ -0,0 +1,105 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// KeyProvider is a source of keys, e.g. static keys, keys rotated by KeyRotator or keys in a KMS,
+// so that OTVID.SignWith, OTVID.VerifyWith, Verifier and OTClient can use any of them.
+type KeyProvider interface {
+	// SigningKey returns the private key to sign OTVIDs.
+	SigningKey() (Key, error)
+	// VerificationKeys returns the public keys to verify OTVIDs.
+	VerificationKeys() (*JWKSet, error)
+	// Refresh reloads the keys from the source.
+	Refresh(ctx context.Context) error
+}
+
+// StaticKeys returns a KeyProvider with the keys, the signing key is looked up by LookupSigningKey.
+// Refresh is a no-op. ks may contain only public keys if the provider is used for verification only.
+func StaticKeys(ks *JWKSet) KeyProvider {
+	return &staticKeys{ks: ks}
+}
+
+type staticKeys struct {
+	ks *JWKSet
+}
+
+func (s *staticKeys) SigningKey() (Key, error) {
+	return LookupSigningKey(s.ks)
+}
+
+func (s *staticKeys) VerificationKeys() (*JWKSet, error) {
+	if s.ks == nil || len(s.ks.Keys) == 0 {
+		return nil, errors.New("otgo.StaticKeys: no keys exists")
+	}
+	return LookupPublicKeys(s.ks), nil
+}
+
+func (s *staticKeys) Refresh(_ context.Context) error {
+	return nil
+}
+
+// VerificationKeys implements KeyProvider, it returns the public keys of the set.
+func (r *KeyRotator) VerificationKeys() (*JWKSet, error) {
+	return r.PublicKeys(), nil
+}
+
+// Refresh implements KeyProvider, it removes the retired keys out of grace period.
+// The keys are rotated by Rotate only.
+func (r *KeyRotator) Refresh(_ context.Context) error {
+	r.Prune()
+	return nil
+}
+
+// SignWith signs the OTVID with the KeyProvider's signing key.
+func (o *OTVID) SignWith(kp KeyProvider) (string, error) {
+	key, err := kp.SigningKey()
+	if err != nil {
+		return "", err
+	}
+	return o.Sign(key)
+}
+
+// VerifyWith verifies the OTVID like Verify with the KeyProvider's verification keys.
+func (o *OTVID) VerifyWith(kp KeyProvider, issuer, audience OTID) error {
+	ks, err := kp.VerificationKeys()
+	if err != nil {
+		return err
+	}
+	return o.Verify(ks, issuer, audience)
+}
+
+// NewVerifierWithKeyProvider creates a Verifier for the audience that verifies OTVIDs with the
+// KeyProvider's verification keys, they are looked up on every verification and reloaded by RefreshKeys.
+func NewVerifierWithKeyProvider(aud OTID, kp KeyProvider) (*Verifier, error) {
+	if err := aud.Validate(); err != nil {
+		return nil, fmt.Errorf("otgo.NewVerifierWithKeyProvider: invalid audience OTID: %s", err.Error())
+	}
+	if kp == nil {
+		return nil, errors.New("otgo.NewVerifierWithKeyProvider: KeyProvider required")
+	}
+	return &Verifier{aud: aud, td: aud.TrustDomain(), cli: DefaultHTTPClient, kp: kp}, nil
+}
+
+// SetKeyProvider sets the source of the subject's private keys, e.g. a KeyRotator or a KMS,
+// it replaces the keys set by SetPrivateKeys. It is safe for concurrent use like SetPrivateKeys.
+func (oc *OTClient) SetKeyProvider(kp KeyProvider) {
+	oc.kp.Store(keyProviderBox{kp})
+}
+
+// keyProviderBox boxes the KeyProviders of different types for atomic.Value.
+type keyProviderBox struct {
+	KeyProvider
+}
+
+// signingKey returns the subject's signing key.
+func (oc *OTClient) signingKey() (Key, error) {
+	b, _ := oc.kp.Load().(keyProviderBox)
+	if b.KeyProvider == nil {
+		return nil, errors.New("otgo.OTClient: no private keys exists")
+	}
+	return b.SigningKey()
+}
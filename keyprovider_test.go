@@ -0,0 +1,139 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// kmsKeys is a KeyProvider that reloads the keys on Refresh, like a KMS.
+type kmsKeys struct {
+	key       otgo.Key
+	refreshes int
+}
+
+func (k *kmsKeys) SigningKey() (otgo.Key, error) {
+	if k.key == nil {
+		return nil, errors.New("no key")
+	}
+	return k.key, nil
+}
+
+func (k *kmsKeys) VerificationKeys() (*otgo.JWKSet, error) {
+	if k.key == nil {
+		return nil, errors.New("no key")
+	}
+	return otgo.LookupPublicKeys(otgo.MustKeys(k.key)), nil
+}
+
+func (k *kmsKeys) Refresh(_ context.Context) error {
+	k.refreshes++
+	k.key = otgo.MustPrivateKey("ES256")
+	return nil
+}
+
+func TestKeyProvider(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "api")
+
+	t.Run("StaticKeys func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		k1 := otgo.MustPrivateKey("ES256")
+		k2 := otgo.MustPrivateKey("ES256")
+		kp := otgo.StaticKeys(otgo.MustKeys(k2, k1))
+		key, err := kp.SigningKey()
+		assert.Nil(err)
+		assert.Equal(k1.KeyID(), key.KeyID())
+		ks, err := kp.VerificationKeys()
+		assert.Nil(err)
+		assert.Equal(2, len(ks.Keys))
+		assert.Nil(kp.Refresh(context.Background()))
+
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}
+		token, err := vid.SignWith(kp)
+		assert.Nil(err)
+		vid2, err := otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Nil(vid2.VerifyWith(kp, td.OTID(), aud))
+		assert.NotNil(vid2.VerifyWith(otgo.StaticKeys(otgo.MustKeys(k2)), td.OTID(), aud))
+
+		kp = otgo.StaticKeys(nil)
+		_, err = kp.SigningKey()
+		assert.NotNil(err)
+		_, err = kp.VerificationKeys()
+		assert.NotNil(err)
+		_, err = vid.SignWith(kp)
+		assert.NotNil(err)
+	})
+
+	t.Run("KeyRotator as KeyProvider", func(t *testing.T) {
+		assert := assert.New(t)
+
+		r, err := otgo.NewKeyRotator(nil, "ES256", time.Hour)
+		assert.Nil(err)
+		assert.Nil(r.Rotate())
+		var kp otgo.KeyProvider = r
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}
+		token, err := vid.SignWith(kp)
+		assert.Nil(err)
+		assert.Nil(r.Rotate())
+		assert.Nil(kp.Refresh(context.Background()))
+		vid2, err := otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Nil(vid2.VerifyWith(kp, td.OTID(), aud))
+	})
+
+	t.Run("NewVerifierWithKeyProvider func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := otgo.NewVerifierWithKeyProvider(aud, nil)
+		assert.NotNil(err)
+		_, err = otgo.NewVerifierWithKeyProvider(otgo.OTID{}, &kmsKeys{})
+		assert.NotNil(err)
+
+		kp := &kmsKeys{}
+		v, err := otgo.NewVerifierWithKeyProvider(aud, kp)
+		assert.Nil(err)
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}
+		_, err = v.ParseOTVID("eyJhbGciOiJFUzI1NiIsImtpZCI6ImtpZCJ9.eyJzdWIiOiJvdGlkOmxvY2FsaG9zdDp1c2VyOmFiYyIsImlzcyI6Im90aWQ6bG9jYWxob3N0IiwiYXVkIjoib3RpZDpsb2NhbGhvc3Q6c3ZjOmFwaSIsImV4cCI6NDEwMjQ0NDgwMH0.c2ln")
+		assert.NotNil(err)
+
+		assert.Nil(v.RefreshKeys(context.Background()))
+		assert.Equal(1, kp.refreshes)
+		token, err := vid.SignWith(kp)
+		assert.Nil(err)
+		vid2, err := v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.True(vid.ID.Equal(vid2.ID))
+
+		// the rotated keys are used immediately
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+	})
+
+	t.Run("OTClient.SetKeyProvider method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		sub := td.NewOTID("app", "123")
+		oc := otgo.NewOTClient(context.Background(), sub)
+		_, err := oc.SignSelf()
+		assert.NotNil(err)
+
+		kp := &kmsKeys{}
+		oc.SetKeyProvider(kp)
+		_, err = oc.SignSelf()
+		assert.NotNil(err)
+		assert.Nil(kp.Refresh(context.Background()))
+		token, err := oc.SignSelf()
+		assert.Nil(err)
+		vid, err := otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Nil(vid.VerifyWith(kp, sub, td.OTID()))
+	})
+}
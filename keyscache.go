@@ -0,0 +1,64 @@
+package otgo
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// saveDomainConfig writes the trust domain's configuration to the file atomically.
+func saveDomainConfig(path string, res *domainConfigProxy) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, b)
+}
+
+// writeFileAtomic writes the data to the file atomically with mode 0600,
+// so that a crash never leaves a partially written file.
+func writeFileAtomic(path string, b []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), ".otgo-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op after rename
+	if _, err = f.Write(b); err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// loadDomainConfig loads the trust domain's configuration saved by saveDomainConfig,
+// only the configuration signed by the root keys is trusted if roots is not nil.
+func loadDomainConfig(path string, td TrustDomain, minValidKeys int, roots *JWKSet) (*domainConfigProxy, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	res := &domainConfigProxy{}
+	if err = json.Unmarshal(b, res); err != nil {
+		return nil, err
+	}
+	if err = res.verifySigned(roots); err != nil {
+		return nil, err
+	}
+	if err = res.parseKeys(td, minValidKeys); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (oc *OTClient) keysCacheFile(td TrustDomain) string {
+	if oc.KeysCacheDir == "" {
+		return ""
+	}
+	return filepath.Join(oc.KeysCacheDir, string(td)+".json")
+}
@@ -0,0 +1,109 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysCache(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	pk := otgo.MustPrivateKey("ES256")
+	aud := td.NewOTID("app", "123")
+	dir, err := ioutil.TempDir("", "otgo-keys")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(200)
+		if r.URL.Path != "/.well-known/open-trust-configuration" {
+			w.Write([]byte(`{"result": "ok"}`))
+			return
+		}
+		b, _ := json.Marshal(map[string]interface{}{
+			"otid":             td.OTID(),
+			"keys":             otgo.LookupPublicKeys(otgo.MustKeys(pk)).Keys,
+			"serviceEndpoints": []string{"http://localhost:1234"},
+		})
+		w.Write(b)
+	}))
+	url := ts.URL
+	defer ts.Close()
+
+	vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}
+	token, err := vid.Sign(pk)
+	assert.Nil(t, err)
+
+	t.Run("NewCachedVerifier func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		file := filepath.Join(dir, "verifier.json")
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = url
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		_, err := otgo.NewCachedVerifier(ctx, aud, otgo.NewClient(nil), file)
+		assert.NotNil(err)
+
+		v, err := otgo.NewCachedVerifier(ctx, aud, cli, file)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		_, err = os.Stat(file)
+		assert.Nil(err)
+
+		// OT-Auth is unreachable
+		cli = otgo.NewClient(nil)
+		cli.ConstraintEndpoint = "http://127.0.0.1:1"
+		v, err = otgo.NewCachedVerifier(ctx, aud, cli, file)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+	})
+
+	t.Run("OTClient.KeysCacheDir", func(t *testing.T) {
+		assert := assert.New(t)
+
+		oc := otgo.NewOTClient(context.Background(), aud)
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = url
+		oc.KeysCacheDir = dir
+		_, err := oc.ParseOTVID(context.Background(), token)
+		assert.Nil(err)
+
+		oc = otgo.NewOTClient(context.Background(), aud)
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = "http://127.0.0.1:1"
+		_, err = oc.ParseOTVID(context.Background(), token)
+		assert.NotNil(err)
+
+		oc = otgo.NewOTClient(context.Background(), aud)
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = "http://127.0.0.1:1"
+		oc.KeysCacheDir = dir
+		_, err = oc.ParseOTVID(context.Background(), token)
+		assert.Nil(err)
+		cfg, err := oc.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal("http://localhost:1234", cfg.Endpoint)
+
+		// the persisted configuration is not signed by the root keys
+		oc = otgo.NewOTClient(context.Background(), aud)
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = "http://127.0.0.1:1"
+		oc.KeysCacheDir = dir
+		oc.ConfigRootKeys = otgo.LookupPublicKeys(otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+		_, err = oc.ParseOTVID(context.Background(), token)
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,48 @@
+package otgo
+
+import (
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+// checkKeyUsage checks the key's "use" and "key_ops" parameters allow the operation, sign or verify.
+// A key without them can be used for any operation. The encryption keys are always rejected.
+// The "sign" operation allows verify too, the public keys copied from private JWK sets often keep it.
+func checkKeyUsage(k Key, op jwk.KeyOperation) error {
+	if use := k.KeyUsage(); use != "" && use != string(jwk.ForSignature) {
+		return fmt.Errorf("otgo.checkKeyUsage: key %q is for '%s' use, not signature", k.KeyID(), use)
+	}
+	ops := k.KeyOps()
+	if len(ops) == 0 || hasKeyOp(ops, op) || (op == jwk.KeyOpVerify && hasKeyOp(ops, jwk.KeyOpSign)) {
+		return nil
+	}
+	return fmt.Errorf("otgo.checkKeyUsage: key %q does not allow '%s' operation", k.KeyID(), op)
+}
+
+func hasKeyOp(ops jwk.KeyOperationList, op jwk.KeyOperation) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// publicKeyOps replaces the "sign" operation copied from the private key with "verify".
+func publicKeyOps(pub Key) error {
+	ops := pub.KeyOps()
+	if len(ops) == 0 {
+		return nil
+	}
+	rs := make(jwk.KeyOperationList, 0, len(ops))
+	for _, op := range ops {
+		if op == jwk.KeyOpSign {
+			op = jwk.KeyOpVerify
+		}
+		if !hasKeyOp(rs, op) {
+			rs = append(rs, op)
+		}
+	}
+	return pub.Set(jwk.KeyOpsKey, rs)
+}
@@ -0,0 +1,93 @@
+package otgo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyUsage(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "abc")
+	newVID := func() *otgo.OTVID {
+		return &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}
+	}
+
+	t.Run("key_ops", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		assert.Nil(key.Set(jwk.KeyOpsKey, []string{"sign"}))
+		pub, err := otgo.ToPublicKey(key)
+		assert.Nil(err)
+		assert.Equal(jwk.KeyOperationList{jwk.KeyOpVerify}, pub.KeyOps())
+
+		token, err := newVID().Sign(key)
+		assert.Nil(err)
+		_, err = otgo.ParseOTVID(token, otgo.LookupPublicKeys(otgo.MustKeys(key)), td.OTID(), aud)
+		assert.Nil(err)
+
+		assert.Nil(key.Set(jwk.KeyOpsKey, []string{"verify"}))
+		_, err = newVID().Sign(key)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "does not allow 'sign' operation")
+
+		// the "sign" operation allows verify
+		assert.Nil(pub.Set(jwk.KeyOpsKey, []string{"sign"}))
+		_, err = otgo.ParseOTVID(token, otgo.MustKeys(pub), td.OTID(), aud)
+		assert.Nil(err)
+
+		assert.Nil(pub.Set(jwk.KeyOpsKey, []string{"encrypt"}))
+		_, err = otgo.ParseOTVID(token, otgo.MustKeys(pub), td.OTID(), aud)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "does not allow 'verify' operation")
+		_, err = otgo.ParseOTVIDWithLaxKeyUsage(token, otgo.MustKeys(pub), td.OTID(), aud)
+		assert.Nil(err)
+
+		assert.Nil(key.Set(jwk.KeyOpsKey, []string{"encrypt"}))
+		_, err = newVID().SignWithLaxKeyUsage(key)
+		assert.Nil(err)
+	})
+
+	t.Run("use", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		assert.Nil(key.Set(jwk.KeyUsageKey, "sig"))
+		token, err := newVID().Sign(key)
+		assert.Nil(err)
+
+		assert.Nil(key.Set(jwk.KeyUsageKey, "enc"))
+		_, err = newVID().Sign(key)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "is for 'enc' use")
+
+		ks := otgo.LookupPublicKeys(otgo.MustKeys(key))
+		_, err = otgo.ParseOTVID(token, ks, td.OTID(), aud)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "is for 'enc' use")
+	})
+
+	t.Run("Verifier.SetLaxKeyUsage method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		token, err := newVID().Sign(key)
+		assert.Nil(err)
+
+		assert.Nil(key.Set(jwk.KeyUsageKey, "enc"))
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, key)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+
+		v.SetLaxKeyUsage(true)
+		vid, err := v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Equal(aud, vid.Audience)
+	})
+}
@@ -0,0 +1,140 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrClosed is the reason of ClosedError, it can be tested with errors.Is.
+var ErrClosed = errors.New("closed")
+
+// ClosedError is returned by the OTClient after Close or Shutdown from the operations
+// that require the OT-Auth service or the trust domains' configurations.
+type ClosedError struct {
+	Op Op // the rejected operation, e.g. OpSign or OpConfigFetch
+}
+
+func (e *ClosedError) Error() string {
+	return fmt.Sprintf("otgo: %s rejected, the OTClient is closed", e.Op)
+}
+
+// Unwrap ...
+func (e *ClosedError) Unwrap() error {
+	return ErrClosed
+}
+
+// lifecycle tracks the in-flight renewals of a OTClient, so that Shutdown can wait for them.
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
+}
+
+// enter registers a renewal, it returns false if the OTClient is closed.
+func (l *lifecycle) enter() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return false
+	}
+	l.inflight.Add(1)
+	return true
+}
+
+func (l *lifecycle) leave() {
+	l.inflight.Done()
+}
+
+func (l *lifecycle) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// close rejects the new renewals and waits for the in-flight ones until ctx is done.
+func (l *lifecycle) close(ctx context.Context) error {
+	l.mu.Lock()
+	l.closed = true
+	l.mu.Unlock()
+	return waitDone(ctx, func() { l.inflight.Wait() })
+}
+
+// waitDone calls wait in a goroutine and returns ctx.Err() if ctx is done before it returns.
+func waitDone(ctx context.Context, wait func()) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wait()
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkOpen returns a ClosedError if the OTClient is closed.
+func (oc *OTClient) checkOpen(op Op) error {
+	if oc.lifecycle.isClosed() {
+		return &ClosedError{Op: op}
+	}
+	return nil
+}
+
+// Close shuts down the OTClient without a deadline, see Shutdown.
+func (oc *OTClient) Close() error {
+	return oc.Shutdown(context.Background())
+}
+
+// Shutdown closes the OTClient: the new calls to the OT-Auth service and the renewals of the caches
+// are rejected with a ClosedError, the in-flight renewals are waited for until ctx is done,
+// then the cached OTVIDs and trust domains' configurations are dropped from memory.
+// They are written to the Store or TokenStore (if any) on renewal already, so they can still be reused.
+// Shutdown returns ctx.Err() if ctx is done before the in-flight renewals finish, the caches are kept then.
+// It is safe to call Shutdown more than once.
+func (oc *OTClient) Shutdown(ctx context.Context) error {
+	if err := oc.lifecycle.close(ctx); err != nil {
+		return fmt.Errorf("otgo.OTClient.Shutdown: %w", err)
+	}
+	oc.domainCache.flush()
+	oc.serviceCache.flush()
+	return nil
+}
+
+// Closed reports whether the OTClient is closed, see Shutdown.
+func (oc *OTClient) Closed() bool {
+	return oc.lifecycle.isClosed()
+}
+
+// startRefresh refreshes the keys in background until ctx is done or the Verifier is closed.
+func (v *Verifier) startRefresh(ctx context.Context, interval time.Duration) {
+	ctx, cancel := context.WithCancel(ctx)
+	v.stop, v.done = cancel, make(chan struct{})
+	go func() {
+		defer close(v.done)
+		v.refreshKeys(ctx, interval)
+	}()
+}
+
+// Close shuts down the Verifier without a deadline, see Shutdown.
+func (v *Verifier) Close() error {
+	return v.Shutdown(context.Background())
+}
+
+// Shutdown stops the background refresh of the keys and waits for an in-flight refresh until ctx is done.
+// The Verifier still verifies OTVIDs with the last fetched keys, and RefreshKeys still fetches them on demand.
+// It is safe to call Shutdown more than once, and on a Verifier with static keys.
+func (v *Verifier) Shutdown(ctx context.Context) error {
+	if v.stop == nil {
+		return nil
+	}
+	v.stop()
+	if err := waitDone(ctx, func() { <-v.done }); err != nil {
+		return fmt.Errorf("otgo.Verifier.Shutdown: %w", err)
+	}
+	return nil
+}
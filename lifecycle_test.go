@@ -0,0 +1,109 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	sub := td.NewOTID("app", "123")
+	aud := td.NewOTID("svc", "tester")
+	domainKey := otgo.MustPrivateKey("ES256")
+
+	t.Run("Verifier.Shutdown method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pub, _ := otgo.ToPublicKey(domainKey)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := json.Marshal(map[string]interface{}{"otid": td.OTID(), "keys": []otgo.Key{pub}})
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write(b)
+		}))
+		defer ts.Close()
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithHTTPClient(cli),
+			otgo.WithAutoRefresh(time.Hour))
+		assert.Nil(err)
+		assert.Nil(v.Shutdown(context.Background()))
+		assert.True(v.NextRefresh().IsZero())
+		assert.Nil(v.Close())
+
+		// still verifies with the last fetched keys
+		token, err := (&otgo.OTVID{ID: sub, Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}).Sign(domainKey)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Nil(v.RefreshKeys(context.Background()))
+
+		v, err = otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(domainKey))
+		assert.Nil(err)
+		assert.Nil(v.Close())
+	})
+
+	t.Run("OTClient.Shutdown method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		svc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"result": "ok"}`))
+		}))
+		defer svc.Close()
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub,
+			otgo.WithTokenFetcher(otgo.TokenFetcherFunc(func(ctx context.Context, input otgo.SignInput) (*otgo.SignOutput, error) {
+				started <- struct{}{}
+				<-release
+				vid := &otgo.OTVID{ID: input.Subject, Issuer: td.OTID(), Audience: input.Audience,
+					Expiry: time.Now().Add(time.Hour)}
+				token, err := vid.Sign(domainKey)
+				if err != nil {
+					return nil, err
+				}
+				return &otgo.SignOutput{Issuer: td.OTID(), Audience: input.Audience, Expiry: vid.Expiry.Unix(),
+					OTVID: token, ServiceEndpoints: []string{svc.URL}}, nil
+			})))
+		assert.Nil(err)
+
+		resolved := make(chan error, 1)
+		go func() {
+			_, err := oc.Service(aud).Resolve(context.Background())
+			resolved <- err
+		}()
+		<-started
+
+		// the renewal is in flight
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		err = oc.Shutdown(ctx)
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+		assert.True(oc.Closed())
+
+		_, err = oc.Sign(context.Background(), otgo.SignInput{Subject: sub, Audience: aud})
+		assert.True(errors.Is(err, otgo.ErrClosed))
+		var ce *otgo.ClosedError
+		assert.True(errors.As(err, &ce))
+		assert.Equal(otgo.OpSign, ce.Op)
+
+		close(release)
+		assert.Nil(<-resolved)
+		assert.Equal(2, oc.Stats().Tokens.Entries)
+		assert.Nil(oc.Close())
+		assert.Equal(1, oc.Stats().Tokens.Entries) // the pinned OT-Auth entry
+
+		_, err = oc.Service(aud).Resolve(context.Background())
+		assert.True(errors.Is(err, otgo.ErrClosed))
+		_, err = oc.SignBatch(context.Background(), []otgo.SignInput{{Subject: sub, Audience: aud}})
+		assert.True(errors.Is(err, otgo.ErrClosed))
+	})
+}
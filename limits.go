@@ -0,0 +1,50 @@
+package otgo
+
+const (
+	otvidMaxSize = 2048
+	otidMaxSize  = 512
+
+	claimsMaxSize = 8 * otvidMaxSize
+)
+
+// Limits are the size limits of OTVIDs and OTIDs. A zero field uses the default limit.
+type Limits struct {
+	OTVIDMaxSize int // max length of a serialized OTVID, 2048 by default
+	OTIDMaxSize  int // max length of a OTID string, 512 by default
+	// ClaimsMaxSize is the max size of the decompressed 'zcl' claim, 16384 by default, see SetCompressedClaims.
+	ClaimsMaxSize int
+}
+
+// DefaultLimits returns the limits used by the package level functions and by OTClient and Verifier
+// without their own limits. The returned value is a copy, use WithLimits or SetLimits to change them.
+func DefaultLimits() Limits {
+	return Limits{OTVIDMaxSize: otvidMaxSize, OTIDMaxSize: otidMaxSize, ClaimsMaxSize: claimsMaxSize}
+}
+
+// WithLimits parses OTIDs with the limits instead of DefaultLimits.
+func WithLimits(l Limits) ParseOption {
+	return func(o *parseOptions) {
+		o.limits = &l
+	}
+}
+
+func (l *Limits) otvidMaxSize() int {
+	if l != nil && l.OTVIDMaxSize > 0 {
+		return l.OTVIDMaxSize
+	}
+	return otvidMaxSize
+}
+
+func (l *Limits) otidMaxSize() int {
+	if l != nil && l.OTIDMaxSize > 0 {
+		return l.OTIDMaxSize
+	}
+	return otidMaxSize
+}
+
+func (l *Limits) claimsMaxSize() int {
+	if l != nil && l.ClaimsMaxSize > 0 {
+		return l.ClaimsMaxSize
+	}
+	return claimsMaxSize
+}
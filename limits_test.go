@@ -0,0 +1,64 @@
+package otgo_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits(t *testing.T) {
+	t.Run("OTID limits", func(t *testing.T) {
+		assert := assert.New(t)
+
+		s := "otid:localhost:user:" + strings.Repeat("a", 600)
+		_, err := otgo.ParseOTID(s)
+		assert.NotNil(err)
+		id, err := otgo.ParseOTID(s, otgo.WithLimits(otgo.Limits{OTIDMaxSize: 1024}))
+		assert.Nil(err)
+		assert.Equal(s, id.String())
+		assert.NotNil(id.Validate())
+
+		_, err = otgo.ParseOTID("otid:localhost:user:abc", otgo.WithLimits(otgo.Limits{OTIDMaxSize: 10}))
+		assert.NotNil(err)
+	})
+
+	t.Run("OTVID limits", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		aud := td.NewOTID("app", "123")
+		key := otgo.MustPrivateKey("ES256")
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud}
+		vid.Expiry = time.Now().Add(time.Hour)
+		vid.Claims = map[string]interface{}{"name": strings.Repeat("a", 2000)}
+
+		_, err := vid.Sign(key)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "is too large")
+		limits := otgo.Limits{OTVIDMaxSize: 4096}
+		token, err := vid.SignWithLimits(key, limits)
+		assert.Nil(err)
+
+		_, err = otgo.ParseOTVIDInsecure(token)
+		assert.NotNil(err)
+
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, key)
+		assert.Nil(err)
+		assert.Equal(otgo.DefaultLimits(), v.Limits())
+		dl := otgo.DefaultLimits()
+		dl.OTVIDMaxSize = 8192 // a copy
+		assert.Equal(2048, otgo.DefaultLimits().OTVIDMaxSize)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+
+		v.SetLimits(limits)
+		assert.Equal(otgo.Limits{OTVIDMaxSize: 4096, OTIDMaxSize: 512, ClaimsMaxSize: 16384}, v.Limits())
+		vid2, err := v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Equal(vid.Claims["name"], vid2.Claims["name"])
+	})
+}
@@ -0,0 +1,49 @@
+package otgo
+
+// Logger logs the background failures of OTClient and Verifier, such as key refresh and renewal errors,
+// which are otherwise only visible to the next caller. The fields are alternating keys and values,
+// like log/slog. A *slog.Logger implements Logger as is, a zap logger can be adapted with SugaredLogger.
+type Logger interface {
+	Debug(msg string, fields ...interface{})
+	Info(msg string, fields ...interface{})
+	Warn(msg string, fields ...interface{})
+	Error(msg string, fields ...interface{})
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Debug(string, ...interface{}) {}
+func (noopLogger) Info(string, ...interface{})  {}
+func (noopLogger) Warn(string, ...interface{})  {}
+func (noopLogger) Error(string, ...interface{}) {}
+
+func loggerOf(l Logger) Logger {
+	if l == nil {
+		return noopLogger{}
+	}
+	return l
+}
+
+// SugaredLoggerInterface is the interface of the go.uber.org/zap.SugaredLogger methods used by SugaredLogger.
+type SugaredLoggerInterface interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// SugaredLogger adapts a zap logger to Logger without depending on zap:
+//
+//	oc.Logger = otgo.SugaredLogger(zapLogger.Sugar())
+func SugaredLogger(l SugaredLoggerInterface) Logger {
+	return &sugaredLogger{l}
+}
+
+type sugaredLogger struct {
+	l SugaredLoggerInterface
+}
+
+func (s *sugaredLogger) Debug(msg string, fields ...interface{}) { s.l.Debugw(msg, fields...) }
+func (s *sugaredLogger) Info(msg string, fields ...interface{})  { s.l.Infow(msg, fields...) }
+func (s *sugaredLogger) Warn(msg string, fields ...interface{})  { s.l.Warnw(msg, fields...) }
+func (s *sugaredLogger) Error(msg string, fields ...interface{}) { s.l.Errorw(msg, fields...) }
@@ -0,0 +1,9 @@
+//go:build go1.21
+// +build go1.21
+
+package otgo
+
+import "log/slog"
+
+// a *slog.Logger is used as Logger without adapter.
+var _ Logger = (*slog.Logger)(nil)
@@ -0,0 +1,36 @@
+//go:build go1.21
+// +build go1.21
+
+package otgo_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	t.Run("OTClient.Logger with slog", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(500)
+		}))
+		defer ts.Close()
+
+		var buf bytes.Buffer
+		td := otgo.TrustDomain("localhost")
+		oc := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		oc.Logger = slog.New(slog.NewTextHandler(&buf, nil))
+		_, err := oc.Domain(td).Resolve(context.Background())
+		assert.NotNil(err)
+		assert.Contains(buf.String(), `level=WARN msg="otgo: renewal failed" op=domain_cache`)
+	})
+}
@@ -0,0 +1,65 @@
+package otgo_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type testLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (tl *testLogger) log(level, msg string, fields ...interface{}) {
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+	tl.logs = append(tl.logs, fmt.Sprint(level, " ", msg, " ", fields))
+}
+
+func (tl *testLogger) Debugw(msg string, fields ...interface{}) { tl.log("debug", msg, fields...) }
+func (tl *testLogger) Infow(msg string, fields ...interface{})  { tl.log("info", msg, fields...) }
+func (tl *testLogger) Warnw(msg string, fields ...interface{})  { tl.log("warn", msg, fields...) }
+func (tl *testLogger) Errorw(msg string, fields ...interface{}) { tl.log("error", msg, fields...) }
+
+func TestLogger(t *testing.T) {
+	t.Run("SugaredLogger", func(t *testing.T) {
+		assert := assert.New(t)
+
+		tl := &testLogger{}
+		l := otgo.SugaredLogger(tl)
+		l.Debug("a", "k", 1)
+		l.Info("b")
+		l.Warn("c", "k", "v")
+		l.Error("d")
+		assert.Equal([]string{"debug a [k 1]", "info b []", "warn c [k v]", "error d []"}, tl.logs)
+	})
+
+	t.Run("OTClient.Logger", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(500)
+		}))
+		defer ts.Close()
+
+		td := otgo.TrustDomain("localhost")
+		oc := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		_, err := oc.Domain(td).Resolve(context.Background())
+		assert.NotNil(err)
+
+		tl := &testLogger{}
+		oc.Logger = otgo.SugaredLogger(tl)
+		_, err = oc.Domain(td).Resolve(context.Background())
+		assert.NotNil(err)
+		assert.Equal(1, len(tl.logs))
+		assert.Contains(tl.logs[0], "warn otgo: renewal failed [op domain_cache error")
+	})
+}
@@ -0,0 +1,70 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+type maintenanceWindow struct {
+	start, end time.Time
+}
+
+// ScheduleMaintenance prepares the OTClient for a planned OT-Auth downtime from start to end.
+// It renews all cached OTVIDs and trust domain configurations to cover the window right now,
+// suppresses renewals during the window as long as the cached values are still usable,
+// and spreads the renewals after the window with jitter.
+func (oc *OTClient) ScheduleMaintenance(ctx context.Context, start, end time.Time) error {
+	if !end.After(start) || !end.After(clockNow()) {
+		return errors.New("otgo.OTClient.ScheduleMaintenance: invalid maintenance window")
+	}
+
+	var err error
+	oc.domainCache.each(func(obj renewer) {
+		r := obj.(*domainRenewer)
+		r.Lock()
+		defer r.Unlock()
+		if r.endpoint != nullhost {
+			if e := r.renew(ctx, oc); e != nil {
+				if err == nil {
+					err = e
+				}
+				return
+			}
+		}
+		if until := afterWindow(end); r.expiresAt.Before(until) {
+			r.expiresAt = until
+		}
+	})
+	oc.serviceCache.each(func(obj renewer) {
+		r := obj.(*serviceRenewer)
+		r.Lock()
+		defer r.Unlock()
+		until := afterWindow(end)
+		if r.vid == nil || r.vid.Expiry.After(until) {
+			return // never used or already covers the window
+		}
+		if e := r.renewUntil(ctx, oc, until); e != nil && err == nil {
+			err = e
+		}
+	})
+	oc.maintenance.Store(&maintenanceWindow{start: start, end: end})
+	return err
+}
+
+// InMaintenance returns true if the OTClient is in a scheduled maintenance window.
+func (oc *OTClient) InMaintenance() bool {
+	w, ok := oc.maintenance.Load().(*maintenanceWindow)
+	if !ok {
+		return false
+	}
+	now := clockNow()
+	return !now.Before(w.start) && now.Before(w.end)
+}
+
+// afterWindow returns a random time in 1~6 minutes after the window ends,
+// so that the fleet does not renew at the same time.
+func afterWindow(end time.Time) time.Time {
+	return end.Add(time.Minute + time.Duration(rand.Int63n(int64(5*time.Minute))))
+}
@@ -0,0 +1,56 @@
+package otgo_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenance(t *testing.T) {
+	assert := assert.New(t)
+
+	td := otgo.TrustDomain("localhost")
+	domainKey := otgo.MustPrivateKey("ES256")
+	var signs int32
+	ts := newTestOTAuth(td, domainKey, &signs)
+	defer ts.Close()
+
+	cli := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+	cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+	cli.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+	aud := td.NewOTID("svc", "tester")
+	cfg, err := cli.Service(aud).Resolve(context.Background())
+	assert.Nil(err)
+	assert.True(cfg.OTVID.Expiry.Before(time.Now().Add(time.Hour)))
+	assert.Equal(int32(1), atomic.LoadInt32(&signs))
+
+	now := time.Now()
+	assert.NotNil(cli.ScheduleMaintenance(context.Background(), now, now))
+	assert.False(cli.InMaintenance())
+
+	end := now.Add(2 * time.Hour)
+	assert.Nil(cli.ScheduleMaintenance(context.Background(), now, end))
+	assert.True(cli.InMaintenance())
+	assert.Equal(int32(2), atomic.LoadInt32(&signs))
+
+	cfg, err = cli.Service(aud).Resolve(context.Background())
+	assert.Nil(err)
+	assert.True(cfg.OTVID.Expiry.After(end))
+	assert.Equal(int32(2), atomic.LoadInt32(&signs))
+
+	dcfg, err := cli.Domain(td).Resolve(context.Background())
+	assert.Nil(err)
+	assert.Equal(domainKey.KeyID(), dcfg.JWKSet.Keys[0].KeyID())
+
+	// the OTVID already covers the window
+	assert.Nil(cli.ScheduleMaintenance(context.Background(), now, end.Add(-time.Hour)))
+	assert.Equal(int32(2), atomic.LoadInt32(&signs))
+
+	assert.Nil(cli.ScheduleMaintenance(context.Background(), now.Add(time.Hour), end))
+	assert.False(cli.InMaintenance())
+}
@@ -0,0 +1,151 @@
+// Package metrics exports the otgo operations as Prometheus metrics.
+//
+//	m, err := metrics.New(prometheus.DefaultRegisterer)
+//	if err != nil {
+//		return err
+//	}
+//	oc, err := otgo.NewOTClientWithOptions(ctx, sub, otgo.WithOTClientInstrumenter(m))
+//	v, err := otgo.NewVerifierWithOptions(ctx, aud, otgo.WithInstrumenter(m))
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Verification failure reasons, the "reason" label of otgo_verifications_total.
+const (
+	ReasonOK         = "ok"
+	ReasonExpired    = "expired"     // the OTVID is expired
+	ReasonClaims     = "claims"      // the issuer or audience is not satisfied
+	ReasonUnknownKey = "unknown_key" // no public key matches the OTVID's kid
+	ReasonSignature  = "signature"   // the signature is invalid
+	ReasonRevoked    = "revoked"     // the OTVID was revoked, see otgo.ErrRevoked
+	ReasonReplayed   = "replayed"    // the OTVID was replayed, see otgo.ErrReplayed
+	ReasonInvalid    = "invalid"     // any other failure, e.g. a malformed OTVID
+)
+
+// Metrics is an otgo.Instrumenter that records the operations of OTClients and Verifiers:
+//
+//	otgo_signs_total{result}                   OTVIDs signed by OT-Auth
+//	otgo_verifications_total{reason}           local OTVID verifications
+//	otgo_key_refreshes_total{result}           Verifier keys refreshes
+//	otgo_cache_lookups_total{cache,result}     domain and OTVID cache lookups
+//	otgo_request_duration_seconds{op,result}   latencies of the operations, including OT-Auth requests
+//	otgo_inflight_requests{op}                 operations in flight
+type Metrics struct {
+	signs         *prometheus.CounterVec
+	verifications *prometheus.CounterVec
+	refreshes     *prometheus.CounterVec
+	cache         *prometheus.CounterVec
+	duration      *prometheus.HistogramVec
+	inflight      *prometheus.GaugeVec
+}
+
+var _ otgo.Instrumenter = (*Metrics)(nil)
+
+// New creates a Metrics and registers its collectors on reg.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := &Metrics{
+		signs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "otgo",
+			Name:      "signs_total",
+			Help:      "Total number of OTVIDs signed by OT-Auth.",
+		}, []string{"result"}),
+		verifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "otgo",
+			Name:      "verifications_total",
+			Help:      "Total number of local OTVID verifications by reason.",
+		}, []string{"reason"}),
+		refreshes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "otgo",
+			Name:      "key_refreshes_total",
+			Help:      "Total number of Verifier keys refreshes.",
+		}, []string{"result"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "otgo",
+			Name:      "cache_lookups_total",
+			Help:      "Total number of cache lookups.",
+		}, []string{"cache", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "otgo",
+			Name:      "request_duration_seconds",
+			Help:      "Latencies of the otgo operations.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op", "result"}),
+		inflight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "otgo",
+			Name:      "inflight_requests",
+			Help:      "Number of the otgo operations in flight.",
+		}, []string{"op"}),
+	}
+	for _, c := range []prometheus.Collector{m.signs, m.verifications, m.refreshes, m.cache, m.duration, m.inflight} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Start implements the otgo.Instrumenter interface.
+func (m *Metrics) Start(ctx context.Context, op otgo.Op) (context.Context, func(error)) {
+	start := time.Now()
+	inflight := m.inflight.WithLabelValues(string(op))
+	inflight.Inc()
+	return ctx, func(err error) {
+		inflight.Dec()
+		m.duration.WithLabelValues(string(op), result(err)).Observe(time.Since(start).Seconds())
+		switch op {
+		case otgo.OpSign:
+			m.signs.WithLabelValues(result(err)).Inc()
+		case otgo.OpParse:
+			m.verifications.WithLabelValues(Reason(err)).Inc()
+		case otgo.OpKeyRefresh:
+			m.refreshes.WithLabelValues(result(err)).Inc()
+		}
+	}
+}
+
+// CacheLookup implements the otgo.Instrumenter interface.
+func (m *Metrics) CacheLookup(op otgo.Op, hit bool) {
+	res := "miss"
+	if hit {
+		res = "hit"
+	}
+	m.cache.WithLabelValues(string(op), res).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// Reason returns the verification failure reason of the error, or ReasonOK if err is nil.
+func Reason(err error) string {
+	if err == nil {
+		return ReasonOK
+	}
+	if errors.Is(err, otgo.ErrRevoked) {
+		return ReasonRevoked
+	}
+	if errors.Is(err, otgo.ErrReplayed) {
+		return ReasonReplayed
+	}
+	switch {
+	case errors.Is(err, otgo.ErrExpired):
+		return ReasonExpired
+	case errors.Is(err, otgo.ErrInvalidIssuer), errors.Is(err, otgo.ErrInvalidAudience):
+		return ReasonClaims
+	case errors.Is(err, otgo.ErrUnknownKey):
+		return ReasonUnknownKey
+	case errors.Is(err, otgo.ErrInvalidSignature):
+		return ReasonSignature
+	}
+	return ReasonInvalid
+}
@@ -0,0 +1,134 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/open-trust/ot-go-lib/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("New func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		reg := prometheus.NewRegistry()
+		_, err := metrics.New(reg)
+		assert.Nil(err)
+		_, err = metrics.New(reg)
+		assert.NotNil(err)
+	})
+
+	t.Run("Metrics with Verifier", func(t *testing.T) {
+		assert := assert.New(t)
+
+		reg := prometheus.NewRegistry()
+		m, err := metrics.New(reg)
+		assert.Nil(err)
+
+		td := otgo.TrustDomain("localhost")
+		aud := td.NewOTID("svc", "tester")
+		key := otgo.MustPrivateKey("ES256")
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key), otgo.WithInstrumenter(m))
+		assert.Nil(err)
+
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token, td.NewOTID("svc", "other"))
+		assert.NotNil(err)
+
+		other := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud}
+		token, err = other.Sign(otgo.MustPrivateKey("ES256"))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+
+		expected := `
+# HELP otgo_verifications_total Total number of local OTVID verifications by reason.
+# TYPE otgo_verifications_total counter
+otgo_verifications_total{reason="claims"} 1
+otgo_verifications_total{reason="ok"} 1
+otgo_verifications_total{reason="unknown_key"} 1
+`
+		assert.Nil(testutil.GatherAndCompare(reg, strings.NewReader(expected), "otgo_verifications_total"))
+	})
+
+	t.Run("Metrics.Start & Metrics.CacheLookup method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		reg := prometheus.NewRegistry()
+		m, err := metrics.New(reg)
+		assert.Nil(err)
+
+		_, end := m.Start(context.Background(), otgo.OpSign)
+		assert.Nil(testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP otgo_inflight_requests Number of the otgo operations in flight.
+# TYPE otgo_inflight_requests gauge
+otgo_inflight_requests{op="sign"} 1
+`), "otgo_inflight_requests"))
+		end(nil)
+		_, end = m.Start(context.Background(), otgo.OpSign)
+		end(errors.New("error"))
+		_, end = m.Start(context.Background(), otgo.OpKeyRefresh)
+		end(nil)
+		m.CacheLookup(otgo.OpDomainCache, true)
+		m.CacheLookup(otgo.OpDomainCache, false)
+		m.CacheLookup(otgo.OpDomainCache, true)
+
+		expected := `
+# HELP otgo_cache_lookups_total Total number of cache lookups.
+# TYPE otgo_cache_lookups_total counter
+otgo_cache_lookups_total{cache="domain_cache",result="hit"} 2
+otgo_cache_lookups_total{cache="domain_cache",result="miss"} 1
+# HELP otgo_key_refreshes_total Total number of Verifier keys refreshes.
+# TYPE otgo_key_refreshes_total counter
+otgo_key_refreshes_total{result="success"} 1
+# HELP otgo_signs_total Total number of OTVIDs signed by OT-Auth.
+# TYPE otgo_signs_total counter
+otgo_signs_total{result="failure"} 1
+otgo_signs_total{result="success"} 1
+`
+		assert.Nil(testutil.GatherAndCompare(reg, strings.NewReader(expected),
+			"otgo_cache_lookups_total", "otgo_key_refreshes_total", "otgo_signs_total"))
+		n, err := testutil.GatherAndCount(reg, "otgo_request_duration_seconds")
+		assert.Nil(err)
+		assert.Equal(3, n)
+	})
+
+	t.Run("Reason func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		assert.Equal(metrics.ReasonOK, metrics.Reason(nil))
+		assert.Equal(metrics.ReasonRevoked, metrics.Reason(fmt.Errorf("x: %w", otgo.ErrRevoked)))
+		assert.Equal(metrics.ReasonReplayed, metrics.Reason(otgo.ErrReplayed))
+
+		td := otgo.TrustDomain("localhost")
+		key := otgo.MustPrivateKey("ES256")
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: td.NewOTID("svc", "tester"),
+			Expiry: time.Now().Add(-time.Minute)}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		_, err = otgo.ParseOTVID(token, otgo.LookupPublicKeys(otgo.MustKeys(key)), td.OTID(), vid.Audience)
+		assert.Equal(metrics.ReasonExpired, metrics.Reason(err))
+		_, err = otgo.ParseOTVID(token, otgo.LookupPublicKeys(otgo.MustKeys(key)), td.OTID(), td.NewOTID("svc", "other"))
+		assert.Equal(metrics.ReasonClaims, metrics.Reason(err))
+		_, err = otgo.ParseOTVID(token, otgo.LookupPublicKeys(otgo.MustKeys(otgo.MustPrivateKey("ES256"))), td.OTID(), vid.Audience)
+		assert.Equal(metrics.ReasonUnknownKey, metrics.Reason(err))
+		other := otgo.MustPrivateKey("ES256")
+		assert.Nil(other.Set("kid", key.KeyID()))
+		_, err = otgo.ParseOTVID(token, otgo.LookupPublicKeys(otgo.MustKeys(other)), td.OTID(), vid.Audience)
+		assert.Equal(metrics.ReasonSignature, metrics.Reason(err))
+		_, err = otgo.ParseOTVID("abc", otgo.LookupPublicKeys(otgo.MustKeys(key)), td.OTID(), vid.Audience)
+		assert.Equal(metrics.ReasonInvalid, metrics.Reason(err))
+	})
+}
@@ -0,0 +1,110 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// WithMirrors fetches the trust domain's public keys from the mirror URLs in order if the trust domain's
+// configuration URL is unreachable, see SetMirrors.
+func WithMirrors(urls ...string) VerifierOption {
+	return func(o *verifierOptions) {
+		o.mirrors = append(o.mirrors, urls...)
+	}
+}
+
+// SetMirrors sets the mirror URLs of the trust domain's configuration, e.g. an internal mirror or a S3 bucket,
+// for the environments where the well-known URL is unreachable. A mirror serves a copy of the trust domain's
+// configuration or a plain JWK set. The configuration URL and then the mirrors are tried in order,
+// the URLs that failed in the last DefaultEndpointHealthTTL are tried after the others.
+func (v *Verifier) SetMirrors(urls ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.mirrors = append([]string(nil), urls...)
+}
+
+// fetchDomainConfigFrom fetches the trust domain's configuration from the configuration URL and the mirrors.
+func (v *Verifier) fetchDomainConfigFrom(ctx context.Context) (*domainConfigProxy, error) {
+	v.mu.RLock()
+	primary := v.configURLs.Lookup(v.td)
+	urls := append([]string{primary}, v.mirrors...)
+	roots := v.configRoots
+	v.mu.RUnlock()
+	if len(urls) == 1 {
+		return fetchDomainConfig(ctx, v.cli, v.td, primary, 0, roots)
+	}
+
+	err := errors.New("no configuration URLs")
+	for _, url := range v.urlHealth.order(urls) {
+		var res *domainConfigProxy
+		if url == primary {
+			res, err = fetchDomainConfig(ctx, v.cli, v.td, url, 0, roots)
+		} else {
+			res, err = fetchMirrorConfig(ctx, v.cli, v.td, url, roots)
+		}
+		v.urlHealth.record(url, err)
+		if err == nil {
+			return res, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// fetchMirrorConfig fetches a copy of the trust domain's configuration or a plain JWK set from the mirror,
+// a plain JWK set is rejected if roots is not nil.
+func fetchMirrorConfig(ctx context.Context, cli HTTPClient, td TrustDomain, url string, roots *JWKSet) (*domainConfigProxy, error) {
+	res := &domainConfigProxy{}
+	if err := cli.Do(ctx, "GET", url, nil, nil, res); err != nil {
+		return nil, err
+	}
+	if err := res.verifySigned(roots); err != nil {
+		return nil, err
+	}
+	if res.OTID.String() == "" {
+		res.OTID = td.OTID() // a plain JWK set
+	}
+	if err := res.parseKeys(td, 0); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// urlHealth remembers the failures of URLs, the zero value is ready to use.
+type urlHealth struct {
+	mu       sync.Mutex
+	failedAt map[string]time.Time
+}
+
+// order returns the URLs without recent failures first, both in the given order.
+func (h *urlHealth) order(urls []string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	healthy := make([]string, 0, len(urls))
+	var failed []string
+	for _, url := range urls {
+		if t, ok := h.failedAt[url]; ok && time.Since(t) < DefaultEndpointHealthTTL {
+			failed = append(failed, url)
+		} else {
+			healthy = append(healthy, url)
+		}
+	}
+	return append(healthy, failed...)
+}
+
+func (h *urlHealth) record(url string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err == nil {
+		delete(h.failedAt, url)
+		return
+	}
+	if h.failedAt == nil {
+		h.failedAt = make(map[string]time.Time)
+	}
+	h.failedAt[url] = time.Now()
+}
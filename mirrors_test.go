@@ -0,0 +1,73 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirrors(t *testing.T) {
+	t.Run("Verifier with mirrors", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		aud := td.NewOTID("svc", "tester")
+		key := otgo.MustPrivateKey("ES256")
+		var mu sync.Mutex
+		hits := map[string]int{}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[r.URL.Path]++
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if r.URL.Path != "/mirror2/jwks.json" {
+				w.WriteHeader(404)
+				w.Write([]byte(`{"error":"not found"}`))
+				return
+			}
+			b, _ := json.Marshal(otgo.LookupPublicKeys(otgo.MustKeys(key)))
+			w.Write(b)
+		}))
+		defer ts.Close()
+		count := func(path string) int {
+			mu.Lock()
+			defer mu.Unlock()
+			return hits[path]
+		}
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		_, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithHTTPClient(cli), otgo.WithAutoRefresh(-1))
+		assert.NotNil(err)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithHTTPClient(cli), otgo.WithAutoRefresh(-1),
+			otgo.WithMirrors("https://mirror1.example/mirror1/jwks.json", "https://mirror2.example/mirror2/jwks.json"))
+		assert.Nil(err)
+		assert.Equal(2, count("/.well-known/open-trust-configuration"))
+		assert.Equal(1, count("/mirror1/jwks.json"))
+		assert.Equal(1, count("/mirror2/jwks.json"))
+
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+
+		// the failed URLs are tried after the healthy mirror
+		assert.Nil(v.RefreshKeys(context.Background()))
+		assert.Equal(2, count("/.well-known/open-trust-configuration"))
+		assert.Equal(1, count("/mirror1/jwks.json"))
+		assert.Equal(2, count("/mirror2/jwks.json"))
+
+		v.SetMirrors("https://mirror1.example/mirror1/jwks.json")
+		assert.NotNil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+	})
+}
@@ -0,0 +1,36 @@
+// Package oauth2 adapts the otgo TokenSources to golang.org/x/oauth2, e.g. for oauth2.NewClient
+// or grpc's oauth.TokenSource credentials:
+//
+//	cli := xoauth2.NewClient(ctx, oauth2.TokenSource(oc.Service(aud)))
+//
+// It is a separate module, so that otgo does not depend on golang.org/x/oauth2.
+package oauth2
+
+import (
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"golang.org/x/oauth2"
+)
+
+// TokenSource adapts the otgo.TokenSource to a oauth2.TokenSource.
+// The tokens are Bearer tokens expiring with the OTVIDs.
+func TokenSource(ts otgo.TokenSource) oauth2.TokenSource {
+	return &tokenSource{ts}
+}
+
+type tokenSource struct {
+	ts otgo.TokenSource
+}
+
+func (s *tokenSource) Token() (*oauth2.Token, error) {
+	token, err := s.ts.Token()
+	if err != nil {
+		return nil, err
+	}
+	var exp time.Time
+	if vid, err := otgo.ParseOTVIDInsecure(token); err == nil {
+		exp = vid.Expiry
+	}
+	return &oauth2.Token{AccessToken: token, TokenType: "Bearer", Expiry: exp}, nil
+}
@@ -0,0 +1,43 @@
+package oauth2_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/open-trust/ot-go-lib/oauth2"
+	"github.com/stretchr/testify/assert"
+)
+
+type errTokenSource struct{}
+
+func (errTokenSource) Token() (string, error) {
+	return "", errors.New("no token")
+}
+
+func TestTokenSource(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	oc := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+	vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud,
+		Expiry: time.Now().Add(time.Hour)}
+	token, err := vid.Sign(otgo.MustPrivateKey("ES256"))
+	assert.Nil(t, err)
+	assert.Nil(t, oc.AddAudience(token, "http://localhost:1234"))
+
+	t.Run("TokenSource func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		tk, err := oauth2.TokenSource(oc.Service(aud)).Token()
+		assert.Nil(err)
+		assert.Equal(token, tk.AccessToken)
+		assert.Equal("Bearer", tk.Type())
+		assert.Equal(vid.Expiry.Unix(), tk.Expiry.Unix())
+		assert.True(tk.Valid())
+
+		_, err = oauth2.TokenSource(errTokenSource{}).Token()
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,57 @@
+package otgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrOffline is the reason of OfflineError, it can be tested with errors.Is.
+var ErrOffline = errors.New("offline mode")
+
+// OfflineError is returned by the OTClient in offline mode (see WithOfflineMode) from the operations
+// that require the OT-Auth service or the trust domains' configurations.
+type OfflineError struct {
+	Op Op // the operation that requires the network, e.g. OpSign or OpConfigFetch
+}
+
+func (e *OfflineError) Error() string {
+	return fmt.Sprintf("otgo: %s requires the OT-Auth service, the OTClient is in offline mode", e.Op)
+}
+
+// Unwrap ...
+func (e *OfflineError) Unwrap() error {
+	return ErrOffline
+}
+
+// WithOfflineMode disables all network calls of the OTClient. The trust domain's public keys must be
+// set with WithDomainKeys, and the OTVIDs of the audiences can be added with AddAudience. The operations that
+// require the OT-Auth service, e.g. Sign, Verify and the renewals of expired OTVIDs, return a OfflineError.
+func WithOfflineMode() OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		oc.offline = true
+		return nil
+	})
+}
+
+// Offline reports whether the OTClient is in offline mode, see WithOfflineMode.
+func (oc *OTClient) Offline() bool {
+	return oc.offline
+}
+
+// checkOnline returns a OfflineError if the OTClient is in offline mode.
+func (oc *OTClient) checkOnline(op Op) error {
+	if oc.offline {
+		return &OfflineError{Op: op}
+	}
+	return nil
+}
+
+// validateOffline checks the configuration of the OTClient in offline mode is complete.
+func (oc *OTClient) validateOffline() error {
+	oc.otDomain.RLock()
+	defer oc.otDomain.RUnlock()
+	if oc.otDomain.ks == nil || oc.otDomain.endpoint != nullhost {
+		return errors.New("offline mode requires the trust domain's public keys, see WithDomainKeys")
+	}
+	return nil
+}
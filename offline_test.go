@@ -0,0 +1,83 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOfflineMode(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	sub := td.NewOTID("app", "123")
+	aud := td.NewOTID("svc", "tester")
+	domainKey := otgo.MustPrivateKey("ES256")
+	domainKeys := *otgo.LookupPublicKeys(otgo.MustKeys(domainKey))
+
+	t.Run("NewOTClientWithOptions func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOfflineMode())
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "WithDomainKeys")
+
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOfflineMode(), otgo.WithDomainKeys(domainKeys))
+		assert.Nil(err)
+		assert.True(oc.Offline())
+
+		oc, err = otgo.NewOTClientWithOptions(context.Background(), sub)
+		assert.Nil(err)
+		assert.False(oc.Offline())
+	})
+
+	t.Run("OTClient in offline mode", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(500)
+		}))
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTClientHTTPClient(cli),
+			otgo.WithOfflineMode(), otgo.WithDomainKeys(domainKeys))
+		assert.Nil(err)
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		vid := &otgo.OTVID{ID: sub, Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}
+		token, err := vid.Sign(domainKey)
+		assert.Nil(err)
+		assert.Nil(oc.AddAudience(token, ts.URL))
+		_, err = oc.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+
+		_, err = oc.ParseOTVID(context.Background(), token, aud)
+		assert.Nil(err)
+
+		var oe *otgo.OfflineError
+		_, err = oc.Sign(context.Background(), otgo.SignInput{Subject: sub, Audience: aud})
+		assert.True(errors.Is(err, otgo.ErrOffline))
+		assert.True(errors.As(err, &oe))
+		assert.Equal(otgo.OpSign, oe.Op)
+
+		_, err = oc.Verify(context.Background(), token)
+		assert.True(errors.Is(err, otgo.ErrOffline))
+
+		_, err = oc.Service(td.NewOTID("svc", "other")).Resolve(context.Background())
+		assert.True(errors.Is(err, otgo.ErrOffline))
+
+		_, err = oc.Domain(otgo.TrustDomain("partner.example")).Resolve(context.Background())
+		assert.True(errors.As(err, &oe))
+		assert.Equal(otgo.OpConfigFetch, oe.Op)
+		assert.Equal(int32(0), atomic.LoadInt32(&calls))
+	})
+}
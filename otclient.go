@@ -2,8 +2,11 @@ package otgo
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,13 +15,79 @@ const nullhost = "nullhost"
 // OTClient ...
 type OTClient struct {
 	sub          OTID
-	ks           *JWKSet
+	kp           atomic.Value // keyProviderBox
+	signer       atomic.Value // signerBox, see SetSigner
 	td           TrustDomain
 	otDomain     *DomainResolver
 	otClient     *ServiceClient
 	domainCache  *cache
 	serviceCache *cache
 	HTTPClient   HTTPClient
+	// MinValidKeys tolerates bad keys in the trust domain's config as long as
+	// at least MinValidKeys keys are valid. All keys must be valid if it is 0.
+	MinValidKeys int
+	// Instrumenter receives spans of OT-Auth calls and cache events, optional.
+	Instrumenter Instrumenter
+	// Logger logs the renewal failures of the caches, optional.
+	Logger Logger
+	// ConfigURLs overrides the trust domains' configuration URLs, the standard URLs are used if nil.
+	ConfigURLs *ConfigURLs
+	// DelegatedIssuers are the trust domain's sub-issuers accepted by ParseOTVID besides the trust domain.
+	DelegatedIssuers OTIDs
+	// KeysCacheDir persists the trust domains' configurations to the dir if not empty,
+	// they are used after restart if the OT-Auth service is unreachable.
+	KeysCacheDir string
+	// Store shares the issued OTVIDs and trust domains' configurations across replicas, optional.
+	Store CacheStore
+	// TokenStore stores the subject's OTVIDs instead of Store, e.g. in a secret manager, optional.
+	TokenStore TokenStore
+	// Limits are the size limits of OTVIDs and OTIDs, DefaultLimits is used if nil.
+	Limits *Limits
+	// EndpointSelector selects the service endpoints of OT-Auth and the audiences with health caching,
+	// the endpoints are probed on every selection without health caching if nil.
+	EndpointSelector *EndpointSelector
+	// PreserveRawClaims preserves the raw claims of the parsed OTVIDs and decodes their numbers as json.Number,
+	// so that the large integer claims, e.g. billing IDs, keep their precision. See OTVID.RawClaims.
+	PreserveRawClaims bool
+	// LaxKeyUsage accepts the keys marked for encryption or without the "sign"/"verify" key_ops,
+	// for legacy key sets. They are rejected by default.
+	LaxKeyUsage bool
+	// ConfigRootKeys are the public keys of the offline root keys, only the trust domains' configurations
+	// signed by them are trusted if not nil, see PublishSignedConfig.
+	ConfigRootKeys *JWKSet
+	// RateLimiter limits the calls to the OT-Auth service (Sign, Verify and the trust domains' configuration
+	// fetches), the calls over the limit return a ClientRateLimitError. Unlimited if nil, see WithRateLimit.
+	RateLimiter *RateLimiter
+	// TokenFetcher mints the subject's OTVIDs instead of the trust domain's OT-Auth service, optional.
+	// See WithOTAuth and WithTokenFetcher.
+	TokenFetcher TokenFetcher
+	// MaxCacheEntries limits the number of cached trust domains' configurations and OTVIDs each,
+	// the expired and then the least recently used entries are evicted. Unlimited if 0.
+	MaxCacheEntries int
+	// SelfTokenLifetime is the lifetime of the self-signed OTVIDs that the subject requests OT-Auth with,
+	// DefaultSelfTokenLifetime is used if 0. See WithSelfTokenLifetime.
+	SelfTokenLifetime time.Duration
+	// RenewBefore renews the cached OTVIDs the duration before they expire, DefaultRenewBefore is used if 0.
+	// It should be less than the OTVIDs' lifetime, or every resolution renews. See WithRenewBefore.
+	RenewBefore time.Duration
+	// ServiceTTL discovers the audiences' service endpoints again the duration after they are selected,
+	// by requesting new OTVIDs from OT-Auth, so that the traffic shifts when a service's endpoints change.
+	// The selected endpoint is kept as long as OT-Auth still lists it if 0. See SetServiceTTL.
+	ServiceTTL time.Duration
+	// SignBatchConcurrency limits the concurrent Sign calls of SignBatch without the OT-Auth batch endpoint,
+	// DefaultSignBatchConcurrency is used if 0.
+	SignBatchConcurrency int
+	ttlMu                sync.RWMutex
+	serviceTTLs          map[string]time.Duration // see SetServiceTTL
+	maintenance          atomic.Value
+	fedMu                sync.RWMutex
+	federated            map[TrustDomain]*DomainResolver
+	claimMu              sync.RWMutex
+	claimPolicies        map[string]*ClaimPolicy // see SetClaimPolicy
+	thirdParty           *ClaimPolicy
+	offline              bool      // see WithOfflineMode
+	noSignBatch          int32     // OT-Auth has no batch endpoint, see SignBatch
+	lifecycle            lifecycle // see Shutdown
 }
 
 // Config ...
@@ -27,49 +96,90 @@ type Config struct {
 	ServiceEndpoint string
 }
 
-// NewOTClient ...
+// NewOTClient creates a OTClient for the subject, it panics if the subject is invalid,
+// see NewOTClientWithOptions.
 func NewOTClient(ctx context.Context, sub OTID) *OTClient {
 	if err := sub.Validate(); err != nil {
 		panic(fmt.Errorf("invalid subject OTID: %s", err.Error()))
 	}
+	return newOTClient(sub)
+}
 
+func newOTClient(sub OTID) *OTClient {
 	cli := &OTClient{
-		HTTPClient: NewClient(nil),
-		sub:        sub,
-		td:         sub.TrustDomain(),
-		domainCache: newCache(func(otid OTID) renewer {
-			return &domainRenewer{td: otid.TrustDomain()}
-		}),
-		serviceCache: newCache(func(otid OTID) renewer {
-			return &serviceRenewer{otid: otid}
-		}),
-	}
-	cli.otDomain = cli.Domain(cli.td)
-	cli.otClient = cli.Service(cli.td.OTID())
+		HTTPClient:       NewClient(nil),
+		EndpointSelector: &EndpointSelector{},
+		sub:              sub,
+		td:               sub.TrustDomain(),
+	}
+	maxEntries := func() int { return cli.MaxCacheEntries }
+	cli.domainCache = newCache(func(otid OTID) renewer {
+		return &domainRenewer{td: otid.TrustDomain()}
+	}, maxEntries)
+	cli.serviceCache = newCache(func(otid OTID) renewer {
+		return &serviceRenewer{otid: otid, renewBefore: cli.renewBefore, endpointTTL: cli.serviceTTL}
+	}, maxEntries)
+	cli.otDomain = &DomainResolver{domainRenewer: cli.domainCache.pin(cli.td.OTID()).(*domainRenewer), oc: cli}
+	cli.otClient = &ServiceClient{serviceRenewer: cli.serviceCache.pin(cli.td.OTID()).(*serviceRenewer), oc: cli}
 	return cli
 }
 
-// SetPrivateKeys ...
+func (oc *OTClient) renewBefore() time.Duration {
+	if oc.RenewBefore > 0 {
+		return oc.RenewBefore
+	}
+	return DefaultRenewBefore
+}
+
+func (oc *OTClient) selfTokenLifetime() time.Duration {
+	if oc.SelfTokenLifetime > 0 {
+		return oc.SelfTokenLifetime
+	}
+	return DefaultSelfTokenLifetime
+}
+
+func (oc *OTClient) selectEndpoint(ctx context.Context, serviceEndpoints []string) (string, error) {
+	if oc.EndpointSelector == nil {
+		return SelectEndpoints(ctx, serviceEndpoints, oc.HTTPClient)
+	}
+	return oc.EndpointSelector.Select(ctx, serviceEndpoints, oc.HTTPClient)
+}
+
+func (oc *OTClient) configURL(td TrustDomain) string {
+	return oc.ConfigURLs.Lookup(td)
+}
+
+// SetPrivateKeys sets the subject's private keys, it is safe for concurrent use.
+// The keys are copied, the OTVIDs signed after it returns are signed with the new keys,
+// and the ones being signed concurrently may be signed with the previous keys.
 func (oc *OTClient) SetPrivateKeys(privateKeys JWKSet) {
-	oc.ks = &privateKeys
+	oc.SetKeyProvider(StaticKeys(&JWKSet{Keys: append([]Key(nil), privateKeys.Keys...)}))
 }
 
 // SetDomainKeys set trust domain's public keys persistently
-// do not call this method if trust domain's OT-Auth service is online.
+// do not call this method if trust domain's OT-Auth service is online, see WithOfflineMode.
+// It is safe for concurrent use, it holds the trust domain's cache entry lock, so a concurrent
+// resolution sees either the previous or the new configuration entirely, and the resolutions
+// after it returns see the new keys.
 func (oc *OTClient) SetDomainKeys(publicKeys JWKSet) {
-	oc.otDomain.ks = &publicKeys
+	ks := &JWKSet{Keys: append([]Key(nil), publicKeys.Keys...)}
+	oc.otDomain.Lock()
+	defer oc.otDomain.Unlock()
+	oc.otDomain.ks = ks
 	oc.otDomain.endpoint = nullhost
-	oc.otDomain.expiresAt = time.Now().Add(time.Hour * 24 * 365 * 99)
+	oc.otDomain.doc = nil
+	oc.otDomain.refreshedAt = clockNow()
+	oc.otDomain.expiresAt = clockNow().Add(time.Hour * 24 * 365 * 99)
 }
 
 // AddAudience add audience service' config to the OTClient.
-// do not call this method if trust domain's OT-Auth service is online.
+// do not call this method if trust domain's OT-Auth service is online, see WithOfflineMode.
 func (oc *OTClient) AddAudience(token, serviceEndpoint string) error {
-	vid, err := ParseOTVIDInsecure(token)
+	vid, err := oc.parseInsecure(token)
 	if err == nil {
 		if !vid.ID.Equal(oc.sub) {
 			err = fmt.Errorf("the OTVID %s is not belong to subject %s", vid.ID.String(), oc.sub.String())
-		} else if vid.ShouldRenew() {
+		} else if vid.ShouldRenewBefore(oc.renewBefore()) {
 			err = fmt.Errorf("the OTVID token(%s) should renew", token)
 		}
 	}
@@ -78,27 +188,53 @@ func (oc *OTClient) AddAudience(token, serviceEndpoint string) error {
 	}
 
 	renewer := oc.serviceCache.Get(vid.Audience).(*serviceRenewer)
+	renewer.Lock()
 	renewer.vid = vid
 	renewer.endpoint = serviceEndpoint
+	renewer.selectedAt = clockNow()
+	renewer.stale = false
+	renewer.Unlock()
 	return nil
 }
 
 // SignSelf ...
 func (oc *OTClient) SignSelf() (string, error) {
-	key, err := LookupSigningKey(oc.ks)
-	if err != nil {
-		return "", err
-	}
+	return oc.SignSelfContext(context.Background())
+}
 
+// SignSelfContext signs a self OTVID like SignSelf, the ctx is passed to the Signer if set, see SetSigner.
+func (oc *OTClient) SignSelfContext(ctx context.Context) (string, error) {
 	vid := &OTVID{}
 	vid.ID = oc.sub
 	vid.Issuer = oc.sub
 	vid.Audience = oc.td.OTID()
-	vid.Expiry = time.Now().Add(time.Minute * 10)
-	return vid.Sign(key)
+	vid.Expiry = clockNow().Add(oc.selfTokenLifetime())
+	// the self-signed OTVIDs are one-time, see Verifier.SetReplayChecker
+	if err := vid.GenerateJTI(); err != nil {
+		return "", err
+	}
+	opts := &signOptions{limits: oc.Limits, laxKeyUsage: oc.LaxKeyUsage}
+	if s := oc.loadSigner(); s != nil {
+		return vid.signContext(ctx, s, opts)
+	}
+
+	key, err := oc.signingKey()
+	if err != nil {
+		return "", err
+	}
+	return vid.sign(key, opts)
+}
+
+// parseInsecure parses a OTVID with the client's limits, the signature is not verified.
+func (oc *OTClient) parseInsecure(token string) (*OTVID, error) {
+	d, err := decodeOTVID(token, false, oc.Limits, oc.PreserveRawClaims)
+	if err != nil {
+		return nil, err
+	}
+	return d.vid, nil
 }
 
-// Response ...
+// Response is the response envelope of the OT-Auth service and the services' APIs, see DoAPI.
 type Response struct {
 	Error  interface{} `json:"error"`
 	Result interface{} `json:"result"`
@@ -106,8 +242,9 @@ type Response struct {
 
 // SignInput ...
 type SignInput struct {
-	Subject        OTID                   `json:"sub"` // 申请签发 OTVID 的 sub，可以是联盟信任域的 sub
-	Audience       OTID                   `json:"aud"` // 申请签发 OTVID 的 aud，可以是联盟信任域的 aud
+	Subject        OTID                   `json:"sub"`           // 申请签发 OTVID 的 sub，可以是联盟信任域的 sub
+	Audience       OTID                   `json:"aud"`           // 申请签发 OTVID 的 aud，可以是联盟信任域的 aud
+	Issuer         *OTID                  `json:"iss,omitempty"` // 指定签发 OTVID 的子签发者，为空则由信任域签发
 	Expiry         int64                  `json:"exp"`
 	Claims         map[string]interface{} `json:"claims"`         // 需要包含的其它签发数据
 	ForwardedOTVID string                 `json:"forwardedOtvid"` // 请求主体与 sub 不一致则是代理申请，且请求主体不是联盟域，需要 sub 的自签发 OTVID
@@ -123,28 +260,45 @@ type SignOutput struct {
 }
 
 // Sign ...
-func (oc *OTClient) Sign(ctx context.Context, input SignInput) (*SignOutput, error) {
-	cfg, err := oc.otDomain.Resolve(ctx)
-	if err != nil {
+func (oc *OTClient) Sign(ctx context.Context, input SignInput) (_ *SignOutput, err error) {
+	ctx, end := instrumenterOf(oc.Instrumenter).Start(ctx, OpSign)
+	defer func() { end(err) }()
+
+	if err = oc.checkOnline(OpSign); err != nil {
 		return nil, err
 	}
-	selfToken, err := oc.SignSelf()
-	if err != nil {
+	if err = oc.checkOpen(OpSign); err != nil {
 		return nil, err
 	}
-	output := &SignOutput{}
-	h := AddTokenToHeader(make(http.Header), selfToken)
-	// call with subject's self OTVID
-	err = oc.HTTPClient.Do(ctx, "POST", cfg.Endpoint+"/sign", h, input, &Response{Result: output})
+	if err = oc.allowCall(OpSign); err != nil {
+		return nil, err
+	}
+	input.Claims = oc.claimPolicy(input.Audience).Filter(input.Claims)
+	if oc.TokenFetcher != nil {
+		return oc.TokenFetcher.FetchOTVID(ctx, input)
+	}
+	cfg, err := oc.otDomain.Resolve(ctx)
 	if err != nil {
 		return nil, err
 	}
-
-	return output, nil
+	return oc.signWith(ctx, cfg.Endpoint, input)
 }
 
 // Verify ...
-func (oc *OTClient) Verify(ctx context.Context, token string, auds ...OTID) (*OTVID, error) {
+func (oc *OTClient) Verify(ctx context.Context, token string, auds ...OTID) (_ *OTVID, err error) {
+	ctx, end := instrumenterOf(oc.Instrumenter).Start(ctx, OpVerify)
+	defer func() { end(err) }()
+
+	if err = oc.checkOnline(OpVerify); err != nil {
+		return nil, err
+	}
+	if err = oc.checkOpen(OpVerify); err != nil {
+		return nil, err
+	}
+	if err = oc.allowCall(OpVerify); err != nil {
+		return nil, err
+	}
+
 	aud := oc.sub
 	if len(auds) > 0 {
 		aud = auds[0]
@@ -156,7 +310,7 @@ func (oc *OTClient) Verify(ctx context.Context, token string, auds ...OTID) (*OT
 	jwt := NewToken()
 
 	// call with subject's OTVID that signing from OT-Auth service
-	err := oc.otClient.Do(ctx, "POST", "/verify", nil, input, &Response{Result: jwt})
+	err = oc.otClient.DoAPI(ctx, "POST", "/verify", input, jwt)
 	if err != nil {
 		return nil, err
 	}
@@ -167,9 +321,55 @@ func (oc *OTClient) Verify(ctx context.Context, token string, auds ...OTID) (*OT
 	return vid, nil
 }
 
+// AddFederatedDomain trusts the OTVIDs issued by the allied trust domain in ParseOTVID,
+// they are verified with the allied trust domain's public keys.
+func (oc *OTClient) AddFederatedDomain(td TrustDomain) error {
+	if err := td.Validate(); err != nil {
+		return fmt.Errorf("otgo.OTClient.AddFederatedDomain: %s", err.Error())
+	}
+	if td == oc.td {
+		return fmt.Errorf("otgo.OTClient.AddFederatedDomain: %s is the client's trust domain", td)
+	}
+	oc.fedMu.Lock()
+	defer oc.fedMu.Unlock()
+	if oc.federated == nil {
+		oc.federated = make(map[TrustDomain]*DomainResolver)
+	}
+	if _, ok := oc.federated[td]; !ok {
+		oc.federated[td] = oc.Domain(td)
+	}
+	return nil
+}
+
+// issuerDomain returns the trust domain and its resolver that should verify the token's issuer.
+func (oc *OTClient) issuerDomain(issuer OTID) (TrustDomain, *DomainResolver, error) {
+	td := issuer.TrustDomain()
+	if td == oc.td {
+		return oc.td, oc.otDomain, nil
+	}
+	oc.fedMu.RLock()
+	dr, ok := oc.federated[td]
+	oc.fedMu.RUnlock()
+	if !ok {
+		return "", nil, fmt.Errorf("otgo.OTClient.ParseOTVID: issuer %s not trusted", issuer.String())
+	}
+	return td, dr, nil
+}
+
 // ParseOTVID ...
-func (oc *OTClient) ParseOTVID(ctx context.Context, token string, auds ...OTID) (*OTVID, error) {
-	cfg, err := oc.otDomain.Resolve(ctx)
+func (oc *OTClient) ParseOTVID(ctx context.Context, token string, auds ...OTID) (_ *OTVID, err error) {
+	ctx, end := instrumenterOf(oc.Instrumenter).Start(ctx, OpParse)
+	defer func() { end(err) }()
+	d, err := decodeOTVID(token, false, oc.Limits, oc.PreserveRawClaims)
+	if err != nil {
+		return nil, err
+	}
+	d.laxKeyUsage = oc.LaxKeyUsage
+	td, dr, err := oc.issuerDomain(d.vid.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := dr.Resolve(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -177,7 +377,14 @@ func (oc *OTClient) ParseOTVID(ctx context.Context, token string, auds ...OTID)
 	if len(auds) > 0 {
 		aud = auds[0]
 	}
-	vid, err := ParseOTVID(token, cfg.JWKSet, oc.td.OTID(), aud)
+	delegated := oc.DelegatedIssuers
+	if td != oc.td {
+		delegated = nil
+	}
+	vid, err := d.parseDelegated(cfg.JWKSet, td, cfg.Issuers, delegated, aud)
+	if err == nil && !vid.Confirmation.empty() {
+		err = errors.New("otgo.OTClient.ParseOTVID: the OTVID is bound to the presenter, see Verifier.ParseBoundOTVID")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -210,6 +417,31 @@ func (oc *OTClient) Domain(td TrustDomain) *DomainResolver {
 	return &DomainResolver{domainRenewer: renewer, oc: oc}
 }
 
+// DomainConfig returns the trust domain's configuration with its full well-known document,
+// e.g. to validate subject types against the trust domain's policy.
+func (oc *OTClient) DomainConfig(ctx context.Context, td TrustDomain) (*DomainConfig, error) {
+	if err := td.Validate(); err != nil {
+		return nil, fmt.Errorf("otgo.OTClient.DomainConfig: %s", err.Error())
+	}
+	if td == oc.td {
+		return oc.otDomain.Resolve(ctx)
+	}
+	return oc.Domain(td).Resolve(ctx)
+}
+
+// SignForDomain requests a OTVID of the subject for the trust domain's audience, i.e. the domain-level
+// OTVID accepted by the trust domain's services that verify the trust domain's OTID as audience.
+// It is not cached, see DomainToken.
+func (oc *OTClient) SignForDomain(ctx context.Context) (*SignOutput, error) {
+	return oc.Sign(ctx, SignInput{Subject: oc.sub, Audience: oc.td.OTID()})
+}
+
+// DomainToken returns the cached domain-level OTVID token of the subject like SignForDomain,
+// it is renewed if it should renew. It is the token that the OTClient calls OT-Auth with.
+func (oc *OTClient) DomainToken(ctx context.Context) (string, error) {
+	return oc.otClient.TokenContext(ctx)
+}
+
 // ServiceClient ...
 type ServiceClient struct {
 	*serviceRenewer
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -98,6 +100,48 @@ func TestOTClient(t *testing.T) {
 		assert.True(vid.ID.Equal(sub))
 	})
 
+	t.Run("OTClient.SetPrivateKeys & OTClient.SetDomainKeys concurrently", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		sub := td.NewOTID("app", "123")
+		cli := otgo.NewOTClient(context.Background(), sub)
+		cli.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+		cli.SetDomainKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					cli.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+					cli.SetDomainKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					_, err := cli.SignSelf()
+					assert.Nil(err)
+					cfg, err := cli.Domain(td).Resolve(context.Background())
+					assert.Nil(err)
+					assert.Equal(1, len(cfg.JWKSet.Keys))
+				}
+			}()
+		}
+		wg.Wait()
+
+		pk := otgo.MustPrivateKey("ES256")
+		ks := otgo.MustKeys(pk)
+		cli.SetPrivateKeys(*ks)
+		ks.Keys[0] = otgo.MustPrivateKey("ES256") // the keys are copied
+		token, err := cli.SignSelf()
+		assert.Nil(err)
+		_, err = otgo.ParseOTVID(token, otgo.LookupPublicKeys(otgo.MustKeys(pk)), sub, td.OTID())
+		assert.Nil(err)
+	})
+
 	t.Run("DomainResolver", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -135,6 +179,16 @@ func TestOTClient(t *testing.T) {
 		assert.Equal("https://localhost/v1", cfg.Endpoint)
 		assert.Equal(1, len(cfg.JWKSet.Keys))
 		assert.Equal("ySQYnCsV4cOZBxbHCv4E410k0gjTbi8WfJJwVkV6QqI", cfg.JWKSet.Keys[0].KeyID())
+		assert.Equal(int64(3600), cfg.KeysRefreshHint)
+		assert.Equal([]string{"https://localhost/v1"}, cfg.ServiceEndpoints)
+		assert.Equal([]string{"agent", "app", "svc"}, cfg.ServiceTypes)
+		assert.Equal([]string{"user", "dev"}, cfg.UserTypes)
+
+		cfg, err = cli.DomainConfig(context.Background(), td)
+		assert.Nil(err)
+		assert.Equal([]string{"user", "dev"}, cfg.UserTypes)
+		_, err = cli.DomainConfig(context.Background(), otgo.TrustDomain("Bad"))
+		assert.NotNil(err)
 
 		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -163,6 +217,52 @@ func TestOTClient(t *testing.T) {
 		df = cli.Domain(td)
 		_, err = df.Resolve(context.Background())
 		assert.NotNil(err)
+
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(200)
+			w.Write([]byte(`
+{
+	"keys": [{
+		"kty": "EC",
+		"alg": "ES512",
+		"crv": "P-521",
+		"kid": "",
+		"x": "AdtXGowadABABWC0FVolCYnRhiBEYdO6-bpyldNh1RrLVIDJJRJelA_O2UB9DyssCN8gLfJio3OdV8YH6uyfvOwb",
+		"y": "AX1Waed_878v_Y1JE2U3dLvAOIScuu_UVGUFZpQyB-hRTXMIQHTqEQw9os_Jcb491-0ZUANJZs_gne7srQ2yOCN6"
+	}, {
+		"kty": "EC",
+		"alg": "ES512",
+		"crv": "P-521",
+		"kid": "ySQYnCsV4cOZBxbHCv4E410k0gjTbi8WfJJwVkV6QqI",
+		"x": "AdtXGowadABABWC0FVolCYnRhiBEYdO6-bpyldNh1RrLVIDJJRJelA_O2UB9DyssCN8gLfJio3OdV8YH6uyfvOwb",
+		"y": "AX1Waed_878v_Y1JE2U3dLvAOIScuu_UVGUFZpQyB-hRTXMIQHTqEQw9os_Jcb491-0ZUANJZs_gne7srQ2yOCN6"
+	}],
+	"keysRefreshHint": 3600,
+	"otid": "otid:localhost",
+	"serviceEndpoints": ["https://localhost/v1"]
+}
+			`))
+		}))
+		defer ts.Close()
+		cli = otgo.NewOTClient(context.Background(), sub)
+		cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		_, err = cli.Domain(td).Resolve(context.Background())
+		assert.NotNil(err)
+
+		cli = otgo.NewOTClient(context.Background(), sub)
+		cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		cli.MinValidKeys = 1
+		cfg, err = cli.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(1, len(cfg.JWKSet.Keys))
+		assert.Equal("ySQYnCsV4cOZBxbHCv4E410k0gjTbi8WfJJwVkV6QqI", cfg.JWKSet.Keys[0].KeyID())
+
+		cli = otgo.NewOTClient(context.Background(), sub)
+		cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		cli.MinValidKeys = 2
+		_, err = cli.Domain(td).Resolve(context.Background())
+		assert.NotNil(err)
 	})
 
 	t.Run("OTClient.Verify method", func(t *testing.T) {
@@ -259,4 +359,94 @@ func TestOTClient(t *testing.T) {
 		_, err = cli.ParseOTVID(context.Background(), token)
 		assert.NotNil(err)
 	})
+
+	t.Run("OTClient.AddFederatedDomain method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		domainKey := otgo.MustPrivateKey("ES256")
+		cli := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		cli.SetDomainKeys(*otgo.LookupPublicKeys(otgo.MustKeys(domainKey)))
+
+		ftd := otgo.TrustDomain("allied.com")
+		fedKey := otgo.MustPrivateKey("ES256")
+		var signs int32
+		ts := newTestOTAuth(ftd, fedKey, &signs)
+		defer ts.Close()
+		cli.ConfigURLs = &otgo.ConfigURLs{}
+		cli.ConfigURLs.Set(ftd, ts.URL+"/.well-known/open-trust-configuration")
+
+		vid := &otgo.OTVID{}
+		vid.ID = ftd.NewOTID("user", "abc")
+		vid.Issuer = ftd.OTID()
+		vid.Audience = td.NewOTID("app", "123")
+		vid.Expiry = time.Now().Add(time.Hour)
+		token, err := vid.Sign(fedKey)
+		assert.Nil(err)
+
+		_, err = cli.ParseOTVID(context.Background(), token)
+		assert.NotNil(err)
+
+		assert.NotNil(cli.AddFederatedDomain(td))
+		assert.NotNil(cli.AddFederatedDomain(otgo.TrustDomain("")))
+		assert.Nil(cli.AddFederatedDomain(ftd))
+		vid2, err := cli.ParseOTVID(context.Background(), token)
+		assert.Nil(err)
+		assert.True(vid2.ID.Equal(vid.ID))
+
+		// the allied trust domain can not sign with other keys
+		token, err = vid.Sign(domainKey)
+		assert.Nil(err)
+		_, err = cli.ParseOTVID(context.Background(), token)
+		assert.NotNil(err)
+
+		// the home trust domain still works
+		vid.ID = td.NewOTID("user", "abc")
+		vid.Issuer = td.OTID()
+		token, err = vid.Sign(domainKey)
+		assert.Nil(err)
+		_, err = cli.ParseOTVID(context.Background(), token)
+		assert.Nil(err)
+
+		vid.Issuer = otgo.TrustDomain("other.com").OTID()
+		token, err = vid.Sign(domainKey)
+		assert.Nil(err)
+		_, err = cli.ParseOTVID(context.Background(), token)
+		assert.NotNil(err)
+	})
+
+	t.Run("OTClient.SignForDomain and OTClient.DomainToken method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		sub := td.NewOTID("app", "123")
+		var signs int32
+		ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), &signs)
+		defer ts.Close()
+
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL))
+		assert.Nil(err)
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		out, err := oc.SignForDomain(context.Background())
+		assert.Nil(err)
+		assert.True(out.Audience.Equal(td.OTID()))
+		vid, err := otgo.ParseOTVIDInsecure(out.OTVID)
+		assert.Nil(err)
+		assert.True(vid.ID.Equal(sub))
+		assert.True(vid.Audience.Equal(td.OTID()))
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+
+		token, err := oc.DomainToken(context.Background())
+		assert.Nil(err)
+		vid, err = otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.True(vid.ID.Equal(sub))
+		assert.True(vid.Audience.Equal(td.OTID()))
+		n := atomic.LoadInt32(&signs)
+		token2, err := oc.DomainToken(context.Background())
+		assert.Nil(err)
+		assert.Equal(token, token2)
+		assert.Equal(n, atomic.LoadInt32(&signs))
+	})
 }
@@ -0,0 +1,147 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OTClientOption configures NewOTClientWithOptions.
+type OTClientOption interface {
+	applyOTClient(*OTClient) error
+}
+
+type otClientOptionFunc func(*OTClient) error
+
+func (fn otClientOptionFunc) applyOTClient(oc *OTClient) error {
+	return fn(oc)
+}
+
+// WithOTClientHTTPClient calls OT-Auth and fetches the trust domains' configurations with cli
+// instead of a new otgo.Client.
+func WithOTClientHTTPClient(cli HTTPClient) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if cli == nil {
+			return errors.New("nil HTTPClient")
+		}
+		oc.HTTPClient = cli
+		return nil
+	})
+}
+
+// WithOTClientInstrumenter reports the spans and cache events to in, see Instrumenter.
+func WithOTClientInstrumenter(in Instrumenter) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		oc.Instrumenter = in
+		return nil
+	})
+}
+
+// WithSigner signs the subject's OTVIDs with the KeyProvider's signing key, see SetKeyProvider.
+func WithSigner(kp KeyProvider) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if kp == nil {
+			return errors.New("nil KeyProvider")
+		}
+		oc.SetKeyProvider(kp)
+		return nil
+	})
+}
+
+// WithCache shares the OTClient's caches across replicas with the store (optional),
+// and limits the number of cached entries if maxEntries > 0.
+func WithCache(store CacheStore, maxEntries int) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		oc.Store = store
+		oc.MaxCacheEntries = maxEntries
+		return nil
+	})
+}
+
+// WithTimeout sets the timeout of the requests instead of 5 seconds, the HTTPClient must be a otgo.Client.
+// The OTClient uses a copy of the HTTPClient, the given one is not modified.
+func WithTimeout(timeout time.Duration) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		c, ok := oc.HTTPClient.(*Client)
+		if !ok {
+			return fmt.Errorf("WithTimeout requires a *otgo.Client, got %T", oc.HTTPClient)
+		}
+		c = c.clone()
+		hc := *c.Client
+		hc.Timeout = timeout
+		c.Client = &hc
+		oc.HTTPClient = c
+		return nil
+	})
+}
+
+// WithUserAgent sets the User-Agent header of the requests, the HTTPClient must be a otgo.Client.
+// The OTClient uses a copy of the HTTPClient, the given one is not modified.
+func WithUserAgent(ua string) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		c, ok := oc.HTTPClient.(*Client)
+		if !ok {
+			return fmt.Errorf("WithUserAgent requires a *otgo.Client, got %T", oc.HTTPClient)
+		}
+		c = c.clone()
+		c.Header.Set("User-Agent", ua)
+		oc.HTTPClient = c
+		return nil
+	})
+}
+
+// WithDomainKeys sets the trust domain's public keys persistently, see SetDomainKeys.
+func WithDomainKeys(publicKeys JWKSet) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if len(publicKeys.Keys) == 0 {
+			return errors.New("no domain keys")
+		}
+		oc.SetDomainKeys(publicKeys)
+		return nil
+	})
+}
+
+// WithSelfTokenLifetime sets the lifetime of the self-signed OTVIDs instead of DefaultSelfTokenLifetime,
+// see OTClient.SelfTokenLifetime.
+func WithSelfTokenLifetime(d time.Duration) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid self token lifetime %s", d)
+		}
+		oc.SelfTokenLifetime = d
+		return nil
+	})
+}
+
+// WithRenewBefore renews the cached OTVIDs d before they expire instead of DefaultRenewBefore,
+// e.g. a long-latency batch job renews a few minutes before, see OTClient.RenewBefore.
+func WithRenewBefore(d time.Duration) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if d <= 0 {
+			return fmt.Errorf("invalid renewal threshold %s", d)
+		}
+		oc.RenewBefore = d
+		return nil
+	})
+}
+
+// NewOTClientWithOptions creates a OTClient for the subject with the options applied in order,
+// it returns a error instead of panicking if the subject or a option is invalid.
+func NewOTClientWithOptions(ctx context.Context, sub OTID, opts ...OTClientOption) (*OTClient, error) {
+	if err := sub.Validate(); err != nil {
+		return nil, fmt.Errorf("otgo.NewOTClient: invalid subject OTID: %s", err.Error())
+	}
+	oc := newOTClient(sub)
+	for _, opt := range opts {
+		if err := opt.applyOTClient(oc); err != nil {
+			return nil, fmt.Errorf("otgo.NewOTClient: %s", err.Error())
+		}
+	}
+	if oc.offline {
+		if err := oc.validateOffline(); err != nil {
+			return nil, fmt.Errorf("otgo.NewOTClient: %w", err)
+		}
+	}
+	return oc, nil
+}
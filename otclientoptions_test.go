@@ -0,0 +1,131 @@
+package otgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type testHTTPClient struct{}
+
+func (testHTTPClient) Do(ctx context.Context, method, api string, h http.Header, input, output interface{}) error {
+	return nil
+}
+
+func TestOTClientOptions(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+
+	t.Run("NewOTClientWithOptions func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", ""))
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "invalid subject OTID")
+
+		_, err = otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"), otgo.WithSigner(nil))
+		assert.NotNil(err)
+		_, err = otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"), otgo.WithDomainKeys(otgo.JWKSet{}))
+		assert.NotNil(err)
+		_, err = otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"),
+			otgo.WithOTClientHTTPClient(testHTTPClient{}), otgo.WithTimeout(time.Second))
+		assert.NotNil(err)
+		_, err = otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"), otgo.WithOTClientHTTPClient(nil))
+		assert.NotNil(err)
+
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"))
+		assert.Nil(err)
+		assert.IsType(&otgo.Client{}, oc.HTTPClient)
+	})
+
+	t.Run("WithTimeout & WithUserAgent & WithSigner & WithDomainKeys", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ua := make(chan string, 1)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ua <- r.Header.Get("User-Agent")
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte(`{"result":{}}`))
+		}))
+		defer ts.Close()
+
+		key := otgo.MustPrivateKey("ES256")
+		store := otgo.NewMemoryStore()
+		cli := otgo.NewClient(nil)
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"),
+			otgo.WithOTClientHTTPClient(cli),
+			otgo.WithTimeout(20*time.Millisecond),
+			otgo.WithUserAgent("otgo-test/1.0"),
+			otgo.WithSigner(otgo.StaticKeys(otgo.MustKeys(key))),
+			otgo.WithDomainKeys(*otgo.LookupPublicKeys(otgo.MustKeys(key))),
+			otgo.WithCache(store, 10),
+		)
+		assert.Nil(err)
+		assert.Equal(store, oc.Store)
+		assert.Equal(10, oc.MaxCacheEntries)
+
+		err = oc.HTTPClient.Do(context.Background(), "GET", ts.URL, nil, nil, nil)
+		assert.NotNil(err)
+		assert.Equal("otgo-test/1.0", <-ua)
+		// the given client is not modified
+		assert.NotEqual(cli, oc.HTTPClient)
+		assert.Equal("", cli.Header.Get("User-Agent"))
+		assert.Equal(5*time.Second, cli.Timeout)
+
+		token, err := oc.SignSelf()
+		assert.Nil(err)
+		cfg, err := oc.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		vid, err := otgo.ParseOTVID(token, cfg.JWKSet, td.NewOTID("app", "123"), td.OTID())
+		assert.Nil(err)
+		assert.Equal(td.NewOTID("app", "123"), vid.ID)
+	})
+
+	t.Run("WithSelfTokenLifetime & WithRenewBefore", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		_, err := otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"), otgo.WithSelfTokenLifetime(0))
+		assert.NotNil(err)
+		_, err = otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"), otgo.WithRenewBefore(-time.Second))
+		assert.NotNil(err)
+
+		var signs int32
+		ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), &signs)
+		defer ts.Close()
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), td.NewOTID("app", "123"),
+			otgo.WithOTAuth(ts.URL),
+			otgo.WithSelfTokenLifetime(time.Hour),
+			otgo.WithRenewBefore(2*time.Minute),
+		)
+		assert.Nil(err)
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		token, err := oc.SignSelf()
+		assert.Nil(err)
+		vid, err := otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Equal(fc.Now().Add(time.Hour).Unix(), vid.Expiry.Unix())
+
+		// the OTVIDs issued by OT-Auth expire in 10 minutes
+		aud := td.NewOTID("svc", "a")
+		_, err = oc.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+		fc.Advance(7 * time.Minute)
+		_, err = oc.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+		fc.Advance(2 * time.Minute)
+		_, err = oc.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(int32(2), atomic.LoadInt32(&signs))
+	})
+}
@@ -1,13 +1,14 @@
 package otgo
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
-const otidMaxSize = 512
-
 // TrustDomain ...
 type TrustDomain string
 
@@ -58,19 +59,99 @@ type OTID struct {
 }
 
 // ParseOTID parses a Open Trust ID from a string.
-func ParseOTID(s string) (OTID, error) {
-	ss := strings.Split(s, ":")
-	if len(ss) < 2 {
-		return OTID{}, fmt.Errorf("otgo.ParseOTID: invalid OTID string '%s'", s)
+func ParseOTID(s string, opts ...ParseOption) (OTID, error) {
+	var o *parseOptions
+	if len(opts) > 0 {
+		o = newParseOptions(opts)
+	}
+	return parseOTID(s, o)
+}
+
+// ParseOTIDBytes parses a Open Trust ID from a byte slice like ParseOTID, e.g. a claim read from a token.
+// The bytes are copied once, the OTID's parts share the copy, the bytes longer than the max size are not copied.
+func ParseOTIDBytes(b []byte, opts ...ParseOption) (OTID, error) {
+	var o *parseOptions
+	if len(opts) > 0 {
+		o = newParseOptions(opts)
+	}
+	if l := len(b); l > o.otidMaxSize() {
+		return OTID{}, errOTIDTooLarge(l)
+	}
+	return parseOTID(string(b), o)
+}
+
+// parseOTID scans the string without intermediate slices, the string is reused as the OTID's
+// string representation if the trust domain is not converted.
+// The strings longer than the max size or with malformed UTF-8 are rejected before scanning,
+// and the errors quote the input, so that untrusted input is not echoed raw.
+func parseOTID(s string, o *parseOptions) (OTID, error) {
+	if l := len(s); l > o.otidMaxSize() {
+		return OTID{}, errOTIDTooLarge(l)
+	}
+	if !utf8.ValidString(s) {
+		return OTID{}, errors.New("otgo.ParseOTID: invalid UTF-8 in OTID string")
+	}
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return OTID{}, fmt.Errorf("otgo.ParseOTID: invalid OTID string %q", s)
+	}
+	if s[:i] != "otid" {
+		return OTID{}, fmt.Errorf("otgo.ParseOTID: invalid OTID scheme %q", s[:i])
+	}
+	id := OTID{}
+	td := s[i+1:]
+	if j := strings.IndexByte(td, ':'); j >= 0 {
+		sub := td[j+1:]
+		td = td[:j]
+		k := strings.IndexByte(sub, ':')
+		if k < 0 || strings.IndexByte(sub[k+1:], ':') >= 0 {
+			return OTID{}, fmt.Errorf("otgo.NewOTID: invalid subject params %#v", strings.Split(sub, ":"))
+		}
+		id.subjectType, id.subjectID = sub[:k], sub[k+1:]
+		if id.subjectType == "" || id.subjectID == "" {
+			return OTID{}, fmt.Errorf("otgo.NewOTID: invalid subject params %#v", []string{id.subjectType, id.subjectID})
+		}
+	}
+
+	var limits *Limits
+	encoded := false
+	id.trustDomain = TrustDomain(td)
+	if o != nil {
+		var err error
+		if id.trustDomain, err = o.trustDomain(td); err != nil {
+			return OTID{}, err
+		}
+		limits, encoded = o.limits, o.encodedID
 	}
-	if ss[0] != "otid" {
-		return OTID{}, fmt.Errorf("otgo.ParseOTID: invalid OTID scheme '%s'", ss[0])
+	if string(id.trustDomain) == td {
+		id.otid = s
+	} else {
+		id.build()
 	}
-	return NewOTID(ss[1], ss[2:]...)
+	if err := id.validateWith(limits, encoded); err != nil {
+		return OTID{}, err
+	}
+	return id, nil
+}
+
+func (o *parseOptions) otidMaxSize() int {
+	var limits *Limits
+	if o != nil {
+		limits = o.limits
+	}
+	return limits.otidMaxSize()
+}
+
+func errOTIDTooLarge(l int) error {
+	return fmt.Errorf("otgo.ParseOTID: invalid OTID, it' length %d is too large", l)
 }
 
 // NewOTID creates a new OTID using the trust domain (e.g. example.org) and subject parameters (type and ID).
 func NewOTID(trustDomain string, subject ...string) (OTID, error) {
+	return newOTID(trustDomain, nil, subject...)
+}
+
+func newOTID(trustDomain string, limits *Limits, subject ...string) (OTID, error) {
 	id := &OTID{}
 	id.trustDomain = TrustDomain(trustDomain)
 	switch len(subject) {
@@ -85,7 +166,7 @@ func NewOTID(trustDomain string, subject ...string) (OTID, error) {
 		return OTID{}, fmt.Errorf("otgo.NewOTID: invalid subject params %#v", subject)
 	}
 	id.build()
-	if err := id.Validate(); err != nil {
+	if err := id.validateWith(limits, false); err != nil {
 		return OTID{}, err
 	}
 	return *id, nil
@@ -93,13 +174,24 @@ func NewOTID(trustDomain string, subject ...string) (OTID, error) {
 
 // Validate returns a error if the OTID is invalid.
 func (id OTID) Validate() error {
-	if e := id.validate(); e != "" {
+	return id.validateWith(nil, false)
+}
+
+// ValidateEncoded returns a error if the OTID is invalid like Validate, but accepts the subject ID
+// percent-encoded by EncodeSubjectID, see NewEncodedOTID.
+func (id OTID) ValidateEncoded() error {
+	return id.validateWith(nil, true)
+}
+
+// validateWith validates the OTID with the limits, the percent-encoded subject ID is accepted if encoded is true.
+func (id OTID) validateWith(limits *Limits, encoded bool) error {
+	if e := id.validate(limits.otidMaxSize(), encoded); e != "" {
 		return fmt.Errorf("otgo.OTID.Validate: %s", e)
 	}
 	return nil
 }
 
-func (id *OTID) validate() string {
+func (id *OTID) validate(maxSize int, encoded bool) string {
 	if err := id.trustDomain.Validate(); err != nil {
 		return err.Error()
 	}
@@ -114,12 +206,16 @@ func (id *OTID) validate() string {
 		if id.subjectID == "" {
 			return "invalid OTID, subject ID required"
 		}
-		if qr := checkRunes(id.subjectID); qr != "" {
+		check := checkRunes
+		if encoded {
+			check = checkSubjectID
+		}
+		if qr := check(id.subjectID); qr != "" {
 			return fmt.Sprintf("invalid OTID subject id: %s", qr)
 		}
 	}
 
-	if l := len(id.otid); l > otidMaxSize {
+	if l := len(id.otid); l > maxSize {
 		return fmt.Sprintf("invalid OTID, it' length %d is too large", l)
 	}
 	return ""
@@ -135,6 +231,12 @@ func (id OTID) Equal(another OTID) bool {
 	return id.otid == another.otid
 }
 
+// EqualConstantTime returns true if the OTID is the same as another OTID like Equal, in time independent
+// of the contents, e.g. to compare a OTID with a secret or allow-listed OTID. The time depends on the lengths.
+func (id OTID) EqualConstantTime(another OTID) bool {
+	return subtle.ConstantTimeCompare([]byte(id.otid), []byte(another.otid)) == 1
+}
+
 // IsDomainID returns true if the OTID is the trust domain' OTID.
 func (id OTID) IsDomainID() bool {
 	return id.subjectType == "" && id.subjectID == ""
@@ -198,10 +300,13 @@ func (id *OTID) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 	if len(data) < 3 || data[0] != '"' || data[len(data)-1] != '"' {
-		return fmt.Errorf("otgo.OTID.UnmarshalJSON: invalid string for OTID %s", string(data))
+		if len(data) > 64 {
+			data = append(data[:64:64], "..."...)
+		}
+		return fmt.Errorf("otgo.OTID.UnmarshalJSON: invalid string for OTID %q", data)
 	}
 	var err error
-	*id, err = ParseOTID(string(data[1 : len(data)-1]))
+	*id, err = ParseOTIDBytes(data[1 : len(data)-1])
 	return err
 }
 
@@ -219,7 +324,7 @@ func (id *OTID) UnmarshalText(data []byte) error {
 		return nil
 	}
 	var err error
-	*id, err = ParseOTID(string(data))
+	*id, err = ParseOTIDBytes(data)
 	return err
 }
 
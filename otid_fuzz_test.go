@@ -0,0 +1,84 @@
+//go:build go1.18
+// +build go1.18
+
+package otgo_test
+
+import (
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+)
+
+func FuzzParseOTID(f *testing.F) {
+	for _, s := range []string{"otid:localhost", "otid:localhost:user:123", "otid:ot.example.com:app:a-b_c.d",
+		"otid:", "otid:localhost:", "otid:localhost:user:1:2", "otid:例子.测试:user:1"} {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		id, err := otgo.ParseOTID(s)
+		if err != nil {
+			return
+		}
+		if id.String() != s {
+			t.Fatalf("ParseOTID(%q).String() = %q", s, id.String())
+		}
+		if err = id.Validate(); err != nil {
+			t.Fatalf("ParseOTID(%q) returns invalid OTID: %v", s, err)
+		}
+		id2, err := otgo.ParseOTIDBytes([]byte(s))
+		if err != nil || !id2.Equal(id) {
+			t.Fatalf("ParseOTIDBytes(%q) = %v, %v", s, id2, err)
+		}
+		id3, err := otgo.NewOTID(string(id.TrustDomain()), splitSubject(id)...)
+		if err != nil || !id3.Equal(id) {
+			t.Fatalf("NewOTID(%q) = %v, %v", s, id3, err)
+		}
+	})
+}
+
+func FuzzOTIDUnmarshalJSON(f *testing.F) {
+	for _, s := range []string{`"otid:localhost"`, `"otid:localhost:user:123"`, `""`, `null`, `"`, `"otid:\u0041"`,
+		`"otid:localhost:user:\xff"`, `123`, `"otid:localhost:user:a%2fb"`} {
+		f.Add([]byte(s))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var id otgo.OTID
+		if err := id.UnmarshalJSON(data); err != nil {
+			// the errors quote at most the OTID max size of the input
+			if len(err.Error()) > 4*otgo.DefaultLimits().OTIDMaxSize+256 {
+				t.Fatalf("UnmarshalJSON(%q) returns a %d bytes error", data, len(err.Error()))
+			}
+			return
+		}
+		if id.String() == "" {
+			return
+		}
+		b, err := id.MarshalJSON()
+		if err != nil || string(b) != string(data) {
+			t.Fatalf("UnmarshalJSON(%q).MarshalJSON() = %q, %v", data, b, err)
+		}
+	})
+}
+
+func FuzzOTIDEqualConstantTime(f *testing.F) {
+	f.Add("otid:localhost:user:123", "otid:localhost:user:123")
+	f.Add("otid:localhost:user:123", "otid:localhost:user:124")
+	f.Add("otid:localhost", "otid:localhost:user:123")
+	f.Fuzz(func(t *testing.T, a, b string) {
+		id1, err1 := otgo.ParseOTID(a)
+		id2, err2 := otgo.ParseOTID(b)
+		if err1 != nil || err2 != nil {
+			return
+		}
+		if id1.EqualConstantTime(id2) != id1.Equal(id2) {
+			t.Fatalf("EqualConstantTime(%q, %q) != Equal", a, b)
+		}
+	})
+}
+
+func splitSubject(id otgo.OTID) []string {
+	if id.IsDomainID() {
+		return nil
+	}
+	return []string{id.Type(), id.ID()}
+}
@@ -187,6 +187,23 @@ func TestOTID(t *testing.T) {
 
 		_, err = otgo.ParseOTID("otid:localhost:app:auth:")
 		assert.NotNil(err)
+
+		// hardened against malformed UTF-8 and huge inputs
+		_, err = otgo.ParseOTID("otid:localhost:app:\xff")
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "invalid UTF-8")
+		huge := "otid:" + strings.Repeat("a:", 1<<20)
+		_, err = otgo.ParseOTID(huge)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "is too large")
+		assert.True(len(err.Error()) < 100)
+		_, err = otgo.ParseOTIDBytes([]byte(huge))
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "is too large")
+		var ids []otgo.OTID
+		err = json.Unmarshal([]byte(`["`+huge+`"]`), &ids)
+		assert.NotNil(err)
+		assert.True(len(err.Error()) < 100)
 	})
 
 	t.Run("OTID.Validate method", func(t *testing.T) {
@@ -262,6 +279,17 @@ func TestOTID(t *testing.T) {
 		assert.False(id.Equal(otgo.TrustDomain("localhost").NewOTID("user", "abc")))
 	})
 
+	t.Run("OTID.EqualConstantTime method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		id, err := otgo.ParseOTID("otid:localhost:user:abc")
+		assert.Nil(err)
+		assert.True(id.EqualConstantTime(otgo.TrustDomain("localhost").NewOTID("user", "abc")))
+		assert.False(id.EqualConstantTime(otgo.TrustDomain("localhost").NewOTID("user", "abd")))
+		assert.False(id.EqualConstantTime(otgo.TrustDomain("localhost").OTID()))
+		assert.True(otgo.OTID{}.EqualConstantTime(otgo.OTID{}))
+	})
+
 	t.Run("OTID.MarshalJSON & OTID.UnmarshalJSON method", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -355,6 +383,53 @@ func TestOTID(t *testing.T) {
 	})
 }
 
+func TestParseOTIDBytes(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, s := range []string{"otid:localhost", "otid:localhost:user:123", "otid:", "otid:localhost:", "otid:localhost:user",
+		"otid:localhost:user:", "otid:localhost:user:1:2", "otid:Localhost", "xid:localhost", "localhost"} {
+		id1, err1 := otgo.ParseOTID(s)
+		id2, err2 := otgo.ParseOTIDBytes([]byte(s))
+		assert.Equal(err1, err2, s)
+		assert.True(id1.Equal(id2), s)
+		if err1 == nil {
+			assert.Equal(s, id2.String())
+		}
+	}
+}
+
+func BenchmarkParseOTID(b *testing.B) {
+	s := "otid:ot.example.com:user:9eebccd2-12bf-40a6-b262-65fe0487d453"
+	bs := []byte(s)
+
+	b.Run("ParseOTID", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := otgo.ParseOTID(s); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ParseOTIDBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := otgo.ParseOTIDBytes(bs); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("ParseOTID with limits", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := otgo.ParseOTID(s, otgo.WithLimits(otgo.Limits{OTIDMaxSize: 128})); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func TestOTIDs(t *testing.T) {
 	t.Run("ParseOTIDs func", func(t *testing.T) {
 		assert := assert.New(t)
@@ -0,0 +1,143 @@
+package otgo
+
+import (
+	"fmt"
+)
+
+// OTIDBuilder builds a OTID component by component, every component is validated when it is set
+// and the first error is returned by Build:
+//
+//	id, err := otgo.NewOTIDBuilder(td).Type("svc").ID("auth").Build()
+//
+// The builder is not safe for concurrent use, Clone it to derive OTIDs from a common prefix.
+type OTIDBuilder struct {
+	id      OTID
+	limits  *Limits
+	encoded bool // the subject ID is percent-encoded, see EncodedID
+	err     error
+}
+
+// NewOTIDBuilder returns a OTIDBuilder of the trust domain's OTID.
+func NewOTIDBuilder(td TrustDomain) *OTIDBuilder {
+	return (&OTIDBuilder{}).TrustDomain(td)
+}
+
+// Builder returns a OTIDBuilder initialized with the OTID's components, e.g. to alter some of them.
+func (id OTID) Builder() *OTIDBuilder {
+	return &OTIDBuilder{id: id}
+}
+
+// TrustDomain sets the trust domain.
+func (b *OTIDBuilder) TrustDomain(td TrustDomain) *OTIDBuilder {
+	if err := td.Validate(); err != nil {
+		b.fail("TrustDomain", err.Error())
+	}
+	b.id.trustDomain = td
+	return b
+}
+
+// Type sets the subject type.
+func (b *OTIDBuilder) Type(subjectType string) *OTIDBuilder {
+	if subjectType == "" {
+		b.fail("Type", "subject type required")
+	} else if qr := checkRunes(subjectType); qr != "" {
+		b.fail("Type", "invalid subject type: "+qr)
+	}
+	b.id.subjectType = subjectType
+	return b
+}
+
+// ID sets the subject ID.
+func (b *OTIDBuilder) ID(subjectID string) *OTIDBuilder {
+	if subjectID == "" {
+		b.fail("ID", "subject ID required")
+	} else if qr := checkRunes(subjectID); qr != "" {
+		b.fail("ID", "invalid subject id: "+qr)
+	}
+	b.id.subjectID, b.encoded = subjectID, false
+	return b
+}
+
+// EncodedID sets the subject ID percent-encoded by EncodeSubjectID, e.g. a email address.
+func (b *OTIDBuilder) EncodedID(subjectID string) *OTIDBuilder {
+	if subjectID == "" {
+		b.fail("EncodedID", "subject ID required")
+	}
+	b.id.subjectID, b.encoded = EncodeSubjectID(subjectID), true
+	return b
+}
+
+// Domain clears the subject, the builder builds the trust domain's OTID.
+func (b *OTIDBuilder) Domain() *OTIDBuilder {
+	b.id.subjectType, b.id.subjectID, b.encoded = "", "", false
+	return b
+}
+
+// Limits validates the size of the OTID with the limits instead of DefaultLimits.
+func (b *OTIDBuilder) Limits(limits *Limits) *OTIDBuilder {
+	b.limits = limits
+	return b
+}
+
+// Clone returns a copy of the builder, including its error.
+func (b *OTIDBuilder) Clone() *OTIDBuilder {
+	c := *b
+	return &c
+}
+
+// Err returns the first error of the builder.
+func (b *OTIDBuilder) Err() error {
+	return b.err
+}
+
+// Build returns the OTID, or the first error of the builder.
+func (b *OTIDBuilder) Build() (OTID, error) {
+	if b.err != nil {
+		return OTID{}, b.err
+	}
+	id := b.id
+	id.build()
+	if err := id.validateWith(b.limits, b.encoded); err != nil {
+		return OTID{}, fmt.Errorf("otgo.OTIDBuilder.Build: %s", err.Error())
+	}
+	return id, nil
+}
+
+// MustBuild returns the OTID like Build, it panics on error.
+func (b *OTIDBuilder) MustBuild() OTID {
+	id, err := b.Build()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func (b *OTIDBuilder) fail(method, msg string) {
+	if b.err == nil {
+		b.err = fmt.Errorf("otgo.OTIDBuilder.%s: %s", method, msg)
+	}
+}
+
+// WithTrustDomain returns a copy of the OTID in the trust domain.
+// The OTID should be checked with Validate() method before using.
+func (id OTID) WithTrustDomain(td TrustDomain) OTID {
+	id.trustDomain = td
+	id.build()
+	return id
+}
+
+// WithType returns a copy of the OTID with the subject type.
+// The OTID should be checked with Validate() method before using.
+func (id OTID) WithType(subjectType string) OTID {
+	id.subjectType = subjectType
+	id.build()
+	return id
+}
+
+// WithID returns a copy of the OTID with the subject ID.
+// The OTID should be checked with Validate() method before using.
+func (id OTID) WithID(subjectID string) OTID {
+	id.subjectID = subjectID
+	id.build()
+	return id
+}
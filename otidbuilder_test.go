@@ -0,0 +1,75 @@
+package otgo_test
+
+import (
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTIDBuilder(t *testing.T) {
+	td := otgo.TrustDomain("ot.example.com")
+
+	t.Run("OTIDBuilder.Build method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		id, err := otgo.NewOTIDBuilder(td).Type("svc").ID("auth").Build()
+		assert.Nil(err)
+		assert.Equal("otid:ot.example.com:svc:auth", id.String())
+		assert.True(id.Equal(td.NewOTID("svc", "auth")))
+
+		id, err = otgo.NewOTIDBuilder(td).Build()
+		assert.Nil(err)
+		assert.True(id.IsDomainID())
+
+		_, err = otgo.NewOTIDBuilder("").Type("svc").ID("auth").Build()
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "otgo.OTIDBuilder.TrustDomain")
+
+		b := otgo.NewOTIDBuilder(td).Type("s vc").ID("")
+		assert.NotNil(b.Err())
+		_, err = b.Build()
+		assert.Equal(b.Err(), err)
+		assert.Contains(err.Error(), "otgo.OTIDBuilder.Type")
+
+		_, err = otgo.NewOTIDBuilder(td).Type("svc").Build()
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "subject ID required")
+
+		_, err = otgo.NewOTIDBuilder(td).Type("svc").ID("auth").Limits(&otgo.Limits{OTIDMaxSize: 10}).Build()
+		assert.NotNil(err)
+
+		assert.Panics(func() {
+			otgo.NewOTIDBuilder(td).ID("auth").MustBuild()
+		})
+	})
+
+	t.Run("OTIDBuilder.Clone method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		svc := otgo.NewOTIDBuilder(td).Type("svc")
+		a := svc.Clone().ID("a").MustBuild()
+		b := svc.Clone().ID("b").MustBuild()
+		assert.Equal("otid:ot.example.com:svc:a", a.String())
+		assert.Equal("otid:ot.example.com:svc:b", b.String())
+		assert.True(svc.Clone().Domain().MustBuild().Equal(td.OTID()))
+
+		id, err := a.Builder().TrustDomain("partner.example").ID("c").Build()
+		assert.Nil(err)
+		assert.Equal("otid:partner.example:svc:c", id.String())
+		assert.Equal("otid:ot.example.com:svc:a", a.String())
+	})
+
+	t.Run("OTID.WithID method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		id := td.NewOTID("svc", "auth")
+		other := id.WithID("other")
+		assert.Equal("otid:ot.example.com:svc:other", other.String())
+		assert.Nil(other.Validate())
+		assert.Equal("otid:ot.example.com:app:auth", id.WithType("app").String())
+		assert.Equal("otid:partner.example:svc:auth", id.WithTrustDomain("partner.example").String())
+		assert.Equal("otid:ot.example.com:svc:auth", id.String())
+		assert.NotNil(id.WithID("").Validate())
+	})
+}
@@ -1,16 +1,26 @@
 package otgo
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
 	"github.com/lestrrat-go/jwx/jws"
 	"github.com/lestrrat-go/jwx/jwt"
 )
 
-const otvidMaxSize = 2048
+// The reasons of the claims verification errors of OTVIDs, they can be tested with errors.Is,
+// e.g. to tell the expired OTVIDs from the invalid ones.
+var (
+	ErrExpired         = errors.New("expiration time not satisfied")
+	ErrInvalidIssuer   = errors.New("issuer not satisfied")
+	ErrInvalidAudience = errors.New("audience not satisfied")
+)
 
 // OTVID represents a Open Trust Verifiable Identity Document.
 type OTVID struct {
@@ -26,12 +36,46 @@ type OTVID struct {
 	IssuedAt time.Time
 	// Release ID
 	ReleaseID string
+	// JTI is the unique identifier of OTVID as present in 'jti' claim, see GenerateJTI
+	JTI string
+	// Confirmation binds the OTVID to a key of the presenter as present in 'cnf' claim, see VerifyBinding
+	Confirmation *Confirmation
 	// Claims is the parsed claims from token
 	Claims map[string]interface{}
+	// RawClaims is the JSON claims set (the JWT payload) as received, it is set by the parsers if the raw claims
+	// are preserved, and the numbers in Claims are json.Number instead of float64 so that the large integers
+	// keep their precision. See WithRawClaims and OTClient.PreserveRawClaims.
+	RawClaims []byte
 	// token is the serialized JWT token
 	token string
 }
 
+// OTVIDHeader is the JWS protected header of a signed OTVID.
+type OTVIDHeader struct {
+	Alg string   `json:"alg"`
+	Kid string   `json:"kid"`
+	Typ string   `json:"typ,omitempty"`
+	X5C []string `json:"x5c,omitempty"`
+}
+
+// Header returns the JWS protected header of the OTVID after it is signed or parsed,
+// e.g. to tell which key signed it. It is empty if the OTVID is not signed.
+func (o *OTVID) Header() OTVIDHeader {
+	h := OTVIDHeader{}
+	i := strings.IndexByte(o.token, '.')
+	if i <= 0 {
+		return h
+	}
+	b, err := base64.RawURLEncoding.DecodeString(o.token[:i])
+	if err == nil {
+		err = json.Unmarshal(b, &h)
+	}
+	if err != nil {
+		return OTVIDHeader{}
+	}
+	return h
+}
+
 // ToJWT returns a JWT from OTVID.
 func (o *OTVID) ToJWT() (Token, error) {
 	var err error
@@ -61,18 +105,67 @@ func (o *OTVID) ToJWT() (Token, error) {
 			return t, err
 		}
 	}
+	if o.JTI != "" {
+		if err = t.Set("jti", o.JTI); err != nil {
+			return t, err
+		}
+	}
+	if !o.Confirmation.empty() {
+		if err = t.Set("cnf", o.Confirmation); err != nil {
+			return t, err
+		}
+	}
 	return t, nil
 }
 
+// reservedClaims are the claims managed by OTVID fields, they can not be set as private claims.
+var reservedClaims = []string{"sub", "iss", "aud", "exp", "iat", "nbf", "jti", "rid", "cnf", claimCompressed, claimParent}
+
+// SetClaims sets the OTVID's private claims from a struct (or map) v via JSON tags.
+// It returns a error if v contains reserved claims, e.g. "sub", "exp".
+func (o *OTVID) SetClaims(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("otgo.OTVID.SetClaims: %s", err.Error())
+	}
+	claims := make(map[string]interface{})
+	if err = json.Unmarshal(b, &claims); err != nil {
+		return fmt.Errorf("otgo.OTVID.SetClaims: %s", err.Error())
+	}
+	for _, k := range reservedClaims {
+		if _, ok := claims[k]; ok {
+			return fmt.Errorf("otgo.OTVID.SetClaims: reserved claim '%s' not allowed", k)
+		}
+	}
+	o.Claims = claims
+	return nil
+}
+
+// BindClaims binds the OTVID's private claims to a struct v via JSON tags.
+func (o *OTVID) BindClaims(v interface{}) error {
+	b, err := json.Marshal(o.Claims)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	if err != nil {
+		return fmt.Errorf("otgo.OTVID.BindClaims: %s", err.Error())
+	}
+	return nil
+}
+
 // Validate ...
 func (o *OTVID) Validate() error {
-	if err := o.ID.Validate(); err != nil {
+	return o.validate(nil)
+}
+
+func (o *OTVID) validate(limits *Limits) error {
+	if err := o.ID.validateWith(limits, false); err != nil {
 		return fmt.Errorf("sub OTID invalid: %s", err.Error())
 	}
-	if err := o.Issuer.Validate(); err != nil {
+	if err := o.Issuer.validateWith(limits, false); err != nil {
 		return fmt.Errorf("iss OTID invalid: %s", err.Error())
 	}
-	if err := o.Audience.Validate(); err != nil {
+	if err := o.Audience.validateWith(limits, false); err != nil {
 		return fmt.Errorf("aud OTID invalid: %s", err.Error())
 	}
 	return nil
@@ -80,31 +173,42 @@ func (o *OTVID) Validate() error {
 
 // Verify ...
 func (o *OTVID) Verify(ks *JWKSet, issuer, audience OTID) error {
+	return o.VerifyWithIssuedAt(ks, issuer, audience, 0, -1)
+}
+
+// VerifyWithIssuedAt verifies the OTVID like Verify with the leeway for clock skew, and checks
+// its issued-at time like VerifyIssuedAt with maxAge, the check is skipped if maxAge is negative.
+func (o *OTVID) VerifyWithIssuedAt(ks *JWKSet, issuer, audience OTID, leeway, maxAge time.Duration) error {
 	err := o.Validate()
 	if err != nil {
 		return err
 	}
-	if err = o.verifyClaims(issuer, audience); err != nil {
+	if err = o.verifyClaims(issuer, audience, leeway, newIssuedAtCheck(maxAge)); err != nil {
 		return err
 	}
 	if ks == nil {
 		return fmt.Errorf("otgo.OTVID.Verify: public keys required")
 	}
-	_, err = jwt.ParseString(o.token, jwt.WithKeySet(ks))
-	return err
+	d := &decodedOTVID{}
+	if _, err = d.split(o.token); err != nil {
+		return err
+	}
+	return d.verify(ks)
 }
 
-func (o *OTVID) verifyClaims(issuer, audience OTID) error {
+// verifyClaims verifies the claims, the expiration time and the issued-at time (if iat is not nil)
+// are checked with the leeway for clock skew.
+func (o *OTVID) verifyClaims(issuer, audience OTID, leeway time.Duration, iat *issuedAtCheck) error {
 	if !o.Issuer.Equal(issuer) {
-		return errors.New(`otgo.OTVID.Verify: issuer not satisfied`)
+		return fmt.Errorf("otgo.OTVID.Verify: %w", ErrInvalidIssuer)
 	}
 	if !o.Audience.Equal(audience) {
-		return errors.New(`otgo.OTVID.Verify: audience not satisfied`)
+		return fmt.Errorf("otgo.OTVID.Verify: %w", ErrInvalidAudience)
 	}
-	if !time.Now().Truncate(time.Second).Before(o.Expiry) {
-		return errors.New(`otgo.OTVID.Validate: expiration time not satisfied`)
+	if !clockNow().Add(-leeway).Truncate(time.Second).Before(o.Expiry) {
+		return fmt.Errorf("otgo.OTVID.Validate: %w", ErrExpired)
 	}
-	return nil
+	return iat.check(o, leeway)
 }
 
 // Token ...
@@ -117,115 +221,177 @@ func (o *OTVID) MaybeRevoked() bool {
 	return o.ReleaseID != ""
 }
 
-// ShouldRenew ...
+// DefaultRenewBefore is the renewal threshold of ShouldRenew, see OTClient.RenewBefore.
+const DefaultRenewBefore = 10 * time.Second
+
+// DefaultSelfTokenLifetime is the lifetime of the self-signed OTVIDs, see OTClient.SelfTokenLifetime.
+const DefaultSelfTokenLifetime = 10 * time.Minute
+
+// ShouldRenew reports whether the OTVID expires in DefaultRenewBefore.
 func (o *OTVID) ShouldRenew() bool {
-	return time.Now().Add(time.Second * 10).After(o.Expiry)
+	return o.ShouldRenewBefore(DefaultRenewBefore)
+}
+
+// ShouldRenewBefore reports whether the OTVID expires in d.
+func (o *OTVID) ShouldRenewBefore(d time.Duration) bool {
+	return clockNow().Add(d).After(o.Expiry)
+}
+
+// GenerateJTI assigns a random UUID to the OTVID's JTI, so that the Verifier's ReplayChecker
+// can reject the replayed OTVID. It should be called before Sign, see Verifier.SetReplayChecker.
+func (o *OTVID) GenerateJTI() error {
+	jti, err := newUUID()
+	if err != nil {
+		return err
+	}
+	o.JTI = jti
+	return nil
 }
 
 // Sign ...
 func (o *OTVID) Sign(key Key) (string, error) {
-	var err error
-	var t Token
-	if err = validateKeys(key); err != nil {
-		return "", err
-	}
+	return o.sign(key, &signOptions{})
+}
 
-	hdrs := jws.NewHeaders()
-	alg := key.Algorithm()
-	if err = hdrs.Set("alg", alg); err != nil {
-		return "", err
-	}
-	if err = hdrs.Set("kid", key.KeyID()); err != nil {
-		return "", err
-	}
+// SignWithLimits signs the OTVID like Sign with the limits instead of DefaultLimits.
+func (o *OTVID) SignWithLimits(key Key, limits Limits) (string, error) {
+	return o.sign(key, &signOptions{limits: &limits})
+}
 
-	o.IssuedAt = time.Now().UTC().Truncate(time.Second)
-	if o.Expiry.Unix() <= 0 {
-		o.Expiry = o.IssuedAt.Add(time.Minute * 10)
-	}
-	if t, err = o.ToJWT(); err != nil {
+// SignWithLaxKeyUsage signs the OTVID like Sign, but ignores the key's "use" and "key_ops" parameters,
+// for legacy key sets. See Verifier.SetLaxKeyUsage.
+func (o *OTVID) SignWithLaxKeyUsage(key Key) (string, error) {
+	return o.sign(key, &signOptions{laxKeyUsage: true})
+}
+
+type signOptions struct {
+	headers     map[string]interface{} // extra protected headers
+	limits      *Limits
+	compress    bool // compress the private claims
+	laxKeyUsage bool // ignore the key's "use" and "key_ops" parameters
+}
+
+// sign signs the OTVID with the options.
+func (o *OTVID) sign(key Key, opts *signOptions) (string, error) {
+	hdrs, t, err := o.prepare(key, opts)
+	if err != nil {
 		return "", err
 	}
-	s, err := jwt.Sign(t, jwa.SignatureAlgorithm(alg), key, jwt.WithHeaders(hdrs))
+	s, err := jwt.Sign(t, jwa.SignatureAlgorithm(key.Algorithm()), key, jwt.WithHeaders(hdrs))
 	if err != nil {
 		return "", err
 	}
 	o.token = string(s)
-	if l := len(s); l > otvidMaxSize {
+	if l := len(s); l > opts.limits.otvidMaxSize() {
 		return "", fmt.Errorf("invalid OTVID, it' length %d is too large", l)
 	}
 	return o.token, nil
 }
 
-// FromJWT returns a OTVID from a JWT token
-func FromJWT(token string, t Token) (*OTVID, error) {
+// prepare fills the OTVID's iat and exp, and returns the protected headers and the JWT to sign.
+func (o *OTVID) prepare(key Key, opts *signOptions) (jws.Headers, Token, error) {
 	var err error
-
-	vid := &OTVID{token: token}
-	vid.ID, err = ParseOTID(t.Subject())
-	if err == nil {
-		vid.Issuer, err = ParseOTID(t.Issuer())
+	if err = validateKeys(key); err != nil {
+		return nil, nil, err
 	}
-	if err == nil {
-		if as := t.Audience(); len(as) > 0 {
-			vid.Audience, err = ParseOTID(as[0])
+	if !opts.laxKeyUsage {
+		if err = checkKeyUsage(key, jwk.KeyOpSign); err != nil {
+			return nil, nil, err
 		}
 	}
-	if err == nil {
-		if rid, ok := t.Get("rid"); ok {
-			if vid.ReleaseID, ok = rid.(string); !ok {
-				return nil, fmt.Errorf("invalid 'rid' field, must be a string")
-			}
+	return o.prepareWith(key.Algorithm(), key.KeyID(), opts)
+}
+
+// prepareWith prepares the OTVID like prepare for the signing key with the algorithm and key ID.
+func (o *OTVID) prepareWith(alg, kid string, opts *signOptions) (jws.Headers, Token, error) {
+	var err error
+	hdrs := jws.NewHeaders()
+	for k, v := range opts.headers {
+		if err = hdrs.Set(k, v); err != nil {
+			return nil, nil, err
 		}
 	}
-	if err == nil {
-		vid.Expiry = t.Expiration()
-		vid.IssuedAt = t.IssuedAt()
-		vid.Claims = t.PrivateClaims()
-		err = vid.Validate()
+	if err = hdrs.Set("alg", alg); err != nil {
+		return nil, nil, err
+	}
+	if err = hdrs.Set("kid", kid); err != nil {
+		return nil, nil, err
+	}
+
+	o.IssuedAt = clockNow().UTC().Truncate(time.Second)
+	if o.Expiry.Unix() <= 0 {
+		o.Expiry = o.IssuedAt.Add(time.Minute * 10)
+	}
+	c := *o
+	if opts.compress {
+		if c.Claims, err = deflateClaims(o.Claims); err != nil {
+			return nil, nil, err
+		}
 	}
+	t, err := c.ToJWT()
 	if err != nil {
+		return nil, nil, err
+	}
+	return hdrs, t, nil
+}
+
+// FromJWT returns a OTVID from a JWT token
+func FromJWT(token string, t Token) (*OTVID, error) {
+	return fromJWT(token, t, false)
+}
+
+// fromJWT returns a OTVID from a JWT token, the 'sub' claim can be a SPIFFE ID if spiffeSub is true.
+// The token's claims are decoded like the serialized tokens by claimsToOTVID.
+func fromJWT(token string, t Token, spiffeSub bool) (*OTVID, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	claims := make(map[string]interface{})
+	if err = json.Unmarshal(data, &claims); err != nil {
 		return nil, err
 	}
-	return vid, nil
+	return claimsToOTVID(token, claims, spiffeSub, nil, false)
 }
 
 // ParseOTVID parses a OTVID from a serialized JWT token.
 // The OTVID signature is verified using the JWK set.
 func ParseOTVID(token string, ks *JWKSet, issuer, audience OTID) (*OTVID, error) {
-	if l := len(token); l < 64 || l > 2048 {
-		return nil, fmt.Errorf("invalid OTVID token with length %d", l)
-	}
+	return parseOTVID(token, ks, issuer, audience, false)
+}
+
+// ParseOTVIDWithLaxKeyUsage parses and verifies the OTVID like ParseOTVID, but ignores the keys' "use"
+// and "key_ops" parameters, for legacy key sets. See Verifier.SetLaxKeyUsage.
+func ParseOTVIDWithLaxKeyUsage(token string, ks *JWKSet, issuer, audience OTID) (*OTVID, error) {
+	return parseOTVIDWith(token, ks, issuer, audience, false, true)
+}
+
+func parseOTVID(token string, ks *JWKSet, issuer, audience OTID, spiffeSub bool) (*OTVID, error) {
+	return parseOTVIDWith(token, ks, issuer, audience, spiffeSub, false)
+}
+
+func parseOTVIDWith(token string, ks *JWKSet, issuer, audience OTID, spiffeSub, laxKeyUsage bool) (*OTVID, error) {
 	if ks == nil {
 		return nil, fmt.Errorf("otgo.ParseOTVID: public keys required")
 	}
-	t, err := jwt.ParseString(token, jwt.WithKeySet(ks))
+	d, err := decodeOTVID(token, spiffeSub, nil, false)
 	if err != nil {
 		return nil, err
 	}
-	vid, err := FromJWT(token, t)
-	if err != nil {
-		return nil, err
-	}
-	if err = vid.verifyClaims(issuer, audience); err != nil {
-		return nil, err
-	}
-	return vid, nil
+	d.laxKeyUsage = laxKeyUsage
+	return d.parse(ks, issuer, audience)
 }
 
 // ParseOTVIDInsecure parses a OTVID from a serialized JWT token.
 // The OTVID signature is not verified.
 func ParseOTVIDInsecure(token string) (*OTVID, error) {
-	if l := len(token); l < 64 || l > 2048 {
-		return nil, fmt.Errorf("invalid OTVID token with length %d", l)
-	}
-	t, err := jwt.ParseString(token)
-	if err != nil {
-		return nil, err
-	}
-	vid, err := FromJWT(token, t)
+	return parseOTVIDInsecure(token, false)
+}
+
+func parseOTVIDInsecure(token string, spiffeSub bool) (*OTVID, error) {
+	d, err := decodeOTVID(token, spiffeSub, nil, false)
 	if err != nil {
 		return nil, err
 	}
-	return vid, nil
+	return d.vid, nil
 }
@@ -47,6 +47,8 @@ func TestOTVID(t *testing.T) {
 		assert.True(vid.ShouldRenew())
 		vid.Expiry = time.Now().Add(time.Second * 61)
 		assert.False(vid.ShouldRenew())
+		assert.True(vid.ShouldRenewBefore(time.Minute * 2))
+		assert.False(vid.ShouldRenewBefore(time.Minute))
 	})
 
 	t.Run("OTVID.ToJWT method", func(t *testing.T) {
@@ -69,6 +71,40 @@ func TestOTVID(t *testing.T) {
 		assert.Equal("test", token.PrivateClaims()["name"].(string))
 	})
 
+	t.Run("OTVID.SetClaims & OTVID.BindClaims method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		type profile struct {
+			Name  string   `json:"name"`
+			Roles []string `json:"roles"`
+			Level int      `json:"level,omitempty"`
+		}
+
+		vid := &otgo.OTVID{}
+		td := otgo.TrustDomain("localhost")
+		vid.ID = td.NewOTID("user", "abc")
+		vid.Issuer = td.OTID()
+		vid.Audience = td.NewOTID("app", "123")
+		vid.Expiry = time.Now().Add(time.Hour)
+		assert.Nil(vid.SetClaims(profile{Name: "tom", Roles: []string{"admin"}, Level: 3}))
+		assert.Equal("tom", vid.Claims["name"])
+
+		assert.NotNil(vid.SetClaims(map[string]interface{}{"sub": "otid:localhost:user:xyz"}))
+		assert.NotNil(vid.SetClaims(make(chan int)))
+		assert.Equal("tom", vid.Claims["name"])
+
+		pk := otgo.MustPrivateKey("ES256")
+		token, err := vid.Sign(pk)
+		assert.Nil(err)
+		vid, err = otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+
+		p := profile{}
+		assert.Nil(vid.BindClaims(&p))
+		assert.Equal(profile{Name: "tom", Roles: []string{"admin"}, Level: 3}, p)
+		assert.NotNil(vid.BindClaims(p))
+	})
+
 	t.Run("OTVID.Sign & OTVID.Verify method", func(t *testing.T) {
 		assert := assert.New(t)
 
@@ -206,4 +242,31 @@ func TestOTVID(t *testing.T) {
 		assert.NotNil(vid2.Verify(pubKeys2, vid.ID, vid.Audience))
 		assert.NotNil(vid2.Verify(pubKeys2, vid.Issuer, vid.ID))
 	})
+
+	t.Run("OTVID.Header method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: td.NewOTID("app", "123")}
+		assert.Equal(otgo.OTVIDHeader{}, vid.Header())
+
+		key := otgo.MustPrivateKey("ES384")
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		h := vid.Header()
+		assert.Equal("ES384", h.Alg)
+		assert.Equal(key.KeyID(), h.Kid)
+		assert.Equal("JWT", h.Typ)
+		assert.Nil(h.X5C)
+
+		vid2, err := otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Equal(h, vid2.Header())
+
+		jwt, err := vid.ToJWT()
+		assert.Nil(err)
+		vid3, err := otgo.FromJWT(token, jwt)
+		assert.Nil(err)
+		assert.Equal(h, vid3.Header())
+	})
 }
@@ -0,0 +1,189 @@
+package otgo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is the reason of RateLimitError, it can be tested with errors.Is.
+var ErrRateLimited = errors.New("rate limited")
+
+// RateLimitError is returned by Client.Do if the server responds with 429 Too Many Requests
+// or 503 Service Unavailable. OTClient backs off the renewals of its cache for RetryAfter
+// (or one second if absent) instead of calling OT-Auth again on every request.
+type RateLimitError struct {
+	StatusCode int
+	RetryAfter time.Duration // parsed from the Retry-After response header, 0 if absent
+	Response   string
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("non-success response, status code: %v, retry after: %v, response: %s",
+		e.StatusCode, e.RetryAfter, e.Response)
+}
+
+// Unwrap ...
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+func isRateLimitStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// rateLimitBackoff is the back off of a rate limited renewal without Retry-After.
+const rateLimitBackoff = time.Second
+
+// renewBackoff holds the back off of a renewer after a rate limited renewal,
+// it should be accessed with the renewer locked.
+type renewBackoff struct {
+	until time.Time
+	err   *RateLimitError
+}
+
+// active returns the rate limited error with the remaining delay if the renewer is backing off.
+func (b *renewBackoff) active() error {
+	d := clockUntil(b.until)
+	if b.err == nil || d <= 0 {
+		return nil
+	}
+	err := *b.err
+	err.RetryAfter = d
+	return &err
+}
+
+// update starts backing off if err is a RateLimitError, it returns true if so.
+func (b *renewBackoff) update(err error) bool {
+	var rl *RateLimitError
+	if !errors.As(err, &rl) {
+		return false
+	}
+	d := rl.RetryAfter
+	if d <= 0 {
+		d = rateLimitBackoff
+	}
+	b.until = clockNow().Add(d)
+	b.err = rl
+	return true
+}
+
+// ErrClientRateLimited is the reason of ClientRateLimitError, it can be tested with errors.Is.
+var ErrClientRateLimited = errors.New("client rate limited")
+
+// ClientRateLimitError is returned by the OTClient if its RateLimiter rejects a call to the OT-Auth service.
+type ClientRateLimitError struct {
+	Op         Op            // the rejected operation, e.g. OpSign or OpConfigFetch
+	RetryAfter time.Duration // the delay until the limiter allows a call
+}
+
+func (e *ClientRateLimitError) Error() string {
+	return fmt.Sprintf("otgo: %s rejected by the client rate limiter, retry after: %v", e.Op, e.RetryAfter)
+}
+
+// Unwrap ...
+func (e *ClientRateLimitError) Unwrap() error {
+	return ErrClientRateLimited
+}
+
+// RateLimiter is a token bucket that limits the calls of a OTClient to the OT-Auth service,
+// so that a misconfigured hot loop cannot overload it. It allows qps calls per second on average
+// and bursts of up to burst calls, the calls over the limit are rejected without waiting.
+// It is safe for concurrent use.
+type RateLimiter struct {
+	mu     sync.Mutex
+	qps    float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a RateLimiter with a full bucket, burst is at least 1.
+func NewRateLimiter(qps float64, burst int) *RateLimiter {
+	l := &RateLimiter{}
+	l.SetLimit(qps, burst)
+	l.tokens = float64(l.burst)
+	return l
+}
+
+// WithRateLimit limits the calls of the OTClient to the OT-Auth service, see RateLimiter.
+func WithRateLimit(qps float64, burst int) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if qps <= 0 {
+			return errors.New("invalid rate limit qps")
+		}
+		oc.RateLimiter = NewRateLimiter(qps, burst)
+		return nil
+	})
+}
+
+// SetLimit changes the QPS and the burst, the available calls are kept up to the new burst.
+func (l *RateLimiter) SetLimit(qps float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(clockNow())
+	l.qps, l.burst = qps, burst
+	l.tokens = math.Min(l.tokens, float64(burst))
+}
+
+// QPS returns the average calls per second.
+func (l *RateLimiter) QPS() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.qps
+}
+
+// Burst returns the max calls in a burst.
+func (l *RateLimiter) Burst() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.burst
+}
+
+// Allow takes a call from the bucket, it returns false if the limit is exceeded.
+func (l *RateLimiter) Allow() bool {
+	return l.reserve() == 0
+}
+
+// reserve takes a call from the bucket, it returns the delay until a call is available if the limit is exceeded.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(clockNow())
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	if l.qps <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	d := time.Duration((1 - l.tokens) / l.qps * float64(time.Second))
+	if d <= 0 {
+		d = time.Nanosecond
+	}
+	return d
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.last.IsZero() && now.After(l.last) {
+		l.tokens = math.Min(float64(l.burst), l.tokens+now.Sub(l.last).Seconds()*l.qps)
+	}
+	l.last = now
+}
+
+// allowCall returns a ClientRateLimitError if the OTClient's RateLimiter rejects the call.
+func (oc *OTClient) allowCall(op Op) error {
+	if oc.RateLimiter == nil {
+		return nil
+	}
+	if d := oc.RateLimiter.reserve(); d > 0 {
+		return &ClientRateLimitError{Op: op, RetryAfter: d}
+	}
+	return nil
+}
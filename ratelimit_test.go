@@ -0,0 +1,147 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Run("Client.Do method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		status := int32(http.StatusTooManyRequests)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(int(atomic.LoadInt32(&status)))
+			w.Write([]byte("slow down"))
+		}))
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		err := cli.Do(context.Background(), "GET", ts.URL, nil, nil, &otgo.Response{})
+		assert.True(errors.Is(err, otgo.ErrRateLimited))
+		var rl *otgo.RateLimitError
+		assert.True(errors.As(err, &rl))
+		assert.Equal(http.StatusTooManyRequests, rl.StatusCode)
+		assert.Equal(7*time.Second, rl.RetryAfter)
+		assert.Equal("slow down", rl.Response)
+
+		atomic.StoreInt32(&status, http.StatusServiceUnavailable)
+		err = cli.Do(context.Background(), "GET", ts.URL, nil, nil, nil)
+		assert.True(errors.Is(err, otgo.ErrRateLimited))
+
+		atomic.StoreInt32(&status, http.StatusInternalServerError)
+		err = cli.Do(context.Background(), "GET", ts.URL, nil, nil, nil)
+		assert.NotNil(err)
+		assert.False(errors.Is(err, otgo.ErrRateLimited))
+	})
+
+	t.Run("OTClient backs off the renewal", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		key := otgo.MustPrivateKey("ES256")
+		var signs int32
+		var ts *httptest.Server
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if r.URL.Path == "/sign" {
+				atomic.AddInt32(&signs, 1)
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			b, _ := json.Marshal(map[string]interface{}{
+				"otid":             td.OTID(),
+				"keys":             otgo.LookupPublicKeys(otgo.MustKeys(key)).Keys,
+				"serviceEndpoints": []string{ts.URL},
+			})
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		oc := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		sc := oc.Service(td.NewOTID("svc", "api"))
+		_, err := sc.Resolve(context.Background())
+		assert.True(errors.Is(err, otgo.ErrRateLimited))
+		_, err = sc.Resolve(context.Background())
+		assert.True(errors.Is(err, otgo.ErrRateLimited))
+		var rl *otgo.RateLimitError
+		assert.True(errors.As(err, &rl))
+		assert.True(rl.RetryAfter > 50*time.Second && rl.RetryAfter <= 60*time.Second)
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+	})
+
+	t.Run("RateLimiter", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		l := otgo.NewRateLimiter(2, 3)
+		assert.Equal(2.0, l.QPS())
+		assert.Equal(3, l.Burst())
+		assert.True(l.Allow())
+		assert.True(l.Allow())
+		assert.True(l.Allow())
+		assert.False(l.Allow())
+		fc.Advance(500 * time.Millisecond)
+		assert.True(l.Allow())
+		assert.False(l.Allow())
+		fc.Advance(time.Hour)
+		l.SetLimit(1, 0)
+		assert.Equal(1, l.Burst())
+		assert.True(l.Allow())
+		assert.False(l.Allow())
+	})
+
+	t.Run("OTClient with rate limit", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		td := otgo.TrustDomain("localhost")
+		sub := td.NewOTID("app", "123")
+		var signs int32
+		ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), &signs)
+		defer ts.Close()
+
+		_, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithRateLimit(0, 1))
+		assert.NotNil(err)
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithRateLimit(1, 2))
+		assert.Nil(err)
+		oc.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		// the configuration fetch and a sign
+		input := otgo.SignInput{Subject: sub, Audience: td.NewOTID("svc", "tester")}
+		_, err = oc.Sign(context.Background(), input)
+		assert.Nil(err)
+		_, err = oc.Sign(context.Background(), input)
+		assert.True(errors.Is(err, otgo.ErrClientRateLimited))
+		assert.False(errors.Is(err, otgo.ErrRateLimited))
+		var rl *otgo.ClientRateLimitError
+		assert.True(errors.As(err, &rl))
+		assert.Equal(otgo.OpSign, rl.Op)
+		assert.Equal(time.Second, rl.RetryAfter)
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+
+		fc.Advance(time.Second)
+		_, err = oc.Sign(context.Background(), input)
+		assert.Nil(err)
+		assert.Equal(int32(2), atomic.LoadInt32(&signs))
+	})
+}
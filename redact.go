@@ -0,0 +1,104 @@
+package otgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are redacted in the logs of Client.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveMembers are the JSON members redacted in the logs of Client, e.g. the OTVIDs of SignInput and SignOutput.
+var sensitiveMembers = map[string]bool{
+	"otvid": true, "forwardedOtvid": true, "token": true, "access_token": true, "refresh_token": true,
+	"id_token": true, "password": true, "secret": true,
+}
+
+// privateKeyMembers are the private members of JWK [RFC7518], redacted in the objects with "kty".
+var privateKeyMembers = map[string]bool{"d": true, "p": true, "q": true, "dp": true, "dq": true, "qi": true, "oth": true, "k": true}
+
+// logExchange logs the request and the response at debug level, see Client.Logger.
+func (c *Client) logExchange(method, api string, h http.Header, body []byte, res *attemptResult, err error, d time.Duration) {
+	fields := []interface{}{"method", method, "url", redactURL(api), "requestHeader", redactHeader(h),
+		"requestBody", redactBody(body), "duration", d}
+	if res != nil && res.statusCode > 0 {
+		fields = append(fields, "status", res.statusCode, "responseBody", redactBody(res.head))
+	}
+	if err != nil {
+		fields = append(fields, "error", err.Error())
+	}
+	c.Logger.Debug("otgo: http request", fields...)
+}
+
+func redactURL(api string) string {
+	u, err := url.Parse(api)
+	if err != nil {
+		return api
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+func redactHeader(h http.Header) http.Header {
+	rh := make(http.Header, len(h))
+	copyHeader(rh, h)
+	for _, k := range sensitiveHeaders {
+		if _, ok := rh[k]; ok {
+			rh.Set(k, redacted)
+		}
+	}
+	return rh
+}
+
+// redactBody returns the JSON body with the tokens and private key members redacted,
+// only the size of a body that is not JSON (or truncated) is returned.
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return fmt.Sprintf("[%d bytes]", len(body))
+	}
+	return string(b)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		_, isJWK := val["kty"]
+		for k, e := range val {
+			if sensitiveMembers[k] || (isJWK && privateKeyMembers[k]) {
+				val[k] = redacted
+			} else {
+				val[k] = redactValue(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range val {
+			val[i] = redactValue(e)
+		}
+	case string:
+		if looksLikeJWT(val) {
+			return redacted
+		}
+	}
+	return v
+}
+
+// looksLikeJWT returns true if s is a compact JWS or JWE, whose header starts with `{"` encoded as "eyJ".
+func looksLikeJWT(s string) bool {
+	return strings.HasPrefix(s, "eyJ") && strings.Count(s, ".") >= 2
+}
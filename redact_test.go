@@ -0,0 +1,67 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientLogger(t *testing.T) {
+	t.Run("Client.Logger", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		td := otgo.TrustDomain("localhost")
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: td.OTID()}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			b, _ := json.Marshal(map[string]interface{}{"result": map[string]interface{}{
+				"otvid": token,
+				"keys":  otgo.MustKeys(key).Keys,
+				"other": "eyJhbGciOiJFUzI1NiJ9.e30.c2ln",
+				"exp":   123,
+			}})
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		tl := &testLogger{}
+		cli := otgo.NewClient(nil)
+		cli.Logger = otgo.SugaredLogger(tl)
+		h := otgo.AddTokenToHeader(make(http.Header), token)
+		h.Set("Cookie", "session=secret")
+		input := otgo.SignInput{Subject: td.NewOTID("app", "123"), Audience: td.OTID(), ForwardedOTVID: token}
+		output := map[string]interface{}{}
+		assert.Nil(cli.Do(context.Background(), "POST", ts.URL+"/sign", h, input, &output))
+		assert.Equal(token, output["result"].(map[string]interface{})["otvid"])
+
+		assert.Equal(1, len(tl.logs))
+		log := tl.logs[0]
+		assert.True(strings.HasPrefix(log, "debug otgo: http request"))
+		assert.Contains(log, "[REDACTED]")
+		assert.Contains(log, "otid:localhost:app:123")
+		assert.Contains(log, `"exp":123`)
+		assert.Contains(log, "status 200")
+		assert.NotContains(log, token)
+		assert.NotContains(log, "session=secret")
+		assert.NotContains(log, "eyJhbGciOiJFUzI1NiJ9")
+		pk := map[string]interface{}{}
+		b, _ := json.Marshal(key)
+		assert.Nil(json.Unmarshal(b, &pk))
+		assert.NotContains(log, pk["d"].(string))
+		assert.Contains(log, pk["x"].(string))
+
+		tl.logs = nil
+		cli.Logger = nil
+		assert.Nil(cli.Do(context.Background(), "GET", ts.URL, nil, nil, &output))
+		assert.Equal(0, len(tl.logs))
+	})
+}
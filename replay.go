@@ -0,0 +1,73 @@
+package otgo
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrReplayed is returned by ReplayChecker if the OTVID was seen before.
+var ErrReplayed = errors.New("otgo: OTVID replayed")
+
+// ReplayChecker is a nonce store to reject replayed OTVIDs within their validity window,
+// e.g. backed by Redis SETNX. It is consulted by Verifier for OTVIDs with 'jti' claim, see OTVID.GenerateJTI.
+type ReplayChecker interface {
+	// Check returns ErrReplayed if the issuer's jti was seen before, otherwise remembers it until exp.
+	Check(issuer OTID, jti string, exp time.Time) error
+}
+
+// replayPruneInterval is the interval MemoryReplayChecker removes the expired jtis.
+const replayPruneInterval = time.Minute
+
+// MemoryReplayChecker is a in-memory ReplayChecker, the expired jtis are removed every minute.
+type MemoryReplayChecker struct {
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	pruneAt time.Time // the next time to remove the expired jtis
+}
+
+// NewMemoryReplayChecker ...
+func NewMemoryReplayChecker() *MemoryReplayChecker {
+	return &MemoryReplayChecker{seen: make(map[string]time.Time)}
+}
+
+// Check implements the ReplayChecker interface.
+func (c *MemoryReplayChecker) Check(issuer OTID, jti string, exp time.Time) error {
+	key := issuer.String() + " " + jti
+	now := clockNow()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.seen[key]; ok && now.Before(t) {
+		return ErrReplayed
+	}
+	if !now.Before(c.pruneAt) {
+		for k, t := range c.seen {
+			if !now.Before(t) {
+				delete(c.seen, k)
+			}
+		}
+		c.pruneAt = now.Add(replayPruneInterval)
+	}
+	c.seen[key] = exp
+	return nil
+}
+
+// Len returns the number of the remembered jtis, the expired ones may not be removed yet.
+func (c *MemoryReplayChecker) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.seen)
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,110 @@
+package otgo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayChecker(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+
+	t.Run("MemoryReplayChecker", func(t *testing.T) {
+		assert := assert.New(t)
+
+		rc := otgo.NewMemoryReplayChecker()
+		exp := time.Now().Add(time.Hour)
+		assert.Nil(rc.Check(td.OTID(), "a", exp))
+		assert.Equal(otgo.ErrReplayed, rc.Check(td.OTID(), "a", exp))
+		assert.Nil(rc.Check(td.NewOTID("app", "123"), "a", exp))
+		assert.Nil(rc.Check(td.OTID(), "b", time.Now().Add(-time.Second)))
+		assert.Nil(rc.Check(td.OTID(), "b", exp))
+	})
+
+	t.Run("OTVID.GenerateJTI method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: td.NewOTID("app", "123")}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		assert.Equal("", vid.JTI)
+		vid2, err := otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Equal("", vid2.JTI)
+
+		assert.Nil(vid.GenerateJTI())
+		assert.Equal(36, len(vid.JTI))
+		token, err = vid.Sign(key)
+		assert.Nil(err)
+		vid2, err = otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Equal(vid.JTI, vid2.JTI)
+		assert.Nil(vid2.Claims["jti"])
+
+		vid3 := &otgo.OTVID{ID: vid.ID, Issuer: vid.Issuer, Audience: vid.Audience}
+		assert.Nil(vid3.GenerateJTI())
+		assert.NotEqual(vid.JTI, vid3.JTI)
+
+		vid3.JTI = "my-jti"
+		token, err = vid3.Sign(key)
+		assert.Nil(err)
+		vid2, err = otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Equal("my-jti", vid2.JTI)
+	})
+
+	t.Run("Verifier.SetReplayChecker method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		aud := td.NewOTID("app", "123")
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, key)
+		assert.Nil(err)
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud}
+		assert.Nil(vid.GenerateJTI())
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+
+		v.SetReplayChecker(otgo.NewMemoryReplayChecker())
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Equal(otgo.ErrReplayed, err)
+		_, err = v.Verify(token)
+		assert.Equal(otgo.ErrReplayed, err)
+
+		// the signature of sampled-out OTVIDs is not verified, so their jti is not checked
+		v.SetMode(otgo.VerifyDegraded, 0)
+		res, err := v.Verify(token)
+		assert.Nil(err)
+		assert.Equal(otgo.ReasonSampledOut, res.Reason)
+		v.SetMode(otgo.VerifyDegraded, 1)
+		_, err = v.Verify(token)
+		assert.Equal(otgo.ErrReplayed, err)
+	})
+
+	t.Run("MemoryReplayChecker prunes the expired jtis", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+		rc := otgo.NewMemoryReplayChecker()
+		assert.Nil(rc.Check(td.OTID(), "a", fc.Now().Add(time.Second)))
+		assert.Nil(rc.Check(td.OTID(), "b", fc.Now().Add(time.Hour)))
+		assert.Equal(2, rc.Len())
+
+		fc.Advance(2 * time.Minute)
+		assert.Nil(rc.Check(td.OTID(), "c", fc.Now().Add(time.Hour)))
+		assert.Equal(2, rc.Len())
+		assert.Equal(otgo.ErrReplayed, rc.Check(td.OTID(), "b", fc.Now().Add(time.Hour)))
+	})
+}
@@ -0,0 +1,104 @@
+package otgo
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how otgo.Client retries a failed request.
+// A request is retried on transport errors and on the RetryStatus status codes.
+// Non-idempotent requests (POST, PATCH) are only retried if RetryNonIdempotent is true
+// or the request carries an "Idempotency-Key" header.
+type RetryPolicy struct {
+	MaxAttempts        int           // total attempts including the first one, default 3
+	MinBackoff         time.Duration // backoff before the first retry, default 100ms
+	MaxBackoff         time.Duration // upper bound of backoff and Retry-After, default 5s
+	RetryStatus        []int         // default 429, 502, 503, 504
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy ...
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  100 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		RetryStatus: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+func (rp *RetryPolicy) maxAttempts() int {
+	if rp.MaxAttempts > 0 {
+		return rp.MaxAttempts
+	}
+	return 3
+}
+
+func (rp *RetryPolicy) canRetry(method string, h http.Header) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return rp.RetryNonIdempotent || h.Get("Idempotency-Key") != ""
+}
+
+func (rp *RetryPolicy) shouldRetry(res *attemptResult) bool {
+	if res.statusCode == 0 {
+		return true // transport error
+	}
+	status := rp.RetryStatus
+	if status == nil {
+		status = DefaultRetryPolicy().RetryStatus
+	}
+	for _, s := range status {
+		if s == res.statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the next attempt: exponential with equal jitter, i.e. half
+// of the delay plus a random part of the other half, or Retry-After if the server sent one.
+// Both are capped by MaxBackoff.
+func (rp *RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	min, max := rp.MinBackoff, rp.MaxBackoff
+	if min <= 0 {
+		min = 100 * time.Millisecond
+	}
+	if max <= 0 {
+		max = 5 * time.Second
+	}
+	if retryAfter > 0 {
+		if retryAfter > max {
+			return max
+		}
+		return retryAfter
+	}
+	d := min << uint(attempt-1)
+	if d <= 0 || d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// parseRetryAfter parses the Retry-After header value in seconds or HTTP-date form.
+func parseRetryAfter(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n > 0 {
+			return time.Duration(n) * time.Second
+		}
+		return 0
+	}
+	if t, err := http.ParseTime(s); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,114 @@
+package otgo_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	newServer := func(fails int32, status int, counter *int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if atomic.AddInt32(counter, 1) <= fails {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(status)
+				w.Write([]byte(`{"error": "unavailable"}`))
+				return
+			}
+			w.WriteHeader(200)
+			w.Write([]byte(`{"result": "ok"}`))
+		}))
+	}
+
+	t.Run("retry GET on retryable status", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var count int32
+		ts := newServer(2, 503, &count)
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.Retry = &otgo.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+		res := map[string]string{}
+		err := cli.Do(context.Background(), "GET", ts.URL, nil, nil, &res)
+		assert.Nil(err)
+		assert.Equal("ok", res["result"])
+		assert.Equal(int32(3), atomic.LoadInt32(&count))
+	})
+
+	t.Run("give up after MaxAttempts", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var count int32
+		ts := newServer(5, 503, &count)
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.Retry = &otgo.RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}
+		err := cli.Do(context.Background(), "GET", ts.URL, nil, nil, nil)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "503")
+		assert.Equal(int32(2), atomic.LoadInt32(&count))
+	})
+
+	t.Run("return the last error if ctx is done in backoff", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var count int32
+		ts := newServer(5, 503, &count)
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.Retry = &otgo.RetryPolicy{MaxAttempts: 3, MinBackoff: time.Second, MaxBackoff: time.Second}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		err := cli.Do(ctx, "GET", ts.URL, nil, nil, nil)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "503")
+		assert.Equal(int32(1), atomic.LoadInt32(&count))
+	})
+
+	t.Run("do not retry non-retryable status", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var count int32
+		ts := newServer(1, 400, &count)
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.Retry = otgo.DefaultRetryPolicy()
+		err := cli.Do(context.Background(), "GET", ts.URL, nil, nil, nil)
+		assert.NotNil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&count))
+	})
+
+	t.Run("idempotency guard for POST", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var count int32
+		ts := newServer(1, 503, &count)
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.Retry = &otgo.RetryPolicy{MinBackoff: time.Millisecond}
+		err := cli.Do(context.Background(), "POST", ts.URL, nil, map[string]string{"a": "b"}, nil)
+		assert.NotNil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&count))
+
+		count = 0
+		h := http.Header{}
+		h.Set("Idempotency-Key", "123")
+		res := map[string]string{}
+		err = cli.Do(context.Background(), "POST", ts.URL, h, map[string]string{"a": "b"}, &res)
+		assert.Nil(err)
+		assert.Equal("ok", res["result"])
+		assert.Equal(int32(2), atomic.LoadInt32(&count))
+	})
+}
@@ -0,0 +1,104 @@
+package otgo
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeyRotator rotates the private keys of a JWKSet with an overlap window.
+// The layout of the set follows LookupSigningKey: the first key is the pre-published next key,
+// the second key is the signing key, and the rest are retired keys kept for verification.
+// Rotate generates a new next key and promotes the previous next key to the signing key,
+// so verifiers learn every key one rotation before it signs any OTVID.
+type KeyRotator struct {
+	alg     string
+	grace   time.Duration
+	mu      sync.Mutex
+	keys    []Key
+	retired map[string]time.Time // kid -> time the key stopped signing
+}
+
+// NewKeyRotator creates a KeyRotator with the private keys, new keys are generated with the alg.
+// Retired keys are removed from the set after the grace period.
+func NewKeyRotator(ks *JWKSet, alg string, grace time.Duration) (*KeyRotator, error) {
+	if !ValidateAlgorithm(alg) {
+		return nil, fmt.Errorf("otgo.NewKeyRotator: invalid algorithm '%s'", alg)
+	}
+	if grace < 0 {
+		return nil, errors.New("otgo.NewKeyRotator: negative grace period")
+	}
+	r := &KeyRotator{alg: alg, grace: grace, retired: make(map[string]time.Time)}
+	if ks != nil {
+		if err := validateKeys(ks.Keys...); err != nil {
+			return nil, err
+		}
+		if _, err := LookupSigningKey(ks); err != nil {
+			return nil, err
+		}
+		r.keys = append(r.keys, ks.Keys...)
+	}
+	now := clockNow()
+	for i, k := range r.keys {
+		if i > 1 {
+			r.retired[k.KeyID()] = now // unknown retired time, keep it for a full grace period
+		}
+	}
+	return r, nil
+}
+
+// Rotate generates a new key and rotates the set, the retired keys out of grace period are removed.
+func (r *KeyRotator) Rotate() error {
+	key, err := NewPrivateKey(r.alg)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := clockNow()
+	if len(r.keys) > 1 {
+		r.retired[r.keys[1].KeyID()] = now
+	}
+	r.keys = append([]Key{key}, r.keys...)
+	r.prune(now)
+	return nil
+}
+
+// Prune removes the retired keys out of grace period.
+func (r *KeyRotator) Prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune(clockNow())
+}
+
+func (r *KeyRotator) prune(now time.Time) {
+	keys := r.keys[:0]
+	for i, k := range r.keys {
+		if i > 1 {
+			if t, ok := r.retired[k.KeyID()]; ok && now.Sub(t) >= r.grace {
+				delete(r.retired, k.KeyID())
+				continue
+			}
+		}
+		keys = append(keys, k)
+	}
+	r.keys = keys
+}
+
+// Keys returns the private keys for OT-Auth or a Holder's OTClient.SetPrivateKeys.
+func (r *KeyRotator) Keys() *JWKSet {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &JWKSet{Keys: append(make([]Key, 0, len(r.keys)), r.keys...)}
+}
+
+// PublicKeys returns the public keys of the set for publishing.
+func (r *KeyRotator) PublicKeys() *JWKSet {
+	return LookupPublicKeys(r.Keys())
+}
+
+// SigningKey returns the current signing key.
+func (r *KeyRotator) SigningKey() (Key, error) {
+	return LookupSigningKey(r.Keys())
+}
@@ -0,0 +1,84 @@
+package otgo_test
+
+import (
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRotator(t *testing.T) {
+	t.Run("NewKeyRotator func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := otgo.NewKeyRotator(nil, "none", time.Hour)
+		assert.NotNil(err)
+		_, err = otgo.NewKeyRotator(nil, "ES256", -time.Hour)
+		assert.NotNil(err)
+		pub, _ := otgo.ToPublicKey(otgo.MustPrivateKey("ES256"))
+		_, err = otgo.NewKeyRotator(&otgo.JWKSet{Keys: []otgo.Key{pub}}, "ES256", time.Hour)
+		assert.NotNil(err)
+
+		r, err := otgo.NewKeyRotator(nil, "ES256", time.Hour)
+		assert.Nil(err)
+		assert.Equal(0, len(r.Keys().Keys))
+		_, err = r.SigningKey()
+		assert.NotNil(err)
+		assert.Nil(r.Rotate())
+		key, err := r.SigningKey()
+		assert.Nil(err)
+		assert.Equal(r.Keys().Keys[0].KeyID(), key.KeyID())
+	})
+
+	t.Run("KeyRotator.Rotate method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		k1 := otgo.MustPrivateKey("ES256")
+		k2 := otgo.MustPrivateKey("ES256")
+		r, err := otgo.NewKeyRotator(otgo.MustKeys(k2, k1), "ES256", time.Millisecond*100)
+		assert.Nil(err)
+		key, err := r.SigningKey()
+		assert.Nil(err)
+		assert.Equal(k1.KeyID(), key.KeyID())
+
+		assert.Nil(r.Rotate())
+		ks := r.Keys()
+		assert.Equal(3, len(ks.Keys))
+		assert.Equal(k2.KeyID(), ks.Keys[1].KeyID())
+		assert.Equal(k1.KeyID(), ks.Keys[2].KeyID())
+		key, err = r.SigningKey()
+		assert.Nil(err)
+		assert.Equal(k2.KeyID(), key.KeyID())
+
+		pubs := r.PublicKeys()
+		assert.Equal(3, len(pubs.Keys))
+		for _, k := range pubs.Keys {
+			_, err := otgo.ToPublicKey(k)
+			assert.Nil(err)
+		}
+
+		// the OTVID signed by the retired key is still valid in grace period
+		td := otgo.TrustDomain("localhost")
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: td.NewOTID("app", "123")}
+		vid.Expiry = time.Now().Add(time.Hour)
+		token, err := vid.Sign(k1)
+		assert.Nil(err)
+		_, err = otgo.ParseOTVID(token, r.PublicKeys(), vid.Issuer, vid.Audience)
+		assert.Nil(err)
+
+		r.Prune()
+		assert.Equal(3, len(r.Keys().Keys))
+		time.Sleep(time.Millisecond * 150)
+		r.Prune()
+		assert.Equal(2, len(r.Keys().Keys))
+		_, err = otgo.ParseOTVID(token, r.PublicKeys(), vid.Issuer, vid.Audience)
+		assert.NotNil(err)
+
+		assert.Nil(r.Rotate())
+		assert.Equal(3, len(r.Keys().Keys))
+		time.Sleep(time.Millisecond * 150)
+		assert.Nil(r.Rotate())
+		assert.Equal(3, len(r.Keys().Keys))
+	})
+}
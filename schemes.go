@@ -0,0 +1,120 @@
+package otgo
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// The endpoint schemes of the sidecar deployments supported by Client besides http:// and https://.
+const (
+	// SchemeUnix is HTTP/1.1 over a Unix domain socket, e.g. unix:///var/run/ot.sock,
+	// the request path follows the socket path: unix:///var/run/ot.sock/sign.
+	SchemeUnix = "unix://"
+	// SchemeH2C is HTTP/2 over cleartext TCP with prior knowledge (RFC 7540 section 3.4), e.g. h2c://localhost:8080.
+	SchemeH2C = "h2c://"
+)
+
+// isEndpointURL reports whether the URL's scheme is supported by Client. The Unix domain sockets are local,
+// they are accepted even if HTTPS is required.
+func isEndpointURL(url string, requireHTTPS bool) bool {
+	switch {
+	case strings.HasPrefix(url, "https://"), strings.HasPrefix(url, SchemeUnix):
+		return true
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, SchemeH2C):
+		return !requireHTTPS
+	}
+	return false
+}
+
+// endpointTransport returns the HTTP request URL of the unix:// and h2c:// URL and the transport of its scheme,
+// the transport is nil for the other URLs.
+func endpointTransport(api string) (string, http.RoundTripper, error) {
+	switch {
+	case strings.HasPrefix(api, SchemeUnix):
+		sock, path, err := splitUnixURL(api)
+		if err != nil {
+			return "", nil, err
+		}
+		return "http://unix" + path, unixTransport(sock), nil
+	case strings.HasPrefix(api, SchemeH2C):
+		return "http://" + strings.TrimPrefix(api, SchemeH2C), h2cTransport, nil
+	}
+	return api, nil, nil
+}
+
+// splitUnixURL splits the unix:// URL into the socket path and the request path,
+// the socket path is the shortest prefix of the URL's path that is a socket file.
+// The sockets that have a transport are matched first, so the socket files are stated once.
+func splitUnixURL(api string) (string, string, error) {
+	p := strings.TrimPrefix(api, SchemeUnix)
+	query := ""
+	if i := strings.IndexAny(p, "?#"); i >= 0 {
+		p, query = p[:i], p[i:]
+	}
+	var ends []int
+	for i := 0; i < len(p); {
+		end := len(p)
+		if j := strings.IndexByte(p[i+1:], '/'); j >= 0 {
+			end = i + 1 + j
+		}
+		ends = append(ends, end)
+		i = end
+	}
+	split := func(end int) (string, string, error) {
+		path := p[end:]
+		if path == "" {
+			path = "/"
+		}
+		return p[:end], path + query, nil
+	}
+	for _, end := range ends {
+		if _, ok := unixTransports.Load(p[:end]); ok {
+			return split(end)
+		}
+	}
+	for _, end := range ends {
+		if fi, err := os.Stat(p[:end]); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			return split(end)
+		}
+	}
+	return "", "", fmt.Errorf("no unix socket found in %q", api)
+}
+
+// unixTransports are the transports of the Unix domain sockets, keyed by the socket path.
+var unixTransports sync.Map
+
+func unixTransport(sock string) http.RoundTripper {
+	if t, ok := unixTransports.Load(sock); ok {
+		return t.(http.RoundTripper)
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	t, _ := unixTransports.LoadOrStore(sock, &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", sock)
+		},
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   100,
+		IdleConnTimeout:       59 * time.Second,
+		ExpectContinueTimeout: 4 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+	})
+	return t.(http.RoundTripper)
+}
+
+var h2cTransport = &http2.Transport{
+	AllowHTTP: true,
+	// dial without TLS for the http:// URLs
+	DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+		return (&net.Dialer{Timeout: 5 * time.Second, KeepAlive: 25 * time.Second}).DialContext(ctx, network, addr)
+	},
+	ReadIdleTimeout: 30 * time.Second,
+}
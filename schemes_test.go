@@ -0,0 +1,87 @@
+package otgo_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestEndpointSchemes(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write([]byte(`{"proto":"` + r.Proto + `","path":"` + r.URL.RequestURI() + `"}`))
+	})
+	type result struct {
+		Proto string `json:"proto"`
+		Path  string `json:"path"`
+	}
+
+	t.Run("unix:// endpoints", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dir, err := ioutil.TempDir("", "otgo")
+		assert.Nil(err)
+		defer os.RemoveAll(dir)
+		sock := filepath.Join(dir, "ot.sock")
+		l, err := net.Listen("unix", sock)
+		assert.Nil(err)
+		srv := &http.Server{Handler: handler}
+		go srv.Serve(l)
+		defer srv.Close()
+
+		cli := otgo.NewClient(nil)
+		res := &result{}
+		assert.Nil(cli.Do(context.Background(), "GET", "unix://"+sock+"/sign?a=1", nil, nil, res))
+		assert.Equal("HTTP/1.1", res.Proto)
+		assert.Equal("/sign?a=1", res.Path)
+		assert.Nil(cli.Do(context.Background(), "GET", "unix://"+sock, nil, nil, res))
+		assert.Equal("/", res.Path)
+
+		err = cli.Do(context.Background(), "GET", "unix://"+filepath.Join(dir, "none.sock")+"/sign", nil, nil, res)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "no unix socket")
+
+		url, err := otgo.SelectEndpoints(context.Background(), []string{"unix://" + sock}, cli)
+		assert.Nil(err)
+		assert.Equal("unix://"+sock, url)
+		// the Unix domain sockets are local
+		s := &otgo.EndpointSelector{RequireHTTPS: true}
+		url, err = s.Select(context.Background(), []string{"http://localhost:1", "unix://" + sock}, cli)
+		assert.Nil(err)
+		assert.Equal("unix://"+sock, url)
+	})
+
+	t.Run("h2c:// endpoints", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+		defer ts.Close()
+		endpoint := otgo.SchemeH2C + strings.TrimPrefix(ts.URL, "http://")
+
+		cli := otgo.NewClient(nil)
+		res := &result{}
+		assert.Nil(cli.Do(context.Background(), "GET", endpoint+"/sign", nil, nil, res))
+		assert.Equal("HTTP/2.0", res.Proto)
+		assert.Equal("/sign", res.Path)
+		assert.Nil(cli.Do(context.Background(), "GET", ts.URL+"/sign", nil, nil, res))
+		assert.Equal("HTTP/1.1", res.Proto)
+
+		url, err := otgo.SelectEndpoints(context.Background(), []string{endpoint}, cli)
+		assert.Nil(err)
+		assert.Equal(endpoint, url)
+		s := &otgo.EndpointSelector{RequireHTTPS: true}
+		_, err = s.Select(context.Background(), []string{endpoint}, cli)
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,90 @@
+package otgo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInsufficientScope is the reason of the RequireScopes validator's error, it can be tested with errors.Is
+// on the Verifier's errors, e.g. to respond 403 with "insufficient_scope" (RFC 6750 section 3.1).
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// The scope claims of OTVIDs: "scope" is a space-delimited string (RFC 8693 section 4.2),
+// "scp" is an array of strings used by some issuers.
+const (
+	claimScope = "scope"
+	claimScp   = "scp"
+)
+
+// SetScopes sets the OTVID's scopes as the space-delimited "scope" claim, the "scp" claim is removed.
+// Both claims are removed if scopes is empty.
+func (o *OTVID) SetScopes(scopes []string) {
+	if o.Claims == nil {
+		o.Claims = make(map[string]interface{})
+	}
+	delete(o.Claims, claimScp)
+	if len(scopes) == 0 {
+		delete(o.Claims, claimScope)
+		return
+	}
+	o.Claims[claimScope] = strings.Join(scopes, " ")
+}
+
+// Scopes returns the OTVID's scopes from the "scope" and "scp" claims, in either the space-delimited
+// string or the array form. The duplicates are removed.
+func (o *OTVID) Scopes() []string {
+	var scopes []string
+	for _, name := range []string{claimScope, claimScp} {
+		for _, s := range scopeValues(o.Claims[name]) {
+			if !stringsHas(scopes, s) {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
+
+// HasScopes reports whether the OTVID has all the scopes, see Scopes.
+func (o *OTVID) HasScopes(scopes ...string) bool {
+	return missingScope(o.Scopes(), scopes) == ""
+}
+
+// RequireScopes returns a ClaimsValidator that requires all the scopes in the OTVIDs, see OTVID.Scopes.
+// Its error wraps ErrInsufficientScope.
+func RequireScopes(scopes ...string) ClaimsValidator {
+	return func(vid *OTVID) error {
+		if s := missingScope(vid.Scopes(), scopes); s != "" {
+			return fmt.Errorf("%w: scope '%s' required", ErrInsufficientScope, s)
+		}
+		return nil
+	}
+}
+
+// missingScope returns the first of the required scopes that is not granted, "" if none.
+func missingScope(granted, required []string) string {
+	for _, s := range required {
+		if !stringsHas(granted, s) {
+			return s
+		}
+	}
+	return ""
+}
+
+func scopeValues(v interface{}) []string {
+	switch val := v.(type) {
+	case string:
+		return strings.Fields(val)
+	case []string:
+		return val
+	case []interface{}:
+		ss := make([]string, 0, len(val))
+		for _, s := range val {
+			if str, ok := s.(string); ok && str != "" {
+				ss = append(ss, str)
+			}
+		}
+		return ss
+	}
+	return nil
+}
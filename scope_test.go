@@ -0,0 +1,75 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopes(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	key := otgo.MustPrivateKey("ES256")
+
+	t.Run("OTVID.SetScopes and OTVID.Scopes methods", func(t *testing.T) {
+		assert := assert.New(t)
+
+		vid := &otgo.OTVID{}
+		assert.Nil(vid.Scopes())
+		vid.SetScopes([]string{"read:users", "write:users"})
+		assert.Equal("read:users write:users", vid.Claims["scope"])
+		assert.Equal([]string{"read:users", "write:users"}, vid.Scopes())
+		assert.True(vid.HasScopes("write:users"))
+		assert.True(vid.HasScopes())
+		assert.False(vid.HasScopes("read:users", "admin"))
+
+		vid = &otgo.OTVID{Claims: map[string]interface{}{"scp": []interface{}{"read", "write"}}}
+		assert.Equal([]string{"read", "write"}, vid.Scopes())
+		vid.Claims["scope"] = " admin  read "
+		assert.Equal([]string{"admin", "read", "write"}, vid.Scopes())
+		vid.Claims["scp"] = "delete"
+		assert.Equal([]string{"admin", "read", "delete"}, vid.Scopes())
+
+		vid.SetScopes([]string{"read"})
+		assert.Equal([]string{"read"}, vid.Scopes())
+		assert.Nil(vid.Claims["scp"])
+		vid.SetScopes(nil)
+		assert.Nil(vid.Scopes())
+		assert.Equal(0, len(vid.Claims))
+	})
+
+	t.Run("RequireScopes func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		sign := func(vid *otgo.OTVID) string {
+			vid.ID, vid.Issuer, vid.Audience = td.NewOTID("app", "123"), td.OTID(), aud
+			vid.Expiry = time.Now().Add(time.Hour)
+			return mustSignOTVID(vid, key)
+		}
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key),
+			otgo.WithClaimsValidator(otgo.RequireScopes("read:users")))
+		assert.Nil(err)
+
+		vid := &otgo.OTVID{}
+		vid.SetScopes([]string{"read:users", "write:users"})
+		got, err := v.ParseOTVID(sign(vid))
+		assert.Nil(err)
+		assert.Equal([]string{"read:users", "write:users"}, got.Scopes())
+
+		// the array form
+		_, err = v.ParseOTVID(sign(&otgo.OTVID{Claims: map[string]interface{}{"scp": []string{"read:users"}}}))
+		assert.Nil(err)
+
+		_, err = v.ParseOTVID(sign(&otgo.OTVID{Claims: map[string]interface{}{"scope": "write:users"}}))
+		assert.True(errors.Is(err, otgo.ErrInsufficientScope))
+		assert.True(errors.Is(err, otgo.ErrClaimsInvalid))
+		assert.Contains(err.Error(), "scope 'read:users' required")
+		_, err = v.ParseOTVID(sign(&otgo.OTVID{}))
+		assert.True(errors.Is(err, otgo.ErrInsufficientScope))
+	})
+}
@@ -0,0 +1,149 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultSignBatchConcurrency is the default OTClient.SignBatchConcurrency.
+const DefaultSignBatchConcurrency = 8
+
+// SignBatchError is returned by OTClient.SignBatch if some of the inputs failed,
+// Errs[i] is the error of the i-th input, nil if it succeeded.
+type SignBatchError struct {
+	Errs []error
+}
+
+func (e *SignBatchError) Error() string {
+	n := 0
+	var first error
+	for _, err := range e.Errs {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			n++
+		}
+	}
+	return fmt.Sprintf("otgo.OTClient.SignBatch: %d of %d failed, the first error: %v", n, len(e.Errs), first)
+}
+
+// SignBatch requests the OTVIDs of the inputs like Sign, the outputs are in the order of the inputs.
+// It sends one request to the batch endpoint of OT-Auth ("/sign/batch"), or if OT-Auth does not support it
+// or a TokenFetcher other than WithOTAuth is used, calls Sign for the inputs concurrently with at most
+// SignBatchConcurrency calls in flight.
+// A *SignBatchError is returned if some of the inputs failed, the outputs of the others are still valid.
+func (oc *OTClient) SignBatch(ctx context.Context, inputs []SignInput) ([]SignOutput, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	if err := oc.checkOpen(OpSign); err != nil {
+		return nil, err
+	}
+	_, otAuth := oc.TokenFetcher.(*otAuthFetcher)
+	if (oc.TokenFetcher == nil || otAuth) && atomic.LoadInt32(&oc.noSignBatch) == 0 {
+		outputs, err := oc.signBatch(ctx, inputs)
+		if !isUnsupportedBatch(err) {
+			return outputs, err
+		}
+		// OT-Auth without the batch endpoint, don't try it again
+		atomic.StoreInt32(&oc.noSignBatch, 1)
+	}
+	return oc.signEach(ctx, inputs)
+}
+
+func (oc *OTClient) signBatch(ctx context.Context, inputs []SignInput) (_ []SignOutput, err error) {
+	ctx, end := instrumenterOf(oc.Instrumenter).Start(ctx, OpSign)
+	defer func() { end(err) }()
+
+	if err = oc.checkOnline(OpSign); err != nil {
+		return nil, err
+	}
+	if err = oc.allowCall(OpSign); err != nil {
+		return nil, err
+	}
+	batch := make([]SignInput, len(inputs))
+	for i, input := range inputs {
+		input.Claims = oc.claimPolicy(input.Audience).Filter(input.Claims)
+		batch[i] = input
+	}
+	var endpoint string
+	if f, ok := oc.TokenFetcher.(*otAuthFetcher); ok {
+		endpoint = f.endpoint
+	} else {
+		cfg, err := oc.otDomain.Resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = cfg.Endpoint
+	}
+	selfToken, err := oc.SignSelfContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var outputs []SignOutput
+	h := AddTokenToHeader(make(http.Header), selfToken)
+	if err = doAPIWith(ctx, oc.HTTPClient, "POST", endpoint+"/sign/batch", h, batch, &outputs); err != nil {
+		return nil, err
+	}
+	if len(outputs) != len(inputs) {
+		return nil, fmt.Errorf("otgo.OTClient.SignBatch: %d outputs for %d inputs", len(outputs), len(inputs))
+	}
+	return outputs, nil
+}
+
+// isUnsupportedBatch reports whether the error means OT-Auth has no batch endpoint.
+func isUnsupportedBatch(err error) bool {
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		return false
+	}
+	switch he.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+func (oc *OTClient) signEach(ctx context.Context, inputs []SignInput) ([]SignOutput, error) {
+	outputs := make([]SignOutput, len(inputs))
+	errs := make([]error, len(inputs))
+	workers := oc.SignBatchConcurrency
+	if workers <= 0 {
+		workers = DefaultSignBatchConcurrency
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+	var next int64 = -1
+	var failed int32
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(inputs) {
+					return
+				}
+				out, err := oc.Sign(ctx, inputs[i])
+				if err != nil {
+					errs[i] = err
+					atomic.AddInt32(&failed, 1)
+					continue
+				}
+				outputs[i] = *out
+			}
+		}()
+	}
+	wg.Wait()
+	if failed > 0 {
+		return outputs, &SignBatchError{Errs: errs}
+	}
+	return outputs, nil
+}
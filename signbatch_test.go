@@ -0,0 +1,125 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignBatch(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	sub := td.NewOTID("app", "123")
+	domainKey := otgo.MustPrivateKey("ES256")
+	var signs, batches int32
+
+	newOTAuth := func(batch bool) *httptest.Server {
+		var ts *httptest.Server
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sign := func(input otgo.SignInput) otgo.SignOutput {
+				token, _ := (&otgo.OTVID{ID: input.Subject, Issuer: td.OTID(), Audience: input.Audience}).Sign(domainKey)
+				return otgo.SignOutput{Issuer: td.OTID(), Audience: input.Audience, OTVID: token, ServiceEndpoints: []string{ts.URL}}
+			}
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			var result interface{}
+			switch r.URL.Path {
+			case "/.well-known/open-trust-configuration":
+				result = map[string]interface{}{
+					"otid":             td.OTID(),
+					"keys":             otgo.LookupPublicKeys(otgo.MustKeys(domainKey)).Keys,
+					"serviceEndpoints": []string{ts.URL},
+				}
+				json.NewEncoder(w).Encode(result)
+				return
+			case "/sign":
+				atomic.AddInt32(&signs, 1)
+				input := otgo.SignInput{}
+				json.NewDecoder(r.Body).Decode(&input)
+				result = sign(input)
+			case "/sign/batch":
+				if !batch {
+					w.WriteHeader(404)
+					w.Write([]byte(`404 page not found`))
+					return
+				}
+				atomic.AddInt32(&batches, 1)
+				inputs := []otgo.SignInput{}
+				json.NewDecoder(r.Body).Decode(&inputs)
+				outputs := make([]otgo.SignOutput, len(inputs))
+				for i, input := range inputs {
+					outputs[i] = sign(input)
+				}
+				result = outputs
+			default:
+				result = "ok"
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"result": result})
+		}))
+		return ts
+	}
+	inputs := []otgo.SignInput{
+		{Subject: sub, Audience: td.NewOTID("svc", "a")},
+		{Subject: sub, Audience: td.NewOTID("svc", "b")},
+		{Subject: sub, Audience: td.NewOTID("svc", "c")},
+	}
+
+	t.Run("with the batch endpoint", func(t *testing.T) {
+		assert := assert.New(t)
+		ts := newOTAuth(true)
+		defer ts.Close()
+
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL))
+		assert.Nil(err)
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		atomic.StoreInt32(&signs, 0)
+		outputs, err := oc.SignBatch(context.Background(), inputs)
+		assert.Nil(err)
+		assert.Equal(3, len(outputs))
+		for i, out := range outputs {
+			assert.True(out.Audience.Equal(inputs[i].Audience))
+			assert.NotEqual("", out.OTVID)
+		}
+		assert.Equal(int32(1), atomic.LoadInt32(&batches))
+		assert.Equal(int32(0), atomic.LoadInt32(&signs))
+
+		outputs, err = oc.SignBatch(context.Background(), nil)
+		assert.Nil(err)
+		assert.Nil(outputs)
+	})
+
+	t.Run("without the batch endpoint", func(t *testing.T) {
+		assert := assert.New(t)
+		ts := newOTAuth(false)
+		defer ts.Close()
+
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL))
+		assert.Nil(err)
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+		oc.SignBatchConcurrency = 2
+
+		atomic.StoreInt32(&signs, 0)
+		outputs, err := oc.SignBatch(context.Background(), inputs)
+		assert.Nil(err)
+		assert.Equal(3, len(outputs))
+		for i, out := range outputs {
+			assert.True(out.Audience.Equal(inputs[i].Audience))
+		}
+		assert.Equal(int32(3), atomic.LoadInt32(&signs))
+
+		bad := append(inputs[:1:1], otgo.SignInput{Subject: sub})
+		outputs, err = oc.SignBatch(context.Background(), bad)
+		var be *otgo.SignBatchError
+		if assert.True(errors.As(err, &be)) {
+			assert.Nil(be.Errs[0])
+			assert.NotNil(be.Errs[1])
+		}
+		assert.NotEqual("", outputs[0].OTVID)
+	})
+}
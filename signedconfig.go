@@ -0,0 +1,178 @@
+package otgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+)
+
+// signedConfigType is the "typ" header of the signed configurations,
+// so that a OTVID signed by the same root key is not accepted as a configuration.
+const signedConfigType = "otcfg+jws"
+
+// PublishSignedConfig returns the trust domain's well-known configuration document cfg (a struct or a map
+// marshaled to a JSON object) with the "signedConfig" member, a compact JWS of the document signed by
+// the offline root key. The clients with the root's public keys (see OTClient.ConfigRootKeys and
+// WithConfigRootKeys) trust only the signed document, the others ignore the member.
+// The signed document carries the "iat" (now) and "exp" (now + ttl) members: the clients reject it after
+// it expires, and reject a document signed before the one they trust, so an old document can not be
+// replayed to roll the keys back. It should be republished before it expires.
+func PublishSignedConfig(cfg interface{}, rootKey Key, ttl time.Duration) ([]byte, error) {
+	if ttl <= 0 {
+		return nil, errors.New("otgo.PublishSignedConfig: ttl required")
+	}
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.PublishSignedConfig: %s", err.Error())
+	}
+	doc := make(map[string]interface{})
+	if err = json.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("otgo.PublishSignedConfig: %s", err.Error())
+	}
+	delete(doc, "signedConfig")
+	now := clockNow()
+	doc["iat"], doc["exp"] = now.Unix(), now.Add(ttl).Unix()
+	if b, err = json.Marshal(doc); err != nil {
+		return nil, fmt.Errorf("otgo.PublishSignedConfig: %s", err.Error())
+	}
+
+	if err = validateKeys(rootKey); err == nil {
+		err = checkKeyUsage(rootKey, jwk.KeyOpSign)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("otgo.PublishSignedConfig: %s", err.Error())
+	}
+	hdrs := jws.NewHeaders()
+	if err = hdrs.Set(jws.TypeKey, signedConfigType); err != nil {
+		return nil, err
+	}
+	sig, err := jws.Sign(b, jwa.SignatureAlgorithm(rootKey.Algorithm()), rootKey, jws.WithHeaders(hdrs))
+	if err != nil {
+		return nil, fmt.Errorf("otgo.PublishSignedConfig: %s", err.Error())
+	}
+	doc["signedConfig"] = string(sig)
+	return json.Marshal(doc)
+}
+
+// VerifySignedConfig verifies the "signedConfig" member of the trust domain's well-known configuration
+// document with the root's public keys and its expiry, and returns the signed document.
+// The unsigned members are ignored.
+func VerifySignedConfig(data []byte, roots *JWKSet) ([]byte, error) {
+	doc := struct {
+		SignedConfig string `json:"signedConfig"`
+	}{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: %s", err.Error())
+	}
+	return verifySignedConfig(doc.SignedConfig, roots)
+}
+
+func verifySignedConfig(signed string, roots *JWKSet) ([]byte, error) {
+	if signed == "" {
+		return nil, errors.New("otgo.VerifySignedConfig: unsigned configuration")
+	}
+	if roots == nil {
+		return nil, errors.New("otgo.VerifySignedConfig: root keys required")
+	}
+	hdrs, err := protectedHeaders(signed)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: %s", err.Error())
+	}
+	if hdrs.Type() != signedConfigType {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: invalid type '%s'", hdrs.Type())
+	}
+	keys := roots.LookupKeyID(hdrs.KeyID())
+	if hdrs.KeyID() == "" || len(keys) == 0 {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: no matching root key for key ID %q", hdrs.KeyID())
+	}
+	key := keys[0]
+	if alg := key.Algorithm(); alg != "" && alg != string(hdrs.Algorithm()) {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: algorithm '%s' not match the key's algorithm '%s'", hdrs.Algorithm(), alg)
+	}
+	if err = checkKeyUsage(key, jwk.KeyOpVerify); err != nil {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: %s", err.Error())
+	}
+	var raw interface{}
+	if err = key.Raw(&raw); err != nil {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: invalid key %q: %s", hdrs.KeyID(), err.Error())
+	}
+	payload, err := jws.Verify([]byte(signed), hdrs.Algorithm(), raw)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: failed to verify signature: %s", err.Error())
+	}
+	ts := signedConfigTimes{}
+	if err = json.Unmarshal(payload, &ts); err != nil {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: %s", err.Error())
+	}
+	if err = ts.validate(clockNow()); err != nil {
+		return nil, fmt.Errorf("otgo.VerifySignedConfig: %s", err.Error())
+	}
+	return payload, nil
+}
+
+// signedConfigTimes are the freshness members of the signed configuration, see PublishSignedConfig.
+type signedConfigTimes struct {
+	IssuedAt int64 `json:"iat,omitempty"`
+	Expiry   int64 `json:"exp,omitempty"`
+}
+
+func (t signedConfigTimes) validate(now time.Time) error {
+	switch {
+	case t.IssuedAt <= 0 || t.Expiry <= 0:
+		return errors.New("the 'iat' and 'exp' members required")
+	case t.IssuedAt > now.Add(time.Minute).Unix():
+		return errors.New("the configuration is signed in the future")
+	case now.Unix() >= t.Expiry:
+		return errors.New("the configuration is expired")
+	}
+	return nil
+}
+
+// checkRollback rejects the signed configuration if it was signed before the trusted one,
+// the unsigned configurations are not checked.
+func (res *domainConfigProxy) checkRollback(trusted *domainConfigProxy) error {
+	if trusted != nil && res.SignedConfig != "" && res.IssuedAt < trusted.IssuedAt {
+		return fmt.Errorf("otgo.VerifySignedConfig: the configuration signed at %d is older than the trusted one signed at %d",
+			res.IssuedAt, trusted.IssuedAt)
+	}
+	return nil
+}
+
+// verifySigned replaces the configuration with its signed document if the root keys are given.
+func (res *domainConfigProxy) verifySigned(roots *JWKSet) error {
+	if roots == nil {
+		return nil
+	}
+	payload, err := verifySignedConfig(res.SignedConfig, roots)
+	if err != nil {
+		return err
+	}
+	signed := domainConfigProxy{}
+	if err = json.Unmarshal(payload, &signed); err != nil {
+		return fmt.Errorf("otgo.VerifySignedConfig: %s", err.Error())
+	}
+	signed.SignedConfig = res.SignedConfig
+	*res = signed
+	return nil
+}
+
+// WithConfigRootKeys trusts only the trust domain's configuration signed by the root keys,
+// see PublishSignedConfig.
+func WithConfigRootKeys(roots *JWKSet) VerifierOption {
+	return func(o *verifierOptions) {
+		o.configRoots = roots
+	}
+}
+
+// SetConfigRootKeys trusts only the trust domain's configuration signed by the root keys from the next
+// refresh, nil to trust the unsigned configuration. See PublishSignedConfig.
+func (v *Verifier) SetConfigRootKeys(roots *JWKSet) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.configRoots = roots
+}
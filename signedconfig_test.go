@@ -0,0 +1,201 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedConfig(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	root := otgo.MustPrivateKey("ES256")
+	roots := otgo.LookupPublicKeys(otgo.MustKeys(root))
+	key := otgo.MustPrivateKey("ES256")
+	cfg := map[string]interface{}{
+		"otid": td.OTID(),
+		"keys": otgo.LookupPublicKeys(otgo.MustKeys(key)).Keys,
+	}
+
+	t.Run("PublishSignedConfig and VerifySignedConfig func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		data, err := otgo.PublishSignedConfig(cfg, root, time.Hour)
+		assert.Nil(err)
+		payload, err := otgo.VerifySignedConfig(data, roots)
+		assert.Nil(err)
+		doc := map[string]interface{}{}
+		assert.Nil(json.Unmarshal(payload, &doc))
+		assert.Equal(td.OTID().String(), doc["otid"])
+		assert.Nil(doc["signedConfig"])
+		assert.NotNil(doc["iat"])
+		assert.NotNil(doc["exp"])
+
+		_, err = otgo.PublishSignedConfig(cfg, root, 0)
+		assert.NotNil(err)
+
+		// republishing replaces the signature
+		data2, err := otgo.PublishSignedConfig(json.RawMessage(data), root, time.Hour)
+		assert.Nil(err)
+		_, err = otgo.VerifySignedConfig(data2, roots)
+		assert.Nil(err)
+
+		_, err = otgo.VerifySignedConfig(data, otgo.LookupPublicKeys(otgo.MustKeys(otgo.MustPrivateKey("ES256"))))
+		assert.NotNil(err)
+		_, err = otgo.VerifySignedConfig([]byte(`{"otid":"otid:localhost"}`), roots)
+		assert.NotNil(err)
+
+		// a OTVID signed by the root key is not a configuration
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud}
+		token, err := vid.Sign(root)
+		assert.Nil(err)
+		_, err = otgo.VerifySignedConfig([]byte(`{"signedConfig":"`+token+`"}`), roots)
+		assert.NotNil(err)
+
+		// the expired configuration
+		fc := otgo.NewFakeClock(time.Now())
+		prev := otgo.SetClock(fc)
+		fc.Advance(2 * time.Hour)
+		_, err = otgo.VerifySignedConfig(data, roots)
+		otgo.SetClock(prev)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "expired")
+
+		_, err = otgo.PublishSignedConfig(cfg, otgo.LookupPublicKeys(otgo.MustKeys(root)).Keys[0], time.Hour)
+		assert.NotNil(err)
+	})
+
+	t.Run("Verifier with config root keys", func(t *testing.T) {
+		assert := assert.New(t)
+
+		signed, err := otgo.PublishSignedConfig(cfg, root, time.Hour)
+		assert.Nil(err)
+		// the unsigned members are tampered
+		tampered := map[string]interface{}{}
+		assert.Nil(json.Unmarshal(signed, &tampered))
+		tampered["keys"] = otgo.LookupPublicKeys(otgo.MustKeys(otgo.MustPrivateKey("ES256"))).Keys
+		tamperedData, _ := json.Marshal(tampered)
+
+		var mu sync.Mutex
+		body := signed
+		setBody := func(b []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			body = b
+		}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write(body)
+		}))
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithHTTPClient(cli),
+			otgo.WithAutoRefresh(-1), otgo.WithConfigRootKeys(roots))
+		assert.Nil(err)
+		vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+
+		setBody(tamperedData)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+
+		b, _ := json.Marshal(cfg)
+		setBody(b)
+		assert.NotNil(v.RefreshKeys(context.Background()))
+		_, err = otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithHTTPClient(cli),
+			otgo.WithAutoRefresh(-1), otgo.WithConfigRootKeys(roots))
+		assert.NotNil(err)
+
+		// the older signed configuration is not trusted
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+		setBody(signed)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		fc.Advance(time.Minute)
+		newer, err := otgo.PublishSignedConfig(cfg, root, time.Hour)
+		assert.Nil(err)
+		setBody(newer)
+		assert.Nil(v.RefreshKeys(context.Background()))
+		setBody(signed)
+		err = v.RefreshKeys(context.Background())
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "older")
+
+		v.SetConfigRootKeys(nil)
+		assert.Nil(v.RefreshKeys(context.Background()))
+	})
+
+	t.Run("OTClient rejects the older signed configuration in the store", func(t *testing.T) {
+		assert := assert.New(t)
+
+		fc := otgo.NewFakeClock(time.Now())
+		defer otgo.SetClock(otgo.SetClock(fc))
+
+		var ts *httptest.Server
+		publish := func() []byte {
+			doc := map[string]interface{}{
+				"otid":             td.OTID(),
+				"keys":             otgo.LookupPublicKeys(otgo.MustKeys(key)).Keys,
+				"serviceEndpoints": []string{ts.URL},
+			}
+			data, err := otgo.PublishSignedConfig(doc, root, 3*time.Hour)
+			assert.Nil(err)
+			return data
+		}
+		var fetches int32
+		var mu sync.Mutex
+		var body []byte
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			if r.URL.Path != "/.well-known/open-trust-configuration" {
+				w.Write([]byte(`{"result": "ok"}`))
+				return
+			}
+			atomic.AddInt32(&fetches, 1)
+			mu.Lock()
+			defer mu.Unlock()
+			w.Write(body)
+		}))
+		defer ts.Close()
+
+		older := publish()
+		fc.Advance(time.Minute)
+		body = publish()
+
+		store := otgo.NewMemoryStore()
+		oc := otgo.NewOTClient(context.Background(), aud)
+		oc.ConfigURLs = &otgo.ConfigURLs{}
+		oc.ConfigURLs.Set(td, ts.URL+"/.well-known/open-trust-configuration")
+		oc.ConfigRootKeys = roots
+		oc.Store = store
+		_, err := oc.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&fetches))
+
+		// the older configuration is replayed into the store after the newer one is cached
+		fc.Advance(time.Hour + time.Minute)
+		assert.Nil(store.Set(context.Background(), "otgo:domain:"+string(td), older, time.Hour))
+		_, err = oc.Domain(td).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(int32(2), atomic.LoadInt32(&fetches))
+		stored, err := store.Get(context.Background(), "otgo:domain:"+string(td))
+		assert.Nil(err)
+		assert.NotEqual(older, stored)
+	})
+}
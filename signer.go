@@ -0,0 +1,117 @@
+package otgo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws/sign"
+)
+
+// Signer signs OTVIDs with a private key held by a backend, e.g. a KMS or a HSM, see OTVID.SignContext.
+type Signer interface {
+	// Algorithm returns the JWS algorithm of the signing key, e.g. "ES256".
+	Algorithm() string
+	// KeyID returns the key ID of the signing key, the trust domain's public keys should have the key.
+	KeyID() string
+	// Sign returns the signature of the JWS signing input, it should respect ctx's deadline and cancellation.
+	Sign(ctx context.Context, input []byte) ([]byte, error)
+}
+
+// KeySigner returns a Signer with the local private key, e.g. to test the code that uses a remote Signer.
+func KeySigner(key Key) Signer {
+	return &keySigner{key: key}
+}
+
+type keySigner struct {
+	key Key
+}
+
+func (s *keySigner) Algorithm() string { return s.key.Algorithm() }
+func (s *keySigner) KeyID() string     { return s.key.KeyID() }
+
+func (s *keySigner) Sign(ctx context.Context, input []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := validateKeys(s.key); err != nil {
+		return nil, err
+	}
+	if err := checkKeyUsage(s.key, jwk.KeyOpSign); err != nil {
+		return nil, err
+	}
+	signer, err := sign.New(jwa.SignatureAlgorithm(s.key.Algorithm()))
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err = s.key.Raw(&raw); err != nil {
+		return nil, err
+	}
+	return signer.Sign(input, raw)
+}
+
+// SignContext signs the OTVID with the Signer like Sign, the ctx is passed to the Signer,
+// so that a remote signing operation respects its deadline and cancellation.
+func (o *OTVID) SignContext(ctx context.Context, s Signer) (string, error) {
+	return o.signContext(ctx, s, &signOptions{})
+}
+
+func (o *OTVID) signContext(ctx context.Context, s Signer, opts *signOptions) (string, error) {
+	if s == nil {
+		return "", errors.New("otgo.OTVID.SignContext: nil Signer")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("otgo.OTVID.SignContext: %w", err)
+	}
+	alg, kid := s.Algorithm(), s.KeyID()
+	if alg == "" || kid == "" {
+		return "", errors.New("otgo.OTVID.SignContext: the Signer's algorithm and key ID required")
+	}
+	hdrs, t, err := o.prepareWith(alg, kid, opts)
+	if err != nil {
+		return "", err
+	}
+	if err = hdrs.Set("typ", "JWT"); err != nil {
+		return "", err
+	}
+	h, err := json.Marshal(hdrs)
+	if err != nil {
+		return "", err
+	}
+	p, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	input := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(p)
+	sig, err := s.Sign(ctx, []byte(input))
+	if err != nil {
+		return "", fmt.Errorf("otgo.OTVID.SignContext: %w", err)
+	}
+	token := input + "." + base64.RawURLEncoding.EncodeToString(sig)
+	if l := len(token); l > opts.limits.otvidMaxSize() {
+		return "", fmt.Errorf("invalid OTVID, it' length %d is too large", l)
+	}
+	o.token = token
+	return token, nil
+}
+
+// SetSigner signs the subject's self OTVIDs with the Signer instead of the private keys, e.g. a KMS,
+// nil to sign with the private keys again. It is safe for concurrent use.
+func (oc *OTClient) SetSigner(s Signer) {
+	oc.signer.Store(signerBox{s})
+}
+
+// signerBox boxes the Signers of different types for atomic.Value.
+type signerBox struct {
+	Signer
+}
+
+func (oc *OTClient) loadSigner() Signer {
+	b, _ := oc.signer.Load().(signerBox)
+	return b.Signer
+}
@@ -0,0 +1,92 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowSigner is a remote Signer that waits for the ctx.
+type slowSigner struct {
+	otgo.Signer
+}
+
+func (s *slowSigner) Sign(ctx context.Context, input []byte) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestSigner(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	sub := td.NewOTID("app", "123")
+	key := otgo.MustPrivateKey("ES256")
+	ks := otgo.LookupPublicKeys(otgo.MustKeys(key))
+
+	t.Run("OTVID.SignContext method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		vid := &otgo.OTVID{ID: sub, Issuer: td.OTID(), Audience: td.NewOTID("svc", "tester")}
+		token, err := vid.SignContext(context.Background(), otgo.KeySigner(key))
+		assert.Nil(err)
+		assert.Equal(token, vid.Token())
+		vid2, err := otgo.ParseOTVID(token, ks, td.OTID(), vid.Audience)
+		assert.Nil(err)
+		assert.True(vid2.ID.Equal(sub))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = vid.SignContext(ctx, otgo.KeySigner(key))
+		assert.True(errors.Is(err, context.Canceled))
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = vid.SignContext(ctx, &slowSigner{otgo.KeySigner(key)})
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+
+		_, err = vid.SignContext(context.Background(), nil)
+		assert.NotNil(err)
+		noKid := otgo.MustPrivateKey("ES256")
+		assert.Nil(noKid.Set("kid", ""))
+		_, err = vid.SignContext(context.Background(), otgo.KeySigner(noKid))
+		assert.NotNil(err)
+		_, err = vid.SignContext(context.Background(), otgo.KeySigner(ks.Keys[0]))
+		assert.NotNil(err)
+	})
+
+	t.Run("OTClient.SetSigner method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		oc := otgo.NewOTClient(context.Background(), sub)
+		_, err := oc.SignSelf()
+		assert.NotNil(err)
+
+		oc.SetSigner(otgo.KeySigner(key))
+		token, err := oc.SignSelfContext(context.Background())
+		assert.Nil(err)
+		_, err = otgo.ParseOTVID(token, ks, sub, td.OTID())
+		assert.Nil(err)
+
+		var signs int32
+		ts := newTestOTAuth(td, key, &signs)
+		defer ts.Close()
+		oc, err = otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL))
+		assert.Nil(err)
+		oc.SetSigner(otgo.KeySigner(key))
+		_, err = oc.Sign(context.Background(), otgo.SignInput{Subject: sub, Audience: td.OTID()})
+		assert.Nil(err)
+		oc.SetSigner(&slowSigner{otgo.KeySigner(key)})
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = oc.Sign(ctx, otgo.SignInput{Subject: sub, Audience: td.OTID()})
+		assert.True(errors.Is(err, context.DeadlineExceeded))
+		assert.Equal(int32(1), signs)
+
+		oc.SetSigner(nil)
+		_, err = oc.SignSelf()
+		assert.NotNil(err)
+	})
+}
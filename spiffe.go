@@ -0,0 +1,91 @@
+package otgo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+const spiffeScheme = "spiffe://"
+
+// ClaimSPIFFEID is the claim that carries the subject's SPIFFE ID in an interop OTVID.
+const ClaimSPIFFEID = "spiffe_id"
+
+// ToSPIFFE returns the SPIFFE ID of the OTID.
+// e.g., "spiffe://ot.example.com/user/abc" for "otid:ot.example.com:user:abc",
+// "spiffe://ot.example.com" for "otid:ot.example.com".
+func (id OTID) ToSPIFFE() string {
+	if id.IsDomainID() {
+		return spiffeScheme + string(id.trustDomain)
+	}
+	return spiffeScheme + string(id.trustDomain) + "/" + id.subjectType + "/" + id.subjectID
+}
+
+// ParseSPIFFE parses a OTID from a SPIFFE ID.
+// The SPIFFE ID's path should be empty or consist of two segments: subject type and subject ID.
+func ParseSPIFFE(s string) (OTID, error) {
+	if !strings.HasPrefix(s, spiffeScheme) {
+		return OTID{}, fmt.Errorf("otgo.ParseSPIFFE: invalid SPIFFE ID scheme '%s'", s)
+	}
+	ss := strings.Split(s[len(spiffeScheme):], "/")
+	switch len(ss) {
+	case 1, 3:
+	default:
+		return OTID{}, fmt.Errorf("otgo.ParseSPIFFE: SPIFFE ID '%s' can not be mapped to OTID", s)
+	}
+	id, err := NewOTID(ss[0], ss[1:]...)
+	if err != nil {
+		return OTID{}, fmt.Errorf("otgo.ParseSPIFFE: %s", err.Error())
+	}
+	return id, nil
+}
+
+// SignWithSPIFFE signs the OTVID like Sign, the token carries the subject's SPIFFE ID
+// in the "spiffe_id" claim in addition to the OTID in the "sub" claim.
+func (o *OTVID) SignWithSPIFFE(key Key) (string, error) {
+	if o.Claims == nil {
+		o.Claims = make(map[string]interface{})
+	}
+	o.Claims[ClaimSPIFFEID] = o.ID.ToSPIFFE()
+	return o.Sign(key)
+}
+
+// ParseJWTSVID parses a SPIFFE JWT-SVID and maps it to a OTVID.
+// The signature is verified using the SPIFFE trust bundle, the audience should be
+// one of the token's audiences. The OTVID's Issuer is the subject's trust domain OTID.
+func ParseJWTSVID(token string, bundle *JWKSet, audience string) (*OTVID, error) {
+	if bundle == nil {
+		return nil, errors.New("otgo.ParseJWTSVID: trust bundle required")
+	}
+	t, err := jwt.ParseString(token, jwt.WithKeySet(bundle))
+	if err != nil {
+		return nil, err
+	}
+	if !stringsHas(t.Audience(), audience) {
+		return nil, fmt.Errorf("otgo.ParseJWTSVID: %w", ErrInvalidAudience)
+	}
+	if !clockNow().Truncate(time.Second).Before(t.Expiration()) {
+		return nil, fmt.Errorf("otgo.ParseJWTSVID: %w", ErrExpired)
+	}
+
+	vid := &OTVID{token: token}
+	if vid.ID, err = ParseSPIFFE(t.Subject()); err != nil {
+		return nil, err
+	}
+	vid.Issuer = vid.ID.TrustDomain().OTID()
+	if strings.HasPrefix(audience, spiffeScheme) {
+		vid.Audience, err = ParseSPIFFE(audience)
+	} else {
+		vid.Audience, err = ParseOTID(audience)
+	}
+	if err != nil {
+		return nil, err
+	}
+	vid.Expiry = t.Expiration()
+	vid.IssuedAt = t.IssuedAt()
+	vid.Claims = t.PrivateClaims()
+	return vid, nil
+}
@@ -0,0 +1,120 @@
+package otgo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwa"
+	"github.com/lestrrat-go/jwx/jwt"
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSPIFFE(t *testing.T) {
+	t.Run("OTID.ToSPIFFE & ParseSPIFFE func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("ot.example.com")
+		assert.Equal("spiffe://ot.example.com", td.OTID().ToSPIFFE())
+		assert.Equal("spiffe://ot.example.com/user/abc", td.NewOTID("user", "abc").ToSPIFFE())
+
+		id, err := otgo.ParseSPIFFE("spiffe://ot.example.com/user/abc")
+		assert.Nil(err)
+		assert.True(id.Equal(td.NewOTID("user", "abc")))
+
+		id, err = otgo.ParseSPIFFE("spiffe://ot.example.com")
+		assert.Nil(err)
+		assert.True(id.Equal(td.OTID()))
+
+		_, err = otgo.ParseSPIFFE("otid:ot.example.com")
+		assert.NotNil(err)
+		_, err = otgo.ParseSPIFFE("spiffe://ot.example.com/ns/default/sa/app")
+		assert.NotNil(err)
+		_, err = otgo.ParseSPIFFE("spiffe://ot.example.com/User/abc")
+		assert.NotNil(err)
+	})
+
+	t.Run("OTVID.SignWithSPIFFE method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		pk := otgo.MustPrivateKey("ES256")
+		vid := &otgo.OTVID{}
+		vid.ID = td.NewOTID("user", "abc")
+		vid.Issuer = td.OTID()
+		vid.Audience = td.NewOTID("app", "123")
+		token, err := vid.SignWithSPIFFE(pk)
+		assert.Nil(err)
+
+		vid, err = otgo.ParseOTVIDInsecure(token)
+		assert.Nil(err)
+		assert.Equal("spiffe://localhost/user/abc", vid.Claims[otgo.ClaimSPIFFEID])
+	})
+
+	t.Run("ParseJWTSVID func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pk := otgo.MustPrivateKey("ES256")
+		bundle := otgo.LookupPublicKeys(otgo.MustKeys(pk))
+
+		sign := func(sub string, aud []string, exp time.Time) string {
+			tk := jwt.New()
+			tk.Set("sub", sub)
+			tk.Set("aud", aud)
+			tk.Set("exp", exp)
+			b, err := jwt.Sign(tk, jwa.SignatureAlgorithm(pk.Algorithm()), pk)
+			if err != nil {
+				panic(err)
+			}
+			return string(b)
+		}
+
+		token := sign("spiffe://localhost/svc/backend", []string{"spiffe://localhost/app/123"}, time.Now().Add(time.Hour))
+		vid, err := otgo.ParseJWTSVID(token, bundle, "spiffe://localhost/app/123")
+		assert.Nil(err)
+		assert.Equal("otid:localhost:svc:backend", vid.ID.String())
+		assert.Equal("otid:localhost", vid.Issuer.String())
+		assert.Equal("otid:localhost:app:123", vid.Audience.String())
+		assert.Equal(token, vid.Token())
+
+		_, err = otgo.ParseJWTSVID(token, bundle, "spiffe://localhost/app/456")
+		assert.NotNil(err)
+		_, err = otgo.ParseJWTSVID(token, nil, "spiffe://localhost/app/123")
+		assert.NotNil(err)
+		_, err = otgo.ParseJWTSVID(token, otgo.LookupPublicKeys(otgo.MustKeys(otgo.MustPrivateKey("ES256"))), "spiffe://localhost/app/123")
+		assert.NotNil(err)
+
+		token = sign("spiffe://localhost/svc/backend", []string{"spiffe://localhost/app/123"}, time.Now().Add(-time.Hour))
+		_, err = otgo.ParseJWTSVID(token, bundle, "spiffe://localhost/app/123")
+		assert.NotNil(err)
+	})
+	t.Run("Verifier.AcceptSPIFFESubject method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		pk := otgo.MustPrivateKey("ES256")
+		tk := jwt.New()
+		tk.Set("sub", "spiffe://localhost/svc/backend")
+		tk.Set("iss", td.OTID().String())
+		tk.Set("aud", []string{td.NewOTID("app", "123").String()})
+		tk.Set("exp", time.Now().Add(time.Hour))
+		b, err := jwt.Sign(tk, jwa.SignatureAlgorithm(pk.Algorithm()), pk)
+		assert.Nil(err)
+
+		v, err := otgo.NewVerifier(context.Background(), td.NewOTID("app", "123"), nil, pk)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(string(b))
+		assert.NotNil(err)
+
+		v.AcceptSPIFFESubject(true)
+		vid, err := v.ParseOTVID(string(b))
+		assert.Nil(err)
+		assert.Equal("otid:localhost:svc:backend", vid.ID.String())
+
+		v.SetMode(otgo.VerifyDegraded, 0)
+		res, err := v.Verify(string(b))
+		assert.Nil(err)
+		assert.Equal("otid:localhost:svc:backend", res.OTVID.ID.String())
+	})
+}
@@ -0,0 +1,150 @@
+package otgo
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CacheStore is a shared store for the OTClient's caches, e.g. backed by Redis or memcached,
+// so that issued OTVIDs and trust domain configurations can be shared across replicas.
+// Renewals of a cache entry are serialized in the process (singleflight), the store is consulted
+// before renewing and updated after renewing.
+type CacheStore interface {
+	// Get returns the value of the key, or nil value and nil error if not found.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set sets the value of the key with a TTL.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// MemoryStore is a in-memory CacheStore.
+type MemoryStore struct {
+	mu sync.Mutex
+	kv map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStore ...
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{kv: make(map[string]memoryEntry)}
+}
+
+// Get implements the CacheStore interface.
+func (s *MemoryStore) Get(_ context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.kv[key]
+	if !ok {
+		return nil, nil
+	}
+	if clockNow().After(e.expiresAt) {
+		delete(s.kv, key)
+		return nil, nil
+	}
+	return e.value, nil
+}
+
+// Set implements the CacheStore interface.
+func (s *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kv[key] = memoryEntry{value: value, expiresAt: clockNow().Add(ttl)}
+	return nil
+}
+
+func tokenStoreKey(sub, aud OTID) string {
+	return "otgo:otvid:" + sub.String() + ":" + aud.String()
+}
+
+func domainStoreKey(td TrustDomain) string {
+	return "otgo:domain:" + string(td)
+}
+
+// loadOTVID returns the OTVID from the TokenStore or the store if it is still valid.
+func (oc *OTClient) loadOTVID(ctx context.Context, aud OTID) (*OTVID, []string) {
+	s := oc.loadStoredOTVID(ctx, aud)
+	if s == nil {
+		return nil, nil
+	}
+	vid, err := oc.parseInsecure(s.OTVID)
+	if oc.TokenStore != nil && (err != nil || !clockNow().Before(vid.Expiry)) {
+		oc.TokenStore.Delete(ctx, aud) // best effort
+	}
+	if err != nil || vid.ShouldRenewBefore(oc.renewBefore()) {
+		return nil, nil
+	}
+	return vid, s.ServiceEndpoints
+}
+
+func (oc *OTClient) loadStoredOTVID(ctx context.Context, aud OTID) *StoredOTVID {
+	if oc.TokenStore != nil {
+		s, err := oc.TokenStore.Load(ctx, aud)
+		if err != nil {
+			return nil
+		}
+		return s
+	}
+	if oc.Store == nil {
+		return nil
+	}
+	b, err := oc.Store.Get(ctx, tokenStoreKey(oc.sub, aud))
+	if err != nil || b == nil {
+		return nil
+	}
+	s := &StoredOTVID{}
+	if err = json.Unmarshal(b, s); err != nil {
+		return nil
+	}
+	return s
+}
+
+func (oc *OTClient) storeOTVID(ctx context.Context, aud OTID, vid *OTVID, endpoints []string) {
+	s := &StoredOTVID{OTVID: vid.Token(), ServiceEndpoints: endpoints}
+	if oc.TokenStore != nil {
+		oc.TokenStore.Store(ctx, aud, s) // best effort
+		return
+	}
+	if oc.Store == nil {
+		return
+	}
+	b, err := json.Marshal(s)
+	if err == nil {
+		oc.Store.Set(ctx, tokenStoreKey(oc.sub, aud), b, clockUntil(vid.Expiry)) // best effort
+	}
+}
+
+// loadStoredDomainConfig returns the trust domain's configuration from the store.
+func (oc *OTClient) loadStoredDomainConfig(ctx context.Context, td TrustDomain) *domainConfigProxy {
+	if oc.Store == nil {
+		return nil
+	}
+	b, err := oc.Store.Get(ctx, domainStoreKey(td))
+	if err != nil || b == nil {
+		return nil
+	}
+	res := &domainConfigProxy{}
+	if err = json.Unmarshal(b, res); err != nil {
+		return nil
+	}
+	if err = res.verifySigned(oc.ConfigRootKeys); err != nil {
+		return nil
+	}
+	if err = res.parseKeys(td, oc.MinValidKeys); err != nil {
+		return nil
+	}
+	return res
+}
+
+func (oc *OTClient) storeDomainConfig(ctx context.Context, td TrustDomain, res *domainConfigProxy) {
+	if oc.Store == nil {
+		return
+	}
+	if b, err := json.Marshal(res); err == nil {
+		oc.Store.Set(ctx, domainStoreKey(td), b, res.refreshInterval()) // best effort
+	}
+}
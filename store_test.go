@@ -0,0 +1,106 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestOTAuth returns a OT-Auth service that serves the trust domain's configuration and /sign.
+func newTestOTAuth(td otgo.TrustDomain, domainKey otgo.Key, signs *int32) *httptest.Server {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		var b []byte
+		switch r.URL.Path {
+		case "/.well-known/open-trust-configuration":
+			b, _ = json.Marshal(map[string]interface{}{
+				"otid":             td.OTID(),
+				"keys":             otgo.LookupPublicKeys(otgo.MustKeys(domainKey)).Keys,
+				"serviceEndpoints": []string{ts.URL},
+			})
+		case "/sign":
+			atomic.AddInt32(signs, 1)
+			input := otgo.SignInput{}
+			json.NewDecoder(r.Body).Decode(&input)
+			vid := &otgo.OTVID{ID: input.Subject, Issuer: td.OTID(), Audience: input.Audience}
+			if input.Expiry > 0 {
+				vid.Expiry = time.Unix(input.Expiry, 0)
+			}
+			token, _ := vid.Sign(domainKey)
+			b, _ = json.Marshal(map[string]interface{}{"result": otgo.SignOutput{
+				Issuer:           td.OTID(),
+				Audience:         input.Audience,
+				Expiry:           vid.Expiry.Unix(),
+				OTVID:            token,
+				ServiceEndpoints: []string{ts.URL},
+			}})
+		default:
+			b = []byte(`{"result": "ok"}`)
+		}
+		w.WriteHeader(200)
+		w.Write(b)
+	}))
+	return ts
+}
+
+func TestCacheStore(t *testing.T) {
+	t.Run("MemoryStore", func(t *testing.T) {
+		assert := assert.New(t)
+
+		s := otgo.NewMemoryStore()
+		v, err := s.Get(context.Background(), "a")
+		assert.Nil(err)
+		assert.Nil(v)
+
+		assert.Nil(s.Set(context.Background(), "a", []byte("1"), time.Hour))
+		v, err = s.Get(context.Background(), "a")
+		assert.Nil(err)
+		assert.Equal([]byte("1"), v)
+
+		assert.Nil(s.Set(context.Background(), "a", []byte("1"), -time.Second))
+		v, err = s.Get(context.Background(), "a")
+		assert.Nil(err)
+		assert.Nil(v)
+	})
+
+	t.Run("OTClient.Store", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("localhost")
+		var signs int32
+		ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), &signs)
+		defer ts.Close()
+
+		store := otgo.NewMemoryStore()
+		sub := td.NewOTID("app", "123")
+		aud := td.NewOTID("svc", "tester")
+		newClient := func() *otgo.OTClient {
+			cli := otgo.NewOTClient(context.Background(), sub)
+			cli.HTTPClient.(*otgo.Client).ConstraintEndpoint = ts.URL
+			cli.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+			cli.Store = store
+			return cli
+		}
+
+		cfg1, err := newClient().Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+
+		cfg2, err := newClient().Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(int32(1), atomic.LoadInt32(&signs))
+		assert.Equal(cfg1.OTVID.Token(), cfg2.OTVID.Token())
+
+		v, err := store.Get(context.Background(), "otgo:domain:localhost")
+		assert.Nil(err)
+		assert.NotNil(v)
+	})
+}
@@ -0,0 +1,74 @@
+package otgo
+
+import (
+	"fmt"
+)
+
+// Subject type groups for Verifier.RequireSubjectType, they are expanded with the serviceTypes and
+// userTypes advertised by the trust domain's configuration, and match nothing if not advertised.
+const (
+	ServiceSubjectTypes = "@service"
+	UserSubjectTypes    = "@user"
+)
+
+// subjectPolicy restricts the subjects of the verified OTVIDs.
+type subjectPolicy struct {
+	types        []string // allowed subject types, any if empty
+	forbidDomain bool
+	serviceTypes []string // advertised by the trust domain's configuration
+	userTypes    []string
+}
+
+func (p *subjectPolicy) check(sub OTID) error {
+	if sub.IsDomainID() {
+		if p.forbidDomain {
+			return fmt.Errorf("otgo.Verifier: domain subject %s not allowed", sub.String())
+		}
+		return nil
+	}
+	if len(p.types) == 0 {
+		return nil
+	}
+	t := sub.Type()
+	for _, s := range p.types {
+		switch s {
+		case ServiceSubjectTypes:
+			if stringsHas(p.serviceTypes, t) {
+				return nil
+			}
+		case UserSubjectTypes:
+			if stringsHas(p.userTypes, t) {
+				return nil
+			}
+		case t:
+			return nil
+		}
+	}
+	return fmt.Errorf("otgo.Verifier: subject type '%s' not allowed", t)
+}
+
+// RequireSubjectType rejects OTVIDs whose subject type is not one of the types, e.g. "user", "svc",
+// or the groups ServiceSubjectTypes and UserSubjectTypes. Any subject type is accepted if no types given.
+// Trust domain subjects (e.g. otid:example.com) have no type, see ForbidDomainSubjects.
+func (v *Verifier) RequireSubjectType(types ...string) {
+	v.mu.Lock()
+	v.subjectTypes = types
+	v.mu.Unlock()
+}
+
+// ForbidDomainSubjects rejects OTVIDs whose subject is a trust domain OTID, e.g. otid:example.com.
+func (v *Verifier) ForbidDomainSubjects() {
+	v.mu.Lock()
+	v.forbidDomain = true
+	v.mu.Unlock()
+}
+
+// policy returns the subject policy, it should be called with v.mu locked.
+func (v *Verifier) policy() *subjectPolicy {
+	return &subjectPolicy{
+		types:        v.subjectTypes,
+		forbidDomain: v.forbidDomain,
+		serviceTypes: v.serviceTypes,
+		userTypes:    v.userTypes,
+	}
+}
@@ -0,0 +1,104 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubjectPolicy(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	pk := otgo.MustPrivateKey("ES256")
+	aud := td.NewOTID("app", "123")
+	sign := func(sub otgo.OTID) string {
+		return mustSignOTVID(&otgo.OTVID{ID: sub, Issuer: td.OTID(), Audience: aud, Expiry: time.Now().Add(time.Hour)}, pk)
+	}
+
+	t.Run("Verifier.RequireSubjectType method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, pk)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(td.NewOTID("dev", "abc")))
+		assert.Nil(err)
+
+		v.RequireSubjectType("user", "svc")
+		_, err = v.ParseOTVID(sign(td.NewOTID("user", "abc")))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(td.NewOTID("dev", "abc")))
+		assert.NotNil(err)
+		_, err = v.VerifyToken(sign(td.NewOTID("dev", "abc")))
+		assert.NotNil(err)
+		v.SetMode(otgo.VerifyDegraded, 0)
+		_, err = v.Verify(sign(td.NewOTID("dev", "abc")))
+		assert.NotNil(err)
+		v.SetMode(otgo.VerifyFull, 0)
+
+		// the groups match nothing without the trust domain's configuration
+		v.RequireSubjectType(otgo.UserSubjectTypes)
+		_, err = v.ParseOTVID(sign(td.NewOTID("user", "abc")))
+		assert.NotNil(err)
+
+		v.RequireSubjectType()
+		_, err = v.ParseOTVID(sign(td.NewOTID("dev", "abc")))
+		assert.Nil(err)
+	})
+
+	t.Run("Verifier.ForbidDomainSubjects method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, pk)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(td.OTID()))
+		assert.Nil(err)
+		v.ForbidDomainSubjects()
+		_, err = v.ParseOTVID(sign(td.OTID()))
+		assert.NotNil(err)
+		_, err = v.ParseOTVID(sign(td.NewOTID("user", "abc")))
+		assert.Nil(err)
+	})
+
+	t.Run("subject type groups from the trust domain's configuration", func(t *testing.T) {
+		assert := assert.New(t)
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := json.Marshal(map[string]interface{}{
+				"otid":         td.OTID(),
+				"keys":         otgo.LookupPublicKeys(otgo.MustKeys(pk)).Keys,
+				"serviceTypes": []string{"app", "svc"},
+				"userTypes":    []string{"user", "dev"},
+			})
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(200)
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		v, err := otgo.NewVerifier(ctx, aud, cli)
+		assert.Nil(err)
+
+		v.RequireSubjectType(otgo.ServiceSubjectTypes)
+		_, err = v.ParseOTVID(sign(td.NewOTID("svc", "abc")))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(td.NewOTID("dev", "abc")))
+		assert.NotNil(err)
+
+		v.RequireSubjectType(otgo.UserSubjectTypes, "agent")
+		_, err = v.ParseOTVID(sign(td.NewOTID("dev", "abc")))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(td.NewOTID("agent", "abc")))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(td.NewOTID("svc", "abc")))
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,70 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// TokenFetcher mints the subject's OTVIDs for OTClient.Sign and the OTVID cache of ServiceClient,
+// e.g. a fixed OT-Auth endpoint (see WithOTAuth) or a secret manager. The output's ServiceEndpoints
+// are the audience's service endpoints.
+type TokenFetcher interface {
+	FetchOTVID(ctx context.Context, input SignInput) (*SignOutput, error)
+}
+
+// TokenFetcherFunc adapts a function to a TokenFetcher.
+type TokenFetcherFunc func(ctx context.Context, input SignInput) (*SignOutput, error)
+
+// FetchOTVID implements the TokenFetcher interface.
+func (fn TokenFetcherFunc) FetchOTVID(ctx context.Context, input SignInput) (*SignOutput, error) {
+	return fn(ctx, input)
+}
+
+// WithTokenFetcher mints the subject's OTVIDs with f instead of the trust domain's OT-Auth service.
+func WithTokenFetcher(f TokenFetcher) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if f == nil {
+			return errors.New("nil TokenFetcher")
+		}
+		oc.TokenFetcher = f
+		return nil
+	})
+}
+
+// WithOTAuth mints the subject's OTVIDs with the OT-Auth service at the endpoint, e.g. "https://auth.example.com",
+// instead of the service endpoints in the trust domain's configuration, so the configuration is not fetched
+// for Sign. The requests are authenticated with the subject's self OTVID like Sign.
+func WithOTAuth(endpoint string) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if endpoint == "" {
+			return errors.New("empty OT-Auth endpoint")
+		}
+		oc.TokenFetcher = &otAuthFetcher{oc: oc, endpoint: strings.TrimSuffix(endpoint, "/")}
+		return nil
+	})
+}
+
+type otAuthFetcher struct {
+	oc       *OTClient
+	endpoint string
+}
+
+func (f *otAuthFetcher) FetchOTVID(ctx context.Context, input SignInput) (*SignOutput, error) {
+	return f.oc.signWith(ctx, f.endpoint, input)
+}
+
+// signWith requests the OTVID from the OT-Auth service at the endpoint with subject's self OTVID.
+func (oc *OTClient) signWith(ctx context.Context, endpoint string, input SignInput) (*SignOutput, error) {
+	selfToken, err := oc.SignSelfContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	output := &SignOutput{}
+	h := AddTokenToHeader(make(http.Header), selfToken)
+	if err = doAPIWith(ctx, oc.HTTPClient, "POST", endpoint+"/sign", h, input, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
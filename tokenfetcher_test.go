@@ -0,0 +1,86 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenFetcher(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	sub := td.NewOTID("app", "123")
+	aud := td.NewOTID("svc", "tester")
+	domainKey := otgo.MustPrivateKey("ES256")
+
+	t.Run("WithOTAuth func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var signs int32
+		ts := newTestOTAuth(td, domainKey, &signs)
+		defer ts.Close()
+
+		_, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(""))
+		assert.NotNil(err)
+
+		// the trust domain's configuration is not fetched from the unreachable well-known URL
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL+"/"))
+		assert.Nil(err)
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		output, err := oc.Sign(context.Background(), otgo.SignInput{Subject: sub, Audience: aud})
+		assert.Nil(err)
+		assert.True(output.Audience.Equal(aud))
+		assert.Equal(int32(1), signs)
+
+		token, err := oc.Service(aud).Token()
+		assert.Nil(err)
+		vid, err := otgo.ParseOTVID(token, otgo.LookupPublicKeys(otgo.MustKeys(domainKey)), td.OTID(), aud)
+		assert.Nil(err)
+		assert.True(vid.ID.Equal(sub))
+		assert.Equal(int32(2), signs)
+	})
+
+	t.Run("WithTokenFetcher func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithTokenFetcher(nil))
+		assert.NotNil(err)
+
+		var signs int32
+		ts := newTestOTAuth(td, domainKey, &signs)
+		defer ts.Close()
+
+		var inputs []otgo.SignInput
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub,
+			otgo.WithTokenFetcher(otgo.TokenFetcherFunc(func(ctx context.Context, input otgo.SignInput) (*otgo.SignOutput, error) {
+				inputs = append(inputs, input)
+				if input.Audience.Equal(td.NewOTID("svc", "other")) {
+					return nil, errors.New("forbidden")
+				}
+				vid := &otgo.OTVID{ID: input.Subject, Issuer: td.OTID(), Audience: input.Audience,
+					Expiry: time.Now().Add(time.Hour)}
+				token, err := vid.Sign(domainKey)
+				if err != nil {
+					return nil, err
+				}
+				return &otgo.SignOutput{Issuer: td.OTID(), Audience: input.Audience, Expiry: vid.Expiry.Unix(),
+					OTVID: token, ServiceEndpoints: []string{ts.URL}}, nil
+			})))
+		assert.Nil(err)
+
+		cfg, err := oc.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(ts.URL, cfg.Endpoint)
+		assert.True(cfg.OTVID.Audience.Equal(aud))
+		assert.Equal(1, len(inputs))
+		assert.True(inputs[0].Subject.Equal(sub))
+		assert.Equal(int32(0), signs)
+
+		_, err = oc.Service(td.NewOTID("svc", "other")).Token()
+		assert.NotNil(err)
+	})
+}
@@ -0,0 +1,62 @@
+package otgo
+
+import (
+	"context"
+)
+
+// TokenSource returns a valid OTVID token for an audience, it is implemented by ServiceClient.
+// See the oauth2 package for golang.org/x/oauth2.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Token returns the cached OTVID token for the audience, it is renewed if it should renew.
+func (sc *ServiceClient) Token() (string, error) {
+	return sc.TokenContext(context.Background())
+}
+
+// TokenContext returns the OTVID token like Token with ctx.
+func (sc *ServiceClient) TokenContext(ctx context.Context) (string, error) {
+	cfg, err := sc.Resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	return cfg.OTVID.Token(), nil
+}
+
+// PerRPCCredentials adapts the TokenSource to grpc's credentials.PerRPCCredentials without depending on grpc:
+//
+//	grpc.Dial(target, grpc.WithPerRPCCredentials(otgo.PerRPCCredentials(oc.Service(aud), true)))
+//
+// The OTVID token is sent in the "authorization" metadata with Bearer scheme.
+// Use requireTLS false only for insecure connections in tests.
+func PerRPCCredentials(ts TokenSource, requireTLS bool) *TokenCredentials {
+	return &TokenCredentials{ts: ts, requireTLS: requireTLS}
+}
+
+// TokenCredentials implements grpc's credentials.PerRPCCredentials interface.
+type TokenCredentials struct {
+	ts         TokenSource
+	requireTLS bool
+}
+
+// GetRequestMetadata implements the credentials.PerRPCCredentials interface. The ctx is used for
+// the renewal if the TokenSource is a ServiceClient.
+func (c *TokenCredentials) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	var token string
+	var err error
+	if sc, ok := c.ts.(*ServiceClient); ok {
+		token, err = sc.TokenContext(ctx)
+	} else {
+		token, err = c.ts.Token()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": authPrefix + token}, nil
+}
+
+// RequireTransportSecurity implements the credentials.PerRPCCredentials interface.
+func (c *TokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
@@ -0,0 +1,55 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type errTokenSource struct{}
+
+func (errTokenSource) Token() (string, error) {
+	return "", errors.New("no token")
+}
+
+func TestTokenSource(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "tester")
+	oc := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+	vid := &otgo.OTVID{ID: td.NewOTID("app", "123"), Issuer: td.OTID(), Audience: aud,
+		Expiry: time.Now().Add(time.Hour)}
+	token, err := vid.Sign(otgo.MustPrivateKey("ES256"))
+	assert.Nil(t, err)
+	assert.Nil(t, oc.AddAudience(token, "http://localhost:1234"))
+
+	t.Run("ServiceClient.Token method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var ts otgo.TokenSource = oc.Service(aud)
+		s, err := ts.Token()
+		assert.Nil(err)
+		assert.Equal(token, s)
+
+		_, err = oc.Service(td.NewOTID("svc", "other")).Token()
+		assert.NotNil(err)
+	})
+
+	t.Run("PerRPCCredentials func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		creds := otgo.PerRPCCredentials(oc.Service(aud), true)
+		assert.True(creds.RequireTransportSecurity())
+		md, err := creds.GetRequestMetadata(context.Background(), "https://localhost")
+		assert.Nil(err)
+		assert.Equal(map[string]string{"authorization": "Bearer " + token}, md)
+
+		creds = otgo.PerRPCCredentials(errTokenSource{}, false)
+		assert.False(creds.RequireTransportSecurity())
+		_, err = creds.GetRequestMetadata(context.Background())
+		assert.NotNil(err)
+	})
+}
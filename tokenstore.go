@@ -0,0 +1,140 @@
+package otgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TokenStore stores the subject's OTVIDs for the audiences, e.g. in Vault or AWS SSM, so that long-lived
+// OTVIDs survive restarts and are managed with the platform's secrets. It is keyed by the audience,
+// a TokenStore should not be shared by OTClients of different subjects.
+// The OTClient loads the OTVID of the audience before requesting a new one from OT-Auth,
+// stores the new one after, and deletes the stored one if it is invalid or expired. See WithTokenStore.
+type TokenStore interface {
+	// Load returns the stored OTVID of the audience, or nil and nil error if not found.
+	Load(ctx context.Context, aud OTID) (*StoredOTVID, error)
+	// Store stores the OTVID of the audience, it replaces the stored one.
+	Store(ctx context.Context, aud OTID, t *StoredOTVID) error
+	// Delete deletes the stored OTVID of the audience, it is not an error if not found.
+	Delete(ctx context.Context, aud OTID) error
+}
+
+// StoredOTVID is a OTVID in a TokenStore with the audience's service endpoints.
+type StoredOTVID struct {
+	OTVID            string   `json:"otvid"`
+	ServiceEndpoints []string `json:"serviceEndpoints"`
+}
+
+// WithTokenStore stores the subject's OTVIDs in the TokenStore instead of the Store of WithCache.
+func WithTokenStore(s TokenStore) OTClientOption {
+	return otClientOptionFunc(func(oc *OTClient) error {
+		if s == nil {
+			return errors.New("nil TokenStore")
+		}
+		oc.TokenStore = s
+		return nil
+	})
+}
+
+// MemoryTokenStore is a in-memory TokenStore.
+type MemoryTokenStore struct {
+	mu sync.RWMutex
+	kv map[string]StoredOTVID
+}
+
+// NewMemoryTokenStore ...
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{kv: make(map[string]StoredOTVID)}
+}
+
+// Load implements the TokenStore interface.
+func (s *MemoryTokenStore) Load(_ context.Context, aud OTID) (*StoredOTVID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.kv[aud.String()]
+	if !ok {
+		return nil, nil
+	}
+	t.ServiceEndpoints = append([]string(nil), t.ServiceEndpoints...)
+	return &t, nil
+}
+
+// Store implements the TokenStore interface.
+func (s *MemoryTokenStore) Store(_ context.Context, aud OTID, t *StoredOTVID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kv[aud.String()] = StoredOTVID{OTVID: t.OTVID, ServiceEndpoints: append([]string(nil), t.ServiceEndpoints...)}
+	return nil
+}
+
+// Delete implements the TokenStore interface.
+func (s *MemoryTokenStore) Delete(_ context.Context, aud OTID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.kv, aud.String())
+	return nil
+}
+
+// FileTokenStore is a TokenStore persisted to a file, optionally encrypted with AES-GCM, see FileStore.
+// The OTVIDs are removed from the file after they expire.
+type FileTokenStore struct {
+	fs *FileStore
+}
+
+// NewFileTokenStore returns a FileTokenStore persisted to the file, the key is the same as NewFileStore's.
+func NewFileTokenStore(path string, key []byte) (*FileTokenStore, error) {
+	fs, err := NewFileStore(path, key)
+	if err != nil {
+		return nil, err
+	}
+	return &FileTokenStore{fs: fs}, nil
+}
+
+// Load implements the TokenStore interface.
+func (s *FileTokenStore) Load(ctx context.Context, aud OTID) (*StoredOTVID, error) {
+	b, err := s.fs.Get(ctx, aud.String())
+	if err != nil || b == nil {
+		return nil, err
+	}
+	t := &StoredOTVID{}
+	if err = json.Unmarshal(b, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Store implements the TokenStore interface, the OTVID is kept until it expires.
+func (s *FileTokenStore) Store(ctx context.Context, aud OTID, t *StoredOTVID) error {
+	exp, err := tokenExpiry(t.OTVID)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return s.fs.Set(ctx, aud.String(), b, clockUntil(exp))
+}
+
+// tokenExpiry returns the expiration time of the OTVID without the size limits, which are
+// the OTClient's, so that the OTVIDs under its raised limits are stored too.
+func tokenExpiry(token string) (time.Time, error) {
+	payload, err := (&decodedOTVID{}).split(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	claims := make(map[string]interface{})
+	if err = unmarshalClaims(payload, &claims, true); err != nil {
+		return time.Time{}, fmt.Errorf("otgo.decodeOTVID: invalid claims: %s", err.Error())
+	}
+	return numericDate(claims, "exp")
+}
+
+// Delete implements the TokenStore interface.
+func (s *FileTokenStore) Delete(ctx context.Context, aud OTID) error {
+	return s.fs.Set(ctx, aud.String(), nil, 0)
+}
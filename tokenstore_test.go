@@ -0,0 +1,134 @@
+package otgo_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenStore(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	sub := td.NewOTID("app", "123")
+	aud := td.NewOTID("svc", "tester")
+	domainKey := otgo.MustPrivateKey("ES256")
+	sign := func(exp time.Time) string {
+		return mustSignOTVID(&otgo.OTVID{ID: sub, Issuer: td.OTID(), Audience: aud, Expiry: exp}, domainKey)
+	}
+
+	testStore := func(assert *assert.Assertions, s otgo.TokenStore) {
+		ctx := context.Background()
+		t, err := s.Load(ctx, aud)
+		assert.Nil(err)
+		assert.Nil(t)
+
+		token := sign(time.Now().Add(time.Hour))
+		assert.Nil(s.Store(ctx, aud, &otgo.StoredOTVID{OTVID: token, ServiceEndpoints: []string{"https://a"}}))
+		t, err = s.Load(ctx, aud)
+		assert.Nil(err)
+		assert.Equal(token, t.OTVID)
+		assert.Equal([]string{"https://a"}, t.ServiceEndpoints)
+		t, err = s.Load(ctx, td.NewOTID("svc", "other"))
+		assert.Nil(err)
+		assert.Nil(t)
+
+		assert.Nil(s.Delete(ctx, aud))
+		t, err = s.Load(ctx, aud)
+		assert.Nil(err)
+		assert.Nil(t)
+		assert.Nil(s.Delete(ctx, aud))
+	}
+
+	t.Run("MemoryTokenStore", func(t *testing.T) {
+		testStore(assert.New(t), otgo.NewMemoryTokenStore())
+	})
+
+	t.Run("FileTokenStore", func(t *testing.T) {
+		assert := assert.New(t)
+
+		dir, err := ioutil.TempDir("", "otgo")
+		assert.Nil(err)
+		defer os.RemoveAll(dir)
+		file := filepath.Join(dir, "tokens")
+		key := []byte("0123456789abcdef")
+
+		s, err := otgo.NewFileTokenStore(file, key)
+		assert.Nil(err)
+		testStore(assert, s)
+
+		token := sign(time.Now().Add(time.Hour))
+		assert.Nil(s.Store(context.Background(), aud, &otgo.StoredOTVID{OTVID: token}))
+		assert.NotNil(s.Store(context.Background(), aud, &otgo.StoredOTVID{OTVID: "invalid"}))
+		s, err = otgo.NewFileTokenStore(file, key)
+		assert.Nil(err)
+		st, err := s.Load(context.Background(), aud)
+		assert.Nil(err)
+		assert.Equal(token, st.OTVID)
+
+		_, err = otgo.NewFileTokenStore(file, []byte("fedcba9876543210"))
+		assert.NotNil(err)
+	})
+
+	t.Run("WithTokenStore func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		var signs int32
+		ts := newTestOTAuth(td, domainKey, &signs)
+		defer ts.Close()
+
+		_, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithTokenStore(nil))
+		assert.NotNil(err)
+
+		s := otgo.NewMemoryTokenStore()
+		token := sign(time.Now().Add(time.Hour))
+		assert.Nil(s.Store(context.Background(), aud, &otgo.StoredOTVID{OTVID: token, ServiceEndpoints: []string{ts.URL}}))
+		oc, err := otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL), otgo.WithTokenStore(s))
+		assert.Nil(err)
+		oc.SetPrivateKeys(*otgo.MustKeys(otgo.MustPrivateKey("ES256")))
+
+		cfg, err := oc.Service(aud).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(token, cfg.OTVID.Token())
+		assert.Equal(ts.URL, cfg.Endpoint)
+		assert.Equal(int32(0), signs)
+
+		// the expired OTVID is deleted and replaced by a new one
+		other := td.NewOTID("svc", "other")
+		expired, err := (&otgo.OTVID{ID: sub, Issuer: td.OTID(), Audience: other, Expiry: time.Now().Add(-time.Minute)}).Sign(domainKey)
+		assert.Nil(err)
+		assert.Nil(s.Store(context.Background(), other, &otgo.StoredOTVID{OTVID: expired}))
+		cfg, err = oc.Service(other).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(int32(1), signs)
+		st, err := s.Load(context.Background(), other)
+		assert.Nil(err)
+		assert.Equal(cfg.OTVID.Token(), st.OTVID)
+		assert.NotEqual(expired, st.OTVID)
+
+		// the stored OTVID over the default size is used with the client's raised limits
+		large := td.NewOTID("svc", "large")
+		vid := &otgo.OTVID{ID: sub, Issuer: td.OTID(), Audience: large, Expiry: time.Now().Add(time.Hour),
+			Claims: map[string]interface{}{"data": strings.Repeat("a", 3000)}}
+		token, err = vid.SignWithLimits(domainKey, otgo.Limits{OTVIDMaxSize: 8192})
+		assert.Nil(err)
+		fs, err := otgo.NewFileTokenStore(filepath.Join(t.TempDir(), "tokens"), nil)
+		assert.Nil(err)
+		assert.Nil(fs.Store(context.Background(), large, &otgo.StoredOTVID{OTVID: token, ServiceEndpoints: []string{ts.URL}}))
+		oc, err = otgo.NewOTClientWithOptions(context.Background(), sub, otgo.WithOTAuth(ts.URL), otgo.WithTokenStore(fs))
+		assert.Nil(err)
+		oc.Limits = &otgo.Limits{OTVIDMaxSize: 8192}
+		cfg, err = oc.Service(large).Resolve(context.Background())
+		assert.Nil(err)
+		assert.Equal(token, cfg.OTVID.Token())
+		assert.Equal(int32(1), signs)
+		st, err = fs.Load(context.Background(), large)
+		assert.Nil(err)
+		assert.Equal(token, st.OTVID)
+	})
+}
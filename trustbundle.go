@@ -0,0 +1,406 @@
+package otgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+)
+
+// TrustBundle is the trust material of trust domains in a single JSON document, e.g. for the air-gapped
+// deployments that can not fetch the trust domains' configurations. See OTClient.ExportTrustBundle,
+// OTClient.ImportTrustBundle and WithTrustBundle.
+type TrustBundle struct {
+	Domains []*TrustBundleDomain
+	Expiry  time.Time // the bundle should not be used after it, zero for never
+}
+
+// TrustBundleDomain is a trust domain's public keys and configuration in a TrustBundle.
+type TrustBundleDomain struct {
+	OTID             OTID
+	Keys             *JWKSet             // the trust domain's public keys
+	ServiceEndpoints []string            // the OT-Auth service endpoints
+	Issuers          map[string][]string // delegated issuer OTID to its key IDs in Keys
+	ServiceTypes     []string
+	UserTypes        []string
+}
+
+type trustBundleProxy struct {
+	Domains []*domainConfigProxy `json:"domains"`
+	Expiry  int64                `json:"exp,omitempty"` // Unix time in seconds
+}
+
+// NewTrustBundle returns a TrustBundle of the trust domains' configurations, the keys are converted
+// to public keys.
+func NewTrustBundle(cfgs ...*DomainConfig) (*TrustBundle, error) {
+	b := &TrustBundle{}
+	for _, cfg := range cfgs {
+		if cfg.JWKSet == nil || len(cfg.JWKSet.Keys) == 0 {
+			return nil, fmt.Errorf("otgo.NewTrustBundle: no keys of %s", cfg.OTID.String())
+		}
+		b.Domains = append(b.Domains, &TrustBundleDomain{
+			OTID:             cfg.OTID,
+			Keys:             LookupPublicKeys(cfg.JWKSet),
+			ServiceEndpoints: cfg.ServiceEndpoints,
+			Issuers:          cfg.Issuers,
+			ServiceTypes:     cfg.ServiceTypes,
+			UserTypes:        cfg.UserTypes,
+		})
+	}
+	if err := b.validate(); err != nil {
+		return nil, fmt.Errorf("otgo.NewTrustBundle: %s", err.Error())
+	}
+	return b, nil
+}
+
+// ParseTrustBundle parses the JSON document of a TrustBundle and validates it, the expiry is not checked.
+func ParseTrustBundle(data []byte) (*TrustBundle, error) {
+	p := &trustBundleProxy{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("otgo.ParseTrustBundle: %s", err.Error())
+	}
+	b := &TrustBundle{}
+	if p.Expiry > 0 {
+		b.Expiry = time.Unix(p.Expiry, 0)
+	}
+	for _, res := range p.Domains {
+		if err := res.OTID.Validate(); err != nil {
+			return nil, fmt.Errorf("otgo.ParseTrustBundle: invalid trust domain OTID: %s", err.Error())
+		}
+		if err := res.parseKeys(res.OTID.TrustDomain(), 0); err != nil {
+			return nil, fmt.Errorf("otgo.ParseTrustBundle: %s", err.Error())
+		}
+		b.Domains = append(b.Domains, bundleDomainOf(res))
+	}
+	if err := b.validate(); err != nil {
+		return nil, fmt.Errorf("otgo.ParseTrustBundle: %s", err.Error())
+	}
+	return b, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (b *TrustBundle) MarshalJSON() ([]byte, error) {
+	p := &trustBundleProxy{Domains: make([]*domainConfigProxy, 0, len(b.Domains))}
+	if !b.Expiry.IsZero() {
+		p.Expiry = b.Expiry.Unix()
+	}
+	for _, d := range b.Domains {
+		res, err := d.proxy()
+		if err != nil {
+			return nil, err
+		}
+		p.Domains = append(p.Domains, res)
+	}
+	return json.Marshal(p)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface, see ParseTrustBundle.
+func (b *TrustBundle) UnmarshalJSON(data []byte) error {
+	res, err := ParseTrustBundle(data)
+	if err != nil {
+		return err
+	}
+	*b = *res
+	return nil
+}
+
+// Domain returns the trust domain in the bundle, nil if not found.
+func (b *TrustBundle) Domain(td TrustDomain) *TrustBundleDomain {
+	for _, d := range b.Domains {
+		if d.OTID.TrustDomain() == td {
+			return d
+		}
+	}
+	return nil
+}
+
+// Validate checks the bundle is valid and not expired.
+func (b *TrustBundle) Validate() error {
+	if err := b.validate(); err != nil {
+		return fmt.Errorf("otgo.TrustBundle.Validate: %s", err.Error())
+	}
+	if b.Expired() {
+		return fmt.Errorf("otgo.TrustBundle.Validate: expired at %s", b.Expiry.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Expired reports whether the bundle expired.
+func (b *TrustBundle) Expired() bool {
+	return !b.Expiry.IsZero() && !clockNow().Before(b.Expiry)
+}
+
+func (b *TrustBundle) validate() error {
+	if len(b.Domains) == 0 {
+		return errors.New("no trust domains")
+	}
+	seen := make(map[TrustDomain]bool, len(b.Domains))
+	for _, d := range b.Domains {
+		td := d.OTID.TrustDomain()
+		if !d.OTID.Equal(td.OTID()) {
+			return fmt.Errorf("invalid trust domain OTID %s", d.OTID.String())
+		}
+		if seen[td] {
+			return fmt.Errorf("duplicate trust domain %s", td)
+		}
+		seen[td] = true
+		if d.Keys == nil || len(d.Keys.Keys) == 0 {
+			return fmt.Errorf("no keys of %s", td)
+		}
+		for _, k := range d.Keys.Keys {
+			switch k.(type) {
+			case jwk.RSAPublicKey, jwk.ECDSAPublicKey:
+			default:
+				return fmt.Errorf("invalid key type %T of %s, public keys required", k, td)
+			}
+		}
+	}
+	return nil
+}
+
+// MergeTrustBundles merges the bundles into one: the keys and the service endpoints of a trust domain
+// in several bundles are merged, the key with the same key ID is kept from the first bundle.
+// The merged bundle expires at the earliest expiry of the bundles.
+func MergeTrustBundles(bundles ...*TrustBundle) (*TrustBundle, error) {
+	m := &TrustBundle{}
+	for _, b := range bundles {
+		if !b.Expiry.IsZero() && (m.Expiry.IsZero() || b.Expiry.Before(m.Expiry)) {
+			m.Expiry = b.Expiry
+		}
+		for _, d := range b.Domains {
+			md := m.Domain(d.OTID.TrustDomain())
+			if md == nil {
+				md = &TrustBundleDomain{OTID: d.OTID, Keys: &JWKSet{}, Issuers: make(map[string][]string)}
+				m.Domains = append(m.Domains, md)
+			}
+			for _, k := range d.Keys.Keys {
+				if k.KeyID() == "" || len(md.Keys.LookupKeyID(k.KeyID())) == 0 {
+					md.Keys.Keys = append(md.Keys.Keys, k)
+				}
+			}
+			md.ServiceEndpoints = mergeStrings(md.ServiceEndpoints, d.ServiceEndpoints)
+			md.ServiceTypes = mergeStrings(md.ServiceTypes, d.ServiceTypes)
+			md.UserTypes = mergeStrings(md.UserTypes, d.UserTypes)
+			for iss, kids := range d.Issuers {
+				md.Issuers[iss] = mergeStrings(md.Issuers[iss], kids)
+			}
+		}
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("otgo.MergeTrustBundles: %s", err.Error())
+	}
+	return m, nil
+}
+
+func mergeStrings(ss, others []string) []string {
+	for _, s := range others {
+		if !stringsHas(ss, s) {
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+func bundleDomainOf(res *domainConfigProxy) *TrustBundleDomain {
+	ks := res.ks
+	return &TrustBundleDomain{
+		OTID:             res.OTID,
+		Keys:             &ks,
+		ServiceEndpoints: res.ServiceEndpoints,
+		Issuers:          res.Issuers,
+		ServiceTypes:     res.ServiceTypes,
+		UserTypes:        res.UserTypes,
+	}
+}
+
+// proxy returns the trust domain's configuration document of the bundle domain.
+func (d *TrustBundleDomain) proxy() (*domainConfigProxy, error) {
+	res := &domainConfigProxy{
+		OTID:             d.OTID,
+		ServiceEndpoints: d.ServiceEndpoints,
+		Issuers:          d.Issuers,
+		ServiceTypes:     d.ServiceTypes,
+		UserTypes:        d.UserTypes,
+	}
+	if d.Keys != nil {
+		res.ks.Keys = d.Keys.Keys
+		for _, k := range d.Keys.Keys {
+			b, err := json.Marshal(k)
+			if err != nil {
+				return nil, err
+			}
+			res.Keys = append(res.Keys, b)
+		}
+	}
+	return res, nil
+}
+
+// ExportTrustBundle exports the trust domains' configurations (the OTClient's trust domain if none)
+// to a TrustBundle, they are fetched if not cached.
+func (oc *OTClient) ExportTrustBundle(ctx context.Context, domains ...TrustDomain) (*TrustBundle, error) {
+	if len(domains) == 0 {
+		domains = []TrustDomain{oc.td}
+	}
+	cfgs := make([]*DomainConfig, 0, len(domains))
+	for _, td := range domains {
+		cfg, err := oc.DomainConfig(ctx, td)
+		if err != nil {
+			return nil, fmt.Errorf("otgo.OTClient.ExportTrustBundle: %s", err.Error())
+		}
+		cfgs = append(cfgs, cfg)
+	}
+	b, err := NewTrustBundle(cfgs...)
+	if err != nil {
+		return nil, fmt.Errorf("otgo.OTClient.ExportTrustBundle: %s", err.Error())
+	}
+	return b, nil
+}
+
+// ImportTrustBundle sets the trust domains' configurations in the bundle persistently like SetDomainKeys,
+// until the bundle expires. The other trust domains than the OTClient's are added as federated domains,
+// see AddFederatedDomain. It returns an error if the bundle is invalid or expired, or if the OTClient
+// trusts only the signed configurations (see ConfigRootKeys and ImportSignedTrustBundle).
+func (oc *OTClient) ImportTrustBundle(b *TrustBundle) error {
+	if oc.ConfigRootKeys != nil {
+		return errors.New("otgo.OTClient.ImportTrustBundle: signed trust bundle required, see ImportSignedTrustBundle")
+	}
+	return oc.importTrustBundle(b)
+}
+
+// ImportSignedTrustBundle imports the signed bundle like ImportTrustBundle, it is verified with
+// the ConfigRootKeys, see ParseSignedTrustBundle.
+func (oc *OTClient) ImportSignedTrustBundle(data []byte) error {
+	if oc.ConfigRootKeys == nil {
+		return errors.New("otgo.OTClient.ImportSignedTrustBundle: root keys required, see ConfigRootKeys")
+	}
+	b, err := ParseSignedTrustBundle(data, oc.ConfigRootKeys)
+	if err != nil {
+		return fmt.Errorf("otgo.OTClient.ImportSignedTrustBundle: %s", err.Error())
+	}
+	return oc.importTrustBundle(b)
+}
+
+func (oc *OTClient) importTrustBundle(b *TrustBundle) error {
+	if err := b.Validate(); err != nil {
+		return fmt.Errorf("otgo.OTClient.ImportTrustBundle: %s", err.Error())
+	}
+	for _, d := range b.Domains {
+		td := d.OTID.TrustDomain()
+		dr := oc.otDomain
+		if td != oc.td {
+			if err := oc.AddFederatedDomain(td); err != nil {
+				return fmt.Errorf("otgo.OTClient.ImportTrustBundle: %s", err.Error())
+			}
+			dr = oc.Domain(td)
+		}
+		res, err := d.proxy()
+		if err != nil {
+			return fmt.Errorf("otgo.OTClient.ImportTrustBundle: %s", err.Error())
+		}
+		expiresAt := b.Expiry
+		if expiresAt.IsZero() {
+			expiresAt = clockNow().Add(time.Hour * 24 * 365 * 99)
+		}
+		dr.Lock()
+		dr.setDoc(res)
+		dr.endpoint = nullhost
+		dr.expiresAt = expiresAt
+		dr.Unlock()
+	}
+	return nil
+}
+
+// WithTrustBundle verifies the OTVIDs with the audience's trust domain in the bundle persistently
+// instead of fetching the trust domain's configuration, like WithKeys. The OTVIDs are rejected after
+// the bundle expires. It can not be used with WithConfigRootKeys, see WithSignedTrustBundle.
+func WithTrustBundle(b *TrustBundle) VerifierOption {
+	return func(o *verifierOptions) {
+		o.bundle = b
+	}
+}
+
+// WithSignedTrustBundle verifies the OTVIDs with the audience's trust domain in the signed bundle like
+// WithTrustBundle, the bundle is verified with the root keys of WithConfigRootKeys, see ParseSignedTrustBundle.
+func WithSignedTrustBundle(data []byte) VerifierOption {
+	return func(o *verifierOptions) {
+		o.signedBundle = data
+	}
+}
+
+// ImportTrustBundle replaces the keys and the delegated issuers' key IDs of the Verifier with the audience's
+// trust domain in the bundle, they are replaced again on the next refresh if the Verifier fetches the
+// trust domain's configuration. The OTVIDs are rejected after the bundle expires until the next refresh.
+// It returns an error if the bundle is invalid, expired or without the trust domain, or if the Verifier
+// trusts only the signed configurations (see SetConfigRootKeys and ImportSignedTrustBundle).
+func (v *Verifier) ImportTrustBundle(b *TrustBundle) error {
+	v.mu.RLock()
+	signed := v.configRoots != nil
+	v.mu.RUnlock()
+	if signed {
+		return errors.New("otgo.Verifier.ImportTrustBundle: signed trust bundle required, see ImportSignedTrustBundle")
+	}
+	if err := v.importTrustBundle(b); err != nil {
+		return fmt.Errorf("otgo.Verifier.ImportTrustBundle: %s", err.Error())
+	}
+	return nil
+}
+
+// ImportSignedTrustBundle imports the signed bundle like ImportTrustBundle, it is verified with the root keys
+// of SetConfigRootKeys, see ParseSignedTrustBundle.
+func (v *Verifier) ImportSignedTrustBundle(data []byte) error {
+	v.mu.RLock()
+	roots := v.configRoots
+	v.mu.RUnlock()
+	if roots == nil {
+		return errors.New("otgo.Verifier.ImportSignedTrustBundle: root keys required, see SetConfigRootKeys")
+	}
+	b, err := ParseSignedTrustBundle(data, roots)
+	if err == nil {
+		err = v.importTrustBundle(b)
+	}
+	if err != nil {
+		return fmt.Errorf("otgo.Verifier.ImportSignedTrustBundle: %s", err.Error())
+	}
+	return nil
+}
+
+func (v *Verifier) importTrustBundle(b *TrustBundle) error {
+	d, err := bundleDomainFor(b, v.td)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.ks = d.Keys
+	v.issuers = d.Issuers
+	v.serviceTypes = d.ServiceTypes
+	v.userTypes = d.UserTypes
+	v.bundleExpiry = b.Expiry
+	return nil
+}
+
+// ParseSignedTrustBundle verifies the bundle signed by PublishSignedConfig with the root's public keys,
+// and parses it like ParseTrustBundle. The bundle expires with the signed document:
+//
+//	data, err := otgo.PublishSignedConfig(bundle, rootKey, 24*time.Hour)
+func ParseSignedTrustBundle(data []byte, roots *JWKSet) (*TrustBundle, error) {
+	payload, err := VerifySignedConfig(data, roots)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTrustBundle(payload)
+}
+
+func bundleDomainFor(b *TrustBundle, td TrustDomain) (*TrustBundleDomain, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	d := b.Domain(td)
+	if d == nil {
+		return nil, fmt.Errorf("no trust domain %s in the bundle", td)
+	}
+	return d, nil
+}
@@ -0,0 +1,215 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustBundle(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	ftd := otgo.TrustDomain("allied.com")
+	sub := td.NewOTID("app", "123")
+	domainKey := otgo.MustPrivateKey("ES256")
+	fedKey := otgo.MustPrivateKey("ES256")
+	var signs int32
+	ts := newTestOTAuth(td, domainKey, &signs)
+	defer ts.Close()
+	fts := newTestOTAuth(ftd, fedKey, &signs)
+	defer fts.Close()
+
+	sign := func(key otgo.Key, iss otgo.TrustDomain) string {
+		return mustSignOTVID(&otgo.OTVID{ID: iss.NewOTID("user", "abc"), Issuer: iss.OTID(), Audience: sub, Expiry: time.Now().Add(time.Hour)}, key)
+	}
+
+	oc := otgo.NewOTClient(context.Background(), sub)
+	oc.ConfigURLs = &otgo.ConfigURLs{}
+	oc.ConfigURLs.Set(td, ts.URL+"/.well-known/open-trust-configuration")
+	oc.ConfigURLs.Set(ftd, fts.URL+"/.well-known/open-trust-configuration")
+
+	t.Run("OTClient.ExportTrustBundle and ParseTrustBundle", func(t *testing.T) {
+		assert := assert.New(t)
+
+		b, err := oc.ExportTrustBundle(context.Background())
+		assert.Nil(err)
+		assert.Equal(1, len(b.Domains))
+		assert.True(b.Domains[0].OTID.Equal(td.OTID()))
+		assert.Equal([]string{ts.URL}, b.Domains[0].ServiceEndpoints)
+
+		b, err = oc.ExportTrustBundle(context.Background(), td, ftd)
+		assert.Nil(err)
+		assert.Equal(2, len(b.Domains))
+		assert.Nil(b.Validate())
+		b.Expiry = time.Now().Add(time.Hour)
+
+		data, err := json.Marshal(b)
+		assert.Nil(err)
+		assert.NotContains(string(data), `"d":`)
+		b2, err := otgo.ParseTrustBundle(data)
+		assert.Nil(err)
+		assert.Equal(b.Expiry.Unix(), b2.Expiry.Unix())
+		assert.Equal(2, len(b2.Domains))
+		assert.Equal(domainKey.KeyID(), b2.Domain(td).Keys.Keys[0].KeyID())
+		assert.Equal(fedKey.KeyID(), b2.Domain(ftd).Keys.Keys[0].KeyID())
+		assert.Nil(b2.Domain(otgo.TrustDomain("other.com")))
+
+		b3 := &otgo.TrustBundle{}
+		assert.Nil(json.Unmarshal(data, b3))
+		assert.Equal(2, len(b3.Domains))
+
+		_, err = otgo.ParseTrustBundle([]byte(`{"domains": []}`))
+		assert.NotNil(err)
+		_, err = otgo.ParseTrustBundle([]byte(`{"domains": [{"otid": "otid:localhost", "keys": []}]}`))
+		assert.NotNil(err)
+		priv, _ := json.Marshal(domainKey)
+		_, err = otgo.ParseTrustBundle([]byte(`{"domains": [{"otid": "otid:localhost", "keys": [` + string(priv) + `]}]}`))
+		assert.NotNil(err)
+		_, err = otgo.ParseTrustBundle([]byte(`{"domains": [{"otid": "otid:localhost:app:123", "keys": []}]}`))
+		assert.NotNil(err)
+
+		_, err = oc.ExportTrustBundle(context.Background(), otgo.TrustDomain("Bad"))
+		assert.NotNil(err)
+	})
+
+	t.Run("OTClient.ImportTrustBundle method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		b, err := oc.ExportTrustBundle(context.Background(), td, ftd)
+		assert.Nil(err)
+
+		cli := otgo.NewOTClient(context.Background(), sub)
+		cli.ConfigURLs = &otgo.ConfigURLs{} // unreachable
+		assert.Nil(cli.ImportTrustBundle(b))
+		_, err = cli.ParseOTVID(context.Background(), sign(domainKey, td))
+		assert.Nil(err)
+		_, err = cli.ParseOTVID(context.Background(), sign(fedKey, ftd))
+		assert.Nil(err)
+		_, err = cli.ParseOTVID(context.Background(), sign(domainKey, ftd))
+		assert.NotNil(err)
+
+		b.Expiry = time.Now().Add(-time.Second)
+		assert.True(b.Expired())
+		assert.NotNil(b.Validate())
+		assert.NotNil(cli.ImportTrustBundle(b))
+	})
+
+	t.Run("WithTrustBundle and Verifier.ImportTrustBundle", func(t *testing.T) {
+		assert := assert.New(t)
+
+		b, err := oc.ExportTrustBundle(context.Background(), td)
+		assert.Nil(err)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), sub, otgo.WithTrustBundle(b))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(domainKey, td))
+		assert.Nil(err)
+
+		_, err = otgo.NewVerifierWithOptions(context.Background(), ftd.NewOTID("app", "123"), otgo.WithTrustBundle(b))
+		assert.NotNil(err)
+
+		v, err = otgo.NewVerifierWithOptions(context.Background(), sub, otgo.WithKeys(otgo.MustPrivateKey("ES256")))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(domainKey, td))
+		assert.NotNil(err)
+		assert.Nil(v.ImportTrustBundle(b))
+		_, err = v.ParseOTVID(sign(domainKey, td))
+		assert.Nil(err)
+
+		fb, err := oc.ExportTrustBundle(context.Background(), ftd)
+		assert.Nil(err)
+		assert.NotNil(v.ImportTrustBundle(fb))
+	})
+
+	t.Run("the OTVIDs are rejected after the bundle expires", func(t *testing.T) {
+		assert := assert.New(t)
+
+		b, err := oc.ExportTrustBundle(context.Background(), td)
+		assert.Nil(err)
+		b.Expiry = time.Now().Add(time.Hour)
+		v, err := otgo.NewVerifierWithOptions(context.Background(), sub, otgo.WithTrustBundle(b))
+		assert.Nil(err)
+		token := sign(domainKey, td)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+
+		fc := otgo.NewFakeClock(time.Now().Add(time.Hour))
+		defer otgo.SetClock(otgo.SetClock(fc))
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "the trust bundle expired")
+	})
+
+	t.Run("WithSignedTrustBundle and Verifier.ImportSignedTrustBundle", func(t *testing.T) {
+		assert := assert.New(t)
+
+		rootKey := otgo.MustPrivateKey("ES256")
+		roots := otgo.LookupPublicKeys(otgo.MustKeys(rootKey))
+		b, err := oc.ExportTrustBundle(context.Background(), td)
+		assert.Nil(err)
+		data, err := otgo.PublishSignedConfig(b, rootKey, time.Hour)
+		assert.Nil(err)
+
+		sb, err := otgo.ParseSignedTrustBundle(data, roots)
+		assert.Nil(err)
+		assert.False(sb.Expiry.IsZero())
+		_, err = otgo.ParseSignedTrustBundle(data, otgo.LookupPublicKeys(otgo.MustKeys(otgo.MustPrivateKey("ES256"))))
+		assert.NotNil(err)
+
+		// the unsigned bundle is rejected with the root keys
+		_, err = otgo.NewVerifierWithOptions(context.Background(), sub, otgo.WithConfigRootKeys(roots), otgo.WithTrustBundle(b))
+		assert.NotNil(err)
+		assert.Contains(err.Error(), "signed trust bundle required")
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), sub, otgo.WithConfigRootKeys(roots), otgo.WithSignedTrustBundle(data))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(sign(domainKey, td))
+		assert.Nil(err)
+		assert.NotNil(v.ImportTrustBundle(b))
+		assert.Nil(v.ImportSignedTrustBundle(data))
+
+		v, err = otgo.NewVerifierWithOptions(context.Background(), sub, otgo.WithKeys(otgo.MustPrivateKey("ES256")))
+		assert.Nil(err)
+		assert.NotNil(v.ImportSignedTrustBundle(data))
+
+		cli := otgo.NewOTClient(context.Background(), sub)
+		cli.ConfigURLs = &otgo.ConfigURLs{} // unreachable
+		cli.ConfigRootKeys = roots
+		assert.NotNil(cli.ImportTrustBundle(b))
+		assert.Nil(cli.ImportSignedTrustBundle(data))
+		_, err = cli.ParseOTVID(context.Background(), sign(domainKey, td))
+		assert.Nil(err)
+	})
+
+	t.Run("MergeTrustBundles func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		b1, err := oc.ExportTrustBundle(context.Background(), td)
+		assert.Nil(err)
+		b1.Expiry = time.Now().Add(2 * time.Hour)
+		newKey := otgo.MustPrivateKey("ES256")
+		b2, err := otgo.NewTrustBundle(&otgo.DomainConfig{OTID: td.OTID(), JWKSet: otgo.MustKeys(newKey, domainKey),
+			ServiceEndpoints: []string{"https://auth.localhost"}})
+		assert.Nil(err)
+		b2.Expiry = time.Now().Add(time.Hour)
+		b3, err := oc.ExportTrustBundle(context.Background(), ftd)
+		assert.Nil(err)
+
+		m, err := otgo.MergeTrustBundles(b1, b2, b3)
+		assert.Nil(err)
+		assert.Equal(2, len(m.Domains))
+		assert.Equal(b2.Expiry, m.Expiry)
+		d := m.Domain(td)
+		assert.Equal(2, len(d.Keys.Keys))
+		assert.Equal([]string{ts.URL, "https://auth.localhost"}, d.ServiceEndpoints)
+		assert.Equal(1, len(m.Domain(ftd).Keys.Keys))
+
+		_, err = otgo.NewTrustBundle(&otgo.DomainConfig{OTID: td.OTID()})
+		assert.NotNil(err)
+		_, err = otgo.NewTrustBundle()
+		assert.NotNil(err)
+	})
+}
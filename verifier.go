@@ -0,0 +1,626 @@
+package otgo
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// VerifyMode ...
+type VerifyMode int32
+
+// Verify modes.
+const (
+	// VerifyFull verifies the signature and claims of every OTVID.
+	VerifyFull VerifyMode = iota
+	// VerifyDegraded verifies the signature of a sampled fraction of OTVIDs and of OTVIDs
+	// for sensitive audiences, the claims of all OTVIDs are still verified.
+	// It should only be used under extreme load, e.g. during a DDoS attack.
+	VerifyDegraded
+)
+
+// ReasonCode explains how a OTVID was verified.
+type ReasonCode string
+
+// Reason codes.
+const (
+	ReasonVerified   ReasonCode = "verified"    // full verification in VerifyFull mode
+	ReasonSampled    ReasonCode = "sampled"     // full verification, sampled in VerifyDegraded mode
+	ReasonSensitive  ReasonCode = "sensitive"   // full verification for a sensitive audience in VerifyDegraded mode
+	ReasonSampledOut ReasonCode = "sampled_out" // claims verified only, the signature and replay were NOT checked
+)
+
+// VerifyResult is the result of Verifier.Verify and Verifier.VerifyToken, for audit logging.
+type VerifyResult struct {
+	OTVID             *OTVID
+	SignatureVerified bool
+	Reason            ReasonCode
+	KeyID             string        // the 'kid' header of the OTVID
+	Algorithm         string        // the 'alg' header of the OTVID
+	Latency           time.Duration // the time spent on decoding and verification
+	// RevocationChecked is true if the OT-Auth service was consulted about the revocation of the OTVID.
+	// A Verifier verifies locally and never consults it, OTVID.MaybeRevoked reports whether it should be.
+	RevocationChecked bool
+}
+
+func newVerifyResult(d *decodedOTVID, verified bool, reason ReasonCode, start time.Time) *VerifyResult {
+	return &VerifyResult{
+		OTVID:             d.vid,
+		SignatureVerified: verified,
+		Reason:            reason,
+		KeyID:             d.header.Kid,
+		Algorithm:         d.header.Alg,
+		Latency:           time.Since(start),
+	}
+}
+
+// Verifier verifies OTVIDs issued by the audience's trust domain locally.
+// The trust domain's public keys are fetched from its configuration and refreshed in background.
+type Verifier struct {
+	aud         OTID
+	auds        OTIDs // other accepted audiences
+	td          TrustDomain
+	cli         HTTPClient
+	mu          sync.RWMutex
+	ks          *JWKSet
+	doc         *domainConfigProxy // the trust domain's configuration of ks
+	issuers     map[string][]string
+	delegated   OTIDs
+	spiffeSub   bool
+	roots       *x509.CertPool
+	x5c         bool   // see AcceptX5C
+	dynamic     bool   // keys are fetched from the trust domain's configuration
+	cacheFile   string // persisted trust domain's configuration
+	mode        int32
+	rate        float64
+	sensitive   OTIDs
+	limits      *Limits
+	replay      ReplayChecker
+	kp          KeyProvider // the source of the keys instead of the trust domain's configuration
+	log         Logger
+	in          Instrumenter
+	laxUsage    bool
+	rawClaims   bool // see SetRawClaims
+	leeway      time.Duration
+	iat         *issuedAtCheck // see SetIssuedAtCheck
+	interval    time.Duration  // the fixed keys refresh interval, see WithAutoRefresh
+	revocation  RevocationChecker
+	pins        *keyPins
+	mirrors     []string    // see SetMirrors
+	configURLs  *ConfigURLs // see WithConfigURLs
+	configRoots *JWKSet     // see SetConfigRootKeys
+	// bundleExpiry is the expiry of the imported trust bundle, zero if the keys are not from a bundle
+	bundleExpiry time.Time
+	urlHealth    urlHealth  // the failures of the configuration URL and the mirrors
+	history      keyHistory // see SetKeyHistory
+	refreshedAt  time.Time  // see LastRefreshed
+	nextRefresh  time.Time  // see NextRefresh
+	// see OnKeysChanged
+	onKeysChanged KeysChangedFunc
+	// see SetBindingRequired
+	bindingRequired bool
+	// see AddClaimsValidator
+	validators []ClaimsValidator
+	// the background refresh of the keys, see Shutdown
+	stop context.CancelFunc
+	done chan struct{}
+	// subject policy, see RequireSubjectType
+	subjectTypes []string
+	forbidDomain bool
+	serviceTypes []string
+	userTypes    []string
+}
+
+// NewVerifier creates a Verifier for the audience. If keys are given, they are used as the trust domain's
+// public keys persistently, otherwise the keys are fetched with cli (DefaultHTTPClient if nil)
+// and refreshed until ctx is done.
+// It is a shorthand of NewVerifierWithOptions with WithHTTPClient and WithKeys.
+func NewVerifier(ctx context.Context, aud OTID, cli HTTPClient, keys ...Key) (*Verifier, error) {
+	return NewVerifierWithOptions(ctx, aud, WithHTTPClient(cli), WithKeys(keys...))
+}
+
+// NewCachedVerifier creates a Verifier like NewVerifier, the fetched trust domain's configuration is
+// persisted to the cacheFile and loaded at startup if the trust domain's configuration can not be fetched,
+// so that OTVIDs can be verified immediately after restart even if OT-Auth is briefly unreachable.
+func NewCachedVerifier(ctx context.Context, aud OTID, cli HTTPClient, cacheFile string) (*Verifier, error) {
+	if err := aud.Validate(); err != nil {
+		return nil, fmt.Errorf("otgo.NewCachedVerifier: invalid audience OTID: %s", err.Error())
+	}
+	if cli == nil {
+		cli = DefaultHTTPClient
+	}
+	v := &Verifier{aud: aud, td: aud.TrustDomain(), cli: cli, dynamic: true, cacheFile: cacheFile}
+	interval, err := v.fetchKeys(ctx)
+	if err != nil {
+		res, e := loadDomainConfig(cacheFile, v.td, 0, nil)
+		if e != nil {
+			return nil, err
+		}
+		v.setDoc(res)
+		interval = time.Minute // retry soon
+	}
+	v.startRefresh(ctx, interval)
+	return v, nil
+}
+
+// RefreshKeys fetches the trust domain's public keys on demand, e.g. after a key-rotation incident.
+func (v *Verifier) RefreshKeys(ctx context.Context) error {
+	if v.kp != nil {
+		return v.kp.Refresh(ctx)
+	}
+	if !v.dynamic {
+		return errors.New("otgo.Verifier.RefreshKeys: the verifier uses static keys")
+	}
+	_, err := v.fetchKeys(ctx)
+	return err
+}
+
+func (v *Verifier) fetchKeys(ctx context.Context) (_ time.Duration, err error) {
+	ctx, end := v.instrumenter().Start(ctx, OpKeyRefresh)
+	defer func() { end(err) }()
+	res, err := v.fetchDomainConfigFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+	v.mu.Lock()
+	if v.configRoots != nil {
+		if err = res.checkRollback(v.doc); err != nil {
+			v.mu.Unlock()
+			return 0, err
+		}
+	}
+	old, fn := v.ks, v.onKeysChanged
+	changed := old != nil && keysChanged(old, &res.ks)
+	if changed {
+		v.history.push(old, clockNow())
+	}
+	v.setDoc(res)
+	v.refreshedAt = clockNow()
+	v.mu.Unlock()
+	if fn != nil && changed {
+		fn(old, &res.ks)
+	}
+	if v.cacheFile != "" {
+		saveDomainConfig(v.cacheFile, res) // best effort
+	}
+	return res.refreshInterval(), nil
+}
+
+func (v *Verifier) setDoc(res *domainConfigProxy) {
+	v.doc = res
+	v.bundleExpiry = time.Time{}
+	v.ks = &res.ks
+	v.issuers = res.Issuers
+	v.serviceTypes = res.ServiceTypes
+	v.userTypes = res.UserTypes
+}
+
+// refreshKeys refreshes the keys with the keysRefreshHint from the trust domain's configuration.
+// The interval is jittered to avoid thundering-herd fetches across a fleet.
+func (v *Verifier) refreshKeys(ctx context.Context, interval time.Duration) {
+	timer := time.NewTimer(v.scheduleRefresh(jitter(interval)))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			v.mu.Lock()
+			v.nextRefresh = time.Time{}
+			v.mu.Unlock()
+			return
+		case <-timer.C:
+			next, err := v.fetchKeys(ctx)
+			switch {
+			case err != nil:
+				v.logger().Error("otgo: refresh keys failed", "trustDomain", v.td, "error", err)
+				next = interval
+				if next > time.Minute {
+					next = time.Minute // retry sooner on failure
+				}
+			case v.interval > 0:
+				next = v.interval
+			default:
+				interval = next
+			}
+			timer.Reset(v.scheduleRefresh(jitter(next)))
+		}
+	}
+}
+
+// SetLogger sets the logger of the background key refresh failures.
+func (v *Verifier) SetLogger(l Logger) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.log = l
+}
+
+func (v *Verifier) logger() Logger {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return loggerOf(v.log)
+}
+
+// SetInstrumenter sets the Instrumenter of the verifications and keys refreshes,
+// the Instrumenter of the HTTPClient is used by default if it is an otgo.Client.
+func (v *Verifier) SetInstrumenter(in Instrumenter) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.in = in
+}
+
+func (v *Verifier) instrumenter() Instrumenter {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.in != nil {
+		return v.in
+	}
+	return httpInstrumenter(v.cli)
+}
+
+// observe starts a OpParse span, the returned function ends it with the verification's error.
+func (v *Verifier) observe() func(error) {
+	_, end := v.instrumenter().Start(context.Background(), OpParse)
+	return end
+}
+
+// jitter returns a random duration in [d*0.9, d*1.1).
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	delta := int64(d) / 10
+	if delta == 0 {
+		return d
+	}
+	return d - time.Duration(delta) + time.Duration(rand.Int63n(2*delta))
+}
+
+// SetRoots sets the root CAs to verify OTVIDs that carry a x5c certificate chain if AcceptX5C is enabled,
+// OTVIDs without x5c header are still verified with the trust domain's public keys.
+func (v *Verifier) SetRoots(roots *x509.CertPool) {
+	v.mu.Lock()
+	v.roots = roots
+	v.mu.Unlock()
+}
+
+// AcceptX5C verifies the OTVIDs that carry a x5c certificate chain with the root CAs of SetRoots
+// instead of the trust domain's public keys if accept is true, it is disabled by default.
+// The certificate should be issued to the trust domain, see ParseOTVIDWithX5C.
+func (v *Verifier) AcceptX5C(accept bool) {
+	v.mu.Lock()
+	v.x5c = accept
+	v.mu.Unlock()
+}
+
+// SetDelegatedIssuers accepts OTVIDs issued by the delegated sub-issuers of the trust domain,
+// e.g. otid:example.com:issuer:eu. A delegated issuer's OTVIDs should be signed by the keys
+// mapped to it in the trust domain's configuration.
+func (v *Verifier) SetDelegatedIssuers(issuers ...OTID) {
+	v.mu.Lock()
+	v.delegated = issuers
+	v.mu.Unlock()
+}
+
+// AcceptSPIFFESubject accepts OTVIDs whose 'sub' claim is a SPIFFE ID that can be mapped to a OTID,
+// e.g. spiffe://example.org/svc/backend.
+func (v *Verifier) AcceptSPIFFESubject(accept bool) {
+	v.mu.Lock()
+	v.spiffeSub = accept
+	v.mu.Unlock()
+}
+
+// SetReplayChecker rejects the replayed OTVIDs that carry 'jti' claim with the ReplayChecker.
+// It should be used for audiences that receive one-time OTVIDs, e.g. self-signed OTVIDs,
+// OTVIDs without 'jti' claim and the sampled-out OTVIDs in VerifyDegraded mode are not checked.
+func (v *Verifier) SetReplayChecker(rc ReplayChecker) {
+	v.mu.Lock()
+	v.replay = rc
+	v.mu.Unlock()
+}
+
+// SetLaxKeyUsage accepts the keys marked for encryption or without the "verify" key_ops if lax is true,
+// for legacy key sets. They are rejected by default.
+func (v *Verifier) SetLaxKeyUsage(lax bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.laxUsage = lax
+}
+
+// SetRawClaims preserves the raw claims of the parsed OTVIDs and decodes their numbers as json.Number
+// if preserve is true, see OTVID.RawClaims.
+func (v *Verifier) SetRawClaims(preserve bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rawClaims = preserve
+}
+
+// SetRevocationChecker sets the RevocationChecker to reject revoked OTVIDs, nil to disable.
+func (v *Verifier) SetRevocationChecker(rc RevocationChecker) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.revocation = rc
+}
+
+// SetLimits sets the size limits of OTVIDs and OTIDs instead of DefaultLimits.
+func (v *Verifier) SetLimits(l Limits) {
+	v.mu.Lock()
+	v.limits = &l
+	v.mu.Unlock()
+}
+
+// Limits returns the current size limits of OTVIDs and OTIDs.
+func (v *Verifier) Limits() Limits {
+	v.mu.RLock()
+	l := v.limits
+	v.mu.RUnlock()
+	return Limits{OTVIDMaxSize: l.otvidMaxSize(), OTIDMaxSize: l.otidMaxSize(), ClaimsMaxSize: l.claimsMaxSize()}
+}
+
+// SetMode switches the verify mode at runtime. sampleRate in [0, 1] is the fraction of OTVIDs
+// whose signature is verified in VerifyDegraded mode, sensitive audiences are always fully verified.
+func (v *Verifier) SetMode(mode VerifyMode, sampleRate float64, sensitive ...OTID) {
+	v.mu.Lock()
+	v.rate = sampleRate
+	v.sensitive = sensitive
+	v.mu.Unlock()
+	atomic.StoreInt32(&v.mode, int32(mode))
+}
+
+// Mode returns the current verify mode.
+func (v *Verifier) Mode() VerifyMode {
+	return VerifyMode(atomic.LoadInt32(&v.mode))
+}
+
+// SetAudiences accepts OTVIDs addressed to any of the audiences besides the Verifier's audience,
+// e.g. a gateway service that receives OTVIDs for otid:example.com:svc:gw and otid:example.com.
+func (v *Verifier) SetAudiences(auds ...OTID) error {
+	for _, aud := range auds {
+		if err := aud.Validate(); err != nil {
+			return fmt.Errorf("otgo.Verifier.SetAudiences: invalid audience OTID: %s", err.Error())
+		}
+	}
+	v.mu.Lock()
+	v.auds = auds
+	v.mu.Unlock()
+	return nil
+}
+
+// audience returns the one of the accepted audiences that the OTVID is addressed to,
+// the accepted audiences are auds if given, otherwise the Verifier's audiences.
+func (v *Verifier) audience(d *decodedOTVID, auds []OTID) OTID {
+	if len(auds) == 0 {
+		v.mu.RLock()
+		auds = append(OTIDs{v.aud}, v.auds...)
+		v.mu.RUnlock()
+	}
+	if len(auds) > 1 && OTIDs(auds).Has(d.vid.Audience) {
+		return d.vid.Audience
+	}
+	return auds[0]
+}
+
+// decode decodes the token once for the whole verification.
+func (v *Verifier) decode(token string) (*decodedOTVID, error) {
+	v.mu.RLock()
+	spiffeSub, limits, rawClaims := v.spiffeSub, v.limits, v.rawClaims
+	v.mu.RUnlock()
+	return decodeOTVID(token, spiffeSub, limits, rawClaims)
+}
+
+// ParseOTVID parses and fully verifies a OTVID for any of the audiences (the Verifier's audiences by default).
+func (v *Verifier) ParseOTVID(token string, auds ...OTID) (_ *OTVID, err error) {
+	end := v.observe()
+	defer func() { end(err) }()
+	d, err := v.decode(token)
+	if err != nil {
+		return nil, err
+	}
+	return v.parse(d, v.audience(d, auds))
+}
+
+// ParseOTVIDBatch parses and fully verifies many OTVIDs concurrently for the Verifier's audiences,
+// with a bounded worker pool and a snapshot of the keys. The errs[i] is the error of tokens[i].
+func (v *Verifier) ParseOTVIDBatch(tokens []string) (vids []*OTVID, errs []error) {
+	vids = make([]*OTVID, len(tokens))
+	errs = make([]error, len(tokens))
+	s := v.snapshot()
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(tokens) {
+		workers = len(tokens)
+	}
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(tokens) {
+					return
+				}
+				end := v.observe()
+				d, err := v.decode(tokens[i])
+				if err == nil {
+					vids[i], err = s.parse(v.td, d, v.audience(d, nil))
+				}
+				end(err)
+				errs[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+	return vids, errs
+}
+
+// verifierKeys is a snapshot of the Verifier's keys.
+type verifierKeys struct {
+	ks        *JWKSet
+	roots     *x509.CertPool // nil if AcceptX5C is disabled
+	issuers   map[string][]string
+	delegated OTIDs
+	replay    ReplayChecker
+	policy    *subjectPolicy
+	laxUsage  bool
+	leeway    time.Duration
+	iat       *issuedAtCheck
+	revoked   RevocationChecker
+	pins      *keyPins      // nil if no key is pinned
+	history   []retiredKeys // the retained previous key sets, see SetKeyHistory
+	err       error         // the KeyProvider's error
+	// see SetBindingRequired
+	bindingRequired bool
+	validators      []ClaimsValidator
+}
+
+func (v *Verifier) snapshot() *verifierKeys {
+	v.mu.RLock()
+	s := &verifierKeys{ks: v.ks, issuers: v.issuers, delegated: v.delegated, replay: v.replay, policy: v.policy(),
+		laxUsage: v.laxUsage, leeway: v.leeway, iat: v.iat, revoked: v.revocation, bindingRequired: v.bindingRequired, validators: v.validators}
+	if v.x5c {
+		s.roots = v.roots
+	}
+	if v.kp == nil {
+		now := clockNow()
+		s.history = v.history.active(now)
+		if !v.bundleExpiry.IsZero() && !now.Before(v.bundleExpiry) {
+			s.err = fmt.Errorf("otgo.Verifier: the trust bundle expired at %s", v.bundleExpiry.UTC().Format(time.RFC3339))
+		}
+	}
+	s.pins = v.pins
+	v.mu.RUnlock()
+	if v.kp != nil {
+		s.ks, s.err = v.kp.VerificationKeys()
+	}
+	return s
+}
+
+func (v *Verifier) parse(d *decodedOTVID, aud OTID) (*OTVID, error) {
+	return v.snapshot().parse(v.td, d, aud)
+}
+
+func (s *verifierKeys) parse(td TrustDomain, d *decodedOTVID, aud OTID) (vid *OTVID, err error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	d.laxKeyUsage, d.leeway, d.iat = s.laxUsage, s.leeway, s.iat
+	if s.roots != nil && len(d.header.X5C) > 0 {
+		vid, err = d.parseX5C(s.roots, td.OTID(), aud)
+	} else {
+		vid, err = d.parseDelegated(s.ks, td, s.issuers, s.delegated, aud)
+		if err != nil && len(s.history) > 0 {
+			vid, err = s.parseHistorical(td, d, aud, err)
+		}
+	}
+	if err == nil && s.pins != nil {
+		err = s.checkPinned(d)
+	}
+	if err == nil {
+		err = s.policy.check(vid.ID)
+	}
+	if err == nil {
+		err = checkBinding(s.bindingRequired, d.binding, vid)
+	}
+	if err == nil {
+		err = validateClaims(s.validators, vid)
+	}
+	if err == nil {
+		err = checkRevoked(s.revoked, vid)
+	}
+	if err == nil {
+		err = checkReplay(s.replay, vid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return vid, nil
+}
+
+func checkReplay(rc ReplayChecker, vid *OTVID) error {
+	if rc == nil || vid.JTI == "" {
+		return nil
+	}
+	return rc.Check(vid.Issuer, vid.JTI, vid.Expiry)
+}
+
+// VerifyToken parses and fully verifies a OTVID like ParseOTVID regardless of the verify mode,
+// the result carries the metadata of the verification for audit logging.
+func (v *Verifier) VerifyToken(token string, auds ...OTID) (_ *VerifyResult, err error) {
+	end := v.observe()
+	defer func() { end(err) }()
+	start := time.Now()
+	d, err := v.decode(token)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = v.parse(d, v.audience(d, auds)); err != nil {
+		return nil, err
+	}
+	return newVerifyResult(d, true, ReasonVerified, start), nil
+}
+
+// Verify verifies a OTVID according to the verify mode, the result explains whether the signature was verified.
+func (v *Verifier) Verify(token string, auds ...OTID) (_ *VerifyResult, err error) {
+	end := v.observe()
+	defer func() { end(err) }()
+	start := time.Now()
+	d, err := v.decode(token)
+	if err != nil {
+		return nil, err
+	}
+	aud := v.audience(d, auds)
+
+	reason := ReasonVerified
+	if v.Mode() == VerifyDegraded {
+		v.mu.RLock()
+		rate, sensitive := v.rate, v.sensitive
+		v.mu.RUnlock()
+		switch {
+		case sensitive.Has(aud):
+			reason = ReasonSensitive
+		case rate > 0 && rand.Float64() < rate:
+			reason = ReasonSampled
+		default:
+			reason = ReasonSampledOut
+		}
+	}
+
+	if reason != ReasonSampledOut {
+		if _, err = v.parse(d, aud); err != nil {
+			return nil, err
+		}
+		return newVerifyResult(d, true, reason, start), nil
+	}
+
+	vid := d.vid
+	issuer := v.td.OTID()
+	v.mu.RLock()
+	if v.delegated.Has(vid.Issuer) && vid.Issuer.MemberOf(v.td) {
+		issuer = vid.Issuer
+	}
+	policy, revoked, leeway, iat, bindingRequired := v.policy(), v.revocation, v.leeway, v.iat, v.bindingRequired
+	validators := v.validators
+	v.mu.RUnlock()
+	if err = vid.verifyClaims(issuer, aud, leeway, iat); err != nil {
+		return nil, err
+	}
+	if err = policy.check(vid.ID); err != nil {
+		return nil, err
+	}
+	if err = checkBinding(bindingRequired, d.binding, vid); err != nil {
+		return nil, err
+	}
+	if err = validateClaims(validators, vid); err != nil {
+		return nil, err
+	}
+	if err = checkRevoked(revoked, vid); err != nil {
+		return nil, err
+	}
+	// the replay check is skipped, a forged OTVID's jti must not be remembered
+	return newVerifyResult(d, false, reason, start), nil
+}
@@ -0,0 +1,253 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifier(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	pk := otgo.MustPrivateKey("ES256")
+	signToken := func(key otgo.Key, aud otgo.OTID) string {
+		vid := &otgo.OTVID{}
+		vid.ID = td.NewOTID("user", "abc")
+		vid.Issuer = td.OTID()
+		vid.Audience = aud
+		vid.Expiry = time.Now().Add(time.Hour)
+		token, err := vid.Sign(key)
+		if err != nil {
+			panic(err)
+		}
+		return token
+	}
+
+	t.Run("NewVerifier func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pub, err := otgo.ToPublicKey(pk)
+		assert.Nil(err)
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := json.Marshal(map[string]interface{}{
+				"otid": td.OTID(),
+				"keys": []otgo.Key{pub},
+			})
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(200)
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		_, err = otgo.NewVerifier(context.Background(), otgo.OTID{}, nil, pk)
+		assert.NotNil(err)
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		aud := td.NewOTID("app", "123")
+		v, err := otgo.NewVerifier(ctx, aud, cli)
+		assert.Nil(err)
+
+		vid, err := v.ParseOTVID(signToken(pk, aud))
+		assert.Nil(err)
+		assert.Equal("otid:localhost:user:abc", vid.ID.String())
+
+		_, err = v.ParseOTVID(signToken(pk, td.NewOTID("app", "456")))
+		assert.NotNil(err)
+		_, err = v.ParseOTVID(signToken(otgo.MustPrivateKey("ES256"), aud))
+		assert.NotNil(err)
+	})
+
+	t.Run("Verifier.RefreshKeys method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pk2 := otgo.MustPrivateKey("ES256")
+		var mu sync.Mutex
+		current := pk
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			pub, _ := otgo.ToPublicKey(current)
+			mu.Unlock()
+			b, _ := json.Marshal(map[string]interface{}{
+				"otid":            td.OTID(),
+				"keys":            []otgo.Key{pub},
+				"keysRefreshHint": 3600,
+			})
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(200)
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		aud := td.NewOTID("app", "123")
+		v, err := otgo.NewVerifier(ctx, aud, cli)
+		assert.Nil(err)
+
+		_, err = v.ParseOTVID(signToken(pk2, aud))
+		assert.NotNil(err)
+
+		mu.Lock()
+		current = pk2
+		mu.Unlock()
+		assert.Nil(v.RefreshKeys(context.Background()))
+		_, err = v.ParseOTVID(signToken(pk2, aud))
+		assert.Nil(err)
+
+		v, err = otgo.NewVerifier(ctx, aud, cli, pk)
+		assert.Nil(err)
+		assert.NotNil(v.RefreshKeys(context.Background()))
+	})
+
+	t.Run("Verifier.SetAudiences method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		aud := td.NewOTID("svc", "gw")
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, pk)
+		assert.Nil(err)
+
+		_, err = v.ParseOTVID(signToken(pk, aud))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(signToken(pk, td.OTID()))
+		assert.NotNil(err)
+
+		assert.NotNil(v.SetAudiences(otgo.OTID{}))
+		assert.Nil(v.SetAudiences(td.OTID()))
+		vid, err := v.ParseOTVID(signToken(pk, td.OTID()))
+		assert.Nil(err)
+		assert.True(vid.Audience.Equal(td.OTID()))
+		_, err = v.ParseOTVID(signToken(pk, aud))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(signToken(pk, td.NewOTID("svc", "other")))
+		assert.NotNil(err)
+
+		res, err := v.Verify(signToken(pk, td.OTID()))
+		assert.Nil(err)
+		assert.True(res.SignatureVerified)
+
+		// explicit audiences override the Verifier's audiences
+		other := td.NewOTID("svc", "other")
+		_, err = v.ParseOTVID(signToken(pk, other), aud, other)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(signToken(pk, td.OTID()), aud, other)
+		assert.NotNil(err)
+	})
+
+	t.Run("Verifier.ParseOTVIDBatch method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		aud := td.NewOTID("app", "123")
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, pk)
+		assert.Nil(err)
+
+		vids, errs := v.ParseOTVIDBatch(nil)
+		assert.Equal(0, len(vids))
+		assert.Equal(0, len(errs))
+
+		tokens := make([]string, 100)
+		for i := range tokens {
+			switch i % 3 {
+			case 0:
+				tokens[i] = signToken(pk, aud)
+			case 1:
+				tokens[i] = signToken(otgo.MustPrivateKey("ES256"), aud)
+			default:
+				tokens[i] = "invalid"
+			}
+		}
+		vids, errs = v.ParseOTVIDBatch(tokens)
+		assert.Equal(len(tokens), len(vids))
+		assert.Equal(len(tokens), len(errs))
+		for i := range tokens {
+			if i%3 == 0 {
+				assert.Nil(errs[i])
+				assert.Equal(tokens[i], vids[i].Token())
+			} else {
+				assert.NotNil(errs[i])
+				assert.Nil(vids[i])
+			}
+		}
+	})
+
+	t.Run("Verifier.Verify method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		aud := td.NewOTID("app", "123")
+		sensitive := td.NewOTID("app", "admin")
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, pk)
+		assert.Nil(err)
+		assert.Equal(otgo.VerifyFull, v.Mode())
+
+		forged := signToken(otgo.MustPrivateKey("ES256"), aud)
+		res, err := v.Verify(signToken(pk, aud))
+		assert.Nil(err)
+		assert.True(res.SignatureVerified)
+		assert.Equal(otgo.ReasonVerified, res.Reason)
+		_, err = v.Verify(forged)
+		assert.NotNil(err)
+
+		v.SetMode(otgo.VerifyDegraded, 0, sensitive)
+		assert.Equal(otgo.VerifyDegraded, v.Mode())
+		res, err = v.Verify(forged)
+		assert.Nil(err)
+		assert.False(res.SignatureVerified)
+		assert.Equal(otgo.ReasonSampledOut, res.Reason)
+
+		_, err = v.Verify(signToken(pk, td.NewOTID("app", "456")))
+		assert.NotNil(err)
+
+		res, err = v.Verify(signToken(pk, sensitive), sensitive)
+		assert.Nil(err)
+		assert.True(res.SignatureVerified)
+		assert.Equal(otgo.ReasonSensitive, res.Reason)
+		_, err = v.Verify(signToken(otgo.MustPrivateKey("ES256"), sensitive), sensitive)
+		assert.NotNil(err)
+
+		v.SetMode(otgo.VerifyDegraded, 1)
+		res, err = v.Verify(signToken(pk, aud))
+		assert.Nil(err)
+		assert.True(res.SignatureVerified)
+		assert.Equal(otgo.ReasonSampled, res.Reason)
+		_, err = v.Verify(forged)
+		assert.NotNil(err)
+	})
+
+	t.Run("Verifier.VerifyToken method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		aud := td.NewOTID("app", "123")
+		v, err := otgo.NewVerifier(context.Background(), aud, nil, pk)
+		assert.Nil(err)
+		v.SetMode(otgo.VerifyDegraded, 0)
+
+		res, err := v.VerifyToken(signToken(pk, aud))
+		assert.Nil(err)
+		assert.True(res.SignatureVerified)
+		assert.Equal(otgo.ReasonVerified, res.Reason)
+		assert.Equal(pk.KeyID(), res.KeyID)
+		assert.Equal("ES256", res.Algorithm)
+		assert.True(res.Latency > 0)
+		assert.False(res.RevocationChecked)
+		assert.True(res.OTVID.Audience.Equal(aud))
+
+		// always fully verified regardless of the verify mode
+		_, err = v.VerifyToken(signToken(otgo.MustPrivateKey("ES256"), aud))
+		assert.NotNil(err)
+
+		res, err = v.Verify(signToken(otgo.MustPrivateKey("ES256"), aud))
+		assert.Nil(err)
+		assert.False(res.SignatureVerified)
+		assert.Equal("ES256", res.Algorithm)
+	})
+}
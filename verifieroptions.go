@@ -0,0 +1,172 @@
+package otgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRevoked is returned by RevocationChecker if the OTVID was revoked.
+var ErrRevoked = errors.New("otgo: OTVID revoked")
+
+// RevocationChecker checks whether a OTVID was revoked, e.g. by its release ID against a revocation list.
+// It is consulted by Verifier for OTVIDs that may be revoked, see OTVID.MaybeRevoked.
+type RevocationChecker interface {
+	// CheckRevoked returns ErrRevoked if the OTVID was revoked.
+	CheckRevoked(vid *OTVID) error
+}
+
+func checkRevoked(rc RevocationChecker, vid *OTVID) error {
+	if rc == nil || !vid.MaybeRevoked() {
+		return nil
+	}
+	return rc.CheckRevoked(vid)
+}
+
+// VerifierOption ...
+type VerifierOption func(*verifierOptions)
+
+type verifierOptions struct {
+	keys        []Key
+	refresh     time.Duration
+	cli         HTTPClient
+	issuers     OTIDs
+	leeway      time.Duration
+	revocation  RevocationChecker
+	in          Instrumenter
+	mirrors     []string
+	configURLs  *ConfigURLs
+	configRoots *JWKSet
+	iat         *issuedAtCheck
+	rawClaims   bool
+	// see WithBindingRequired
+	bindingRequired bool
+	validators      []ClaimsValidator
+	// see WithKeyHistory
+	historySize      int
+	historyRetention time.Duration
+	// see WithTrustBundle and WithSignedTrustBundle
+	bundle       *TrustBundle
+	signedBundle []byte
+}
+
+// WithKeys uses the keys as the trust domain's public keys persistently instead of fetching them.
+func WithKeys(keys ...Key) VerifierOption {
+	return func(o *verifierOptions) {
+		o.keys = keys
+	}
+}
+
+// WithAutoRefresh refreshes the fetched keys every interval instead of the keysRefreshHint
+// of the trust domain's configuration. The keys are not refreshed in background if interval is negative.
+func WithAutoRefresh(interval time.Duration) VerifierOption {
+	return func(o *verifierOptions) {
+		o.refresh = interval
+	}
+}
+
+// WithHTTPClient fetches the trust domain's configuration with cli instead of DefaultHTTPClient.
+func WithHTTPClient(cli HTTPClient) VerifierOption {
+	return func(o *verifierOptions) {
+		o.cli = cli
+	}
+}
+
+// WithConfigURLs fetches the trust domain's configuration from its URL in urls, e.g. behind a gateway
+// path prefix, instead of the standard well-known URL.
+func WithConfigURLs(urls *ConfigURLs) VerifierOption {
+	return func(o *verifierOptions) {
+		o.configURLs = urls
+	}
+}
+
+// WithInstrumenter reports the spans of the verifications and keys refreshes to in, see Instrumenter.
+func WithInstrumenter(in Instrumenter) VerifierOption {
+	return func(o *verifierOptions) {
+		o.in = in
+	}
+}
+
+// WithIssuer accepts the trust domain's delegated issuers besides the trust domain, see SetDelegatedIssuers.
+func WithIssuer(issuers ...OTID) VerifierOption {
+	return func(o *verifierOptions) {
+		o.issuers = append(o.issuers, issuers...)
+	}
+}
+
+// WithLeeway tolerates the clock skew between the issuer and the Verifier when checking the expiration time.
+func WithLeeway(leeway time.Duration) VerifierOption {
+	return func(o *verifierOptions) {
+		o.leeway = leeway
+	}
+}
+
+// WithRawClaims preserves the raw claims of the parsed OTVIDs, see SetRawClaims.
+func WithRawClaims() VerifierOption {
+	return func(o *verifierOptions) {
+		o.rawClaims = true
+	}
+}
+
+// WithRevocationChecker rejects the revoked OTVIDs with rc.
+func WithRevocationChecker(rc RevocationChecker) VerifierOption {
+	return func(o *verifierOptions) {
+		o.revocation = rc
+	}
+}
+
+// NewVerifierWithOptions creates a Verifier for the audience with the options. The keys are fetched from
+// the trust domain's configuration and refreshed until ctx is done or Shutdown, unless WithKeys or WithTrustBundle is given.
+func NewVerifierWithOptions(ctx context.Context, aud OTID, opts ...VerifierOption) (*Verifier, error) {
+	if err := aud.Validate(); err != nil {
+		return nil, fmt.Errorf("otgo.NewVerifier: invalid audience OTID: %s", err.Error())
+	}
+	o := &verifierOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.cli == nil {
+		o.cli = DefaultHTTPClient
+	}
+	if o.leeway < 0 {
+		return nil, errors.New("otgo.NewVerifier: negative leeway")
+	}
+
+	v := &Verifier{aud: aud, td: aud.TrustDomain(), cli: o.cli, delegated: o.issuers,
+		leeway: o.leeway, iat: o.iat, rawClaims: o.rawClaims, bindingRequired: o.bindingRequired, validators: o.validators, revocation: o.revocation, in: o.in, mirrors: o.mirrors, configURLs: o.configURLs, configRoots: o.configRoots}
+	v.history.size, v.history.retention = o.historySize, o.historyRetention
+	if o.bundle != nil || o.signedBundle != nil {
+		var err error
+		if o.signedBundle != nil {
+			err = v.ImportSignedTrustBundle(o.signedBundle)
+		} else {
+			err = v.ImportTrustBundle(o.bundle)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("otgo.NewVerifier: %s", err.Error())
+		}
+		return v, nil
+	}
+	if len(o.keys) > 0 {
+		ks, err := NewKeys(o.keys...)
+		if err != nil {
+			return nil, err
+		}
+		v.ks = LookupPublicKeys(ks)
+		return v, nil
+	}
+
+	interval, err := v.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v.dynamic = true
+	if o.refresh >= 0 {
+		if o.refresh > 0 {
+			v.interval, interval = o.refresh, o.refresh
+		}
+		v.startRefresh(ctx, interval)
+	}
+	return v, nil
+}
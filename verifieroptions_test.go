@@ -0,0 +1,128 @@
+package otgo_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRevocationChecker struct {
+	revoked string
+}
+
+func (rc *testRevocationChecker) CheckRevoked(vid *otgo.OTVID) error {
+	if vid.ReleaseID == rc.revoked {
+		return otgo.ErrRevoked
+	}
+	return nil
+}
+
+func TestVerifierOptions(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	aud := td.NewOTID("svc", "abc")
+
+	t.Run("WithKeys & WithLeeway", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud,
+			Expiry: time.Now().Add(-2 * time.Second)}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+
+		v, err = otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key), otgo.WithLeeway(5*time.Second))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		res, err := v.Verify(token)
+		assert.Nil(err)
+		assert.True(res.SignatureVerified)
+
+		_, err = otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithLeeway(-time.Second))
+		assert.NotNil(err)
+	})
+
+	t.Run("WithRevocationChecker", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: td.OTID(), Audience: aud,
+			Expiry: time.Now().Add(time.Hour), ReleaseID: "r1"}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		vid.ReleaseID = "r2"
+		token2, err := vid.Sign(key)
+		assert.Nil(err)
+
+		v, err := otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithKeys(key),
+			otgo.WithRevocationChecker(&testRevocationChecker{revoked: "r1"}))
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.True(errors.Is(err, otgo.ErrRevoked))
+		_, err = v.ParseOTVID(token2)
+		assert.Nil(err)
+
+		v.SetRevocationChecker(nil)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+	})
+
+	t.Run("WithAutoRefresh & WithIssuer & WithHTTPClient", func(t *testing.T) {
+		assert := assert.New(t)
+
+		key := otgo.MustPrivateKey("ES256")
+		pub, _ := otgo.ToPublicKey(key)
+		iss := td.NewOTID("svc", "issuer")
+		var fetches int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&fetches, 1)
+			b, _ := json.Marshal(map[string]interface{}{
+				"otid":            td.OTID(),
+				"keys":            []otgo.Key{pub},
+				"issuers":         map[string][]string{iss.String(): {pub.KeyID()}},
+				"keysRefreshHint": 3600,
+			})
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write(b)
+		}))
+		defer ts.Close()
+
+		cli := otgo.NewClient(nil)
+		cli.ConstraintEndpoint = ts.URL
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		v, err := otgo.NewVerifierWithOptions(ctx, aud, otgo.WithHTTPClient(cli),
+			otgo.WithAutoRefresh(50*time.Millisecond), otgo.WithIssuer(iss))
+		assert.Nil(err)
+		time.Sleep(180 * time.Millisecond)
+		assert.True(atomic.LoadInt32(&fetches) >= 3)
+
+		vid := &otgo.OTVID{ID: td.NewOTID("user", "abc"), Issuer: iss, Audience: aud}
+		token, err := vid.Sign(key)
+		assert.Nil(err)
+		vid, err = v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Equal(iss, vid.Issuer)
+
+		cancel()
+		time.Sleep(60 * time.Millisecond)
+		atomic.StoreInt32(&fetches, 0)
+		_, err = otgo.NewVerifierWithOptions(context.Background(), aud, otgo.WithHTTPClient(cli), otgo.WithAutoRefresh(-1))
+		assert.Nil(err)
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(int32(1), atomic.LoadInt32(&fetches))
+	})
+}
@@ -0,0 +1,58 @@
+package otgo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WarmUpError is returned by OTClient.WarmUp with the errors of the trust domains that failed to resolve.
+type WarmUpError struct {
+	Errors map[TrustDomain]error
+}
+
+// Error implements the error interface.
+func (e *WarmUpError) Error() string {
+	ss := make([]string, 0, len(e.Errors))
+	for td, err := range e.Errors {
+		ss = append(ss, fmt.Sprintf("%s: %s", td, err.Error()))
+	}
+	sort.Strings(ss)
+	return "otgo.OTClient.WarmUp: " + strings.Join(ss, "; ")
+}
+
+// WarmUp resolves the trust domains' configurations and keys concurrently, e.g. at startup,
+// so that the first requests are not delayed by discovery. The client's trust domain is resolved
+// if no domains are given. It returns a *WarmUpError if any trust domain failed to resolve.
+func (oc *OTClient) WarmUp(ctx context.Context, domains ...TrustDomain) error {
+	if len(domains) == 0 {
+		domains = []TrustDomain{oc.td}
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := make(map[TrustDomain]error)
+	for _, td := range domains {
+		if err := td.Validate(); err != nil {
+			mu.Lock()
+			errs[td] = err
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(dr *DomainResolver) {
+			defer wg.Done()
+			if _, err := dr.Resolve(ctx); err != nil {
+				mu.Lock()
+				errs[dr.td] = err
+				mu.Unlock()
+			}
+		}(oc.Domain(td))
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return &WarmUpError{Errors: errs}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package otgo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmUp(t *testing.T) {
+	t.Run("OTClient.WarmUp method", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td1 := otgo.TrustDomain("a.example.com")
+		td2 := otgo.TrustDomain("b.example.com")
+		var signs int32
+		ts1 := newTestOTAuth(td1, otgo.MustPrivateKey("ES256"), &signs)
+		defer ts1.Close()
+		ts2 := newTestOTAuth(td2, otgo.MustPrivateKey("ES256"), &signs)
+		defer ts2.Close()
+
+		cli := otgo.NewOTClient(context.Background(), td1.NewOTID("app", "123"))
+		cli.ConfigURLs = &otgo.ConfigURLs{}
+		cli.ConfigURLs.Set(td1, ts1.URL+"/.well-known/open-trust-configuration")
+		cli.ConfigURLs.Set(td2, ts2.URL+"/.well-known/open-trust-configuration")
+		cli.ConfigURLs.Set("c.example.com", ts2.URL+"/.well-known/open-trust-configuration")
+
+		assert.Nil(cli.WarmUp(context.Background()))
+		assert.Nil(cli.WarmUp(context.Background(), td1, td2))
+
+		err := cli.WarmUp(context.Background(), td1, "c.example.com", "")
+		assert.NotNil(err)
+		var we *otgo.WarmUpError
+		assert.True(errors.As(err, &we))
+		assert.Equal(2, len(we.Errors))
+		assert.NotNil(we.Errors["c.example.com"])
+		assert.NotNil(we.Errors[""])
+		assert.Nil(we.Errors[td1])
+	})
+
+	t.Run("the invalid and failed trust domains are collected concurrently", func(t *testing.T) {
+		assert := assert.New(t)
+
+		td := otgo.TrustDomain("a.example.com")
+		ts := newTestOTAuth(td, otgo.MustPrivateKey("ES256"), nil)
+		defer ts.Close()
+
+		cli := otgo.NewOTClient(context.Background(), td.NewOTID("app", "123"))
+		cli.ConfigURLs = &otgo.ConfigURLs{}
+		domains := make([]otgo.TrustDomain, 0, 20)
+		for i := 0; i < 10; i++ {
+			failed := otgo.TrustDomain(fmt.Sprintf("f%d.example.com", i))
+			cli.ConfigURLs.Set(failed, ts.URL+"/.well-known/open-trust-configuration")
+			domains = append(domains, failed, otgo.TrustDomain(fmt.Sprintf("I%d.example.com", i)))
+		}
+
+		err := cli.WarmUp(context.Background(), domains...)
+		var we *otgo.WarmUpError
+		assert.True(errors.As(err, &we))
+		assert.Equal(20, len(we.Errors))
+	})
+}
@@ -0,0 +1,134 @@
+package otgo
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jws"
+)
+
+// SignWithX5C signs the OTVID like Sign and attaches the X.509 certificate chain in the x5c header.
+// The first certificate should be the certificate of the signing key, the others are intermediates.
+func (o *OTVID) SignWithX5C(key Key, chain []*x509.Certificate) (string, error) {
+	if len(chain) == 0 {
+		return "", errors.New("otgo.OTVID.SignWithX5C: certificate chain required")
+	}
+	x5c := make([]string, len(chain))
+	for i, cert := range chain {
+		x5c[i] = base64.StdEncoding.EncodeToString(cert.Raw)
+	}
+	return o.sign(key, &signOptions{headers: map[string]interface{}{jws.X509CertChainKey: x5c}})
+}
+
+// HasX5C returns true if the token carries a x5c header.
+func HasX5C(token string) bool {
+	hdrs, err := protectedHeaders(token)
+	return err == nil && len(hdrs.X509CertChain()) > 0
+}
+
+// ParseOTVIDWithX5C parses a OTVID from a serialized JWT token.
+// The OTVID signature is verified using the certificate in the token's x5c header,
+// which should chain up to one of the roots, see verifyX5C for the requirements of the certificate.
+func ParseOTVIDWithX5C(token string, roots *x509.CertPool, issuer, audience OTID) (*OTVID, error) {
+	if roots == nil {
+		return nil, errors.New("otgo.ParseOTVIDWithX5C: root CAs required")
+	}
+	d, err := decodeOTVID(token, false, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	return d.parseX5C(roots, issuer, audience)
+}
+
+// parseX5C verifies the signature with the certificate in the x5c header and the claims.
+func (d *decodedOTVID) parseX5C(roots *x509.CertPool, issuer, audience OTID) (*OTVID, error) {
+	leaf, err := verifyX5C(d.header.X5C, roots, issuer, d.spiffeSub)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.verifyWith(leaf.PublicKey); err != nil {
+		return nil, err
+	}
+	if d.key, err = jwk.New(leaf.PublicKey); err != nil {
+		return nil, fmt.Errorf("otgo.verifyX5C: invalid public key: %s", err.Error())
+	}
+	if err = d.vid.verifyClaims(issuer, audience, d.leeway, d.iat); err != nil {
+		return nil, err
+	}
+	return d.vid, nil
+}
+
+func protectedHeaders(token string) (jws.Headers, error) {
+	msg, err := jws.ParseString(token)
+	if err != nil {
+		return nil, err
+	}
+	sigs := msg.Signatures()
+	if len(sigs) != 1 {
+		return nil, errors.New("otgo.protectedHeaders: invalid JWS signatures")
+	}
+	return sigs[0].ProtectedHeaders(), nil
+}
+
+// verifyX5C verifies the certificate chain in the x5c header and returns the leaf certificate.
+// The leaf certificate should be issued to the issuer: it should have a URI SAN equal to the issuer's OTID
+// (or its SPIFFE ID if spiffeSub is true), the digital signature key usage and the client authentication
+// extended key usage.
+func verifyX5C(x5c []string, roots *x509.CertPool, issuer OTID, spiffeSub bool) (*x509.Certificate, error) {
+	if len(x5c) == 0 {
+		return nil, errors.New("otgo.verifyX5C: x5c header required")
+	}
+	certs := make([]*x509.Certificate, len(x5c))
+	for i, s := range x5c {
+		der, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("otgo.verifyX5C: invalid certificate #%d: %s", i, err.Error())
+		}
+		if certs[i], err = x509.ParseCertificate(der); err != nil {
+			return nil, fmt.Errorf("otgo.verifyX5C: invalid certificate #%d: %s", i, err.Error())
+		}
+	}
+	leaf := certs[0]
+	if leaf.IsCA || leaf.KeyUsage&x509.KeyUsageDigitalSignature == 0 || !hasClientAuth(leaf) {
+		return nil, errors.New("otgo.verifyX5C: the certificate is not for digital signature and client authentication")
+	}
+	if !hasURISAN(leaf, issuer.String()) && !(spiffeSub && hasURISAN(leaf, issuer.ToSPIFFE())) {
+		return nil, fmt.Errorf("otgo.verifyX5C: the certificate is not issued to %s", issuer.String())
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("otgo.verifyX5C: %s", err.Error())
+	}
+	return leaf, nil
+}
+
+// hasClientAuth reports whether the certificate lists the client authentication extended key usage explicitly,
+// x509.Certificate.Verify accepts the certificates without extended key usages for any usage.
+func hasClientAuth(cert *x509.Certificate) bool {
+	for _, u := range cert.ExtKeyUsage {
+		if u == x509.ExtKeyUsageClientAuth {
+			return true
+		}
+	}
+	return false
+}
+
+func hasURISAN(cert *x509.Certificate, uri string) bool {
+	for _, u := range cert.URIs {
+		if u.String() == uri {
+			return true
+		}
+	}
+	return false
+}
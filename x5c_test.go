@@ -0,0 +1,180 @@
+package otgo_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/jwk"
+	otgo "github.com/open-trust/ot-go-lib"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCert(template, parent *x509.Certificate, pub, priv interface{}) *x509.Certificate {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, priv)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	return cert
+}
+
+func newTestCA() (*x509.Certificate, *ecdsa.PrivateKey) {
+	pk, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	return newTestCert(tpl, tpl, &pk.PublicKey, pk), pk
+}
+
+func newTestLeaf(ca *x509.Certificate, caKey *ecdsa.PrivateKey, san string, ekus ...x509.ExtKeyUsage) (*x509.Certificate, otgo.Key) {
+	pk, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  ekus,
+	}
+	if san != "" {
+		u, err := url.Parse(san)
+		if err != nil {
+			panic(err)
+		}
+		tpl.URIs = []*url.URL{u}
+	}
+	key, err := jwk.New(pk)
+	if err != nil {
+		panic(err)
+	}
+	key.Set("alg", "ES256")
+	jwk.AssignKeyID(key)
+	return newTestCert(tpl, ca, &pk.PublicKey, caKey), key
+}
+
+func TestX5C(t *testing.T) {
+	td := otgo.TrustDomain("localhost")
+	ca, caKey := newTestCA()
+	leaf, key := newTestLeaf(ca, caKey, "otid:localhost", x509.ExtKeyUsageClientAuth)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+
+	newVid := func() *otgo.OTVID {
+		vid := &otgo.OTVID{}
+		vid.ID = td.NewOTID("user", "abc")
+		vid.Issuer = td.OTID()
+		vid.Audience = td.NewOTID("app", "123")
+		vid.Expiry = time.Now().Add(time.Hour)
+		return vid
+	}
+
+	t.Run("OTVID.SignWithX5C & ParseOTVIDWithX5C func", func(t *testing.T) {
+		assert := assert.New(t)
+
+		_, err := newVid().SignWithX5C(key, nil)
+		assert.NotNil(err)
+
+		token, err := newVid().SignWithX5C(key, []*x509.Certificate{leaf})
+		assert.Nil(err)
+		assert.True(otgo.HasX5C(token))
+
+		vid, err := otgo.ParseOTVIDWithX5C(token, roots, td.OTID(), td.NewOTID("app", "123"))
+		assert.Nil(err)
+		assert.Equal("otid:localhost:user:abc", vid.ID.String())
+
+		_, err = otgo.ParseOTVIDWithX5C(token, nil, td.OTID(), td.NewOTID("app", "123"))
+		assert.NotNil(err)
+		_, err = otgo.ParseOTVIDWithX5C(token, roots, td.OTID(), td.NewOTID("app", "456"))
+		assert.NotNil(err)
+
+		otherCA, _ := newTestCA()
+		otherRoots := x509.NewCertPool()
+		otherRoots.AddCert(otherCA)
+		_, err = otgo.ParseOTVIDWithX5C(token, otherRoots, td.OTID(), td.NewOTID("app", "123"))
+		assert.NotNil(err)
+
+		// signed by a key that does not match the leaf certificate
+		token, err = newVid().SignWithX5C(otgo.MustPrivateKey("ES256"), []*x509.Certificate{leaf})
+		assert.Nil(err)
+		_, err = otgo.ParseOTVIDWithX5C(token, roots, td.OTID(), td.NewOTID("app", "123"))
+		assert.NotNil(err)
+
+		// the certificate should be issued to the issuer for client authentication
+		for _, c := range []struct {
+			san  string
+			ekus []x509.ExtKeyUsage
+		}{
+			{"", []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}},
+			{"otid:localhost:user:abc", []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}},
+			{"otid:example.com", []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}},
+			{"otid:localhost", nil},
+			{"otid:localhost", []x509.ExtKeyUsage{x509.ExtKeyUsageAny}},
+			{"otid:localhost", []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+		} {
+			otherLeaf, otherKey := newTestLeaf(ca, caKey, c.san, c.ekus...)
+			token, err = newVid().SignWithX5C(otherKey, []*x509.Certificate{otherLeaf})
+			assert.Nil(err)
+			_, err = otgo.ParseOTVIDWithX5C(token, roots, td.OTID(), td.NewOTID("app", "123"))
+			assert.NotNil(err, c.san)
+		}
+
+		token, err = newVid().Sign(key)
+		assert.Nil(err)
+		assert.False(otgo.HasX5C(token))
+		_, err = otgo.ParseOTVIDWithX5C(token, roots, td.OTID(), td.NewOTID("app", "123"))
+		assert.NotNil(err)
+	})
+
+	t.Run("Verifier.SetRoots and Verifier.AcceptX5C methods", func(t *testing.T) {
+		assert := assert.New(t)
+
+		pk := otgo.MustPrivateKey("ES256")
+		v, err := otgo.NewVerifier(context.Background(), td.NewOTID("app", "123"), nil, pk)
+		assert.Nil(err)
+
+		x5cToken, err := newVid().SignWithX5C(key, []*x509.Certificate{leaf})
+		assert.Nil(err)
+		_, err = v.ParseOTVID(x5cToken)
+		assert.NotNil(err)
+
+		v.SetRoots(roots)
+		_, err = v.ParseOTVID(x5cToken)
+		assert.NotNil(err)
+		v.AcceptX5C(true)
+		_, err = v.ParseOTVID(x5cToken)
+		assert.Nil(err)
+
+		token, err := newVid().Sign(pk)
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.Nil(err)
+
+		// the certificate issued to the trust domain's SPIFFE ID
+		spiffeLeaf, spiffeKey := newTestLeaf(ca, caKey, "spiffe://localhost", x509.ExtKeyUsageClientAuth)
+		token, err = newVid().SignWithX5C(spiffeKey, []*x509.Certificate{spiffeLeaf})
+		assert.Nil(err)
+		_, err = v.ParseOTVID(token)
+		assert.NotNil(err)
+		v.AcceptSPIFFESubject(true)
+		vid, err := v.ParseOTVID(token)
+		assert.Nil(err)
+		assert.Equal("otid:localhost:user:abc", vid.ID.String())
+	})
+}